@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagAccessLogFrom   = "from"
+	flagAccessLogTo     = "to"
+	flagAccessLogOutput = "output"
+
+	// accessLogDateFormat is the format of the --from and --to dates
+	accessLogDateFormat = "2006-01-02"
+	// accessLogBatchSize is the number of access events read from the database at once
+	accessLogBatchSize = 1000
+)
+
+// accessLogCmd groups the commands on the log of the accesses to the participants
+var accessLogCmd = &cobra.Command{
+	Use:   "access-log",
+	Short: "Review who viewed or downloaded participants",
+}
+
+// accessLogExportCmd exports the access events of a period for the periodic reviews
+var accessLogExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the access events of a period as CSV",
+	Long: `Export the access events between --from and --to, oldest first, as CSV.
+The period defaults to the last 30 days. Both dates are included.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		dbDsn := getFlagOrEnv(cmd, flagDbDSN, envDbDSN)
+		if len(dbDsn) == 0 {
+			return fmt.Errorf("--%s is required", flagDbDSN)
+		}
+		dbDriver := getFlagOrEnv(cmd, flagDbDriver, envDbDriver)
+		if len(dbDriver) == 0 {
+			return fmt.Errorf("--%s is required", flagDbDriver)
+		}
+
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		from, err := parseAccessLogDate(getFlag(cmd, flagAccessLogFrom), today.AddDate(0, 0, -29))
+		if err != nil {
+			return fmt.Errorf("--%s is invalid: %w", flagAccessLogFrom, err)
+		}
+		to, err := parseAccessLogDate(getFlag(cmd, flagAccessLogTo), today)
+		if err != nil {
+			return fmt.Errorf("--%s is invalid: %w", flagAccessLogTo, err)
+		}
+		// the to date is included
+		to = to.AddDate(0, 0, 1)
+		if !from.Before(to) {
+			return fmt.Errorf("--%s must not be after --%s", flagAccessLogFrom, flagAccessLogTo)
+		}
+
+		var out io.Writer = os.Stdout
+		if output := getFlag(cmd, flagAccessLogOutput); output != "" {
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		sqlDb, err := sqlx.ConnectContext(ctx, dbDriver, dbDsn)
+		if err != nil {
+			return err
+		}
+		defer sqlDb.Close()
+
+		return exportAccessEvents(ctx, db.NewAccessEventRepo(sqlDb), from, to, out)
+	},
+}
+
+func parseAccessLogDate(value string, defaultValue time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.Parse(accessLogDateFormat, value)
+}
+
+// exportAccessEvents writes the access events between from and to as CSV, reading them in batches
+func exportAccessEvents(ctx context.Context, repo db.AccessEventRepo, from time.Time, to time.Time, out io.Writer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{
+		"id",
+		"occurred_at",
+		"kind",
+		"user_id",
+		"user_email",
+		"impersonated_user_id",
+		"country_id",
+		"individual_ids",
+		"filter",
+		"row_count",
+		"request_id",
+	}); err != nil {
+		return err
+	}
+
+	options := api.ListAccessEventsOptions{
+		From:        &from,
+		To:          &to,
+		OldestFirst: true,
+		Take:        accessLogBatchSize,
+	}
+	for {
+		events, err := repo.List(ctx, options)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := w.Write([]string{
+				event.ID,
+				event.OccurredAt.UTC().Format(time.RFC3339),
+				string(event.Kind),
+				event.UserID,
+				event.UserEmail,
+				event.ImpersonatedUserID,
+				event.CountryID,
+				strings.Join(event.IndividualIDs.Items(), " "),
+				event.Filter,
+				strconv.Itoa(event.RowCount),
+				event.RequestID,
+			}); err != nil {
+				return err
+			}
+		}
+		if len(events) < accessLogBatchSize {
+			break
+		}
+		options.Skip += len(events)
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(accessLogCmd)
+	accessLogCmd.AddCommand(accessLogExportCmd)
+
+	accessLogExportCmd.Flags().String(flagDbDriver, "", fmt.Sprintf("database driver. Can also be set with %s", envDbDriver))
+	accessLogExportCmd.Flags().String(flagDbDSN, "", fmt.Sprintf("database dsn. Can also be set with %s", envDbDSN))
+	accessLogExportCmd.Flags().String(flagAccessLogFrom, "", "first day of the export, as YYYY-MM-DD. Defaults to 29 days ago, so that 30 days are exported")
+	accessLogExportCmd.Flags().String(flagAccessLogTo, "", "last day of the export, as YYYY-MM-DD. Defaults to today")
+	accessLogExportCmd.Flags().String(flagAccessLogOutput, "", "file to write the CSV to. Defaults to the standard output")
+}
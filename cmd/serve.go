@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/nrc-no/notcore/internal/utils"
 
 	"github.com/nrc-no/notcore/internal/server"
@@ -24,6 +25,13 @@ const (
 	envLoginURL                     = "CORE_LOGIN_URL"
 	envTokenRefreshURL              = "CORE_TOKEN_REFRESH_URL"
 	envTokenRefreshInterval         = "CORE_TOKEN_REFRESH_INTERVAL"
+	envLogoutURL                    = "CORE_LOGOUT_URL"
+	envAuthMode                     = "CORE_AUTH_MODE"
+	envOAuthClientSecret            = "CORE_OAUTH_CLIENT_SECRET"
+	envOAuthRedirectURL             = "CORE_OAUTH_REDIRECT_URL"
+	envOAuthScopes                  = "CORE_OAUTH_SCOPES"
+	envDevUsersFile                 = "CORE_DEV_USERS_FILE"
+	envSessionGroupsRefresh         = "CORE_SESSION_GROUPS_REFRESH_INTERVAL"
 	envJwtGlobalAdminGroup          = "CORE_JWT_GLOBAL_ADMIN_GROUP"
 	envIdTokenHeaderName            = "CORE_ID_TOKEN_HEADER_NAME"
 	envIdTokenHeaderFormat          = "CORE_ID_TOKEN_HEADER_FORMAT"
@@ -39,6 +47,8 @@ const (
 	envAzureBlobStorageUrl          = "CORE_AZURE_BLOB_STORAGE_URL"
 	envDownloadsContainerName       = "CORE_DOWNLOADS_CONTAINER_NAME"
 	envUserAssignedIdentityClientId = "USER_ASSIGNED_IDENTITY_CLIENT_ID"
+	envBlobStorageBackend           = "CORE_BLOB_STORAGE_BACKEND"
+	envBlobStorageLocalDir          = "CORE_BLOB_STORAGE_LOCAL_DIR"
 
 	flagDbDSN                   = "db-dsn"
 	flagDbDriver                = "db-driver"
@@ -46,6 +56,13 @@ const (
 	flagLoginURL                = "login-url"
 	flagTokenRefreshURL         = "token-refresh-url"
 	flagTokenRefreshInterval    = "token-refresh-interval"
+	flagLogoutURL               = "logout-url"
+	flagAuthMode                = "auth-mode"
+	flagOAuthClientSecret       = "oauth-client-secret"
+	flagOAuthRedirectURL        = "oauth-redirect-url"
+	flagOAuthScopes             = "oauth-scopes"
+	flagDevUsersFile            = "dev-users-file"
+	flagSessionGroupsRefresh    = "session-groups-refresh-interval"
 	flagJwtGlobalAdminGroup     = "jwt-global-admin-group"
 	flagIdTokenHeaderName       = "id-token-header-name"
 	flagIdTokenHeaderFormat     = "id-token-header-format"
@@ -62,8 +79,16 @@ const (
 	flagDownloadsContainerName  = "downloads-container-name"
 	flagAzuriteAccountName      = "azurite-account-name"
 	flagAzuriteAccountKey       = "azurite-account-key"
+	flagBlobStorageBackend      = "blob-storage-backend"
+	flagBlobStorageLocalDir     = "blob-storage-local-dir"
 )
 
+// defaultSessionGroupsRefresh is how often the groups of the users are fetched again by default
+const defaultSessionGroupsRefresh = 5 * time.Minute
+
+// defaultOAuthScopes are the scopes requested by the built-in OIDC login by default
+var defaultOAuthScopes = []string{oidc.ScopeOpenID, "profile", "email", oidc.ScopeOfflineAccess}
+
 // serveCmd represents the serve command
 var serveCmd = &cobra.Command{
 	Use:   "serve",
@@ -102,13 +127,17 @@ var serveCmd = &cobra.Command{
 			return fmt.Errorf("--%s is required", flagListenAddress)
 		}
 
+		authMode := getFlagOrEnv(cmd, flagAuthMode, envAuthMode)
+		proxyAuth := authMode == "" || authMode == server.AuthModeProxy
+		devAuth := authMode == server.AuthModeDev
+
 		loginURL := getFlagOrEnv(cmd, flagLoginURL, envLoginURL)
-		if len(loginURL) == 0 {
+		if len(loginURL) == 0 && proxyAuth {
 			return fmt.Errorf("--%s is required", flagLoginURL)
 		}
 
 		refreshURL := getFlagOrEnv(cmd, flagTokenRefreshURL, envTokenRefreshURL)
-		if len(refreshURL) == 0 {
+		if len(refreshURL) == 0 && !devAuth {
 			return fmt.Errorf("--%s is required", flagTokenRefreshURL)
 		}
 
@@ -116,7 +145,10 @@ var serveCmd = &cobra.Command{
 		if tokenRefreshIntervalStr == "0s" {
 			tokenRefreshIntervalStr = os.Getenv(envTokenRefreshInterval)
 			if tokenRefreshIntervalStr == "" {
-				return fmt.Errorf("--%s is required", flagTokenRefreshInterval)
+				if !devAuth {
+					return fmt.Errorf("--%s is required", flagTokenRefreshInterval)
+				}
+				tokenRefreshIntervalStr = "0s"
 			}
 		}
 		tokenRefreshInterval, err := time.ParseDuration(tokenRefreshIntervalStr)
@@ -124,36 +156,59 @@ var serveCmd = &cobra.Command{
 			return fmt.Errorf("--%s is invalid: %w", flagTokenRefreshInterval, err)
 		}
 
+		logoutURL := getFlagOrEnv(cmd, flagLogoutURL, envLogoutURL)
+
+		sessionGroupsRefreshStr := cmd.Flag(flagSessionGroupsRefresh).Value.String()
+		if sessionGroupsRefreshStr == "0s" {
+			sessionGroupsRefreshStr = os.Getenv(envSessionGroupsRefresh)
+			if sessionGroupsRefreshStr == "" {
+				sessionGroupsRefreshStr = defaultSessionGroupsRefresh.String()
+			}
+		}
+		sessionGroupsRefreshInterval, err := time.ParseDuration(sessionGroupsRefreshStr)
+		if err != nil {
+			return fmt.Errorf("--%s is invalid: %w", flagSessionGroupsRefresh, err)
+		}
+
 		idTokenHeaderName := getFlagOrEnv(cmd, flagIdTokenHeaderName, envIdTokenHeaderName)
-		if len(idTokenHeaderName) == 0 {
+		if len(idTokenHeaderName) == 0 && proxyAuth {
 			return fmt.Errorf("--%s is required", flagIdTokenHeaderName)
 		}
 
 		idTokenHeaderFormat := getFlagOrEnv(cmd, flagIdTokenHeaderFormat, envIdTokenHeaderFormat)
-		if len(idTokenHeaderFormat) == 0 {
+		if len(idTokenHeaderFormat) == 0 && proxyAuth {
 			return fmt.Errorf("--%s is required", flagIdTokenHeaderFormat)
 		}
 
 		accessTokenHeaderName := getFlagOrEnv(cmd, flagAccessTokenHeaderName, envAccessTokenHeaderName)
-		if len(idTokenHeaderName) == 0 {
+		if len(idTokenHeaderName) == 0 && proxyAuth {
 			return fmt.Errorf("--%s is required", flagAccessTokenHeaderName)
 		}
 
 		accessTokenHeaderFormat := getFlagOrEnv(cmd, flagAccessTokenHeaderFormat, envAccessTokenHeaderFormat)
-		if len(idTokenHeaderFormat) == 0 {
+		if len(idTokenHeaderFormat) == 0 && proxyAuth {
 			return fmt.Errorf("--%s is required", flagAccessTokenHeaderFormat)
 		}
 
 		oidcIssuerURL := getFlagOrEnv(cmd, flagOidcIssuerURL, envOidcIssuerURL)
-		if len(oidcIssuerURL) == 0 {
+		if len(oidcIssuerURL) == 0 && !devAuth {
 			return fmt.Errorf("--%s is required", flagOidcIssuerURL)
 		}
 
 		oauthClientID := getFlagOrEnv(cmd, flagOidcClientID, envOidcClientID)
-		if len(oauthClientID) == 0 {
+		if len(oauthClientID) == 0 && !devAuth {
 			return fmt.Errorf("--%s is required", flagOidcClientID)
 		}
 
+		devUsersFile := getFlagOrEnv(cmd, flagDevUsersFile, envDevUsersFile)
+
+		oauthClientSecret := getFlagOrEnv(cmd, flagOAuthClientSecret, envOAuthClientSecret)
+		oauthRedirectURL := getFlagOrEnv(cmd, flagOAuthRedirectURL, envOAuthRedirectURL)
+		oauthScopes := defaultOAuthScopes
+		if scopes := getFlagOrEnv(cmd, flagOAuthScopes, envOAuthScopes); len(scopes) > 0 {
+			oauthScopes = strings.Split(scopes, ",")
+		}
+
 		hashKey1 := getFlagOrEnv(cmd, flagHashKey1, envHashKey1)
 		if len(hashKey1) == 0 {
 			return fmt.Errorf("--%s is required", flagHashKey1)
@@ -181,13 +236,20 @@ var serveCmd = &cobra.Command{
 		azuriteAccountName := getFlag(cmd, flagAzuriteAccountName)
 		azuriteAccountKey := getFlag(cmd, flagAzuriteAccountKey)
 
+		blobStorageBackend := getFlagOrEnv(cmd, flagBlobStorageBackend, envBlobStorageBackend)
+		blobStorageLocalDir := getFlagOrEnv(cmd, flagBlobStorageLocalDir, envBlobStorageLocalDir)
+
 		options := server.Options{
-			Address:              listenAddress,
-			DatabaseDriver:       dbDriver,
-			DatabaseDSN:          dbDsn,
-			LoginURL:             loginURL,
-			TokenRefreshURL:      refreshURL,
-			TokenRefreshInterval: tokenRefreshInterval,
+			Address:                      listenAddress,
+			AuthMode:                     authMode,
+			DevUsersFile:                 devUsersFile,
+			DatabaseDriver:               dbDriver,
+			DatabaseDSN:                  dbDsn,
+			LoginURL:                     loginURL,
+			TokenRefreshURL:              refreshURL,
+			TokenRefreshInterval:         tokenRefreshInterval,
+			LogoutURL:                    logoutURL,
+			SessionGroupsRefreshInterval: sessionGroupsRefreshInterval,
 			JwtGroups: utils.JwtGroupOptions{
 				GlobalAdmin: jwtGroupGlobalAdmin,
 			},
@@ -197,6 +259,9 @@ var serveCmd = &cobra.Command{
 			AccessTokenHeaderFormat:      accessTokenHeaderFormat,
 			OIDCIssuerURL:                oidcIssuerURL,
 			OAuthClientID:                oauthClientID,
+			OAuthClientSecret:            oauthClientSecret,
+			OAuthRedirectURL:             oauthRedirectURL,
+			OAuthScopes:                  oauthScopes,
 			HashKey1:                     hashKey1,
 			BlockKey1:                    blockKey1,
 			HashKey2:                     hashKey2,
@@ -207,6 +272,8 @@ var serveCmd = &cobra.Command{
 			UserAssignedIdentityClientId: userAssignedIdentityClientId,
 			AzuriteAccountName:           azuriteAccountName,
 			AzuriteAccountKey:            azuriteAccountKey,
+			BlobStorageBackend:           blobStorageBackend,
+			BlobStorageLocalDir:          blobStorageLocalDir,
 		}
 
 		srv, err := options.New(ctx)
@@ -259,6 +326,21 @@ This URL is used to refresh the user session. It is called by the frontend appli
 when it is about to expire.
 `, envTokenRefreshURL)))
 
+	serveCmd.PersistentFlags().String(flagLogoutURL, "", cleanDoc(fmt.Sprintf(`
+logout url. Can also be set with %[2]s
+
+This URL is where the users are sent after logging out, for example the sign out endpoint of the
+authentication proxy. Defaults to the value of --%[1]s.
+`, flagLoginURL, envLogoutURL)))
+
+	serveCmd.PersistentFlags().Duration(flagSessionGroupsRefresh, 0, cleanDoc(fmt.Sprintf(`
+This flag specifies the interval at which the groups of the users are fetched again from the
+identity provider. Can also be set with %s. Defaults to %s.
+
+The groups are stored in the server-side session of the user. Removing a user from a group
+takes effect at the latest after this interval, without the user having to log out.
+`, envSessionGroupsRefresh, defaultSessionGroupsRefresh)))
+
 	serveCmd.PersistentFlags().String(flagJwtGlobalAdminGroup, "", cleanDoc(fmt.Sprintf(`
 jwt global admin group. Can also be set with %s
 
@@ -335,6 +417,44 @@ OIDC provider's discovery document'
 
 	serveCmd.PersistentFlags().String(flagOidcClientID, "", fmt.Sprintf("oauth client id. Can also be set with %s", envOidcClientID))
 
+	serveCmd.PersistentFlags().String(flagAuthMode, "", cleanDoc(fmt.Sprintf(`
+authentication mode. Can also be set with %[1]s
+Allowed values are
+	- %[2]s (default): an authenticating proxy, such as the Envoy OAuth filter, forwards the ID and
+	  access tokens in the headers given by --%[4]s and --%[5]s
+	- %[3]s: the application signs the users in itself with the authorization code flow and PKCE.
+	  The tokens are stored encrypted on the server, and refreshed at --%[6]s.
+	  Requires --%[7]s, and --%[8]s for confidential clients.
+	- %[9]s: for development only. The users pick who to sign in as from the users of --%[10]s,
+	  without an identity provider. Only allowed when --%[11]s is a loopback address.
+`, envAuthMode, server.AuthModeProxy, server.AuthModeOIDC,
+		flagIdTokenHeaderName, flagAccessTokenHeaderName, flagTokenRefreshURL,
+		flagOAuthRedirectURL, flagOAuthClientSecret,
+		server.AuthModeDev, flagDevUsersFile, flagListenAddress)))
+
+	serveCmd.PersistentFlags().String(flagDevUsersFile, "", cleanDoc(fmt.Sprintf(`
+YAML file of the users of the development login. Can also be set with %[1]s
+
+The users have an id, an email, a name and the groups given by the identity provider in production.
+See deploy/dev-users.yaml for an example.
+`, envDevUsersFile)))
+
+	serveCmd.PersistentFlags().String(flagOAuthClientSecret, "", fmt.Sprintf("oauth client secret, for the built-in OIDC login. Can also be set with %s", envOAuthClientSecret))
+
+	serveCmd.PersistentFlags().String(flagOAuthRedirectURL, "", cleanDoc(fmt.Sprintf(`
+oauth redirect url, for the built-in OIDC login. Can also be set with %[1]s
+
+This is the absolute URL of the %[2]s endpoint of the application, and must be registered with the identity provider.
+For example
+	--%[3]s="https://core.example.org%[2]s"
+`, envOAuthRedirectURL, middleware.OIDCCallbackPath, flagOAuthRedirectURL)))
+
+	serveCmd.PersistentFlags().String(flagOAuthScopes, "", cleanDoc(fmt.Sprintf(`
+comma separated oauth scopes requested by the built-in OIDC login. Can also be set with %s. Defaults to %s
+
+The offline_access scope gives the refresh token, and the groups of the user must be returned by the user info endpoint.
+`, envOAuthScopes, strings.Join(defaultOAuthScopes, ","))))
+
 	serveCmd.PersistentFlags().Duration(flagTokenRefreshInterval, 0, cleanDoc(fmt.Sprintf(`
 This flag specifies the interval at which user token should be refreshed. Can also be set with %s
 
@@ -387,6 +507,20 @@ This flag specifies the Azurite account name to be used when running the applica
 	serveCmd.PersistentFlags().String(flagAzuriteAccountKey, "", cleanDoc(fmt.Sprintf(`
 This flag specifies the Azurite account key to be used when running the application locally.
 `)))
+
+	serveCmd.PersistentFlags().String(flagBlobStorageBackend, "", cleanDoc(fmt.Sprintf(`
+This flag specifies where exported files are stored. Must be one of: %s (default), %s, %s.
+The %s backend keeps files on the local disk, in the directory given by --%s.
+The %s backend keeps files in memory and is only meant for development and tests.
+Can also be set with %s
+`, server.BlobStorageBackendAzure, server.BlobStorageBackendLocal, server.BlobStorageBackendMemory,
+		server.BlobStorageBackendLocal, flagBlobStorageLocalDir,
+		server.BlobStorageBackendMemory, envBlobStorageBackend)))
+
+	serveCmd.PersistentFlags().String(flagBlobStorageLocalDir, "", cleanDoc(fmt.Sprintf(`
+This flag specifies the directory where exported files are stored when using the local blob storage backend.
+Can also be set with %s
+`, envBlobStorageLocalDir)))
 }
 
 func cleanDoc(s string) string {
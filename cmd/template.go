@@ -22,7 +22,8 @@ import (
 var templateCmd = &cobra.Command{
 	Use:   "template",
 	Short: "Create the default template for users to download",
-	Long:  `Create an excel file that contains an example participant that users can download in the app`,
+	Long: `Create an excel file for each language that users can download in the app. It contains an example participant,
+dropdowns for the columns with a fixed set of values, comments explaining each column and an instructions sheet`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Commented out fields are currently blank, but left in place in case we want to change values later
 		individual := &api.Individual{
@@ -130,7 +131,7 @@ var templateCmd = &cobra.Command{
 			defer func() {
 				templateFile.Close()
 			}()
-			if err := api.MarshalIndividualsExcel(templateFile, individualList); err != nil {
+			if err := api.MarshalIndividualsTemplateExcel(templateFile, individualList); err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"time"
+
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+// AccessEventKind is what a user did with the data of the participants
+type AccessEventKind string
+
+const (
+	// AccessEventKindViewIndividual is the opening of the page of a participant
+	AccessEventKindViewIndividual AccessEventKind = "view_individual"
+	// AccessEventKindListIndividuals is a page of the list of participants, with its filters
+	AccessEventKindListIndividuals AccessEventKind = "list_individuals"
+	// AccessEventKindDownloadIndividuals is a download of the participants matching the filters
+	AccessEventKindDownloadIndividuals AccessEventKind = "download_individuals"
+	// AccessEventKindViewDuplicates is the list of existing participants found as duplicates of an upload
+	AccessEventKindViewDuplicates AccessEventKind = "view_duplicates"
+)
+
+// AccessEventKinds are all the access event kinds, in the order they are shown in the search
+var AccessEventKinds = []AccessEventKind{
+	AccessEventKindViewIndividual,
+	AccessEventKindListIndividuals,
+	AccessEventKindDownloadIndividuals,
+	AccessEventKindViewDuplicates,
+}
+
+// IsValid returns true if the access event kind is known
+func (k AccessEventKind) IsValid() bool {
+	for _, kind := range AccessEventKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessEvent records that a user saw or exported the data of participants.
+// The access events are append-only, for the reviews required by the protection policy.
+type AccessEvent struct {
+	ID         string          `db:"id"`
+	OccurredAt time.Time       `db:"occurred_at"`
+	Kind       AccessEventKind `db:"kind"`
+	// UserID is the id of the user or service account, which is the global admin when impersonating
+	UserID    string `db:"user_id"`
+	UserEmail string `db:"user_email"`
+	// ImpersonatedUserID is the user the global admin viewed the application as, if any
+	ImpersonatedUserID string `db:"impersonated_user_id"`
+	CountryID          string `db:"country_id"`
+	// IndividualIDs are the participants that were shown. They are not recorded for the downloads,
+	// whose participants are given by the filter.
+	IndividualIDs containers.StringSet `db:"individual_ids"`
+	// Filter is the url-encoded filter of the list and download queries
+	Filter string `db:"filter"`
+	// RowCount is the number of participants that were shown or downloaded
+	RowCount  int    `db:"row_count"`
+	RequestID string `db:"request_id"`
+}
+
+// ListAccessEventsOptions are the filters of the access events search. Empty filters match all the events.
+type ListAccessEventsOptions struct {
+	// User matches the id or the email of the user, or of the impersonated user
+	User         string
+	Kind         AccessEventKind
+	CountryID    string
+	IndividualID string
+	From         *time.Time
+	To           *time.Time
+	// OldestFirst sorts the events by ascending time, instead of the most recent first
+	OldestFirst bool
+	Skip        int
+	Take        int
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+const (
+	// maxColumnSuggestions is the number of suggestions offered for an unknown header
+	maxColumnSuggestions = 3
+	// minColumnSuggestionScore is the similarity below which a column is not suggested
+	minColumnSuggestionScore = 0.5
+)
+
+// ColumnMapping maps the headers of an uploaded file to db columns.
+// Headers mapped to an empty string are ignored during the import.
+type ColumnMapping map[string]string
+
+// Scan implements sql.Scanner
+func (m *ColumnMapping) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into ColumnMapping", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (m ColumnMapping) Value() (driver.Value, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Covers returns true if all the given headers are mapped, or explicitly ignored
+func (m ColumnMapping) Covers(header []string) bool {
+	for _, h := range header {
+		if _, ok := m[normalizeHeader(h)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Headers returns the normalized headers of the mapping
+func (m ColumnMapping) Headers() []string {
+	headers := make([]string, 0, len(m))
+	for h := range m {
+		headers = append(headers, h)
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// Get returns the db column the header is mapped to
+func (m ColumnMapping) Get(header string) (string, bool) {
+	col, ok := m[normalizeHeader(header)]
+	return col, ok
+}
+
+// Set maps the header to the db column. An empty column ignores the header
+func (m ColumnMapping) Set(header string, column string) {
+	m[normalizeHeader(header)] = column
+}
+
+// ColumnSuggestion is a column that an unknown header could be mapped to
+type ColumnSuggestion struct {
+	// Column is the db column
+	Column string
+	// FileColumn is the translation key of the column
+	FileColumn string
+	// Score is the similarity between the header and the column name, between 0 and 1
+	Score float64
+}
+
+// HeaderMapping is the resolution of a single header of an uploaded file
+type HeaderMapping struct {
+	Index  int
+	Header string
+	// Column is the db column the header was resolved to. It is empty when the header is unknown
+	Column string
+	// Suggestions are the columns most similar to an unknown header, best first
+	Suggestions []ColumnSuggestion
+}
+
+// IsKnown returns true if the header matches a column name exactly
+func (h HeaderMapping) IsKnown() bool {
+	return h.Column != ""
+}
+
+// ResolveHeaders matches each header with the column of the same name. The headers that do not
+// match any column come with suggestions of similar columns.
+func ResolveHeaders(header []string) []HeaderMapping {
+	ret := make([]HeaderMapping, len(header))
+	for i, h := range header {
+		ret[i] = HeaderMapping{Index: i, Header: h}
+		if col, ok := locales.GetDBColumn(h); ok {
+			ret[i].Column = col
+		} else {
+			ret[i].Suggestions = SuggestColumns(h)
+		}
+	}
+	return ret
+}
+
+// HasUnknownHeaders returns true if any of the headers could not be resolved
+func HasUnknownHeaders(mappings []HeaderMapping) bool {
+	for _, m := range mappings {
+		if !m.IsKnown() {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestColumns returns the columns whose names, in any language, are the most similar to the header
+func SuggestColumns(header string) []ColumnSuggestion {
+	normalized := normalizeHeader(header)
+	if normalized == "" {
+		return nil
+	}
+	var suggestions []ColumnSuggestion
+	for _, fileColumn := range constants.IndividualFileColumns {
+		column := constants.IndividualFileToDBMap[fileColumn]
+		names := append(locales.GetColumnNames(fileColumn), column)
+		best := 0.0
+		for _, name := range names {
+			if score := headerSimilarity(normalized, normalizeHeader(name)); score > best {
+				best = score
+			}
+		}
+		if best >= minColumnSuggestionScore {
+			suggestions = append(suggestions, ColumnSuggestion{Column: column, FileColumn: fileColumn, Score: best})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > maxColumnSuggestions {
+		suggestions = suggestions[:maxColumnSuggestions]
+	}
+	return suggestions
+}
+
+// HeaderSignature identifies a set of headers regardless of their order, case and spacing
+func HeaderSignature(header []string) string {
+	normalized := containers.NewStringSet()
+	for _, h := range header {
+		normalized.Add(normalizeHeader(h))
+	}
+	items := normalized.Items()
+	sort.Strings(items)
+	sum := sha256.Sum256([]byte(strings.Join(items, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApplyColumnMapping rewrites the header row of the records with the mapped db column names,
+// and drops the ignored columns, so that the records can be imported like a file using our own headers
+func ApplyColumnMapping(records [][]string, mapping ColumnMapping) ([][]string, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+	var (
+		keep    []int
+		header  []string
+		columns = containers.NewStringSet()
+	)
+	for i, h := range records[0] {
+		column, ok := mapping.Get(h)
+		if !ok {
+			column, ok = locales.GetDBColumn(h)
+		}
+		if !ok {
+			return nil, fmt.Errorf(locales.GetTranslator()("error_unknown_columns", h))
+		}
+		if column == "" {
+			continue
+		}
+		if !constants.IndividualDBColumns.Contains(column) {
+			return nil, fmt.Errorf(locales.GetTranslator()("error_unknown_columns", column))
+		}
+		if columns.Contains(column) {
+			return nil, fmt.Errorf(locales.GetTranslator()("error_duplicate_column_mapping", column))
+		}
+		columns.Add(column)
+		keep = append(keep, i)
+		header = append(header, column)
+	}
+
+	ret := make([][]string, len(records))
+	ret[0] = header
+	for r, row := range records[1:] {
+		mapped := make([]string, len(keep))
+		for j, i := range keep {
+			if i < len(row) {
+				mapped[j] = row[i]
+			}
+		}
+		ret[r+1] = mapped
+	}
+	return ret, nil
+}
+
+// normalizeHeader lower-cases the header and collapses punctuation and spaces into single spaces
+func normalizeHeader(header string) string {
+	fields := strings.FieldsFunc(strings.ToLower(header), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// headerSimilarity scores two normalized headers between 0 and 1, taking the best of their
+// edit distance and of the words they have in common
+func headerSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	editScore := 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+
+	wordsA := containers.NewStringSet(strings.Fields(a)...)
+	wordsB := containers.NewStringSet(strings.Fields(b)...)
+	common := 0
+	for _, w := range wordsA.Items() {
+		if wordsB.Contains(w) {
+			common++
+		}
+	}
+	wordScore := 2 * float64(common) / float64(wordsA.Len()+wordsB.Len())
+
+	if wordScore > editScore {
+		return wordScore
+	}
+	return editScore
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestColumns(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "Full nme", want: "full_name"},
+		{header: "first_nme", want: "first_name"},
+		{header: "FULL NAME", want: "full_name"},
+		{header: "Phone number", want: "phone_number_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			suggestions := SuggestColumns(tt.header)
+			require.NotEmpty(t, suggestions)
+			assert.LessOrEqual(t, len(suggestions), maxColumnSuggestions)
+			assert.Equal(t, tt.want, suggestions[0].Column)
+			for i := 1; i < len(suggestions); i++ {
+				assert.GreaterOrEqual(t, suggestions[i-1].Score, suggestions[i].Score)
+			}
+		})
+	}
+
+	assert.Empty(t, SuggestColumns(""))
+	assert.Empty(t, SuggestColumns("zzzzzzzzzzzzzzzz"))
+}
+
+func TestResolveHeaders(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	mappings := ResolveHeaders([]string{"Full name", "Full nme"})
+	require.Len(t, mappings, 2)
+	assert.True(t, mappings[0].IsKnown())
+	assert.Equal(t, "full_name", mappings[0].Column)
+	assert.Empty(t, mappings[0].Suggestions)
+	assert.False(t, mappings[1].IsKnown())
+	assert.NotEmpty(t, mappings[1].Suggestions)
+	assert.True(t, HasUnknownHeaders(mappings))
+	assert.False(t, HasUnknownHeaders(mappings[:1]))
+}
+
+func TestHeaderSignature(t *testing.T) {
+	a := HeaderSignature([]string{"Full name", "Phone number"})
+	assert.Equal(t, a, HeaderSignature([]string{"phone  number", "FULL NAME"}))
+	assert.Equal(t, a, HeaderSignature([]string{"full_name", "phone-number"}))
+	assert.NotEqual(t, a, HeaderSignature([]string{"Full name"}))
+}
+
+func TestApplyColumnMapping(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	records := [][]string{
+		{"Nom complet", "Remarks", "E-Mail 1"},
+		{"John", "ignore me", "john@example.com"},
+		{"Jane"},
+	}
+
+	mapping := ColumnMapping{}
+	mapping.Set("Nom complet", "full_name")
+	mapping.Set("Remarks", "")
+
+	got, err := ApplyColumnMapping(records, mapping)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"full_name", "email_1"},
+		{"John", "john@example.com"},
+		{"Jane", ""},
+	}, got)
+
+	mapping.Set("E-Mail 1", "full_name")
+	_, err = ApplyColumnMapping(records, mapping)
+	assert.Error(t, err)
+
+	_, err = ApplyColumnMapping(records, ColumnMapping{"nom complet": "not_a_column"})
+	assert.Error(t, err)
+
+	_, err = ApplyColumnMapping([][]string{{"Unknown"}}, ColumnMapping{})
+	assert.Error(t, err)
+}
+
+func TestColumnMappingScanValue(t *testing.T) {
+	mapping := ColumnMapping{}
+	mapping.Set("Full Name", "full_name")
+	mapping.Set("Remarks", "")
+	assert.True(t, mapping.Covers([]string{"full name", "REMARKS"}))
+	assert.False(t, mapping.Covers([]string{"full name", "email"}))
+	assert.Equal(t, []string{"full name", "remarks"}, mapping.Headers())
+
+	value, err := mapping.Value()
+	require.NoError(t, err)
+
+	var scanned ColumnMapping
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, mapping, scanned)
+	require.NoError(t, scanned.Scan([]byte(value.(string))))
+	assert.Equal(t, mapping, scanned)
+	assert.Error(t, scanned.Scan(1))
+}
@@ -1,13 +1,42 @@
 package api
 
+import (
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/constants"
+)
+
 type Country struct {
 	ID               string               `db:"id"`
 	Code             string               `db:"code"`
 	Name             string               `db:"name"`
-	ReadGroup 			 string               `db:"read_group"`
-	WriteGroup 			 string               `db:"write_group"`
+	ValidationRules  CountryValidationRules `db:"validation_rules"`
+	ServiceCatalogue ServiceCatalogue       `db:"service_catalogue"`
+	Roles            CountryRoles           `db:"roles"`
+	Offices          CountryOffices         `db:"offices"`
+	// Groups are the JWT groups that have access to the country, stored apart from the country
+	Groups           CountryGroups          `db:"-"`
 }
 
 type CountryList struct {
 	Items []*Country `db:"items"`
 }
+
+// PhoneNumberRegion returns the ISO 3166-1 alpha-2 code of the country, used to read national phone numbers.
+// The code of the country is matched against the ISO 3166-1 codes, then its name against the country names.
+// It returns an empty string when the country is not found.
+func (c *Country) PhoneNumberRegion() string {
+	code := strings.ToUpper(strings.TrimSpace(c.Code))
+	if country, ok := constants.CountriesByAlpha2[code]; ok {
+		return country.ISO3166Alpha2
+	}
+	if country, ok := constants.CountriesByCode[code]; ok {
+		return country.ISO3166Alpha2
+	}
+	for _, country := range constants.Countries {
+		if strings.EqualFold(country.Name, strings.TrimSpace(c.Name)) {
+			return country.ISO3166Alpha2
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+const (
+	// CountryGroupRoleRead is the built-in role that can read all the individuals of the country
+	CountryGroupRoleRead = "read"
+	// CountryGroupRoleWrite is the built-in role that can read and write all the individuals of the country
+	CountryGroupRoleWrite = "write"
+)
+
+// CountryGroupRoles are the built-in roles that JWT groups can be mapped to, next to the roles of the country
+var CountryGroupRoles = []string{CountryGroupRoleRead, CountryGroupRoleWrite}
+
+// CountryGroup maps a JWT group to a role in a country. The role is either one of the built-in CountryGroupRoles,
+// or the id of one of the roles of the country.
+type CountryGroup struct {
+	CountryID string `db:"country_id"`
+	Group     string `db:"jwt_group"`
+	Role      string `db:"role"`
+}
+
+// CountryGroups are the JWT groups of a country and their roles. A group can have several roles,
+// and several groups can have the same role.
+type CountryGroups []CountryGroup
+
+// Groups returns the groups that have the role, sorted
+func (g CountryGroups) Groups(role string) []string {
+	groups := containers.NewStringSet()
+	for _, group := range g {
+		if group.Role == role {
+			groups.Add(group.Group)
+		}
+	}
+	return groups.Items()
+}
+
+// Roles returns the union of the roles of the given user groups
+func (g CountryGroups) Roles(userGroups containers.StringSet) containers.StringSet {
+	roles := containers.NewStringSet()
+	for _, group := range g {
+		if userGroups.Contains(group.Group) {
+			roles.Add(group.Role)
+		}
+	}
+	return roles
+}
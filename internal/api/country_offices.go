@@ -0,0 +1,53 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// CountryOffice is a field office of a country. The members of its JWT groups can only access the individuals
+// whose collection office is the name of the office, unless they also have access to the whole country.
+type CountryOffice struct {
+	ID string `json:"id"`
+	// Name is the collection office of the individuals registered by the office
+	Name       string `json:"name"`
+	ReadGroup  string `json:"readGroup,omitempty"`
+	WriteGroup string `json:"writeGroup,omitempty"`
+}
+
+// CountryOffices are the field offices of a country
+type CountryOffices struct {
+	Offices []CountryOffice `json:"offices,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (c *CountryOffices) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into CountryOffices", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (c CountryOffices) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Get returns the office with the given id
+func (c CountryOffices) Get(id string) (CountryOffice, bool) {
+	for _, office := range c.Offices {
+		if office.ID == id {
+			return office, true
+		}
+	}
+	return CountryOffice{}, false
+}
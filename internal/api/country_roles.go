@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nrc-no/notcore/internal/auth"
+)
+
+// CountryRole is a role of a country, such as a registration clerk or a protection officer.
+// The members of the JWT groups mapped to the role get the permissions of the role on the individual field groups.
+type CountryRole struct {
+	ID          string                                     `json:"id"`
+	Name        string                                     `json:"name"`
+	Permissions map[auth.FieldGroup][]auth.FieldPermission `json:"permissions,omitempty"`
+}
+
+// Has returns true if the role grants the permission on the field group
+func (r CountryRole) Has(group auth.FieldGroup, perm auth.FieldPermission) bool {
+	for _, p := range r.Permissions[group] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEdit returns true if the role can edit any of the field groups
+func (r CountryRole) CanEdit() bool {
+	for _, group := range auth.FieldGroups {
+		if r.Has(group, auth.FieldPermissionEdit) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPermissions returns the permissions of the role on the field groups
+func (r CountryRole) FieldPermissions() auth.FieldGroupPermissions {
+	perms := auth.FieldGroupPermissions{}
+	for group, groupPerms := range r.Permissions {
+		perms.Add(group, groupPerms...)
+	}
+	return perms
+}
+
+// CountryRoles are the roles of a country
+type CountryRoles struct {
+	Roles []CountryRole `json:"roles,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (c *CountryRoles) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into CountryRoles", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (c CountryRoles) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Get returns the role with the given id
+func (c CountryRoles) Get(id string) (CountryRole, bool) {
+	for _, role := range c.Roles {
+		if role.ID == id {
+			return role, true
+		}
+	}
+	return CountryRole{}, false
+}
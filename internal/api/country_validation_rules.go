@@ -0,0 +1,184 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"golang.org/x/exp/slices"
+)
+
+// CountryValidationRules are the rules a country adds to the validation of its individuals,
+// on top of the rules that apply to all countries
+type CountryValidationRules struct {
+	// RequiredFields are the db columns that must have a value
+	RequiredFields []string `json:"requiredFields,omitempty"`
+	// HiddenFields are the db columns that are not collected in the country.
+	// They are left out of the form and cannot be imported.
+	HiddenFields []string `json:"hiddenFields,omitempty"`
+	// AllowedValues restricts the values of the enums, by RestrictableEnum name, to a subset
+	AllowedValues map[string][]string `json:"allowedValues,omitempty"`
+	// IdentificationNumberPatterns are the regular expressions the identification numbers must match,
+	// by identification type. A pattern must match the whole identification number.
+	IdentificationNumberPatterns map[string]string `json:"identificationNumberPatterns,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (r *CountryValidationRules) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into CountryValidationRules", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (r CountryValidationRules) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// IsRequired returns true if the db column must have a value
+func (r CountryValidationRules) IsRequired(column string) bool {
+	return slices.Contains(r.RequiredFields, column)
+}
+
+// IsHidden returns true if the db column is not collected in the country
+func (r CountryValidationRules) IsHidden(column string) bool {
+	return slices.Contains(r.HiddenFields, column)
+}
+
+// IsRestricted returns true if the values of the enum are restricted to a subset
+func (r CountryValidationRules) IsRestricted(enum string) bool {
+	return len(r.AllowedValues[enum]) > 0
+}
+
+// IsAllowed returns true if the value of the enum is allowed in the country.
+// Empty values are always allowed, whether the field is required is checked separately.
+func (r CountryValidationRules) IsAllowed(enum string, value string) bool {
+	return value == "" || !r.IsRestricted(enum) || slices.Contains(r.AllowedValues[enum], value)
+}
+
+// IdentificationNumberPattern returns the pattern of the identification numbers of the given type,
+// or an empty string if there is none
+func (r CountryValidationRules) IdentificationNumberPattern(identificationType string) string {
+	return r.IdentificationNumberPatterns[identificationType]
+}
+
+// IdentificationNumberRegexp compiles the pattern of the identification numbers of the given type.
+// It returns nil if there is no pattern.
+func (r CountryValidationRules) IdentificationNumberRegexp(identificationType string) (*regexp.Regexp, error) {
+	pattern := r.IdentificationNumberPattern(identificationType)
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// RestrictableEnum is an enum whose values a country can restrict to a subset
+type RestrictableEnum struct {
+	// Name is the key of the enum in CountryValidationRules.AllowedValues
+	Name string
+	// Columns are the db columns holding values of the enum
+	Columns []string
+	// Values are the values of the enum
+	Values []RestrictableEnumValue
+	// labelKey is the translation key of the name of the enum
+	labelKey string
+}
+
+// Label returns the name of the enum in the current language
+func (e RestrictableEnum) Label() string {
+	return locales.GetTranslator()(e.labelKey)
+}
+
+// RestrictableEnumValue is a value of a RestrictableEnum
+type RestrictableEnumValue struct {
+	Value string
+	label fmt.Stringer
+}
+
+// Label returns the value in the current language
+func (v RestrictableEnumValue) Label() string {
+	return v.label.String()
+}
+
+func restrictableEnum[T interface {
+	~string
+	String() string
+}](name string, labelKey string, values containers.Set[T], columns ...string) RestrictableEnum {
+	ret := RestrictableEnum{Name: name, Columns: columns, labelKey: labelKey}
+	for _, v := range values.Items() {
+		ret.Values = append(ret.Values, RestrictableEnumValue{Value: string(v), label: v})
+	}
+	return ret
+}
+
+var restrictableEnums = []RestrictableEnum{
+	restrictableEnum("sex", "sex", enumTypes.AllSexes(),
+		constants.DBColumnIndividualSex),
+	restrictableEnum("displacement_status", "displacement_status", enumTypes.AllDisplacementStatuses(),
+		constants.DBColumnIndividualDisplacementStatus),
+	restrictableEnum("engagement_context", "engagement_context", enumTypes.AllEngagementContexts(),
+		constants.DBColumnIndividualEngagementContext),
+	restrictableEnum("identification_type", "identification_type", enumTypes.AllIdentificationTypes(),
+		constants.DBColumnIndividualIdentificationType1,
+		constants.DBColumnIndividualIdentificationType2,
+		constants.DBColumnIndividualIdentificationType3),
+	restrictableEnum("contact_method", "preferred_contact_method", enumTypes.AllContactMethods(),
+		constants.DBColumnIndividualPreferredContactMethod),
+	restrictableEnum("service_cc", "service_cc", enumTypes.AllServiceCCs(),
+		constants.DBColumnIndividualServiceCC1,
+		constants.DBColumnIndividualServiceCC2,
+		constants.DBColumnIndividualServiceCC3,
+		constants.DBColumnIndividualServiceCC4,
+		constants.DBColumnIndividualServiceCC5,
+		constants.DBColumnIndividualServiceCC6,
+		constants.DBColumnIndividualServiceCC7),
+}
+
+// RestrictableEnums returns the enums whose values a country can restrict
+func RestrictableEnums() []RestrictableEnum {
+	return restrictableEnums
+}
+
+// RestrictableEnumByName returns the enum with the given name
+func RestrictableEnumByName(name string) (RestrictableEnum, bool) {
+	for _, enum := range restrictableEnums {
+		if enum.Name == name {
+			return enum, true
+		}
+	}
+	return RestrictableEnum{}, false
+}
+
+// ruleFieldExclusions are the db columns that are filled by the system, so they cannot be required or hidden
+var ruleFieldExclusions = containers.NewStringSet(
+	constants.DBColumnIndividualID,
+	constants.DBColumnIndividualCreatedAt,
+	constants.DBColumnIndividualUpdatedAt,
+)
+
+// ValidationRuleFields returns the db columns that a country can require or hide, in the order of the file columns
+func ValidationRuleFields() []string {
+	ret := make([]string, 0, len(constants.IndividualFileColumns))
+	for _, fileColumn := range constants.IndividualFileColumns {
+		column := constants.IndividualFileToDBMap[fileColumn]
+		if column == "" || ruleFieldExclusions.Contains(column) {
+			continue
+		}
+		ret = append(ret, column)
+	}
+	return ret
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountryValidationRulesScanValue(t *testing.T) {
+	rules := CountryValidationRules{
+		RequiredFields:               []string{"last_name"},
+		AllowedValues:                map[string][]string{"sex": {"female"}},
+		IdentificationNumberPatterns: map[string]string{"national_id": "[0-9]+"},
+	}
+	value, err := rules.Value()
+	require.NoError(t, err)
+
+	var scanned CountryValidationRules
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, rules, scanned)
+
+	var empty CountryValidationRules
+	require.NoError(t, empty.Scan([]byte("{}")))
+	assert.Equal(t, CountryValidationRules{}, empty)
+}
+
+func TestCountryValidationRulesIsAllowed(t *testing.T) {
+	rules := CountryValidationRules{AllowedValues: map[string][]string{"sex": {"female"}}}
+	assert.True(t, rules.IsAllowed("sex", "female"))
+	assert.False(t, rules.IsAllowed("sex", "male"))
+	assert.True(t, rules.IsAllowed("sex", ""))
+	assert.True(t, rules.IsAllowed("service_cc", "wash"))
+}
+
+func TestCountryValidationRulesIdentificationNumberRegexp(t *testing.T) {
+	rules := CountryValidationRules{IdentificationNumberPatterns: map[string]string{"national_id": "[0-9]{3}|[A-Z]{2}"}}
+
+	re, err := rules.IdentificationNumberRegexp("national_id")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("123"))
+	assert.True(t, re.MatchString("AB"))
+	assert.False(t, re.MatchString("1234"))
+	assert.False(t, re.MatchString("ABC"))
+
+	re, err = rules.IdentificationNumberRegexp("passport")
+	require.NoError(t, err)
+	assert.Nil(t, re)
+}
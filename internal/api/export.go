@@ -0,0 +1,25 @@
+package api
+
+import "time"
+
+// Export is a file of individuals exported by a user. The file itself is kept in the blob store
+// until the export expires.
+type Export struct {
+	ID        string `db:"id"`
+	CountryID string `db:"country_id"`
+	// OwnerID is the id of the user that requested the export. Only they can download it
+	OwnerID    string `db:"owner_id"`
+	OwnerEmail string `db:"owner_email"`
+	BlobName   string `db:"blob_name"`
+	Format     string `db:"format"`
+	// Filter is the url-encoded list options used to select the exported individuals
+	Filter    string     `db:"filter"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// IsAvailable returns true if the export can still be downloaded at the given time
+func (e *Export) IsAvailable(now time.Time) bool {
+	return e.DeletedAt == nil && now.Before(e.ExpiresAt)
+}
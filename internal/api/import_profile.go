@@ -0,0 +1,18 @@
+package api
+
+import "time"
+
+// ImportProfile is a named column mapping saved for a country, so that files sharing the same
+// headers can be imported again without mapping their columns by hand
+type ImportProfile struct {
+	ID        string `db:"id"`
+	CountryID string `db:"country_id"`
+	Name      string `db:"name"`
+	// HeaderSignature identifies the set of headers the profile was created for
+	HeaderSignature string        `db:"header_signature"`
+	Mapping         ColumnMapping `db:"mapping"`
+	// Settings tell how the dates and numbers of the files are written
+	Settings  TabularParseSettings `db:"parse_settings"`
+	CreatedAt time.Time            `db:"created_at"`
+	UpdatedAt time.Time            `db:"updated_at"`
+}
@@ -714,9 +714,7 @@ func (i *Individual) Normalize() {
 	i.PhoneNumber2 = trimString(i.PhoneNumber2)
 	i.PhoneNumber3 = trimString(i.PhoneNumber3)
 
-	i.NormalizedPhoneNumber1 = NormalizePhoneNumber(i.PhoneNumber1)
-	i.NormalizedPhoneNumber2 = NormalizePhoneNumber(i.PhoneNumber2)
-	i.NormalizedPhoneNumber3 = NormalizePhoneNumber(i.PhoneNumber3)
+	i.NormalizePhoneNumbers("")
 	i.PreferredContactMethod = enumTypes.ContactMethod(trimString(string(i.PreferredContactMethod)))
 	i.PreferredContactMethodComments = trimString(i.PreferredContactMethodComments)
 	i.PreferredName = trimString(i.PreferredName)
@@ -803,3 +801,21 @@ func (i *Individual) Normalize() {
 		i.NativeName = ""
 	}
 }
+
+// NormalizePhoneNumbers sets the normalized phone numbers to the E.164 form of the phone numbers.
+// National numbers are read with the calling code of region, an ISO 3166-1 alpha-2 code,
+// or of the first nationality of the individual when region is empty.
+func (i *Individual) NormalizePhoneNumbers(region string) {
+	region = i.PhoneNumberRegion(region)
+	i.NormalizedPhoneNumber1 = NormalizePhoneNumber(i.PhoneNumber1, region)
+	i.NormalizedPhoneNumber2 = NormalizePhoneNumber(i.PhoneNumber2, region)
+	i.NormalizedPhoneNumber3 = NormalizePhoneNumber(i.PhoneNumber3, region)
+}
+
+// PhoneNumberRegion returns region, or the region of the first nationality of the individual when region is empty
+func (i *Individual) PhoneNumberRegion(region string) string {
+	if region != "" {
+		return region
+	}
+	return PhoneNumberRegionOfNationality(i.Nationality1)
+}
@@ -0,0 +1,62 @@
+package api
+
+import "time"
+
+// AdultAge is the age from which an individual is not a minor
+const AdultAge = 18
+
+// AgeSource is what the derived age of an individual was computed from
+type AgeSource string
+
+const (
+	// AgeSourceNone means the individual has neither a birth date nor a recorded age
+	AgeSourceNone AgeSource = ""
+	// AgeSourceBirthDate means the age was computed from the birth date
+	AgeSourceBirthDate AgeSource = "birth_date"
+	// AgeSourceRecordedAge means the age was computed from the recorded age and the time elapsed since the collection time
+	AgeSourceRecordedAge AgeSource = "recorded_age"
+)
+
+// AgeAt returns the age in full years of someone born on birthDate at the given date
+func AgeAt(birthDate time.Time, at time.Time) int {
+	age := at.Year() - birthDate.Year()
+	if at.Month() < birthDate.Month() || (at.Month() == birthDate.Month() && at.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// DerivedAge returns the age of the individual at the given time.
+// The age is computed from the birth date when present. Otherwise, the full years elapsed since
+// the collection time are added to the recorded age, as the recorded age was true at the collection time.
+func (i *Individual) DerivedAge(at time.Time) (*int, AgeSource) {
+	if i.BirthDate != nil {
+		age := AgeAt(*i.BirthDate, at)
+		return &age, AgeSourceBirthDate
+	}
+	if i.Age != nil {
+		age := *i.Age
+		if !i.CollectionTime.IsZero() && i.CollectionTime.Before(at) {
+			age += AgeAt(i.CollectionTime, at)
+		}
+		return &age, AgeSourceRecordedAge
+	}
+	return nil, AgeSourceNone
+}
+
+// CurrentAge returns the derived age of the individual now, as shown in the lists of individuals
+func (i *Individual) CurrentAge() *int {
+	age, _ := i.DerivedAge(time.Now())
+	return age
+}
+
+// DerivedIsMinor returns whether the individual is a minor at the given time, based on the derived age.
+// It falls back to the recorded minor flag when the age is unknown.
+func (i *Individual) DerivedIsMinor(at time.Time) *bool {
+	age, _ := i.DerivedAge(at)
+	if age == nil {
+		return i.IsMinor
+	}
+	isMinor := *age < AdultAge
+	return &isMinor
+}
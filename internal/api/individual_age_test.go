@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgeAt(t *testing.T) {
+	birthDate := time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 22, AgeAt(birthDate, time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 23, AgeAt(birthDate, time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 0, AgeAt(birthDate, time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestIndividual_DerivedAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		individual  Individual
+		wantAge     *int
+		wantSource  AgeSource
+		wantIsMinor *bool
+	}{
+		{
+			name:       "unknown",
+			individual: Individual{},
+			wantSource: AgeSourceNone,
+		}, {
+			name:        "unknown keeps the recorded minor flag",
+			individual:  Individual{IsMinor: pointers.Bool(true)},
+			wantSource:  AgeSourceNone,
+			wantIsMinor: pointers.Bool(true),
+		}, {
+			name:        "birth date",
+			individual:  Individual{BirthDate: pointers.Time(time.Date(2006, 7, 1, 0, 0, 0, 0, time.UTC)), Age: pointers.Int(30), IsMinor: pointers.Bool(false)},
+			wantAge:     pointers.Int(17),
+			wantSource:  AgeSourceBirthDate,
+			wantIsMinor: pointers.Bool(true),
+		}, {
+			name:        "recorded age grows with time",
+			individual:  Individual{Age: pointers.Int(17), IsMinor: pointers.Bool(true), CollectionTime: time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)},
+			wantAge:     pointers.Int(19),
+			wantSource:  AgeSourceRecordedAge,
+			wantIsMinor: pointers.Bool(false),
+		}, {
+			name:        "recorded age without collection time",
+			individual:  Individual{Age: pointers.Int(12)},
+			wantAge:     pointers.Int(12),
+			wantSource:  AgeSourceRecordedAge,
+			wantIsMinor: pointers.Bool(true),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, source := tt.individual.DerivedAge(now)
+			assert.Equal(t, tt.wantAge, age)
+			assert.Equal(t, tt.wantSource, source)
+			assert.Equal(t, tt.wantIsMinor, tt.individual.DerivedIsMinor(now))
+		})
+	}
+}
+
+func TestIndividual_CurrentAge(t *testing.T) {
+	assert.Nil(t, (&Individual{}).CurrentAge())
+	assert.Equal(t, pointers.Int(12), (&Individual{Age: pointers.Int(12)}).CurrentAge())
+	birthDate := time.Now().AddDate(-30, 0, -1)
+	assert.Equal(t, pointers.Int(30), (&Individual{BirthDate: &birthDate, Age: pointers.Int(5)}).CurrentAge())
+}
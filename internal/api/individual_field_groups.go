@@ -0,0 +1,150 @@
+package api
+
+import (
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/pkg/api/deduplication"
+)
+
+// individualFieldGroups are the field groups of the individual columns holding sensitive attributes.
+// The columns that are not listed are in the general field group.
+var individualFieldGroups = map[string]auth.FieldGroup{
+	constants.DBColumnIndividualAddress:                         auth.FieldGroupContact,
+	constants.DBColumnIndividualEmail1:                          auth.FieldGroupContact,
+	constants.DBColumnIndividualEmail2:                          auth.FieldGroupContact,
+	constants.DBColumnIndividualEmail3:                          auth.FieldGroupContact,
+	constants.DBColumnIndividualPhoneNumber1:                    auth.FieldGroupContact,
+	constants.DBColumnIndividualPhoneNumber2:                    auth.FieldGroupContact,
+	constants.DBColumnIndividualPhoneNumber3:                    auth.FieldGroupContact,
+	constants.DBColumnIndividualNormalizedPhoneNumber1:          auth.FieldGroupContact,
+	constants.DBColumnIndividualNormalizedPhoneNumber2:          auth.FieldGroupContact,
+	constants.DBColumnIndividualNormalizedPhoneNumber3:          auth.FieldGroupContact,
+	constants.DBColumnIndividualPreferredContactMethod:          auth.FieldGroupContact,
+	constants.DBColumnIndividualPreferredContactMethodComments:  auth.FieldGroupContact,
+	constants.DBColumnIndividualIdentificationType1:             auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationType2:             auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationType3:             auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationTypeExplanation1:  auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationTypeExplanation2:  auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationTypeExplanation3:  auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationNumber1:           auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationNumber2:           auth.FieldGroupIdentification,
+	constants.DBColumnIndividualIdentificationNumber3:           auth.FieldGroupIdentification,
+	constants.DBColumnIndividualPresentsProtectionConcerns:      auth.FieldGroupProtection,
+	constants.DBColumnIndividualNeedsLegalAndPhysicalProtection: auth.FieldGroupProtection,
+	constants.DBColumnIndividualIsChildAtRisk:                   auth.FieldGroupProtection,
+	constants.DBColumnIndividualIsWomanAtRisk:                   auth.FieldGroupProtection,
+	constants.DBColumnIndividualIsElderAtRisk:                   auth.FieldGroupProtection,
+	constants.DBColumnIndividualIsSeparatedChild:                auth.FieldGroupProtection,
+	constants.DBColumnIndividualIsSingleParent:                  auth.FieldGroupProtection,
+	constants.DBColumnIndividualVulnerabilityComments:           auth.FieldGroupProtection,
+	constants.DBColumnIndividualHasDisability:                   auth.FieldGroupHealth,
+	constants.DBColumnIndividualPWDComments:                     auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasVisionDisability:             auth.FieldGroupHealth,
+	constants.DBColumnIndividualVisionDisabilityLevel:           auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasHearingDisability:            auth.FieldGroupHealth,
+	constants.DBColumnIndividualHearingDisabilityLevel:          auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasMobilityDisability:           auth.FieldGroupHealth,
+	constants.DBColumnIndividualMobilityDisabilityLevel:         auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasCognitiveDisability:          auth.FieldGroupHealth,
+	constants.DBColumnIndividualCognitiveDisabilityLevel:        auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasSelfCareDisability:           auth.FieldGroupHealth,
+	constants.DBColumnIndividualSelfCareDisabilityLevel:         auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasCommunicationDisability:      auth.FieldGroupHealth,
+	constants.DBColumnIndividualCommunicationDisabilityLevel:    auth.FieldGroupHealth,
+	constants.DBColumnIndividualHasMedicalCondition:             auth.FieldGroupHealth,
+	constants.DBColumnIndividualIsPregnant:                      auth.FieldGroupHealth,
+	constants.DBColumnIndividualIsLactating:                     auth.FieldGroupHealth,
+}
+
+// IndividualFieldGroup returns the field group of the individual column
+func IndividualFieldGroup(column string) auth.FieldGroup {
+	if group, ok := individualFieldGroups[column]; ok {
+		return group
+	}
+	return auth.FieldGroupGeneral
+}
+
+// IndividualFieldGroupColumns returns the individual columns of the field group
+func IndividualFieldGroupColumns(group auth.FieldGroup) []string {
+	var columns []string
+	for _, column := range constants.IndividualDBColumns.Items() {
+		if IndividualFieldGroup(column) == group {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// IndividualFieldAccess tells which individual columns a user can view, edit or export in a country
+type IndividualFieldAccess struct {
+	permissions auth.Interface
+	countryID   string
+}
+
+// NewIndividualFieldAccess returns the access of the user to the individual columns of the country
+func NewIndividualFieldAccess(permissions auth.Interface, countryID string) IndividualFieldAccess {
+	return IndividualFieldAccess{permissions: permissions, countryID: countryID}
+}
+
+// Can returns true if the user has the permission on the column.
+// The id and the country of the individuals are always allowed, since the pages cannot work without them.
+func (a IndividualFieldAccess) Can(column string, perm auth.FieldPermission) bool {
+	if column == constants.DBColumnIndividualID || column == constants.DBColumnIndividualCountryID {
+		return true
+	}
+	if a.permissions == nil {
+		return false
+	}
+	return a.permissions.HasFieldPermission(a.countryID, IndividualFieldGroup(column), perm)
+}
+
+// CanView returns true if the user can view the column
+func (a IndividualFieldAccess) CanView(column string) bool {
+	return a.Can(column, auth.FieldPermissionView)
+}
+
+// CanEdit returns true if the user can edit the column
+func (a IndividualFieldAccess) CanEdit(column string) bool {
+	return a.Can(column, auth.FieldPermissionEdit)
+}
+
+// CanExport returns true if the user can export the column
+func (a IndividualFieldAccess) CanExport(column string) bool {
+	return a.Can(column, auth.FieldPermissionExport)
+}
+
+// CanDeduplicate returns true if the user can view all the columns compared by the deduplication type,
+// since the duplicates that are found show their values
+func (a IndividualFieldAccess) CanDeduplicate(dType deduplication.DeduplicationType) bool {
+	for _, columns := range [][]string{dType.Config.Columns, dType.Config.QueryColumns} {
+		for _, column := range columns {
+			if !a.CanView(column) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Columns returns the given columns on which the user has the permission
+func (a IndividualFieldAccess) Columns(columns []string, perm auth.FieldPermission) []string {
+	allowed := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if a.Can(column, perm) {
+			allowed = append(allowed, column)
+		}
+	}
+	return allowed
+}
+
+// FileColumns returns the columns of the tabular files whose fields the user has the permission on
+func (a IndividualFieldAccess) FileColumns(perm auth.FieldPermission) []string {
+	columns := make([]string, 0, len(constants.IndividualFileColumns))
+	for _, fileColumn := range constants.IndividualFileColumns {
+		if a.Can(constants.IndividualFileToDBMap[fileColumn], perm) {
+			columns = append(columns, fileColumn)
+		}
+	}
+	return columns
+}
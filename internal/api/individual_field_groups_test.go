@@ -0,0 +1,133 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/nrc-no/notcore/pkg/api/deduplication"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFieldAccess(groupPermissions auth.FieldGroupPermissions) IndividualFieldAccess {
+	permissions := auth.New(
+		auth.CountryPermissions{"1": containers.NewSet(auth.PermissionRead)},
+		auth.CountryFieldPermissions{"1": groupPermissions},
+		auth.CountryOfficePermissions{},
+		containers.NewStringSet("1"),
+		false,
+	)
+	return NewIndividualFieldAccess(permissions, "1")
+}
+
+func TestIndividualFieldGroup(t *testing.T) {
+	assert.Equal(t, auth.FieldGroupGeneral, IndividualFieldGroup(constants.DBColumnIndividualFullName))
+	assert.Equal(t, auth.FieldGroupContact, IndividualFieldGroup(constants.DBColumnIndividualPhoneNumber1))
+	assert.Equal(t, auth.FieldGroupIdentification, IndividualFieldGroup(constants.DBColumnIndividualIdentificationNumber1))
+	assert.Equal(t, auth.FieldGroupProtection, IndividualFieldGroup(constants.DBColumnIndividualIsChildAtRisk))
+	assert.Equal(t, auth.FieldGroupHealth, IndividualFieldGroup(constants.DBColumnIndividualHasDisability))
+	assert.Contains(t, IndividualFieldGroupColumns(auth.FieldGroupContact), constants.DBColumnIndividualEmail1)
+	assert.NotContains(t, IndividualFieldGroupColumns(auth.FieldGroupContact), constants.DBColumnIndividualFullName)
+}
+
+func TestIndividualFieldAccess(t *testing.T) {
+	perms := auth.FieldGroupPermissions{}
+	perms.Add(auth.FieldGroupGeneral, auth.FieldPermissionView, auth.FieldPermissionEdit)
+	perms.Add(auth.FieldGroupContact, auth.FieldPermissionView)
+	access := newTestFieldAccess(perms)
+
+	assert.True(t, access.CanView(constants.DBColumnIndividualFullName))
+	assert.True(t, access.CanEdit(constants.DBColumnIndividualFullName))
+	assert.False(t, access.CanExport(constants.DBColumnIndividualFullName))
+	assert.True(t, access.CanView(constants.DBColumnIndividualPhoneNumber1))
+	assert.False(t, access.CanEdit(constants.DBColumnIndividualPhoneNumber1))
+	assert.False(t, access.CanView(constants.DBColumnIndividualIsChildAtRisk))
+	assert.True(t, access.CanExport(constants.DBColumnIndividualID))
+
+	assert.Equal(t,
+		[]string{constants.DBColumnIndividualFullName},
+		access.Columns([]string{constants.DBColumnIndividualFullName, constants.DBColumnIndividualPhoneNumber1}, auth.FieldPermissionEdit),
+	)
+	assert.Equal(t, []string{constants.FileColumnIndividualID}, access.FileColumns(auth.FieldPermissionExport))
+
+	assert.False(t, NewIndividualFieldAccess(nil, "1").CanView(constants.DBColumnIndividualFullName))
+}
+
+func TestIndividualFieldAccessCanDeduplicate(t *testing.T) {
+	perms := auth.FieldGroupPermissions{}
+	perms.Add(auth.FieldGroupGeneral, auth.FieldPermissionView)
+	perms.Add(auth.FieldGroupContact, auth.FieldPermissionView)
+	access := newTestFieldAccess(perms)
+
+	assert.True(t, access.CanDeduplicate(deduplication.DeduplicationTypes[deduplication.DeduplicationTypeNameFullName]))
+	assert.True(t, access.CanDeduplicate(deduplication.DeduplicationTypes[deduplication.DeduplicationTypeNamePhoneNumbers]))
+	assert.False(t, access.CanDeduplicate(deduplication.DeduplicationTypes[deduplication.DeduplicationTypeNameIds]))
+	assert.False(t, NewIndividualFieldAccess(nil, "1").CanDeduplicate(deduplication.DeduplicationTypes[deduplication.DeduplicationTypeNameFullName]))
+}
+
+func TestListIndividualsOptionsRestrictToViewableFields(t *testing.T) {
+	perms := auth.FieldGroupPermissions{}
+	perms.Add(auth.FieldGroupGeneral, auth.FieldPermissionView)
+	access := newTestFieldAccess(perms)
+
+	o := ListIndividualsOptions{
+		FullName:      "John",
+		PhoneNumber:   "123",
+		IsChildAtRisk: pointers.Bool(true),
+		Sort: SortTerms{
+			{Field: constants.DBColumnIndividualFullName},
+			{Field: constants.DBColumnIndividualPhoneNumber1},
+		},
+	}
+	o.RestrictToViewableFields(access)
+
+	assert.Equal(t, ListIndividualsOptions{
+		FullName: "John",
+		Sort:     SortTerms{{Field: constants.DBColumnIndividualFullName}},
+	}, o)
+}
+
+func TestListIndividualsOptionsRestrictToOffices(t *testing.T) {
+	officePermissions := auth.CountryOfficePermissions{}
+	officePermissions.Get("1").Add("north", auth.PermissionRead)
+	officePermissions.Get("1").Add("south", auth.PermissionWrite)
+	officeUser := auth.New(auth.CountryPermissions{}, auth.CountryFieldPermissions{}, officePermissions, containers.NewStringSet("1", "2"), false)
+	countryUser := auth.New(
+		auth.CountryPermissions{"1": containers.NewSet(auth.PermissionRead)},
+		auth.CountryFieldPermissions{},
+		officePermissions,
+		containers.NewStringSet("1", "2"),
+		false,
+	)
+
+	tests := []struct {
+		name        string
+		permissions auth.Interface
+		countryID   string
+		perm        auth.Permission
+		want        []string
+	}{
+		{name: "read", permissions: officeUser, countryID: "1", perm: auth.PermissionRead, want: []string{"north", "south"}},
+		{name: "write", permissions: officeUser, countryID: "1", perm: auth.PermissionWrite, want: []string{"south"}},
+		{name: "other country", permissions: officeUser, countryID: "2", perm: auth.PermissionRead, want: []string{}},
+		{name: "country-wide read", permissions: countryUser, countryID: "1", perm: auth.PermissionRead, want: nil},
+		{name: "office write only", permissions: countryUser, countryID: "1", perm: auth.PermissionWrite, want: []string{"south"}},
+		{name: "no permissions", permissions: nil, countryID: "1", perm: auth.PermissionRead, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := ListIndividualsOptions{CountryID: tt.countryID}
+			o.RestrictToOffices(tt.permissions, tt.countryID, tt.perm)
+			assert.Equal(t, tt.want, o.Offices)
+		})
+	}
+
+	assert.True(t, officeUser.HasCountryPermissionRead("1"))
+	assert.True(t, officeUser.HasCountryPermissionWrite("1"))
+	assert.True(t, officeUser.HasOfficePermission("1", "north", auth.PermissionRead))
+	assert.False(t, officeUser.HasOfficePermission("1", "north", auth.PermissionWrite))
+	assert.False(t, officeUser.HasOfficePermission("1", "east", auth.PermissionRead))
+	assert.True(t, countryUser.HasOfficePermission("1", "east", auth.PermissionRead))
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"io"
+)
+
+// IndividualIterator yields individuals in batches, so that large result sets
+// never have to be held in memory at once
+type IndividualIterator interface {
+	// Next returns the next batch of individuals, or io.EOF when there are no more individuals
+	Next(ctx context.Context) ([]*Individual, error)
+}
+
+type individualSliceIterator struct {
+	individuals []*Individual
+	done        bool
+}
+
+// NewIndividualSliceIterator returns an iterator yielding the given individuals as a single batch
+func NewIndividualSliceIterator(individuals []*Individual) IndividualIterator {
+	return &individualSliceIterator{individuals: individuals}
+}
+
+func (s *individualSliceIterator) Next(ctx context.Context) ([]*Individual, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	s.done = true
+	return s.individuals, nil
+}
+
+// forEachIndividual calls fn with every individual yielded by the iterator, until the
+// iterator is exhausted or fn returns an error
+func forEachIndividual(ctx context.Context, it IndividualIterator, fn func(individual *Individual) error) error {
+	for {
+		batch, err := it.Next(ctx)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		for _, individual := range batch {
+			if err := fn(individual); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// batchIterator yields the given batches one at a time
+type batchIterator struct {
+	batches [][]*Individual
+}
+
+func (b *batchIterator) Next(ctx context.Context) ([]*Individual, error) {
+	if len(b.batches) == 0 {
+		return nil, io.EOF
+	}
+	batch := b.batches[0]
+	b.batches = b.batches[1:]
+	return batch, nil
+}
+
+func newTestBatchIterator() IndividualIterator {
+	return &batchIterator{batches: [][]*Individual{
+		{{ID: "1", FullName: "A"}, {ID: "2", FullName: "B"}},
+		{},
+		{{ID: "3", FullName: "C"}},
+	}}
+}
+
+func TestStreamIndividualsCSV(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	var buf bytes.Buffer
+	require.NoError(t, StreamIndividualsCSV(context.Background(), &buf, newTestBatchIterator(), constants.IndividualFileColumns))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Contains(t, records[1], "A")
+	assert.Contains(t, records[2], "B")
+	assert.Contains(t, records[3], "C")
+}
+
+func TestStreamIndividualsCSVColumns(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	columns := []string{constants.FileColumnIndividualID, constants.FileColumnIndividualFullName}
+	var buf bytes.Buffer
+	require.NoError(t, StreamIndividualsCSV(context.Background(), &buf, newTestBatchIterator(), columns))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, locales.TranslateSlice(columns), records[0])
+	assert.Equal(t, []string{"1", "A"}, records[1])
+	assert.Equal(t, []string{"3", "C"}, records[3])
+}
+
+func TestStreamIndividualsExcel(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	var buf bytes.Buffer
+	require.NoError(t, StreamIndividualsExcel(context.Background(), &buf, newTestBatchIterator(), constants.IndividualFileColumns))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := f.GetRows("Individuals")
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Contains(t, rows[3], "C")
+}
+
+func TestStreamIndividualsCSVError(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	err := StreamIndividualsCSV(context.Background(), io.Discard, failingIterator{}, constants.IndividualFileColumns)
+	assert.ErrorIs(t, err, errIteratorFailed)
+}
+
+var errIteratorFailed = io.ErrUnexpectedEOF
+
+type failingIterator struct{}
+
+func (failingIterator) Next(ctx context.Context) ([]*Individual, error) {
+	return nil, errIteratorFailed
+}
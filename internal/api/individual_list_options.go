@@ -16,6 +16,7 @@ type ListIndividualsOptions struct {
 	Address                         string
 	AgeFrom                         *int
 	AgeTo                           *int
+	AgeBands                        AgeBands
 	BirthDateFrom                   *time.Time
 	BirthDateTo                     *time.Time
 	CognitiveDisabilityLevel        enumTypes.DisabilityLevel
@@ -98,7 +99,14 @@ type ListIndividualsOptions struct {
 	ServiceDonor                    string
 	ServiceProjectName              string
 	ServiceAgentName                string
-	VisionDisabilityLevel           enumTypes.DisabilityLevel
+	// ServiceCatalogueID is the ID of an entry of the service catalogue of the country
+	ServiceCatalogueID string
+	// ServiceCatalogueFilter is the filter of the entry with ServiceCatalogueID, set by ResolveServiceCatalogueFilter
+	ServiceCatalogueFilter *IndividualServiceFilter
+	VisionDisabilityLevel  enumTypes.DisabilityLevel
+	// Offices restricts the individuals to those collected by these offices, when it is not nil.
+	// It is set from the permissions of the user by RestrictToOffices, never from the URL.
+	Offices []string
 }
 
 type SortDirection string
@@ -267,6 +275,15 @@ func (o ListIndividualsOptions) IsNotFemaleHeadedHouseholdSelected() bool {
 	return o.IsFemaleHeadedHousehold != nil && !*o.IsFemaleHeadedHousehold
 }
 
+func (o ListIndividualsOptions) IsAgeBandSelected(label string) bool {
+	for _, band := range o.AgeBands {
+		if band.Label() == label {
+			return true
+		}
+	}
+	return false
+}
+
 func (o ListIndividualsOptions) IsMinorSelected() bool {
 	return o.IsMinor != nil && *o.IsMinor
 }
@@ -392,6 +409,26 @@ func (o ListIndividualsOptions) QueryParams() string {
 	return u.String()
 }
 
+// EncodeFilter returns the url-encoded filters of the options, without paging and sorting
+func (o ListIndividualsOptions) EncodeFilter() string {
+	o.Skip = 0
+	o.Take = 0
+	o.Sort = nil
+	return newListIndividualsOptionsEncoder(o, time.Now()).encode().Encode()
+}
+
+// DashboardQueryParams returns the URL of the country dashboard restricted to the same filters.
+// Paging and sorting are dropped since they do not apply to statistics.
+func (o ListIndividualsOptions) DashboardQueryParams() string {
+	o.Skip = 0
+	o.Take = 0
+	o.Sort = nil
+	params := newListIndividualsOptionsEncoder(o, time.Now()).encode()
+	u := url.URL{Path: "/countries/" + o.CountryID + "/dashboard"}
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
 func NewIndividualListFromURLValues(values url.Values, into *ListIndividualsOptions) error {
 	parser := listIndividualsOptionsDecoder{
 		out:    into,
@@ -400,3 +437,18 @@ func NewIndividualListFromURLValues(values url.Values, into *ListIndividualsOpti
 	}
 	return parser.parse()
 }
+
+// ResolveServiceCatalogueFilter sets the filter matching the service catalogue entry selected in the options.
+// It returns an error if the entry is not in the catalogue.
+func (o *ListIndividualsOptions) ResolveServiceCatalogueFilter(catalogue ServiceCatalogue) error {
+	o.ServiceCatalogueFilter = nil
+	if o.ServiceCatalogueID == "" {
+		return nil
+	}
+	filter, ok := catalogue.Filter(o.ServiceCatalogueID)
+	if !ok {
+		return fmt.Errorf("unknown service catalogue entry %q", o.ServiceCatalogueID)
+	}
+	o.ServiceCatalogueFilter = &filter
+	return nil
+}
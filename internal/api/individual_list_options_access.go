@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+)
+
+// listIndividualsOptionFilters are the columns that each filter of ListIndividualsOptions looks at,
+// with a function clearing the filter
+var listIndividualsOptionFilters = []struct {
+	column string
+	clear  func(o *ListIndividualsOptions)
+}{
+	{constants.DBColumnIndividualInactive, func(o *ListIndividualsOptions) { o.Inactive = nil }},
+	{constants.DBColumnIndividualAddress, func(o *ListIndividualsOptions) { o.Address = "" }},
+	{constants.DBColumnIndividualAge, func(o *ListIndividualsOptions) { o.AgeFrom, o.AgeTo, o.AgeBands = nil, nil, nil }},
+	{constants.DBColumnIndividualBirthDate, func(o *ListIndividualsOptions) { o.BirthDateFrom, o.BirthDateTo = nil, nil }},
+	{constants.DBColumnIndividualCognitiveDisabilityLevel, func(o *ListIndividualsOptions) { o.CognitiveDisabilityLevel = "" }},
+	{constants.DBColumnIndividualCollectionAdministrativeArea1, func(o *ListIndividualsOptions) { o.CollectionAdministrativeArea1 = "" }},
+	{constants.DBColumnIndividualCollectionAdministrativeArea2, func(o *ListIndividualsOptions) { o.CollectionAdministrativeArea2 = "" }},
+	{constants.DBColumnIndividualCollectionAdministrativeArea3, func(o *ListIndividualsOptions) { o.CollectionAdministrativeArea3 = "" }},
+	{constants.DBColumnIndividualCollectionOffice, func(o *ListIndividualsOptions) { o.CollectionOffice = "" }},
+	{constants.DBColumnIndividualCollectionAgentName, func(o *ListIndividualsOptions) { o.CollectionAgentName = "" }},
+	{constants.DBColumnIndividualCollectionAgentTitle, func(o *ListIndividualsOptions) { o.CollectionAgentTitle = "" }},
+	{constants.DBColumnIndividualCollectionTime, func(o *ListIndividualsOptions) { o.CollectionTimeFrom, o.CollectionTimeTo = nil, nil }},
+	{constants.DBColumnIndividualCommunityID, func(o *ListIndividualsOptions) { o.CommunityID = "" }},
+	{constants.DBColumnIndividualCreatedAt, func(o *ListIndividualsOptions) { o.CreatedAtFrom, o.CreatedAtTo = nil, nil }},
+	{constants.DBColumnIndividualDisplacementStatus, func(o *ListIndividualsOptions) { o.DisplacementStatuses = nil }},
+	{constants.DBColumnIndividualEmail1, func(o *ListIndividualsOptions) { o.Email = "" }},
+	{constants.DBColumnIndividualFreeField1, func(o *ListIndividualsOptions) { o.FreeField1 = "" }},
+	{constants.DBColumnIndividualFreeField2, func(o *ListIndividualsOptions) { o.FreeField2 = "" }},
+	{constants.DBColumnIndividualFreeField3, func(o *ListIndividualsOptions) { o.FreeField3 = "" }},
+	{constants.DBColumnIndividualFreeField4, func(o *ListIndividualsOptions) { o.FreeField4 = "" }},
+	{constants.DBColumnIndividualFreeField5, func(o *ListIndividualsOptions) { o.FreeField5 = "" }},
+	{constants.DBColumnIndividualFullName, func(o *ListIndividualsOptions) { o.FullName = "" }},
+	{constants.DBColumnIndividualSex, func(o *ListIndividualsOptions) { o.Sexes = nil }},
+	{constants.DBColumnIndividualHasCognitiveDisability, func(o *ListIndividualsOptions) { o.HasCognitiveDisability = nil }},
+	{constants.DBColumnIndividualHasCommunicationDisability, func(o *ListIndividualsOptions) { o.HasCommunicationDisability = nil }},
+	{constants.DBColumnIndividualHasConsentedToRGPD, func(o *ListIndividualsOptions) { o.HasConsentedToRGPD = nil }},
+	{constants.DBColumnIndividualHasConsentedToReferral, func(o *ListIndividualsOptions) { o.HasConsentedToReferral = nil }},
+	{constants.DBColumnIndividualHasDisability, func(o *ListIndividualsOptions) { o.HasDisability = nil }},
+	{constants.DBColumnIndividualHasHearingDisability, func(o *ListIndividualsOptions) { o.HasHearingDisability = nil }},
+	{constants.DBColumnIndividualHasMobilityDisability, func(o *ListIndividualsOptions) { o.HasMobilityDisability = nil }},
+	{constants.DBColumnIndividualHasSelfCareDisability, func(o *ListIndividualsOptions) { o.HasSelfCareDisability = nil }},
+	{constants.DBColumnIndividualHasVisionDisability, func(o *ListIndividualsOptions) { o.HasVisionDisability = nil }},
+	{constants.DBColumnIndividualHearingDisabilityLevel, func(o *ListIndividualsOptions) { o.HearingDisabilityLevel = "" }},
+	{constants.DBColumnIndividualHouseholdID, func(o *ListIndividualsOptions) { o.HouseholdID = "" }},
+	{constants.DBColumnIndividualIdentificationNumber1, func(o *ListIndividualsOptions) { o.IdentificationNumber = "" }},
+	{constants.DBColumnIndividualEngagementContext, func(o *ListIndividualsOptions) { o.EngagementContext = nil }},
+	{constants.DBColumnIndividualInternalID, func(o *ListIndividualsOptions) { o.InternalID = "" }},
+	{constants.DBColumnIndividualIsHeadOfCommunity, func(o *ListIndividualsOptions) { o.IsHeadOfCommunity = nil }},
+	{constants.DBColumnIndividualIsHeadOfHousehold, func(o *ListIndividualsOptions) { o.IsHeadOfHousehold = nil }},
+	{constants.DBColumnIndividualIsFemaleHeadedHousehold, func(o *ListIndividualsOptions) { o.IsFemaleHeadedHousehold = nil }},
+	{constants.DBColumnIndividualIsMinorHeadedHousehold, func(o *ListIndividualsOptions) { o.IsMinorHeadedHousehold = nil }},
+	{constants.DBColumnIndividualIsMinor, func(o *ListIndividualsOptions) { o.IsMinor = nil }},
+	{constants.DBColumnIndividualIsChildAtRisk, func(o *ListIndividualsOptions) { o.IsChildAtRisk = nil }},
+	{constants.DBColumnIndividualIsWomanAtRisk, func(o *ListIndividualsOptions) { o.IsWomanAtRisk = nil }},
+	{constants.DBColumnIndividualIsElderAtRisk, func(o *ListIndividualsOptions) { o.IsElderAtRisk = nil }},
+	{constants.DBColumnIndividualIsPregnant, func(o *ListIndividualsOptions) { o.IsPregnant = nil }},
+	{constants.DBColumnIndividualIsLactating, func(o *ListIndividualsOptions) { o.IsLactating = nil }},
+	{constants.DBColumnIndividualIsSeparatedChild, func(o *ListIndividualsOptions) { o.IsSeparatedChild = nil }},
+	{constants.DBColumnIndividualIsSingleParent, func(o *ListIndividualsOptions) { o.IsSingleParent = nil }},
+	{constants.DBColumnIndividualHasMedicalCondition, func(o *ListIndividualsOptions) { o.HasMedicalCondition = nil }},
+	{constants.DBColumnIndividualNeedsLegalAndPhysicalProtection, func(o *ListIndividualsOptions) { o.NeedsLegalAndPhysicalProtection = nil }},
+	{constants.DBColumnIndividualMobilityDisabilityLevel, func(o *ListIndividualsOptions) { o.MobilityDisabilityLevel = "" }},
+	{constants.DBColumnIndividualMothersName, func(o *ListIndividualsOptions) { o.MothersName = "" }},
+	{constants.DBColumnIndividualNationality1, func(o *ListIndividualsOptions) { o.Nationality = "" }},
+	{constants.DBColumnIndividualPhoneNumber1, func(o *ListIndividualsOptions) { o.PhoneNumber = "" }},
+	{constants.DBColumnIndividualPreferredContactMethod, func(o *ListIndividualsOptions) { o.PreferredContactMethod = "" }},
+	{constants.DBColumnIndividualPreferredCommunicationLanguage, func(o *ListIndividualsOptions) { o.PreferredCommunicationLanguage = "" }},
+	{constants.DBColumnIndividualPrefersToRemainAnonymous, func(o *ListIndividualsOptions) { o.PrefersToRemainAnonymous = nil }},
+	{constants.DBColumnIndividualPresentsProtectionConcerns, func(o *ListIndividualsOptions) { o.PresentsProtectionConcerns = nil }},
+	{constants.DBColumnIndividualPWDComments, func(o *ListIndividualsOptions) { o.PWDComments = "" }},
+	{constants.DBColumnIndividualVulnerabilityComments, func(o *ListIndividualsOptions) { o.VulnerabilityComments = "" }},
+	{constants.DBColumnIndividualSelfCareDisabilityLevel, func(o *ListIndividualsOptions) { o.SelfCareDisabilityLevel = "" }},
+	{constants.DBColumnIndividualSpokenLanguage1, func(o *ListIndividualsOptions) { o.SpokenLanguage = "" }},
+	{constants.DBColumnIndividualUpdatedAt, func(o *ListIndividualsOptions) { o.UpdatedAtFrom, o.UpdatedAtTo = nil, nil }},
+	{constants.DBColumnIndividualServiceCC1, func(o *ListIndividualsOptions) { o.ServiceCC = nil }},
+	{constants.DBColumnIndividualServiceRequestedDate1, func(o *ListIndividualsOptions) { o.ServiceRequestedDateFrom, o.ServiceRequestedDateTo = nil, nil }},
+	{constants.DBColumnIndividualServiceDeliveredDate1, func(o *ListIndividualsOptions) { o.ServiceDeliveredDateFrom, o.ServiceDeliveredDateTo = nil, nil }},
+	{constants.DBColumnIndividualServiceType1, func(o *ListIndividualsOptions) { o.ServiceType = "" }},
+	{constants.DBColumnIndividualService1, func(o *ListIndividualsOptions) { o.Service = "" }},
+	{constants.DBColumnIndividualServiceSubService1, func(o *ListIndividualsOptions) { o.ServiceSubService = "" }},
+	{constants.DBColumnIndividualServiceLocation1, func(o *ListIndividualsOptions) { o.ServiceLocation = "" }},
+	{constants.DBColumnIndividualServiceDonor1, func(o *ListIndividualsOptions) { o.ServiceDonor = "" }},
+	{constants.DBColumnIndividualServiceProjectName1, func(o *ListIndividualsOptions) { o.ServiceProjectName = "" }},
+	{constants.DBColumnIndividualServiceAgentName1, func(o *ListIndividualsOptions) { o.ServiceAgentName = "" }},
+	{constants.DBColumnIndividualServiceType1, func(o *ListIndividualsOptions) { o.ServiceCatalogueID, o.ServiceCatalogueFilter = "", nil }},
+	{constants.DBColumnIndividualVisionDisabilityLevel, func(o *ListIndividualsOptions) { o.VisionDisabilityLevel = "" }},
+}
+
+// RestrictToViewableFields clears the filters and the sort terms on the columns the user cannot view,
+// so that the list of individuals does not tell anything about them
+func (o *ListIndividualsOptions) RestrictToViewableFields(access IndividualFieldAccess) {
+	for _, filter := range listIndividualsOptionFilters {
+		if !access.CanView(filter.column) {
+			filter.clear(o)
+		}
+	}
+	sort := make(SortTerms, 0, len(o.Sort))
+	for _, term := range o.Sort {
+		if access.CanView(term.Field) {
+			sort = append(sort, term)
+		}
+	}
+	o.Sort = sort
+}
+
+// RestrictToOffices restricts the individuals to those collected by the offices on which the user has the permission,
+// unless the user has it on the whole country
+func (o *ListIndividualsOptions) RestrictToOffices(permissions auth.Interface, countryID string, perm auth.Permission) {
+	if permissions == nil {
+		o.Offices = []string{}
+		return
+	}
+	offices, scoped := permissions.GetAllowedOffices(countryID, perm)
+	if !scoped {
+		o.Offices = nil
+		return
+	}
+	o.Offices = append([]string{}, offices...)
+}
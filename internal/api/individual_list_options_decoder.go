@@ -24,6 +24,7 @@ func (p *listIndividualsOptionsDecoder) parse() error {
 		p.parseAddress,
 		p.parseAgeFrom,
 		p.parseAgeTo,
+		p.parseAgeBands,
 		p.parseBirthDateFrom,
 		p.parseBirthDateTo,
 		p.parseCognitiveDisabilityLevel,
@@ -99,6 +100,7 @@ func (p *listIndividualsOptionsDecoder) parse() error {
 		p.parseServiceDonor,
 		p.parseServiceProjectName,
 		p.parseServiceAgentName,
+		p.parseServiceCatalogueID,
 		p.parseUpdatedAtFrom,
 		p.parseUpdatedAtTo,
 		p.parseSkip,
@@ -323,6 +325,25 @@ func (p *listIndividualsOptionsDecoder) parseMothersName() error {
 	return nil
 }
 
+func (p *listIndividualsOptionsDecoder) parseAgeBands() error {
+	if len(p.values[constants.FormParamsGetIndividualsAgeBand]) == 0 {
+		return nil
+	}
+	var bands AgeBands
+	for _, v := range p.values[constants.FormParamsGetIndividualsAgeBand] {
+		if v == "" {
+			continue
+		}
+		band, err := parseAgeBand(v)
+		if err != nil {
+			return err
+		}
+		bands = append(bands, band)
+	}
+	p.out.AgeBands = bands
+	return nil
+}
+
 func (p *listIndividualsOptionsDecoder) parseSexes() error {
 	if len(p.values[constants.FormParamsGetIndividualsSex]) == 0 {
 		return nil
@@ -489,6 +510,11 @@ func (p *listIndividualsOptionsDecoder) parseServiceAgentName() error {
 	return nil
 }
 
+func (p *listIndividualsOptionsDecoder) parseServiceCatalogueID() error {
+	p.out.ServiceCatalogueID = p.values.Get(constants.FormParamsGetIndividualsServiceCatalogueID)
+	return nil
+}
+
 func (p *listIndividualsOptionsDecoder) parseInternalID() error {
 	p.out.InternalID = p.values.Get(constants.FormParamsGetIndividualsInternalID)
 	return nil
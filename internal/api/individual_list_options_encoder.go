@@ -32,6 +32,7 @@ func (p *listIndividualsOptionsEncoder) encode() url.Values {
 		p.encodeAddress,
 		p.encodeAgeFrom,
 		p.encodeAgeTo,
+		p.encodeAgeBands,
 		p.encodeBirthDateFrom,
 		p.encodeBirthDateTo,
 		p.encodeCognitiveDisabilityLevel,
@@ -105,6 +106,7 @@ func (p *listIndividualsOptionsEncoder) encode() url.Values {
 		p.encodeServiceDonor,
 		p.encodeServiceProjectName,
 		p.encodeServiceAgentName,
+		p.encodeServiceCatalogueID,
 		p.encodeSpokenLanguage,
 		p.encodeUpdatedAtFrom,
 		p.encodeUpdatedAtTo,
@@ -137,6 +139,12 @@ func (p *listIndividualsOptionsEncoder) encodeAgeFrom() {
 	}
 }
 
+func (p *listIndividualsOptionsEncoder) encodeAgeBands() {
+	for _, band := range p.values.AgeBands {
+		p.out.Add(constants.FormParamsGetIndividualsAgeBand, band.Label())
+	}
+}
+
 func (p *listIndividualsOptionsEncoder) encodeAgeTo() {
 	if p.values.AgeTo != nil {
 		p.out.Add(constants.FormParamsGetIndividualsAgeTo, strconv.Itoa(*p.values.AgeTo))
@@ -549,6 +557,12 @@ func (p *listIndividualsOptionsEncoder) encodeServiceAgentName() {
 	}
 }
 
+func (p *listIndividualsOptionsEncoder) encodeServiceCatalogueID() {
+	if len(p.values.ServiceCatalogueID) != 0 {
+		p.out.Add(constants.FormParamsGetIndividualsServiceCatalogueID, p.values.ServiceCatalogueID)
+	}
+}
+
 func (p *listIndividualsOptionsEncoder) encodeNationality() {
 	if len(p.values.Nationality) != 0 {
 		p.out.Add(constants.FormParamsGetIndividualsNationality, p.values.Nationality)
@@ -47,6 +47,22 @@ func TestNewIndividualListFromURLValues(t *testing.T) {
 			name:    "ageTo (invalid)",
 			args:    url.Values{"age_to": []string{"invalid"}},
 			wantErr: true,
+		}, {
+			name: "ageBands",
+			args: url.Values{"age_band": []string{"0-4", "60+"}},
+			want: ListIndividualsOptions{AgeBands: AgeBands{{From: pointers.Int(0), To: pointers.Int(4)}, {From: pointers.Int(60)}}},
+		}, {
+			name: "ageBands (all)",
+			args: url.Values{"age_band": []string{""}},
+			want: ListIndividualsOptions{},
+		}, {
+			name:    "ageBands (invalid)",
+			args:    url.Values{"age_band": []string{"4-0"}},
+			wantErr: true,
+		}, {
+			name: "serviceCatalogueID",
+			args: url.Values{"service_catalogue_id": []string{"abc"}},
+			want: ListIndividualsOptions{ServiceCatalogueID: "abc"},
 		}, {
 			name: "birthDateFrom",
 			args: url.Values{"birth_date_from": []string{"2009-01-01"}},
@@ -574,6 +590,14 @@ func TestListIndividualsOptions_QueryParams(t *testing.T) {
 			name: "ageTo",
 			o:    ListIndividualsOptions{CountryID: countryId, AgeTo: pointers.Int(1)},
 			want: "/countries/usa/participants?age_to=1",
+		}, {
+			name: "ageBands",
+			o:    ListIndividualsOptions{CountryID: countryId, AgeBands: AgeBands{{From: pointers.Int(0), To: pointers.Int(4)}, {From: pointers.Int(60)}}},
+			want: "/countries/usa/participants?age_band=0-4&age_band=60%2B",
+		}, {
+			name: "serviceCatalogueID",
+			o:    ListIndividualsOptions{CountryID: countryId, ServiceCatalogueID: "abc"},
+			want: "/countries/usa/participants?service_catalogue_id=abc",
 		}, {
 			name: "birthDateFrom",
 			o:    ListIndividualsOptions{CountryID: countryId, BirthDateFrom: pointers.Time(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))},
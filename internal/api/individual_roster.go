@@ -0,0 +1,225 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+// RosterHouseholdColumns are the columns that may be given once per household in a household roster.
+//
+// A household roster is a workbook where:
+//   - the first sheet lists the households, one per row, with a household ID column and any of these columns
+//   - the following sheets list the members, one per row, with a household ID column linking them to their household
+//
+// Each member inherits the household columns it leaves empty from its household. Sheets without a household
+// ID column, such as instructions, are ignored.
+var RosterHouseholdColumns = containers.NewStringSet(
+	constants.DBColumnIndividualHouseholdID,
+	constants.DBColumnIndividualHouseholdSize,
+	constants.DBColumnIndividualAddress,
+	constants.DBColumnIndividualCollectionAdministrativeArea1,
+	constants.DBColumnIndividualCollectionAdministrativeArea2,
+	constants.DBColumnIndividualCollectionAdministrativeArea3,
+	constants.DBColumnIndividualCollectionAgentName,
+	constants.DBColumnIndividualCollectionAgentTitle,
+	constants.DBColumnIndividualCollectionOffice,
+	constants.DBColumnIndividualCollectionTime,
+	constants.DBColumnIndividualIsFemaleHeadedHousehold,
+	constants.DBColumnIndividualIsMinorHeadedHousehold,
+)
+
+// RecordSource is the sheet and row of the uploaded file that a record was read from
+type RecordSource struct {
+	Sheet string
+	Row   int
+}
+
+// RecordsFromSheets returns the records to import from the sheets of an uploaded file, and the source
+// of each record after the header.
+// Household rosters are flattened into one row per member, otherwise only the first sheet is imported.
+// The instructions and lists sheets and the instruction row of the upload template are skipped.
+func RecordsFromSheets(sheets []Sheet) ([][]string, []RecordSource, []FileError) {
+	t := locales.GetTranslator()
+	sheets = withoutTemplateInstructions(sheets)
+	if len(sheets) == 0 || len(sheets[0].Records) == 0 {
+		return nil, nil, []FileError{{Message: t("error_failed_to_parse_file"), Err: []error{errors.New("no rows found")}}}
+	}
+	if !IsHouseholdRoster(sheets) {
+		sheet := sheets[0]
+		sources := make([]RecordSource, len(sheet.Records)-1)
+		for i := range sources {
+			sources[i] = RecordSource{Sheet: sheet.Name, Row: sheet.rowNumber(i + 1)}
+		}
+		return sheet.Records, sources, nil
+	}
+	records, sources, errs := flattenHouseholdRoster(sheets)
+	if len(errs) > 0 {
+		return nil, nil, []FileError{{Message: t("error_household_roster"), Err: errs}}
+	}
+	return records, sources, nil
+}
+
+func withoutTemplateInstructions(sheets []Sheet) []Sheet {
+	ret := make([]Sheet, 0, len(sheets))
+	for _, sheet := range sheets {
+		if isTemplateSheet(sheet.Name) {
+			continue
+		}
+		records, rows := dropTemplateInstructionRows(sheet.Records)
+		ret = append(ret, Sheet{Name: sheet.Name, Records: records, rows: rows})
+	}
+	return ret
+}
+
+// IsHouseholdRoster returns true if the first sheet only holds household columns, including the household ID,
+// and at least one other sheet links its rows to the households
+func IsHouseholdRoster(sheets []Sheet) bool {
+	if len(sheets) < 2 || len(sheets[0].Records) == 0 {
+		return false
+	}
+	if rosterHouseholdIDIndex(sheets[0].Records[0]) < 0 {
+		return false
+	}
+	for _, h := range sheets[0].Records[0] {
+		if column, ok := locales.GetDBColumn(h); ok && !RosterHouseholdColumns.Contains(column) {
+			return false
+		}
+	}
+	return len(rosterMemberSheets(sheets)) > 0
+}
+
+// rosterMemberSheets returns the sheets after the households sheet that have a household ID column
+func rosterMemberSheets(sheets []Sheet) []Sheet {
+	var members []Sheet
+	for _, sheet := range sheets[1:] {
+		if len(sheet.Records) > 0 && rosterHouseholdIDIndex(sheet.Records[0]) >= 0 {
+			members = append(members, sheet)
+		}
+	}
+	return members
+}
+
+func rosterHouseholdIDIndex(header []string) int {
+	for i, h := range header {
+		if column, ok := locales.GetDBColumn(h); ok && column == constants.DBColumnIndividualHouseholdID {
+			return i
+		}
+	}
+	return -1
+}
+
+// rosterColumnKey identifies a header across sheets, by its db column when it is known
+func rosterColumnKey(header string) string {
+	if column, ok := locales.GetDBColumn(header); ok {
+		return column
+	}
+	return normalizeHeader(header)
+}
+
+// flattenHouseholdRoster merges the member sheets into a single table, in order, and fills the
+// household columns each member leaves empty from its household. It also returns the member row each record comes from.
+func flattenHouseholdRoster(sheets []Sheet) ([][]string, []RecordSource, []error) {
+	t := locales.GetTranslator()
+	var errs []error
+
+	households := sheets[0]
+	householdHeader := households.Records[0]
+	householdIDIdx := rosterHouseholdIDIndex(householdHeader)
+
+	householdRows := map[string][]string{}
+	for i, row := range households.Records[1:] {
+		id := strings.TrimSpace(row[householdIDIdx])
+		if id == "" {
+			if !isEmptyRecord(row) {
+				errs = append(errs, errors.New(t("error_roster_missing_household_id", households.Name, households.rowNumber(i+1))))
+			}
+			continue
+		}
+		if _, ok := householdRows[id]; ok {
+			errs = append(errs, errors.New(t("error_roster_duplicate_household", households.Name, households.rowNumber(i+1), id)))
+			continue
+		}
+		householdRows[id] = row
+	}
+
+	// the header is the union of the member headers, followed by the household columns the members do not have
+	var (
+		header     []string
+		columnIdx  = map[string]int{}
+		addColumns = func(h []string) {
+			for _, col := range h {
+				key := rosterColumnKey(col)
+				if _, ok := columnIdx[key]; ok || key == "" {
+					continue
+				}
+				columnIdx[key] = len(header)
+				header = append(header, col)
+			}
+		}
+	)
+	members := rosterMemberSheets(sheets)
+	for _, sheet := range members {
+		addColumns(sheet.Records[0])
+	}
+	addColumns(householdHeader)
+
+	records := [][]string{header}
+	var sources []RecordSource
+	householdsWithMembers := containers.NewStringSet()
+	for _, sheet := range members {
+		memberIDIdx := rosterHouseholdIDIndex(sheet.Records[0])
+		for i, row := range sheet.Records[1:] {
+			if isEmptyRecord(row) {
+				continue
+			}
+			id := strings.TrimSpace(row[memberIDIdx])
+			if id == "" {
+				errs = append(errs, errors.New(t("error_roster_missing_household_id", sheet.Name, sheet.rowNumber(i+1))))
+				continue
+			}
+			household, ok := householdRows[id]
+			if !ok {
+				errs = append(errs, errors.New(t("error_roster_unknown_household", sheet.Name, sheet.rowNumber(i+1), id)))
+				continue
+			}
+			householdsWithMembers.Add(id)
+
+			record := make([]string, len(header))
+			for j, col := range sheet.Records[0] {
+				if idx, ok := columnIdx[rosterColumnKey(col)]; ok && j < len(row) {
+					record[idx] = row[j]
+				}
+			}
+			for j, col := range householdHeader {
+				idx, ok := columnIdx[rosterColumnKey(col)]
+				if ok && j < len(household) && strings.TrimSpace(record[idx]) == "" {
+					record[idx] = household[j]
+				}
+			}
+			records = append(records, record)
+			sources = append(sources, RecordSource{Sheet: sheet.Name, Row: sheet.rowNumber(i + 1)})
+		}
+	}
+
+	for i, row := range households.Records[1:] {
+		id := strings.TrimSpace(row[householdIDIdx])
+		if id != "" && !householdsWithMembers.Contains(id) {
+			errs = append(errs, errors.New(t("error_roster_household_without_members", households.Name, households.rowNumber(i+1), id)))
+		}
+	}
+
+	return records, sources, errs
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
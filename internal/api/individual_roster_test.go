@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRecordsFromSheets(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	households := Sheet{Name: "Households", Records: [][]string{
+		{"Household ID", "Address", "Household size", "Collection office"},
+		{"H1", "1 Main street", "2", "Nairobi"},
+		{"H2", "2 Side street", "1", "Dadaab"},
+	}}
+	members := Sheet{Name: "Members", Records: [][]string{
+		{"Full name", "Household ID", "Address"},
+		{"Jane", "H1", ""},
+		{"John", "H1", "Moved out"},
+		{"", "", ""},
+		{"Ali", "H2", ""},
+	}}
+	instructions := Sheet{Name: "Instructions", Records: [][]string{{"Fill in one row per member"}}}
+
+	records, sources, fileErrors := RecordsFromSheets([]Sheet{households, members, instructions})
+	require.Nil(t, fileErrors)
+	assert.Equal(t, [][]string{
+		{"Full name", "Household ID", "Address", "Household size", "Collection office"},
+		{"Jane", "H1", "1 Main street", "2", "Nairobi"},
+		{"John", "H1", "Moved out", "2", "Nairobi"},
+		{"Ali", "H2", "2 Side street", "1", "Dadaab"},
+	}, records)
+	// the empty member row is skipped, so Ali is still reported on the fifth row
+	assert.Equal(t, []RecordSource{
+		{Sheet: "Members", Row: 2},
+		{Sheet: "Members", Row: 3},
+		{Sheet: "Members", Row: 5},
+	}, sources)
+}
+
+func TestRecordsFromSheetsMultipleMemberSheets(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	households := Sheet{Name: "Households", Records: [][]string{
+		{"Household ID", "Address"},
+		{"H1", "1 Main street"},
+	}}
+	adults := Sheet{Name: "Adults", Records: [][]string{
+		{"Household ID", "Full name"},
+		{"H1", "Jane"},
+	}}
+	children := Sheet{Name: "Children", Records: [][]string{
+		{"Full name", "Age", "Household ID"},
+		{"Sam", "4", "H1"},
+	}}
+
+	records, sources, fileErrors := RecordsFromSheets([]Sheet{households, adults, children})
+	require.Nil(t, fileErrors)
+	assert.Equal(t, [][]string{
+		{"Household ID", "Full name", "Age", "Address"},
+		{"H1", "Jane", "", "1 Main street"},
+		{"H1", "Sam", "4", "1 Main street"},
+	}, records)
+	assert.Equal(t, []RecordSource{
+		{Sheet: "Adults", Row: 2},
+		{Sheet: "Children", Row: 2},
+	}, sources)
+}
+
+func TestRecordsFromSheetsWithoutRoster(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	// the first sheet has individual columns, so it is imported on its own
+	individuals := Sheet{Records: [][]string{
+		{"Full name", "Household ID"},
+		{"Jane", "H1"},
+	}}
+	other := Sheet{Records: [][]string{
+		{"Household ID", "Full name"},
+		{"H1", "John"},
+	}}
+	records, sources, fileErrors := RecordsFromSheets([]Sheet{individuals, other})
+	require.Nil(t, fileErrors)
+	assert.Equal(t, individuals.Records, records)
+	assert.Equal(t, []RecordSource{{Row: 2}}, sources)
+	assert.False(t, IsHouseholdRoster([]Sheet{individuals}))
+
+	_, _, fileErrors = RecordsFromSheets([]Sheet{{}})
+	assert.NotNil(t, fileErrors)
+}
+
+func TestRecordsFromSheetsRosterErrors(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	households := Sheet{Name: "Households", Records: [][]string{
+		{"Household ID", "Address"},
+		{"H1", "1 Main street"},
+		{"H1", "duplicate"},
+		{"", "no id"},
+		{"H3", "no members"},
+	}}
+	members := Sheet{Name: "Members", Records: [][]string{
+		{"Full name", "Household ID"},
+		{"Jane", "H1"},
+		{"John", ""},
+		{"Ali", "H2"},
+	}}
+
+	_, _, fileErrors := RecordsFromSheets([]Sheet{households, members})
+	require.Len(t, fileErrors, 1)
+	require.Len(t, fileErrors[0].Err, 5)
+	assert.Contains(t, fileErrors[0].Err[0].Error(), "row 3")
+	assert.Contains(t, fileErrors[0].Err[1].Error(), "row 4")
+	assert.Contains(t, fileErrors[0].Err[2].Error(), "row 3")
+	assert.Contains(t, fileErrors[0].Err[3].Error(), "H2")
+	assert.Contains(t, fileErrors[0].Err[4].Error(), "H3")
+}
+
+func TestUnmarshalSheetsFromExcel(t *testing.T) {
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetName("Sheet1", "Households"))
+	require.NoError(t, f.SetSheetRow("Households", "A1", &[]interface{}{"Household ID", "Address"}))
+	require.NoError(t, f.SetSheetRow("Households", "A2", &[]interface{}{"H1"}))
+	_, err := f.NewSheet("Members")
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow("Members", "A1", &[]interface{}{"Household ID", "Full name"}))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	sheets, err := UnmarshalSheetsFromFile(&buf, "roster.xlsx")
+	require.NoError(t, err)
+	assert.Equal(t, []Sheet{
+		{Name: "Households", Records: [][]string{{"Household ID", "Address"}, {"H1", ""}}},
+		{Name: "Members", Records: [][]string{{"Household ID", "Full name"}}},
+	}, sheets)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+)
+
+// IndividualService is one of the service slots recorded for an individual
+type IndividualService struct {
+	CC            enumTypes.ServiceCC
+	RequestedDate *time.Time
+	DeliveredDate *time.Time
+	Comments      string
+	Type          string
+	Service       string
+	SubService    string
+	Location      string
+	Donor         string
+	ProjectName   string
+	AgentName     string
+}
+
+// IsEmpty returns true if no core competency was recorded for the slot
+func (s IndividualService) IsEmpty() bool {
+	return s.CC == enumTypes.ServiceCCNone
+}
+
+// GetServices returns the service slots of the individual, in order
+func (i *Individual) GetServices() []IndividualService {
+	return []IndividualService{
+		{i.ServiceCC1, i.ServiceRequestedDate1, i.ServiceDeliveredDate1, i.ServiceComments1, i.ServiceType1, i.Service1, i.ServiceSubService1, i.ServiceLocation1, i.ServiceDonor1, i.ServiceProjectName1, i.ServiceAgentName1},
+		{i.ServiceCC2, i.ServiceRequestedDate2, i.ServiceDeliveredDate2, i.ServiceComments2, i.ServiceType2, i.Service2, i.ServiceSubService2, i.ServiceLocation2, i.ServiceDonor2, i.ServiceProjectName2, i.ServiceAgentName2},
+		{i.ServiceCC3, i.ServiceRequestedDate3, i.ServiceDeliveredDate3, i.ServiceComments3, i.ServiceType3, i.Service3, i.ServiceSubService3, i.ServiceLocation3, i.ServiceDonor3, i.ServiceProjectName3, i.ServiceAgentName3},
+		{i.ServiceCC4, i.ServiceRequestedDate4, i.ServiceDeliveredDate4, i.ServiceComments4, i.ServiceType4, i.Service4, i.ServiceSubService4, i.ServiceLocation4, i.ServiceDonor4, i.ServiceProjectName4, i.ServiceAgentName4},
+		{i.ServiceCC5, i.ServiceRequestedDate5, i.ServiceDeliveredDate5, i.ServiceComments5, i.ServiceType5, i.Service5, i.ServiceSubService5, i.ServiceLocation5, i.ServiceDonor5, i.ServiceProjectName5, i.ServiceAgentName5},
+		{i.ServiceCC6, i.ServiceRequestedDate6, i.ServiceDeliveredDate6, i.ServiceComments6, i.ServiceType6, i.Service6, i.ServiceSubService6, i.ServiceLocation6, i.ServiceDonor6, i.ServiceProjectName6, i.ServiceAgentName6},
+		{i.ServiceCC7, i.ServiceRequestedDate7, i.ServiceDeliveredDate7, i.ServiceComments7, i.ServiceType7, i.Service7, i.ServiceSubService7, i.ServiceLocation7, i.ServiceDonor7, i.ServiceProjectName7, i.ServiceAgentName7},
+	}
+}
+
+// individualServiceCatalogueValues points to the fields of a service slot whose values come from the service catalogue
+type individualServiceCatalogueValues struct {
+	cc          *enumTypes.ServiceCC
+	serviceType *string
+	service     *string
+	subService  *string
+	donor       *string
+	projectName *string
+}
+
+func (i *Individual) serviceCatalogueValues() []individualServiceCatalogueValues {
+	return []individualServiceCatalogueValues{
+		{&i.ServiceCC1, &i.ServiceType1, &i.Service1, &i.ServiceSubService1, &i.ServiceDonor1, &i.ServiceProjectName1},
+		{&i.ServiceCC2, &i.ServiceType2, &i.Service2, &i.ServiceSubService2, &i.ServiceDonor2, &i.ServiceProjectName2},
+		{&i.ServiceCC3, &i.ServiceType3, &i.Service3, &i.ServiceSubService3, &i.ServiceDonor3, &i.ServiceProjectName3},
+		{&i.ServiceCC4, &i.ServiceType4, &i.Service4, &i.ServiceSubService4, &i.ServiceDonor4, &i.ServiceProjectName4},
+		{&i.ServiceCC5, &i.ServiceType5, &i.Service5, &i.ServiceSubService5, &i.ServiceDonor5, &i.ServiceProjectName5},
+		{&i.ServiceCC6, &i.ServiceType6, &i.Service6, &i.ServiceSubService6, &i.ServiceDonor6, &i.ServiceProjectName6},
+		{&i.ServiceCC7, &i.ServiceType7, &i.Service7, &i.ServiceSubService7, &i.ServiceDonor7, &i.ServiceProjectName7},
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+)
+
+// AgeBand is an inclusive age range used to disaggregate statistics.
+// A nil From or To means the band is open on that side.
+type AgeBand struct {
+	From *int
+	To   *int
+}
+
+// Label returns a short human-readable representation of the band, e.g. "0-4" or "60+".
+func (a AgeBand) Label() string {
+	switch {
+	case a.From != nil && a.To != nil:
+		return fmt.Sprintf("%d-%d", *a.From, *a.To)
+	case a.From != nil:
+		return fmt.Sprintf("%d+", *a.From)
+	case a.To != nil:
+		return fmt.Sprintf("<%d", *a.To+1)
+	default:
+		return ""
+	}
+}
+
+// Contains returns true if the given age falls within the band.
+func (a AgeBand) Contains(age int) bool {
+	if a.From != nil && age < *a.From {
+		return false
+	}
+	if a.To != nil && age > *a.To {
+		return false
+	}
+	return true
+}
+
+type AgeBands []AgeBand
+
+// DefaultAgeBands are the age bands commonly used in humanitarian reporting.
+var DefaultAgeBands = AgeBands{
+	{From: pointers.Int(0), To: pointers.Int(4)},
+	{From: pointers.Int(5), To: pointers.Int(11)},
+	{From: pointers.Int(12), To: pointers.Int(17)},
+	{From: pointers.Int(18), To: pointers.Int(59)},
+	{From: pointers.Int(60)},
+}
+
+// String returns the bands in the format accepted by ParseAgeBands.
+func (a AgeBands) String() string {
+	labels := make([]string, len(a))
+	for i, band := range a {
+		labels[i] = band.Label()
+	}
+	return strings.Join(labels, ",")
+}
+
+// ParseAgeBands parses a comma separated list of age bands such as "0-4,5-17,18-59,60+".
+// Bands must be given in ascending order and must not overlap.
+func ParseAgeBands(s string) (AgeBands, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("age bands must not be empty")
+	}
+	var bands AgeBands
+	for _, part := range strings.Split(s, ",") {
+		band, err := parseAgeBand(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if len(bands) > 0 {
+			prev := bands[len(bands)-1]
+			if prev.To == nil || band.From == nil || *band.From <= *prev.To {
+				return nil, fmt.Errorf("age band %q overlaps with %q", band.Label(), prev.Label())
+			}
+		}
+		bands = append(bands, band)
+	}
+	return bands, nil
+}
+
+func parseAgeBand(s string) (AgeBand, error) {
+	if strings.HasSuffix(s, "+") {
+		from, err := strconv.Atoi(strings.TrimSuffix(s, "+"))
+		if err != nil || from < 0 {
+			return AgeBand{}, fmt.Errorf("invalid age band %q", s)
+		}
+		return AgeBand{From: &from}, nil
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return AgeBand{}, fmt.Errorf("invalid age band %q", s)
+	}
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || from < 0 {
+		return AgeBand{}, fmt.Errorf("invalid age band %q", s)
+	}
+	to, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || to < from {
+		return AgeBand{}, fmt.Errorf("invalid age band %q", s)
+	}
+	return AgeBand{From: &from, To: &to}, nil
+}
+
+// IndividualStatistics holds the disaggregated registration counts for a set of individuals
+type IndividualStatistics struct {
+	Total     int
+	BySex     []SexCount
+	ByAgeBand []StatisticsCount
+	// ByDisability is nil when the user cannot view the health fields
+	ByDisability         *DisabilityCounts
+	ByDisplacementStatus []DisplacementStatusCount
+	ByAdministrativeArea []StatisticsCount
+	ByRegistrationMonth  []StatisticsCount
+}
+
+// StatisticsCount is the number of individuals sharing the same Key
+type StatisticsCount struct {
+	Key   string `db:"key"`
+	Count int    `db:"count"`
+}
+
+type SexCount struct {
+	Sex   enumTypes.Sex `db:"key"`
+	Count int           `db:"count"`
+}
+
+type DisplacementStatusCount struct {
+	DisplacementStatus enumTypes.DisplacementStatus `db:"key"`
+	Count              int                          `db:"count"`
+}
+
+// DisabilityCounts is the number of individuals having each kind of disability
+type DisabilityCounts struct {
+	HasDisability              int `db:"has_disability"`
+	HasVisionDisability        int `db:"has_vision_disability"`
+	HasHearingDisability       int `db:"has_hearing_disability"`
+	HasMobilityDisability      int `db:"has_mobility_disability"`
+	HasCognitiveDisability     int `db:"has_cognitive_disability"`
+	HasSelfCareDisability      int `db:"has_selfcare_disability"`
+	HasCommunicationDisability int `db:"has_communication_disability"`
+}
+
+// Percentage returns the share of the total represented by count, rounded to the nearest integer
+func (s IndividualStatistics) Percentage(count int) int {
+	if s.Total == 0 {
+		return 0
+	}
+	return (count*100 + s.Total/2) / s.Total
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAgeBands(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    AgeBands
+		wantErr bool
+	}{
+		{
+			name:  "default",
+			value: "0-4,5-11,12-17,18-59,60+",
+			want:  DefaultAgeBands,
+		}, {
+			name:  "with spaces",
+			value: " 0 - 17 , 18+ ",
+			want: AgeBands{
+				{From: pointers.Int(0), To: pointers.Int(17)},
+				{From: pointers.Int(18)},
+			},
+		}, {
+			name:    "empty",
+			value:   "",
+			wantErr: true,
+		}, {
+			name:    "invalid",
+			value:   "0-4,abc",
+			wantErr: true,
+		}, {
+			name:    "reversed",
+			value:   "17-0",
+			wantErr: true,
+		}, {
+			name:    "overlapping",
+			value:   "0-17,17-59",
+			wantErr: true,
+		}, {
+			name:    "after open band",
+			value:   "60+,70-80",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAgeBands(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAgeBand_Contains(t *testing.T) {
+	band := AgeBand{From: pointers.Int(5), To: pointers.Int(11)}
+	assert.False(t, band.Contains(4))
+	assert.True(t, band.Contains(5))
+	assert.True(t, band.Contains(11))
+	assert.False(t, band.Contains(12))
+	assert.True(t, AgeBand{From: pointers.Int(60)}.Contains(99))
+}
+
+func TestIndividualStatistics_Percentage(t *testing.T) {
+	assert.Equal(t, 0, IndividualStatistics{}.Percentage(0))
+	assert.Equal(t, 33, IndividualStatistics{Total: 3}.Percentage(1))
+	assert.Equal(t, 67, IndividualStatistics{Total: 3}.Percentage(2))
+	assert.Equal(t, 100, IndividualStatistics{Total: 3}.Percentage(3))
+}
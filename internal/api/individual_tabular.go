@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
@@ -22,6 +23,59 @@ type FileError struct {
 	Err     []error
 }
 
+// Sheet is a named table of records read from an uploaded file. CSV files have a single unnamed sheet.
+type Sheet struct {
+	Name    string
+	Records [][]string
+	// rows are the numbers of the rows of the file the records were read from, once some rows
+	// were dropped. When nil, each record is the row following the previous one.
+	rows []int
+}
+
+// rowNumber returns the number of the row of the file that the record at the given index was read from
+func (s Sheet) rowNumber(idx int) int {
+	if s.rows != nil {
+		return s.rows[idx]
+	}
+	return idx + 1
+}
+
+// Uploaded spreadsheets are read whole in memory. A few bytes can describe a large sheet, through
+// repeated or far away cells, so the size of the sheets read is bounded as well as the size of the file.
+const (
+	// MaxUploadSize is the size in bytes of the largest file that is read
+	MaxUploadSize = 32 << 20
+	// maxSheetRows is the number of rows of a sheet that are read at most
+	maxSheetRows = 50000
+	// maxSheetColumns is the number of columns of a sheet that are read at most
+	maxSheetColumns = 1024
+	// maxFileCells is the number of cells of all the sheets of a file that are read at most,
+	// once the rows are padded to the same length
+	maxFileCells = 4000000
+	// maxCellLength is the number of characters of a cell that are read at most, as in Excel
+	maxCellLength = 32767
+)
+
+var (
+	errFileTooLarge   = fmt.Errorf("the file is larger than %d MB", MaxUploadSize>>20)
+	errTooManyRows    = fmt.Errorf("a sheet has more than %d rows", maxSheetRows)
+	errTooManyColumns = fmt.Errorf("a sheet has more than %d columns", maxSheetColumns)
+	errTooManyCells   = fmt.Errorf("the file has more than %d cells", maxFileCells)
+	errCellTooLong    = fmt.Errorf("a cell has more than %d characters", maxCellLength)
+)
+
+// readUpload reads the whole content of an uploaded file, unless it is larger than MaxUploadSize
+func readUpload(reader io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(reader, MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > MaxUploadSize {
+		return nil, errFileTooLarge
+	}
+	return content, nil
+}
+
 // Unmarshal
 
 func UnmarshalRecordsFromCSV(records *[][]string, reader io.Reader) error {
@@ -64,26 +118,81 @@ func UnmarshalRecordsFromExcel(records *[][]string, reader io.Reader) error {
 		return err
 	}
 	f.Close()
-	if err == nil {
-		*records = rows
+	padded, err := padRecordsToHeader(rows)
+	if err != nil {
+		return err
+	}
+	*records = padded
+	return nil
+}
+
+// UnmarshalSheetsFromExcel reads all the sheets of an Excel workbook, in order
+func UnmarshalSheetsFromExcel(reader io.Reader) ([]Sheet, error) {
+	f, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	var sheets []Sheet
+	for _, name := range f.GetSheetList() {
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return nil, err
+		}
+		records, err := padRecordsToHeader(rows)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, Sheet{Name: name, Records: records})
 	}
+	if len(sheets) == 0 {
+		return nil, errors.New("no sheets found")
+	}
+	return sheets, nil
+}
 
-	for i, record := range *records {
-		header := (*records)[0]
+// padRecordsToHeader fills the rows shorter than the header with empty cells, as spreadsheet
+// readers leave out the trailing empty cells of a row. The padded records must fit in maxFileCells.
+func padRecordsToHeader(records [][]string) ([][]string, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+	header := records[0]
+	if len(records) > maxSheetRows {
+		return nil, errTooManyRows
+	}
+	if len(header) > maxSheetColumns {
+		return nil, errTooManyColumns
+	}
+	if len(records)*len(header) > maxFileCells {
+		return nil, errTooManyCells
+	}
+	for i, record := range records {
 		diff := len(header) - len(record)
 		if diff > 0 {
 			filler := make([]string, diff)
-			(*records)[i] = append((*records)[i], filler...)
+			records[i] = append(records[i], filler...)
 		}
 	}
-	return err
+	return records, nil
 }
 
 func UnmarshallRecordsFromFile(records *[][]string, reader io.Reader, filename string) error {
-	if strings.HasSuffix(filename, ".csv") {
+	lowerFilename := strings.ToLower(filename)
+	if strings.HasSuffix(lowerFilename, ".csv") {
 		return UnmarshalRecordsFromCSV(records, reader)
-	} else if strings.HasSuffix(filename, ".xlsx") || strings.HasSuffix(filename, ".xls") {
+	} else if strings.HasSuffix(lowerFilename, ".xlsx") {
 		return UnmarshalRecordsFromExcel(records, reader)
+	} else if strings.HasSuffix(lowerFilename, ".xls") {
+		return UnmarshalRecordsFromXLS(records, reader)
+	} else if strings.HasSuffix(lowerFilename, ".ods") {
+		return UnmarshalRecordsFromODS(records, reader)
 	} else {
 		t := locales.GetTranslator()
 		fileNameParts := strings.Split(filename, ".")
@@ -93,6 +202,23 @@ func UnmarshallRecordsFromFile(records *[][]string, reader io.Reader, filename s
 	}
 }
 
+// UnmarshalSheetsFromFile reads all the sheets of an uploaded file, in order
+func UnmarshalSheetsFromFile(reader io.Reader, filename string) ([]Sheet, error) {
+	lowerFilename := strings.ToLower(filename)
+	if strings.HasSuffix(lowerFilename, ".xlsx") {
+		return UnmarshalSheetsFromExcel(reader)
+	} else if strings.HasSuffix(lowerFilename, ".xls") {
+		return UnmarshalSheetsFromXLS(reader)
+	} else if strings.HasSuffix(lowerFilename, ".ods") {
+		return UnmarshalSheetsFromODS(reader)
+	}
+	var records [][]string
+	if err := UnmarshallRecordsFromFile(&records, reader, filename); err != nil {
+		return nil, err
+	}
+	return []Sheet{{Records: records}}, nil
+}
+
 func GetColumnMapping(header []string, fields *[]string) (map[string]int, []FileError) {
 	dbCols, err := locales.GetDBColumns(header)
 	t := locales.GetTranslator()
@@ -887,27 +1013,37 @@ func (i *Individual) UnmarshalTabularData(colMapping map[string]int, cols []stri
 // Marshal
 
 func MarshalIndividualsCSV(w io.Writer, individuals []*Individual) error {
+	return StreamIndividualsCSV(context.Background(), w, NewIndividualSliceIterator(individuals), constants.IndividualFileColumns)
+}
+
+// StreamIndividualsCSV writes the given file columns of the individuals as CSV as they are read from the iterator
+func StreamIndividualsCSV(ctx context.Context, w io.Writer, individuals IndividualIterator, columns []string) error {
 	csvEncoder := csv.NewWriter(w)
 	defer csvEncoder.Flush()
 
-	if err := csvEncoder.Write(locales.TranslateSlice(constants.IndividualFileColumns)); err != nil {
+	if err := csvEncoder.Write(locales.TranslateSlice(columns)); err != nil {
 		return err
 	}
 
-	for _, individual := range individuals {
-		row, err := individual.MarshalTabularData()
+	return forEachIndividual(ctx, individuals, func(individual *Individual) error {
+		row, err := individual.MarshalTabularColumns(columns)
 		if err != nil {
 			return err
 		}
 		if err := csvEncoder.Write(row); err != nil {
 			return err
 		}
-	}
-
-	return nil
+		return csvEncoder.Error()
+	})
 }
 
 func MarshalIndividualsExcel(w io.Writer, individuals []*Individual) error {
+	return StreamIndividualsExcel(context.Background(), w, NewIndividualSliceIterator(individuals), constants.IndividualFileColumns)
+}
+
+// StreamIndividualsExcel writes the given file columns of the individuals as an xlsx workbook as they are read from the iterator.
+// Rows go through the excelize stream writer, which keeps at most a small chunk of the sheet in memory.
+func StreamIndividualsExcel(ctx context.Context, w io.Writer, individuals IndividualIterator, columns []string) error {
 	const sheetName = "Individuals"
 
 	f := excelize.NewFile()
@@ -925,18 +1061,24 @@ func MarshalIndividualsExcel(w io.Writer, individuals []*Individual) error {
 		return err
 	}
 
-	if err := streamWriter.SetRow("A1", stringArrayToInterfaceArray(locales.TranslateSlice(constants.IndividualFileColumns))); err != nil {
+	if err := streamWriter.SetRow("A1", stringArrayToInterfaceArray(locales.TranslateSlice(columns))); err != nil {
 		return err
 	}
 
-	for idx, individual := range individuals {
-		row, err := individual.MarshalTabularData()
+	rowIdx := 2
+	err = forEachIndividual(ctx, individuals, func(individual *Individual) error {
+		row, err := individual.MarshalTabularColumns(columns)
 		if err != nil {
 			return err
 		}
-		if err := streamWriter.SetRow(fmt.Sprintf("A%d", idx+2), stringArrayToInterfaceArray(row)); err != nil {
+		if err := streamWriter.SetRow(fmt.Sprintf("A%d", rowIdx), stringArrayToInterfaceArray(row)); err != nil {
 			return err
 		}
+		rowIdx++
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if err := streamWriter.Flush(); err != nil {
@@ -951,8 +1093,13 @@ func MarshalIndividualsExcel(w io.Writer, individuals []*Individual) error {
 }
 
 func (i *Individual) MarshalTabularData() ([]string, error) {
-	row := make([]string, len(constants.IndividualFileColumns))
-	for j, col := range constants.IndividualFileColumns {
+	return i.MarshalTabularColumns(constants.IndividualFileColumns)
+}
+
+// MarshalTabularColumns returns the values of the given file columns of the individual
+func (i *Individual) MarshalTabularColumns(columns []string) ([]string, error) {
+	row := make([]string, len(columns))
+	for j, col := range columns {
 		field, ok := constants.IndividualFileToDBMap[col]
 		if !ok {
 			return nil, fmt.Errorf("unknown column %s", col) // should not happen but we never know.
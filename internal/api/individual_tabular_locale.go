@@ -0,0 +1,540 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+// DateOrder is the order of the day, month and year of dates written with numbers only, such as 03/04/2021
+type DateOrder string
+
+const (
+	// DateOrderAuto detects the order from the values of each column
+	DateOrderAuto DateOrder = ""
+	DateOrderDMY  DateOrder = "dmy"
+	DateOrderMDY  DateOrder = "mdy"
+	DateOrderYMD  DateOrder = "ymd"
+)
+
+func AllDateOrders() []DateOrder {
+	return []DateOrder{DateOrderAuto, DateOrderDMY, DateOrderMDY, DateOrderYMD}
+}
+
+func ParseDateOrder(s string) (DateOrder, error) {
+	for _, o := range AllDateOrders() {
+		if string(o) == s {
+			return o, nil
+		}
+	}
+	return DateOrderAuto, fmt.Errorf("unknown date order %q", s)
+}
+
+// Label returns the translated description of the order
+func (o DateOrder) Label() string {
+	t := locales.GetTranslator()
+	switch o {
+	case DateOrderDMY:
+		return t("date_order_dmy")
+	case DateOrderMDY:
+		return t("date_order_mdy")
+	case DateOrderYMD:
+		return t("date_order_ymd")
+	default:
+		return t("date_order_auto")
+	}
+}
+
+// tabularLocale describes how the offices using a locale write dates and numbers
+type tabularLocale struct {
+	dateOrder DateOrder
+	// groupSeparators are the thousands separators
+	groupSeparators string
+	// decimalSeparators are the separators of the decimal part
+	decimalSeparators string
+}
+
+// tabularLocales are the locales uploaded files can be written in
+var tabularLocales = map[string]tabularLocale{
+	"ar":    {dateOrder: DateOrderDMY, groupSeparators: "٬", decimalSeparators: "٫."},
+	"en-GB": {dateOrder: DateOrderDMY, groupSeparators: ",", decimalSeparators: "."},
+	"en-US": {dateOrder: DateOrderMDY, groupSeparators: ",", decimalSeparators: "."},
+	"es":    {dateOrder: DateOrderDMY, groupSeparators: ".", decimalSeparators: ","},
+	"fr":    {dateOrder: DateOrderDMY, groupSeparators: ".", decimalSeparators: ","},
+	"ja":    {dateOrder: DateOrderYMD, groupSeparators: ",", decimalSeparators: "."},
+}
+
+// defaultTabularLocale is used when the locale of the file is not known. Only separators that cannot be
+// mistaken for one another are accepted.
+var defaultTabularLocale = tabularLocale{groupSeparators: "٬", decimalSeparators: "٫"}
+
+// TabularLocales returns the locales uploaded files can be written in, sorted
+func TabularLocales() []string {
+	ret := make([]string, 0, len(tabularLocales))
+	for l := range tabularLocales {
+		ret = append(ret, l)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// TabularParseSettings tell how the dates and numbers of an uploaded file are written.
+// They are chosen for each upload, or saved with an import profile.
+type TabularParseSettings struct {
+	DateOrder DateOrder `json:"date_order,omitempty"`
+	// Locale is one of TabularLocales, or empty when unknown
+	Locale string `json:"locale,omitempty"`
+}
+
+func NewTabularParseSettings(dateOrder string, locale string) (TabularParseSettings, error) {
+	order, err := ParseDateOrder(dateOrder)
+	if err != nil {
+		return TabularParseSettings{}, err
+	}
+	if _, ok := tabularLocales[locale]; locale != "" && !ok {
+		return TabularParseSettings{}, fmt.Errorf("unknown locale %q", locale)
+	}
+	return TabularParseSettings{DateOrder: order, Locale: locale}, nil
+}
+
+// WithDefaults fills the settings left unset from the given defaults
+func (s TabularParseSettings) WithDefaults(defaults TabularParseSettings) TabularParseSettings {
+	if s.DateOrder == DateOrderAuto {
+		s.DateOrder = defaults.DateOrder
+	}
+	if s.Locale == "" {
+		s.Locale = defaults.Locale
+	}
+	return s
+}
+
+func (s TabularParseSettings) locale() tabularLocale {
+	if l, ok := tabularLocales[s.Locale]; ok {
+		return l
+	}
+	return defaultTabularLocale
+}
+
+// Scan implements sql.Scanner
+func (s *TabularParseSettings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into TabularParseSettings", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (s TabularParseSettings) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// DateAmbiguityWarning reports a date column whose values could be read both as day/month/year
+// and as month/day/year
+type DateAmbiguityWarning struct {
+	// Column is the db column
+	Column string
+	// Example is one of the ambiguous values
+	Example string
+	// Guess is the order the values were read with
+	Guess DateOrder
+}
+
+func (w DateAmbiguityWarning) String() string {
+	t := locales.GetTranslator()
+	return t("warning_ambiguous_dates", ColumnLabel(w.Column), w.Example, w.Guess.Label())
+}
+
+// ColumnLabel returns the translated file column name of a db column
+func ColumnLabel(column string) string {
+	return locales.GetTranslator()(fileColumnOf(column))
+}
+
+// fileColumnOf returns the translation key of a db column
+func fileColumnOf(column string) string {
+	for fileColumn, dbColumn := range constants.IndividualFileToDBMap {
+		if dbColumn == column {
+			return fileColumn
+		}
+	}
+	return column
+}
+
+var tabularDateColumns = containers.NewStringSet(
+	constants.DBColumnIndividualBirthDate,
+	constants.DBColumnIndividualCollectionTime,
+	constants.DBColumnIndividualServiceRequestedDate1,
+	constants.DBColumnIndividualServiceRequestedDate2,
+	constants.DBColumnIndividualServiceRequestedDate3,
+	constants.DBColumnIndividualServiceRequestedDate4,
+	constants.DBColumnIndividualServiceRequestedDate5,
+	constants.DBColumnIndividualServiceRequestedDate6,
+	constants.DBColumnIndividualServiceRequestedDate7,
+	constants.DBColumnIndividualServiceDeliveredDate1,
+	constants.DBColumnIndividualServiceDeliveredDate2,
+	constants.DBColumnIndividualServiceDeliveredDate3,
+	constants.DBColumnIndividualServiceDeliveredDate4,
+	constants.DBColumnIndividualServiceDeliveredDate5,
+	constants.DBColumnIndividualServiceDeliveredDate6,
+	constants.DBColumnIndividualServiceDeliveredDate7,
+)
+
+var tabularIntegerColumns = containers.NewStringSet(
+	constants.DBColumnIndividualAge,
+	constants.DBColumnIndividualCommunitySize,
+	constants.DBColumnIndividualHouseholdSize,
+)
+
+var tabularPhoneNumberColumns = containers.NewStringSet(
+	constants.DBColumnIndividualPhoneNumber1,
+	constants.DBColumnIndividualPhoneNumber2,
+	constants.DBColumnIndividualPhoneNumber3,
+)
+
+// NormalizeTabularRecords rewrites the dates of the records as YYYY-MM-DD, and the numbers with ASCII digits
+// and without separators, as expected by UnmarshalIndividualsTabularData. Values that cannot be read are left
+// untouched, so that they are reported when the individuals are unmarshalled.
+//
+// The order of numeric dates is detected for each column, unless set in the settings. Columns where it
+// cannot be detected are read in the order of the locale, or day first with a warning when the locale is unknown.
+func NormalizeTabularRecords(records [][]string, settings TabularParseSettings) ([][]string, []DateAmbiguityWarning) {
+	if len(records) == 0 {
+		return records, nil
+	}
+	ret := make([][]string, len(records))
+	ret[0] = records[0]
+	for i, row := range records[1:] {
+		ret[i+1] = append([]string(nil), row...)
+	}
+
+	var warnings []DateAmbiguityWarning
+	locale := settings.locale()
+	for col, header := range records[0] {
+		column, ok := locales.GetDBColumn(header)
+		if !ok {
+			continue
+		}
+		switch {
+		case tabularDateColumns.Contains(column):
+			if warning := normalizeDateColumn(ret[1:], col, settings); warning != nil {
+				warning.Column = column
+				warnings = append(warnings, *warning)
+			}
+		case tabularIntegerColumns.Contains(column):
+			for _, row := range ret[1:] {
+				if col < len(row) {
+					row[col] = normalizeInteger(row[col], locale)
+				}
+			}
+		case tabularPhoneNumberColumns.Contains(column):
+			for _, row := range ret[1:] {
+				if col < len(row) {
+					row[col] = NormalizeDigits(row[col])
+				}
+			}
+		}
+	}
+	return ret, warnings
+}
+
+// NormalizeDigits replaces the decimal digits of any script, such as Eastern Arabic or Devanagari digits,
+// with ASCII digits
+func NormalizeDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x80 || !unicode.IsDigit(r) {
+			return r
+		}
+		// digits come in runs of ten, from zero to nine, so the start of the run gives the value
+		start := r
+		for unicode.IsDigit(start - 1) {
+			start--
+		}
+		return '0' + (r-start)%10
+	}, s)
+}
+
+// normalizeInteger removes the thousands separators and a zero decimal part, e.g. "1,000.0" in en-GB
+func normalizeInteger(value string, locale tabularLocale) string {
+	value = strings.TrimSpace(NormalizeDigits(value))
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune(locale.groupSeparators, r) {
+			return -1
+		}
+		return r
+	}, value)
+	if idx := strings.IndexAny(value, locale.decimalSeparators); idx > 0 {
+		_, size := utf8.DecodeRuneInString(value[idx:])
+		if strings.Trim(value[idx+size:], "0") == "" {
+			value = value[:idx]
+		}
+	}
+	return value
+}
+
+var (
+	// timeSuffixRegex matches the time following a date, such as "T10:00:00Z" or " 10:00 AM"
+	timeSuffixRegex = regexp.MustCompile(`(?i)(T|\s+)\d{1,2}:\d{2}(:\d{2}(\.\d+)?)?\s*(Z|[+-]\d{2}:?\d{2}|AM|PM)?$`)
+	// dateTokenRegex splits a date into its numbers and words
+	dateTokenRegex = regexp.MustCompile(`\d+|\p{L}+`)
+)
+
+// parsedDate is a date read from a single value. Numeric dates where the day and month could be swapped
+// hold both readings until the order of the column is known.
+type parsedDate struct {
+	date time.Time
+	// dayMonth is the first of the two numbers which could be the day or the month, and monthDay the second
+	dayMonth, monthDay, year int
+	swappable                bool
+	ok                       bool
+}
+
+// dmyEvidence returns true if the date can only be read day first
+func (p parsedDate) dmyEvidence() bool {
+	return p.swappable && p.dayMonth > 12 && p.monthDay <= 12
+}
+
+// mdyEvidence returns true if the date can only be read month first
+func (p parsedDate) mdyEvidence() bool {
+	return p.swappable && p.monthDay > 12 && p.dayMonth <= 12
+}
+
+func (p parsedDate) ambiguous() bool {
+	return p.swappable && p.dayMonth <= 12 && p.monthDay <= 12 && p.dayMonth != p.monthDay
+}
+
+func (p parsedDate) format(order DateOrder) (string, bool) {
+	if !p.ok {
+		return "", false
+	}
+	if !p.swappable {
+		return p.date.Format(dateFormat), true
+	}
+	day, month := p.dayMonth, p.monthDay
+	if order == DateOrderMDY {
+		day, month = month, day
+	}
+	date, ok := newDate(p.year, month, day)
+	if !ok {
+		return "", false
+	}
+	return date.Format(dateFormat), true
+}
+
+// normalizeDateColumn rewrites the dates of a column, and returns a warning if their order was guessed
+func normalizeDateColumn(rows [][]string, col int, settings TabularParseSettings) *DateAmbiguityWarning {
+	// dates starting with the year are never swapped, so locales writing the year first need not be detected
+	parseOrder := settings.DateOrder
+	if parseOrder == DateOrderAuto && settings.Locale != "" && settings.locale().dateOrder == DateOrderYMD {
+		parseOrder = DateOrderYMD
+	}
+
+	parsed := make([]parsedDate, len(rows))
+	var dmy, mdy int
+	var ambiguous string
+	for i, row := range rows {
+		if col >= len(row) {
+			continue
+		}
+		parsed[i] = parseTabularDate(row[col], parseOrder)
+		switch {
+		case parsed[i].dmyEvidence():
+			dmy++
+		case parsed[i].mdyEvidence():
+			mdy++
+		case parsed[i].ambiguous() && ambiguous == "":
+			ambiguous = strings.TrimSpace(row[col])
+		}
+	}
+
+	var warning *DateAmbiguityWarning
+	order := parseOrder
+	if order == DateOrderAuto {
+		switch {
+		case dmy > 0 || mdy > 0:
+			// values contradicting the majority are invalid dates, and are reported as such
+			order = DateOrderDMY
+			if mdy > dmy {
+				order = DateOrderMDY
+			}
+		case settings.Locale != "":
+			order = settings.locale().dateOrder
+		default:
+			order = DateOrderDMY
+			if ambiguous != "" {
+				warning = &DateAmbiguityWarning{Example: ambiguous, Guess: order}
+			}
+		}
+	}
+
+	for i, row := range rows {
+		if value, ok := parsed[i].format(order); ok {
+			row[col] = value
+		}
+	}
+	return warning
+}
+
+// parseTabularDate reads a date written as YYYY-MM-DD, with numbers in any order, with a month name,
+// as YYYYMMDD, or as an Excel serial number. A time following the date is ignored.
+func parseTabularDate(value string, order DateOrder) parsedDate {
+	value = strings.TrimSpace(NormalizeDigits(value))
+	if value == "" {
+		return parsedDate{}
+	}
+	if date, err := time.Parse(dateFormat, value); err == nil {
+		return parsedDate{date: date, ok: true}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && !strings.ContainsAny(value, "eE") {
+		return parseSerialDate(value, serial)
+	}
+
+	value = timeSuffixRegex.ReplaceAllString(value, "")
+	tokens := dateTokenRegex.FindAllString(value, -1)
+	if len(tokens) != 3 {
+		return parsedDate{}
+	}
+
+	var numbers []string
+	month := 0
+	for _, token := range tokens {
+		if token[0] >= '0' && token[0] <= '9' {
+			numbers = append(numbers, token)
+		} else if m := parseMonthName(token); m > 0 && month == 0 {
+			month = m
+		} else {
+			return parsedDate{}
+		}
+	}
+
+	if month > 0 {
+		// e.g. "3 April 2021", "April 3, 2021", "2021 avril 3" or "3-Apr-21"
+		if len(numbers) != 2 {
+			return parsedDate{}
+		}
+		day, year := numbers[0], numbers[1]
+		if len(day) == 4 {
+			day, year = year, day
+		}
+		return newParsedDate(parseYear(year), month, atoi(day))
+	}
+
+	a, b, c := numbers[0], numbers[1], numbers[2]
+	if len(a) == 4 || order == DateOrderYMD {
+		return newParsedDate(parseYear(a), atoi(b), atoi(c))
+	}
+	if len(c) != 4 && len(c) != 2 {
+		return parsedDate{}
+	}
+	p := parsedDate{dayMonth: atoi(a), monthDay: atoi(b), year: parseYear(c), swappable: true}
+	_, okDMY := newDate(p.year, p.monthDay, p.dayMonth)
+	_, okMDY := newDate(p.year, p.dayMonth, p.monthDay)
+	p.ok = okDMY || okMDY
+	return p
+}
+
+// parseSerialDate reads a date written as YYYYMMDD or as an Excel serial number, the number of days since 1899-12-30
+func parseSerialDate(value string, serial float64) parsedDate {
+	if len(value) == 8 && !strings.Contains(value, ".") {
+		return newParsedDate(atoi(value[:4]), atoi(value[4:6]), atoi(value[6:]))
+	}
+	// a year alone is not a date
+	if len(value) == 4 && serial >= 1900 && serial <= 2100 {
+		return parsedDate{}
+	}
+	if serial < 1 || serial > 2958465 {
+		return parsedDate{}
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return parsedDate{date: epoch.AddDate(0, 0, int(math.Floor(serial))), ok: true}
+}
+
+func newParsedDate(year, month, day int) parsedDate {
+	date, ok := newDate(year, month, day)
+	return parsedDate{date: date, ok: ok}
+}
+
+// newDate returns the date if it exists, e.g. not on the 31st of April
+func newDate(year, month, day int) (time.Time, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return date, date.Day() == day
+}
+
+// parseYear reads a year of four digits, or of two digits in the last hundred years
+func parseYear(s string) int {
+	year := atoi(s)
+	if len(s) != 2 {
+		return year
+	}
+	century := time.Now().Year() / 100 * 100
+	if century+year > time.Now().Year() {
+		return century - 100 + year
+	}
+	return century + year
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// monthNames are the month names, and their abbreviations as prefixes, recognized in dates
+var monthNames = [][]string{
+	{"january", "janvier", "enero", "يناير"},
+	{"february", "février", "fevrier", "febrero", "فبراير"},
+	{"march", "mars", "marzo", "مارس"},
+	{"april", "avril", "abril", "أبريل", "ابريل"},
+	{"may", "mai", "mayo", "مايو"},
+	{"june", "juin", "junio", "يونيو"},
+	{"july", "juillet", "julio", "يوليو"},
+	{"august", "août", "aout", "agosto", "أغسطس", "اغسطس"},
+	{"september", "septembre", "septiembre", "setiembre", "سبتمبر"},
+	{"october", "octobre", "octubre", "أكتوبر", "اكتوبر"},
+	{"november", "novembre", "noviembre", "نوفمبر"},
+	{"december", "décembre", "decembre", "diciembre", "ديسمبر"},
+}
+
+// parseMonthName returns the month of a name or of an abbreviation of at least three letters,
+// or 0 if it does not designate a single month
+func parseMonthName(token string) int {
+	token = strings.ToLower(token)
+	if len([]rune(token)) < 3 {
+		return 0
+	}
+	found := 0
+	for i, names := range monthNames {
+		for _, name := range names {
+			if strings.HasPrefix(name, token) {
+				if found != 0 && found != i+1 {
+					return 0
+				}
+				found = i + 1
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDigits(t *testing.T) {
+	assert.Equal(t, "0123456789", NormalizeDigits("٠١٢٣٤٥٦٧٨٩"))
+	assert.Equal(t, "0123456789", NormalizeDigits("۰۱۲۳۴۵۶۷۸۹"))
+	assert.Equal(t, "+254 712", NormalizeDigits("+٢٥٤ ٧١٢"))
+	assert.Equal(t, "42", NormalizeDigits("४२"))
+	assert.Equal(t, "abc 12", NormalizeDigits("abc 12"))
+}
+
+func TestNormalizeTabularRecordsDetectsDateOrder(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	records := [][]string{
+		{"birth_date", "collection_time", "service_delivered_date_1", "full_name"},
+		{"03/04/2021", "2021-04-03T10:00:00Z", "4/25/2021", "03/04/2021"},
+		{"25/12/1990", "44927", "12/1/21", ""},
+		{"٠٣/٠٤/٢٠٢١", "3 avril 2021", "Apr 3, 2021", ""},
+		{"", "20210403", "not a date", ""},
+	}
+	normalized, warnings := NormalizeTabularRecords(records, TabularParseSettings{})
+	assert.Empty(t, warnings)
+	assert.Equal(t, [][]string{
+		{"birth_date", "collection_time", "service_delivered_date_1", "full_name"},
+		{"2021-04-03", "2021-04-03", "2021-04-25", "03/04/2021"},
+		{"1990-12-25", "2023-01-01", "2021-12-01", ""},
+		{"2021-04-03", "2021-04-03", "2021-04-03", ""},
+		{"", "2021-04-03", "not a date", ""},
+	}, normalized)
+	// the records are copied
+	assert.Equal(t, "03/04/2021", records[1][0])
+}
+
+func TestNormalizeTabularRecordsWarnsOnAmbiguousDates(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	records := [][]string{
+		{"Date of Birth", "age"},
+		{"03/04/2021", "1"},
+		{"05/05/2020", "2"},
+	}
+	normalized, warnings := NormalizeTabularRecords(records, TabularParseSettings{})
+	require.Len(t, warnings, 1)
+	assert.Equal(t, DateAmbiguityWarning{Column: "birth_date", Example: "03/04/2021", Guess: DateOrderDMY}, warnings[0])
+	assert.Contains(t, warnings[0].String(), "03/04/2021")
+	assert.Equal(t, "2021-04-03", normalized[1][0])
+
+	// an explicit order or a locale settles the ambiguity
+	normalized, warnings = NormalizeTabularRecords(records, TabularParseSettings{DateOrder: DateOrderMDY})
+	assert.Empty(t, warnings)
+	assert.Equal(t, "2021-03-04", normalized[1][0])
+
+	normalized, warnings = NormalizeTabularRecords(records, TabularParseSettings{Locale: "en-US"})
+	assert.Empty(t, warnings)
+	assert.Equal(t, "2021-03-04", normalized[1][0])
+
+	normalized, warnings = NormalizeTabularRecords([][]string{{"birth_date"}, {"21/04/03"}}, TabularParseSettings{Locale: "ja"})
+	assert.Empty(t, warnings)
+	assert.Equal(t, "2021-04-03", normalized[1][0])
+}
+
+func TestNormalizeTabularRecordsIntegers(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	records := [][]string{
+		{"age", "household_size", "community_size", "phone_number_1"},
+		{"٤٢", "3.0", "1 200", "+٢٥٤ ٧١٢ ٣٤٥"},
+		{"42", "3", "1٬200", ""},
+		{"4.5", "", "12", ""},
+	}
+	normalized, _ := NormalizeTabularRecords(records, TabularParseSettings{})
+	assert.Equal(t, [][]string{
+		{"age", "household_size", "community_size", "phone_number_1"},
+		{"42", "3.0", "1200", "+254 712 345"},
+		{"42", "3", "1200", ""},
+		{"4.5", "", "12", ""},
+	}, normalized)
+
+	normalized, _ = NormalizeTabularRecords([][]string{{"community_size", "age"}, {"1.200", "3,00"}}, TabularParseSettings{Locale: "fr"})
+	assert.Equal(t, []string{"1200", "3"}, normalized[1])
+
+	normalized, _ = NormalizeTabularRecords([][]string{{"community_size", "age"}, {"1,200", "3.0"}}, TabularParseSettings{Locale: "en-GB"})
+	assert.Equal(t, []string{"1200", "3"}, normalized[1])
+}
+
+func TestParseTabularDate(t *testing.T) {
+	testCases := []struct {
+		value  string
+		order  DateOrder
+		expect string
+	}{
+		{value: "2021-04-03", expect: "2021-04-03"},
+		{value: "2021/4/3", expect: "2021-04-03"},
+		{value: "03.04.2021", order: DateOrderDMY, expect: "2021-04-03"},
+		{value: "03-04-2021 10:30 PM", order: DateOrderMDY, expect: "2021-03-04"},
+		{value: "3-Apr-21", expect: "2021-04-03"},
+		{value: "3 أبريل 2021", expect: "2021-04-03"},
+		{value: "3 de abril de 2021"},
+		{value: "1 Ma 2021"},
+		{value: "44927.75", expect: "2023-01-01"},
+		{value: "2021"},
+		{value: "31/04/2021", order: DateOrderDMY},
+		{value: "2021-02-30"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			value, ok := parseTabularDate(tc.value, tc.order).format(tc.order)
+			assert.Equal(t, tc.expect != "", ok)
+			assert.Equal(t, tc.expect, value)
+		})
+	}
+}
+
+func TestTabularParseSettings(t *testing.T) {
+	settings, err := NewTabularParseSettings("dmy", "")
+	require.NoError(t, err)
+	assert.Equal(t, TabularParseSettings{DateOrder: DateOrderDMY, Locale: "fr"}, settings.WithDefaults(TabularParseSettings{DateOrder: DateOrderMDY, Locale: "fr"}))
+
+	_, err = NewTabularParseSettings("dym", "")
+	assert.Error(t, err)
+	_, err = NewTabularParseSettings("", "xx")
+	assert.Error(t, err)
+
+	value, err := settings.Value()
+	require.NoError(t, err)
+	var scanned TabularParseSettings
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, settings, scanned)
+	require.NoError(t, scanned.Scan([]byte("{}")))
+}
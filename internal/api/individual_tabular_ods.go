@@ -0,0 +1,385 @@
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+const (
+	odsMimeType        = "application/vnd.oasis.opendocument.spreadsheet"
+	odsNamespaceOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsNamespaceTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsNamespaceText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// UnmarshalRecordsFromODS reads the first sheet of an OpenDocument spreadsheet (.ods)
+func UnmarshalRecordsFromODS(records *[][]string, reader io.Reader) error {
+	sheets, err := UnmarshalSheetsFromODS(reader)
+	if err != nil {
+		return err
+	}
+	if len(sheets[0].Records) == 0 {
+		return errors.New("no rows found")
+	}
+	*records = sheets[0].Records
+	return nil
+}
+
+// UnmarshalSheetsFromODS reads all the sheets of an OpenDocument spreadsheet (.ods), in order
+func UnmarshalSheetsFromODS(reader io.Reader) ([]Sheet, error) {
+	content, err := readUpload(reader)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range archive.File {
+		if file.Name != "content.xml" {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets, err := readODSTables(f)
+		if err != nil {
+			return nil, err
+		}
+		if len(sheets) == 0 {
+			return nil, errors.New("no sheets found")
+		}
+		return sheets, nil
+	}
+	return nil, errors.New("no sheets found")
+}
+
+// odsCell is the value of a table cell, read from its value attributes, or from its paragraphs for text cells
+type odsCell struct {
+	valueType string
+	value     string
+	text      strings.Builder
+	length    int
+	paragraph int
+	repeat    int
+}
+
+// write adds to the text of the cell, unless the text gets longer than maxCellLength
+func (c *odsCell) write(s string) error {
+	c.length += utf8.RuneCountInString(s)
+	if c.length > maxCellLength {
+		return errCellTooLong
+	}
+	c.text.WriteString(s)
+	return nil
+}
+
+func (c *odsCell) String() string {
+	switch c.valueType {
+	case "float", "percentage", "currency":
+		return c.value
+	case "date":
+		// dates without a time are written as YYYY-MM-DD, and with a time as YYYY-MM-DDThh:mm:ss
+		if date, _, found := strings.Cut(c.value, "T"); found {
+			return date
+		}
+		return c.value
+	case "boolean":
+		if c.value == "true" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return c.text.String()
+	}
+}
+
+// readODSTables reads the rows of the tables of a content.xml document.
+// LibreOffice pads sheets with empty rows and cells repeated up to the size of the sheet. Trailing empty
+// cells and rows are dropped without being expanded, and the other repetitions are expanded up to the
+// limits of the size of the sheets.
+func readODSTables(r io.Reader) ([]Sheet, error) {
+	var (
+		decoder = xml.NewDecoder(r)
+		sheets  []Sheet
+		sheet   *Sheet
+		// cells is the number of cells of the sheets read so far, once padded to the width of their longest row
+		cells      int
+		width      int
+		row        []string
+		rowRepeat  int
+		emptyRows  int
+		emptyCells int
+		cell       *odsCell
+		inText     bool
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return sheets, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsNamespaceTable && t.Name.Local == "table":
+				sheet = &Sheet{}
+				width = 0
+				emptyRows = 0
+				for _, attr := range t.Attr {
+					if attr.Name.Space == odsNamespaceTable && attr.Name.Local == "name" {
+						sheet.Name = attr.Value
+					}
+				}
+			case sheet == nil:
+			case t.Name.Space == odsNamespaceTable && t.Name.Local == "table-row":
+				row = nil
+				emptyCells = 0
+				rowRepeat = odsRepeat(t, "number-rows-repeated")
+			case t.Name.Space == odsNamespaceTable && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				cell = &odsCell{repeat: odsRepeat(t, "number-columns-repeated")}
+				for _, attr := range t.Attr {
+					if attr.Name.Space != odsNamespaceOffice {
+						continue
+					}
+					switch attr.Name.Local {
+					case "value-type":
+						cell.valueType = attr.Value
+					case "value", "date-value", "boolean-value":
+						cell.value = attr.Value
+					}
+				}
+			case cell == nil:
+			case t.Name.Space == odsNamespaceOffice && t.Name.Local == "annotation":
+				// comments are not part of the value
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			case t.Name.Space == odsNamespaceText && t.Name.Local == "p":
+				if cell.paragraph > 0 {
+					if err := cell.write("\n"); err != nil {
+						return nil, err
+					}
+				}
+				cell.paragraph++
+				inText = true
+			case t.Name.Space == odsNamespaceText && t.Name.Local == "s":
+				spaces := odsRepeat(t, "c")
+				if cell.length+spaces > maxCellLength {
+					return nil, errCellTooLong
+				}
+				if err := cell.write(strings.Repeat(" ", spaces)); err != nil {
+					return nil, err
+				}
+			case t.Name.Space == odsNamespaceText && t.Name.Local == "tab":
+				if err := cell.write("\t"); err != nil {
+					return nil, err
+				}
+			case t.Name.Space == odsNamespaceText && t.Name.Local == "line-break":
+				if err := cell.write("\n"); err != nil {
+					return nil, err
+				}
+			}
+
+		case xml.CharData:
+			if cell != nil && inText {
+				if err := cell.write(string(t)); err != nil {
+					return nil, err
+				}
+			}
+
+		case xml.EndElement:
+			switch {
+			case sheet == nil:
+			case t.Name.Space == odsNamespaceText && t.Name.Local == "p":
+				inText = false
+			case t.Name.Space == odsNamespaceTable && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				if cell == nil {
+					continue
+				}
+				value := cell.String()
+				if value == "" {
+					emptyCells += cell.repeat
+				} else {
+					if len(row)+emptyCells+cell.repeat > maxSheetColumns {
+						return nil, errTooManyColumns
+					}
+					for ; emptyCells > 0; emptyCells-- {
+						row = append(row, "")
+					}
+					for i := 0; i < cell.repeat; i++ {
+						row = append(row, value)
+					}
+				}
+				cell = nil
+			case t.Name.Space == odsNamespaceTable && t.Name.Local == "table-row":
+				if len(row) == 0 {
+					emptyRows += rowRepeat
+					continue
+				}
+				rows := len(sheet.Records) + emptyRows + rowRepeat
+				if rows > maxSheetRows {
+					return nil, errTooManyRows
+				}
+				if len(row) > width {
+					width = len(row)
+				}
+				if cells+rows*width > maxFileCells {
+					return nil, errTooManyCells
+				}
+				for ; emptyRows > 0; emptyRows-- {
+					sheet.Records = append(sheet.Records, []string{})
+				}
+				for i := 0; i < rowRepeat; i++ {
+					sheet.Records = append(sheet.Records, append([]string(nil), row...))
+				}
+			case t.Name.Space == odsNamespaceTable && t.Name.Local == "table":
+				cells += len(sheet.Records) * width
+				records, err := padRecordsToHeader(sheet.Records)
+				if err != nil {
+					return nil, err
+				}
+				sheet.Records = records
+				sheets = append(sheets, *sheet)
+				sheet = nil
+			}
+		}
+	}
+}
+
+// odsRepeat returns the value of the given repetition attribute, which defaults to 1. The value is capped,
+// so that the repetitions can be added up without overflowing.
+func odsRepeat(element xml.StartElement, name string) int {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+				if n > math.MaxInt32 {
+					return math.MaxInt32
+				}
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// StreamIndividualsODS writes the given file columns of the individuals as an OpenDocument spreadsheet as they are read
+// from the iterator. The rows are written straight into the compressed archive, so only a single batch is held in memory.
+func StreamIndividualsODS(ctx context.Context, w io.Writer, individuals IndividualIterator, columns []string) error {
+	const sheetName = "Individuals"
+
+	archive := zip.NewWriter(w)
+
+	// the mimetype must be the first entry of the archive, and stored uncompressed
+	mimeType := []byte(odsMimeType)
+	mimeTypeFile, err := archive.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimeType),
+		CompressedSize64:   uint64(len(mimeType)),
+		UncompressedSize64: uint64(len(mimeType)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := mimeTypeFile.Write(mimeType); err != nil {
+		return err
+	}
+
+	manifest, err := archive.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(manifest, xml.Header+
+		`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">`+
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="`+odsMimeType+`"/>`+
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>`+
+		`</manifest:manifest>`); err != nil {
+		return err
+	}
+
+	contentFile, err := archive.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	content := bufio.NewWriter(contentFile)
+	if _, err := content.WriteString(xml.Header +
+		`<office:document-content xmlns:office="` + odsNamespaceOffice + `" xmlns:table="` + odsNamespaceTable + `" xmlns:text="` + odsNamespaceText + `" office:version="1.2">` +
+		`<office:body><office:spreadsheet><table:table table:name="` + sheetName + `">`); err != nil {
+		return err
+	}
+
+	if err := writeODSRow(content, locales.TranslateSlice(columns)); err != nil {
+		return err
+	}
+	err = forEachIndividual(ctx, individuals, func(individual *Individual) error {
+		row, err := individual.MarshalTabularColumns(columns)
+		if err != nil {
+			return err
+		}
+		return writeODSRow(content, row)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := content.WriteString(`</table:table></office:spreadsheet></office:body></office:document-content>`); err != nil {
+		return err
+	}
+	if err := content.Flush(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeODSRow(w *bufio.Writer, row []string) error {
+	if _, err := w.WriteString("<table:table-row>"); err != nil {
+		return err
+	}
+	for _, value := range row {
+		if value == "" {
+			if _, err := w.WriteString("<table:table-cell/>"); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.WriteString(`<table:table-cell office:value-type="string">`); err != nil {
+			return err
+		}
+		// line breaks are only kept as separate paragraphs
+		for _, line := range strings.Split(value, "\n") {
+			if _, err := w.WriteString("<text:p>"); err != nil {
+				return err
+			}
+			if err := xml.EscapeText(w, []byte(line)); err != nil {
+				return err
+			}
+			if _, err := w.WriteString("</text:p>"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.WriteString("</table:table-cell>"); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("</table:table-row>")
+	return err
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testODSContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content
+	xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Participants">
+	<table:table-column table:number-columns-repeated="1024"/>
+	<table:table-header-rows>
+		<table:table-row>
+			<table:table-cell office:value-type="string"><text:p>Full name</text:p></table:table-cell>
+			<table:table-cell office:value-type="string"><text:p>Birth <text:span>date</text:span></text:p></table:table-cell>
+			<table:table-cell office:value-type="string"><text:p>Age</text:p></table:table-cell>
+			<table:table-cell table:number-columns-repeated="1021"/>
+		</table:table-row>
+	</table:table-header-rows>
+	<table:table-row>
+		<table:table-cell office:value-type="string">
+			<office:annotation><text:p>a comment</text:p></office:annotation>
+			<text:p>Jane<text:s text:c="2"/>Doe</text:p>
+			<text:p>second line</text:p>
+		</table:table-cell>
+		<table:table-cell office:value-type="date" office:date-value="2000-01-31"><text:p>31/01/2000</text:p></table:table-cell>
+		<table:table-cell office:value-type="float" office:value="23"><text:p>23.00</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="2">
+		<table:table-cell table:number-columns-repeated="2"/>
+		<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>VRAI</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="3">
+		<table:table-cell table:number-columns-repeated="1024"/>
+	</table:table-row>
+	<table:table-row>
+		<table:table-cell office:value-type="date" office:date-value="2001-02-03T04:05:06"/>
+		<table:covered-table-cell/>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="1048570">
+		<table:table-cell table:number-columns-repeated="1024"/>
+	</table:table-row>
+</table:table>
+<table:table table:name="Other">
+	<table:table-row><table:table-cell office:value-type="string"><text:p>ignored</text:p></table:table-cell></table:table-row>
+</table:table>
+</office:spreadsheet></office:body>
+</office:document-content>`
+
+func TestUnmarshalRecordsFromODS(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	f, err := archive.Create("content.xml")
+	require.NoError(t, err)
+	_, err = io.WriteString(f, testODSContent)
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	var records [][]string
+	require.NoError(t, UnmarshallRecordsFromFile(&records, &buf, "upload.ods"))
+	assert.Equal(t, [][]string{
+		{"Full name", "Birth date", "Age"},
+		{"Jane  Doe\nsecond line", "2000-01-31", "23"},
+		{"", "", "TRUE"},
+		{"", "", "TRUE"},
+		{"", "", ""},
+		{"", "", ""},
+		{"", "", ""},
+		{"2001-02-03", "", ""},
+	}, records)
+}
+
+func TestReadODSTablesBoundsTheSheets(t *testing.T) {
+	document := func(tables ...string) string {
+		content := `<office:document-content xmlns:office="` + odsNamespaceOffice + `" xmlns:table="` + odsNamespaceTable + `" xmlns:text="` + odsNamespaceText + `"><office:body><office:spreadsheet>`
+		for _, table := range tables {
+			content += `<table:table table:name="Sheet">` + table + `</table:table>`
+		}
+		return content + `</office:spreadsheet></office:body></office:document-content>`
+	}
+	const value = `<table:table-cell office:value-type="string"><text:p>x</text:p></table:table-cell>`
+	tests := []struct {
+		name    string
+		content string
+		want    error
+	}{
+		{
+			name: "trailing empty rows and cells",
+			content: document(`<table:table-row>` + value + `<table:table-cell table:number-columns-repeated="16384"/></table:table-row>` +
+				`<table:table-row table:number-rows-repeated="1048576"><table:table-cell table:number-columns-repeated="16384"/></table:table-row>`),
+		}, {
+			name:    "repeated cells and rows",
+			content: document(`<table:table-row table:number-rows-repeated="1048576"><table:table-cell office:value-type="string" table:number-columns-repeated="16384"><text:p>x</text:p></table:table-cell></table:table-row>`),
+			want:    errTooManyColumns,
+		}, {
+			name:    "repeated rows",
+			content: document(`<table:table-row table:number-rows-repeated="1048576">` + value + `</table:table-row>`),
+			want:    errTooManyRows,
+		}, {
+			name:    "empty rows before a row",
+			content: document(`<table:table-row table:number-rows-repeated="1048576"><table:table-cell/></table:table-row><table:table-row>` + value + `</table:table-row>`),
+			want:    errTooManyRows,
+		}, {
+			name:    "empty cells before a cell",
+			content: document(`<table:table-row><table:table-cell table:number-columns-repeated="16384"/>` + value + `</table:table-row>`),
+			want:    errTooManyColumns,
+		}, {
+			name:    "large sheet",
+			content: document(`<table:table-row table:number-rows-repeated="50000"><table:table-cell office:value-type="string" table:number-columns-repeated="1000"><text:p>x</text:p></table:table-cell></table:table-row>`),
+			want:    errTooManyCells,
+		}, {
+			name: "large sheets",
+			content: document(
+				`<table:table-row table:number-rows-repeated="3000"><table:table-cell office:value-type="string" table:number-columns-repeated="1000"><text:p>x</text:p></table:table-cell></table:table-row>`,
+				`<table:table-row table:number-rows-repeated="3000"><table:table-cell office:value-type="string" table:number-columns-repeated="1000"><text:p>x</text:p></table:table-cell></table:table-row>`),
+			want: errTooManyCells,
+		}, {
+			name:    "repeated spaces",
+			content: document(`<table:table-row><table:table-cell office:value-type="string"><text:p>x<text:s text:c="2147483647"/></text:p></table:table-cell></table:table-row>`),
+			want:    errCellTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readODSTables(strings.NewReader(tt.content))
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestStreamIndividualsODS(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	var buf bytes.Buffer
+	require.NoError(t, StreamIndividualsODS(context.Background(), &buf, newTestBatchIterator(), constants.IndividualFileColumns))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.NotEmpty(t, archive.File)
+	assert.Equal(t, "mimetype", archive.File[0].Name)
+	assert.Equal(t, zip.Store, archive.File[0].Method)
+	mimeType, err := archive.File[0].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(mimeType)
+	require.NoError(t, err)
+	assert.Equal(t, odsMimeType, string(content))
+
+	var records [][]string
+	require.NoError(t, UnmarshalRecordsFromODS(&records, bytes.NewReader(buf.Bytes())))
+	require.Len(t, records, 4)
+	assert.Equal(t, locales.TranslateSlice(constants.IndividualFileColumns), records[0])
+	fullNameIdx := -1
+	for i, col := range constants.IndividualFileColumns {
+		if col == constants.FileColumnIndividualFullName {
+			fullNameIdx = i
+		}
+	}
+	require.NotEqual(t, -1, fullNameIdx)
+	assert.Equal(t, "A", records[1][fullNameIdx])
+	assert.Equal(t, "B", records[2][fullNameIdx])
+	assert.Equal(t, "C", records[3][fullNameIdx])
+}
+
+func TestWriteODSRowKeepsLineBreaks(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	f, err := archive.Create("content.xml")
+	require.NoError(t, err)
+	_, err = io.WriteString(f, `<office:document-content xmlns:office="`+odsNamespaceOffice+`" xmlns:table="`+odsNamespaceTable+`" xmlns:text="`+odsNamespaceText+`"><office:body><office:spreadsheet><table:table>`)
+	require.NoError(t, err)
+	var row bytes.Buffer
+	w := bufio.NewWriter(&row)
+	require.NoError(t, writeODSRow(w, []string{"a <b> & c", "", "line 1\nline 2"}))
+	require.NoError(t, w.Flush())
+	_, err = f.Write(row.Bytes())
+	require.NoError(t, err)
+	_, err = io.WriteString(f, `</table:table></office:spreadsheet></office:body></office:document-content>`)
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	var records [][]string
+	require.NoError(t, UnmarshalRecordsFromODS(&records, &buf))
+	assert.Equal(t, [][]string{{"a <b> & c", "", "line 1\nline 2"}}, records)
+}
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/xuri/excelize/v2"
+)
+
+// templateColumnKind is the kind of values a column of the upload template holds
+type templateColumnKind string
+
+const (
+	templateColumnKindText      templateColumnKind = "text"
+	templateColumnKindList      templateColumnKind = "list"
+	templateColumnKindBoolean   templateColumnKind = "boolean"
+	templateColumnKindDate      templateColumnKind = "date"
+	templateColumnKindNumber    templateColumnKind = "number"
+	templateColumnKindID        templateColumnKind = "id"
+	templateColumnKindAutomatic templateColumnKind = "automatic"
+)
+
+const (
+	// templateFirstDataRow is the first row participants are entered on, after the header and the instruction row
+	templateFirstDataRow = 3
+	// templateLastDataRow is the last row the data validations apply to
+	templateLastDataRow   = 10002
+	templateCommentAuthor = "NRC"
+)
+
+// templateList is a list of the values allowed in a column, written to the lists sheet of the template
+type templateList struct {
+	name   string
+	values []string
+	// comment describes the values in the header comments of the columns using the list
+	comment string
+}
+
+// templateColumn describes how to fill a column of the upload template
+type templateColumn struct {
+	fileColumn string
+	column     string
+	kind       templateColumnKind
+	list       *templateList
+}
+
+// hintKey is the translation key of the short hint written on the instruction row
+func (c templateColumn) hintKey() string {
+	return "template_hint_" + string(c.kind)
+}
+
+// comment explains how to fill the column, in the header comment and on the instructions sheet
+func (c templateColumn) comment() string {
+	t := locales.GetTranslator()
+	if c.list != nil && c.list.comment != "" {
+		return c.list.comment
+	}
+	return t("template_comment_" + string(c.kind))
+}
+
+func enumTemplateList[T interface {
+	~string
+	String() string
+}](name string, values containers.Set[T]) *templateList {
+	t := locales.GetTranslator()
+	list := &templateList{name: name}
+	var labels []string
+	for _, v := range values.Items() {
+		list.values = append(list.values, string(v))
+		labels = append(labels, fmt.Sprintf("%s (%s)", string(v), v.String()))
+	}
+	list.comment = t("template_comment_list", strings.Join(labels, ", "))
+	return list
+}
+
+// templateListNames are the names of the lists, in the order of the lists sheet
+var templateListNames = []string{
+	"sex",
+	"disability_level",
+	"displacement_status",
+	"engagement_context",
+	"identification_type",
+	"contact_method",
+	"service_cc",
+	"boolean",
+	"countries",
+	"languages",
+}
+
+// templateLists returns the lists of the template by name, in the current language
+func templateLists() map[string]*templateList {
+	t := locales.GetTranslator()
+
+	countries := &templateList{name: "countries", comment: t("template_comment_country")}
+	for _, c := range constants.Countries {
+		countries.values = append(countries.values, c.Name)
+	}
+	languages := &templateList{name: "languages", comment: t("template_comment_language")}
+	for _, l := range constants.Languages {
+		languages.values = append(languages.values, l.Name)
+	}
+
+	return map[string]*templateList{
+		"sex":                 enumTemplateList("sex", enumTypes.AllSexes()),
+		"disability_level":    enumTemplateList("disability_level", enumTypes.AllDisabilityLevels()),
+		"displacement_status": enumTemplateList("displacement_status", enumTypes.AllDisplacementStatuses()),
+		"engagement_context":  enumTemplateList("engagement_context", enumTypes.AllEngagementContexts()),
+		"identification_type": enumTemplateList("identification_type", enumTypes.AllIdentificationTypes()),
+		"contact_method":      enumTemplateList("contact_method", enumTypes.AllContactMethods()),
+		"service_cc":          enumTemplateList("service_cc", enumTypes.AllServiceCCs()),
+		"boolean": {
+			name:   "boolean",
+			values: []string{string(enumTypes.OptionalBooleanYes), string(enumTypes.OptionalBooleanNo)},
+		},
+		"countries": countries,
+		"languages": languages,
+	}
+}
+
+var (
+	templateCountryColumns = containers.NewStringSet(
+		constants.DBColumnIndividualNationality1,
+		constants.DBColumnIndividualNationality2,
+	)
+	templateLanguageColumns = containers.NewStringSet(
+		constants.DBColumnIndividualPreferredCommunicationLanguage,
+		constants.DBColumnIndividualSpokenLanguage1,
+		constants.DBColumnIndividualSpokenLanguage2,
+		constants.DBColumnIndividualSpokenLanguage3,
+	)
+	templateAutomaticColumns = containers.NewStringSet(
+		constants.DBColumnIndividualCreatedAt,
+		constants.DBColumnIndividualUpdatedAt,
+	)
+)
+
+// templateColumns returns the columns of the template, in the order of the file columns. The kind of
+// each column is the type of the matching individual field.
+func templateColumns(lists map[string]*templateList) ([]templateColumn, error) {
+	empty := &Individual{}
+	columns := make([]templateColumn, 0, len(constants.IndividualFileColumns))
+	for _, fileColumn := range constants.IndividualFileColumns {
+		column := templateColumn{fileColumn: fileColumn, column: constants.IndividualFileToDBMap[fileColumn], kind: templateColumnKindText}
+		value, err := empty.GetFieldValue(column.column)
+		if err != nil {
+			return nil, err
+		}
+
+		setList := func(name string) {
+			column.kind = templateColumnKindList
+			column.list = lists[name]
+		}
+		switch value.(type) {
+		case enumTypes.Sex:
+			setList("sex")
+		case enumTypes.DisabilityLevel:
+			setList("disability_level")
+		case enumTypes.DisplacementStatus:
+			setList("displacement_status")
+		case enumTypes.EngagementContext:
+			setList("engagement_context")
+		case enumTypes.IdentificationType:
+			setList("identification_type")
+		case enumTypes.ContactMethod:
+			setList("contact_method")
+		case enumTypes.ServiceCC:
+			setList("service_cc")
+		case bool, *bool, enumTypes.OptionalBoolean:
+			column.kind = templateColumnKindBoolean
+			column.list = lists["boolean"]
+		case int, *int:
+			column.kind = templateColumnKindNumber
+		case time.Time, *time.Time:
+			column.kind = templateColumnKindDate
+		}
+
+		switch {
+		case column.column == constants.DBColumnIndividualID:
+			column.kind = templateColumnKindID
+		case templateAutomaticColumns.Contains(column.column):
+			column.kind = templateColumnKindAutomatic
+		case templateCountryColumns.Contains(column.column):
+			setList("countries")
+		case templateLanguageColumns.Contains(column.column):
+			setList("languages")
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// MarshalIndividualsTemplateExcel writes the upload template in the current language, with the given
+// individuals as examples. Besides the participants sheet, it has:
+//   - an instruction row below the header, with a short hint for each column, which is skipped on upload
+//   - a comment on each header explaining how to fill the column
+//   - dropdowns on the columns with a fixed set of values, fed from a hidden lists sheet
+//   - an instructions sheet
+func MarshalIndividualsTemplateExcel(w io.Writer, individuals []*Individual) error {
+	t := locales.GetTranslator()
+	var (
+		participantsSheet = t("template_sheet_participants")
+		instructionsSheet = t("template_sheet_instructions")
+		listsSheet        = t("template_sheet_lists")
+	)
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	if err := f.SetSheetName("Sheet1", participantsSheet); err != nil {
+		return err
+	}
+	if _, err := f.NewSheet(instructionsSheet); err != nil {
+		return err
+	}
+	if _, err := f.NewSheet(listsSheet); err != nil {
+		return err
+	}
+
+	lists := templateLists()
+	columns, err := templateColumns(lists)
+	if err != nil {
+		return err
+	}
+
+	// lists sheet, one list per column
+	listRanges := map[string]string{}
+	for i, name := range templateListNames {
+		list := lists[name]
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		values := append([]interface{}{list.name}, stringArrayToInterfaceArray(list.values)...)
+		if err := f.SetSheetCol(listsSheet, col+"1", &values); err != nil {
+			return err
+		}
+		listRanges[name] = fmt.Sprintf("'%s'!$%s$2:$%s$%d", strings.ReplaceAll(listsSheet, "'", "''"), col, col, len(list.values)+1)
+	}
+	if err := f.SetSheetVisible(listsSheet, false); err != nil {
+		return err
+	}
+
+	// participants sheet
+	header := make([]interface{}, len(columns))
+	hints := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = t(column.fileColumn)
+		hints[i] = t(column.hintKey())
+	}
+	if err := f.SetSheetRow(participantsSheet, "A1", &header); err != nil {
+		return err
+	}
+	if err := f.SetSheetRow(participantsSheet, "A2", &hints); err != nil {
+		return err
+	}
+	hintStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Italic: true, Color: "808080"}})
+	if err != nil {
+		return err
+	}
+	lastCol, err := excelize.ColumnNumberToName(len(columns))
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(participantsSheet, "A2", lastCol+"2", hintStyle); err != nil {
+		return err
+	}
+
+	for i, individual := range individuals {
+		row, err := individual.MarshalTabularData()
+		if err != nil {
+			return err
+		}
+		for j, column := range columns {
+			if column.kind == templateColumnKindBoolean && row[j] != "" {
+				row[j] = templateBooleanValue(row[j])
+			}
+		}
+		values := stringArrayToInterfaceArray(row)
+		if err := f.SetSheetRow(participantsSheet, fmt.Sprintf("A%d", templateFirstDataRow+i), &values); err != nil {
+			return err
+		}
+	}
+
+	for i, column := range columns {
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		if err := f.AddComment(participantsSheet, excelize.Comment{
+			Author: templateCommentAuthor,
+			Cell:   col + "1",
+			Text:   column.comment(),
+		}); err != nil {
+			return err
+		}
+
+		dv := excelize.NewDataValidation(true)
+		dv.Sqref = fmt.Sprintf("%s%d:%s%d", col, templateFirstDataRow, col, templateLastDataRow)
+		switch {
+		case column.list != nil:
+			dv.SetSqrefDropList(listRanges[column.list.name])
+			dv.SetError(excelize.DataValidationErrorStyleStop, t("template_error_title"), t("template_error_list"))
+		case column.kind == templateColumnKindNumber:
+			if err := dv.SetRange(0, 1000000, excelize.DataValidationTypeWhole, excelize.DataValidationOperatorBetween); err != nil {
+				return err
+			}
+			dv.SetError(excelize.DataValidationErrorStyleStop, t("template_error_title"), t("template_comment_number"))
+		default:
+			continue
+		}
+		if err := f.AddDataValidation(participantsSheet, dv); err != nil {
+			return err
+		}
+	}
+	if err := f.SetPanes(participantsSheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      templateFirstDataRow - 1,
+		TopLeftCell: fmt.Sprintf("A%d", templateFirstDataRow),
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	if err := writeTemplateInstructions(f, instructionsSheet, columns); err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
+
+func writeTemplateInstructions(f *excelize.File, sheet string, columns []templateColumn) error {
+	t := locales.GetTranslator()
+	rows := [][]interface{}{
+		{t("template_instructions_title")},
+		{t("template_instructions_rows")},
+		{t("template_instructions_lists")},
+		{t("template_instructions_dates")},
+		{t("template_instructions_update")},
+		{},
+		{t("template_instructions_column"), t("template_instructions_how")},
+	}
+	for _, column := range columns {
+		rows = append(rows, []interface{}{t(column.fileColumn), column.comment()})
+	}
+	for i := range rows {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", i+1), &rows[i]); err != nil {
+			return err
+		}
+	}
+	boldStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A1", "A1", boldStyle); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A7", "B7", boldStyle); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheet, "A", "A", 40); err != nil {
+		return err
+	}
+	return f.SetColWidth(sheet, "B", "B", 100)
+}
+
+func templateBooleanValue(value string) string {
+	if isExplicitlyTrue(value) {
+		return string(enumTypes.OptionalBooleanYes)
+	}
+	return string(enumTypes.OptionalBooleanNo)
+}
+
+// isTemplateSheet returns true if the sheet is the instructions or lists sheet of the upload template,
+// in any language
+func isTemplateSheet(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, key := range []string{"template_sheet_instructions", "template_sheet_lists"} {
+		for _, translated := range locales.GetTranslations(key) {
+			if name == translated {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dropTemplateInstructionRows removes the instruction rows of the upload template from the records,
+// i.e. the rows where each value is the hint of its column, in any language. It also returns the number
+// of the row each kept record was read from.
+func dropTemplateInstructionRows(records [][]string) ([][]string, []int) {
+	rows := make([]int, len(records))
+	for i := range records {
+		rows[i] = i + 1
+	}
+	if len(records) < 2 {
+		return records, rows
+	}
+	columns, err := templateColumns(nil)
+	if err != nil {
+		return records, rows
+	}
+	kinds := map[string]templateColumnKind{}
+	for _, c := range columns {
+		kinds[c.column] = c.kind
+	}
+	hints := make([]containers.StringSet, len(records[0]))
+	for i, h := range records[0] {
+		if column, ok := locales.GetDBColumn(h); ok {
+			hints[i] = containers.NewStringSet(locales.GetTranslations(templateColumn{kind: kinds[column]}.hintKey())...)
+		}
+	}
+
+	ret := records[:1:1]
+	retRows := rows[:1:1]
+	for i, row := range records[1:] {
+		if !isTemplateInstructionRow(hints, row) {
+			ret = append(ret, row)
+			retRows = append(retRows, rows[i+1])
+		}
+	}
+	return ret, retRows
+}
+
+func isTemplateInstructionRow(hints []containers.StringSet, row []string) bool {
+	found := false
+	for i, value := range row {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if i >= len(hints) || !hints[i].Contains(value) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
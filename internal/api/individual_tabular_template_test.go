@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMarshalIndividualsTemplateExcel(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	example := &Individual{
+		FullName:          "John Doe",
+		LastName:          "Doe",
+		Sex:               enumTypes.SexMale,
+		BirthDate:         pointers.Time(time.Date(1984, 1, 1, 0, 0, 0, 0, time.UTC)),
+		HasDisability:     pointers.Bool(false),
+		IsHeadOfHousehold: pointers.Bool(true),
+		Nationality1:      "AFG",
+		SpokenLanguage1:   "fra",
+		HouseholdSize:     pointers.Int(5),
+	}
+	var buf bytes.Buffer
+	require.NoError(t, MarshalIndividualsTemplateExcel(&buf, []*Individual{example}))
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Participants", "Instructions", "Lists"}, f.GetSheetList())
+	visible, err := f.GetSheetVisible("Lists")
+	require.NoError(t, err)
+	assert.False(t, visible)
+
+	comments, err := f.GetComments("Participants")
+	require.NoError(t, err)
+	assert.Len(t, comments, len(constants.IndividualFileColumns))
+
+	validations, err := f.GetDataValidations("Participants")
+	require.NoError(t, err)
+	sexIdx := -1
+	for i, col := range constants.IndividualFileColumns {
+		if col == constants.FileColumnIndividualSex {
+			sexIdx = i
+		}
+	}
+	sexCol, err := excelize.ColumnNumberToName(sexIdx + 1)
+	require.NoError(t, err)
+	var sexValidation *excelize.DataValidation
+	for _, dv := range validations {
+		if strings.HasPrefix(dv.Sqref, sexCol+"3:") {
+			sexValidation = dv
+		}
+	}
+	require.NotNil(t, sexValidation)
+	assert.Equal(t, "list", sexValidation.Type)
+	assert.Contains(t, sexValidation.Formula1, "'Lists'!$A$2:$A$5")
+	sexes, err := f.GetCols("Lists")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sex", "female", "male", "other", "prefers_not_to_say", ""}, sexes[0][:6])
+
+	// the template uploads as is, without the instruction row and the other sheets
+	sheets, err := UnmarshalSheetsFromFile(bytes.NewReader(buf.Bytes()), "template.xlsx")
+	require.NoError(t, err)
+	records, sources, fileErrors := RecordsFromSheets(sheets)
+	require.Nil(t, fileErrors)
+	require.Len(t, records, 2)
+	// the instruction row is the second row of the sheet
+	assert.Equal(t, []RecordSource{{Sheet: sheets[0].Name, Row: 3}}, sources)
+
+	var fields []string
+	colMapping, fileErrors := GetColumnMapping(records[0], &fields)
+	require.Nil(t, fileErrors)
+	var individuals []*Individual
+	require.Nil(t, UnmarshalIndividualsTabularData(records, &individuals, colMapping, nil))
+	require.Len(t, individuals, 1)
+	assert.Equal(t, enumTypes.SexMale, individuals[0].Sex)
+	assert.Equal(t, "AFG", individuals[0].Nationality1)
+	assert.Equal(t, "fra", individuals[0].SpokenLanguage1)
+	assert.Equal(t, pointers.Bool(false), individuals[0].HasDisability)
+	assert.Equal(t, pointers.Bool(true), individuals[0].IsHeadOfHousehold)
+	assert.Equal(t, pointers.Int(5), individuals[0].HouseholdSize)
+}
+
+func TestDropTemplateInstructionRows(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	records := [][]string{
+		{"Full name", "Sex", "Date of Birth", "Unknown"},
+		{"Text", "Choose from the list", "YYYY-MM-DD", ""},
+		{"Text", "male", "", ""},
+		{"Jane", "female", "2000-01-01", ""},
+		{"", "", "", "Text"},
+	}
+	kept, rows := dropTemplateInstructionRows(records)
+	assert.Equal(t, [][]string{
+		records[0],
+		records[2],
+		records[3],
+		records[4],
+	}, kept)
+	assert.Equal(t, []int{1, 3, 4, 5}, rows)
+	assert.True(t, isTemplateSheet("Instructions"))
+	assert.False(t, isTemplateSheet(""))
+	assert.False(t, isTemplateSheet("Members"))
+}
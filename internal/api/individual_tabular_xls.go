@@ -0,0 +1,773 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Legacy .xls files are BIFF8 record streams stored in an OLE2 compound file.
+// Only the records needed to read cell values are decoded.
+const (
+	xlsRecordFormula    = 0x0006
+	xlsRecordEOF        = 0x000A
+	xlsRecordDateMode   = 0x0022
+	xlsRecordContinue   = 0x003C
+	xlsRecordBoundSheet = 0x0085
+	xlsRecordMulRK      = 0x00BD
+	xlsRecordXF         = 0x00E0
+	xlsRecordSST        = 0x00FC
+	xlsRecordLabelSST   = 0x00FD
+	xlsRecordNumber     = 0x0203
+	xlsRecordLabel      = 0x0204
+	xlsRecordBoolErr    = 0x0205
+	xlsRecordString     = 0x0207
+	xlsRecordRK         = 0x027E
+	xlsRecordFormat     = 0x041E
+	xlsRecordBOF        = 0x0809
+
+	xlsVersionBIFF8   = 0x0600
+	xlsSheetTypeSheet = 0
+
+	// xlsMaxColumns is the number of columns of a BIFF8 worksheet
+	xlsMaxColumns = 256
+)
+
+var (
+	errXLSUnsupportedVersion = errors.New("only Excel 97-2003 .xls files are supported, please save the file as .xlsx")
+	errXLSNoWorkbook         = errors.New("no workbook found in .xls file")
+	errXLSTruncated          = errors.New("truncated .xls record")
+	errXLSCorrupted          = errors.New("corrupted .xls file")
+)
+
+var (
+	zipSignature  = []byte("PK\x03\x04")
+	ole2Signature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+)
+
+// UnmarshalRecordsFromXLS reads the first worksheet of a legacy Excel 97-2003 (.xls) file.
+// Files saved as .xlsx but named .xls are read as .xlsx.
+func UnmarshalRecordsFromXLS(records *[][]string, reader io.Reader) error {
+	sheets, err := UnmarshalSheetsFromXLS(reader)
+	if err != nil {
+		return err
+	}
+	if len(sheets[0].Records) == 0 {
+		return errors.New("no rows found")
+	}
+	*records = sheets[0].Records
+	return nil
+}
+
+// UnmarshalSheetsFromXLS reads all the worksheets of a legacy Excel 97-2003 (.xls) file, in order.
+// Chart and macro sheets are skipped.
+func UnmarshalSheetsFromXLS(reader io.Reader) ([]Sheet, error) {
+	content, err := readUpload(reader)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(content, zipSignature) {
+		return UnmarshalSheetsFromExcel(bytes.NewReader(content))
+	}
+	if !bytes.HasPrefix(content, ole2Signature) {
+		return nil, errXLSUnsupportedVersion
+	}
+
+	wb, err := openXLSWorkbook(content)
+	if err != nil {
+		return nil, err
+	}
+	var sheets []Sheet
+	for _, sheet := range wb.sheets {
+		if sheet.kind != xlsSheetTypeSheet {
+			continue
+		}
+		rows, err := wb.readSheet(sheet)
+		if err != nil {
+			return nil, err
+		}
+		records, err := padRecordsToHeader(rows)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, Sheet{Name: sheet.name, Records: records})
+	}
+	if len(sheets) == 0 {
+		return nil, errors.New("no sheets found")
+	}
+	return sheets, nil
+}
+
+type xlsSheet struct {
+	name   string
+	offset uint32
+	kind   uint8
+}
+
+type xlsWorkbook struct {
+	stream []byte
+	sheets []xlsSheet
+	sst    []string
+	// xfFormats holds the number format of each cell format, by index
+	xfFormats []uint16
+	formats   map[uint16]string
+	date1904  bool
+	// cells is the number of cells of the sheets read so far, once padded to the width of their longest row
+	cells int
+}
+
+// xlsRecord is a BIFF record. Records longer than 8224 bytes are split into CONTINUE records,
+// which are kept as separate segments because strings restart their encoding flags on each segment.
+type xlsRecord struct {
+	id       uint16
+	segments [][]byte
+}
+
+func (r xlsRecord) reader() *xlsRecordReader {
+	return &xlsRecordReader{segments: r.segments}
+}
+
+// checkXLSCompoundFile checks the sector counts of the header of the compound file against the size of the file.
+// The compound file reader allocates from these counts before reading the sectors.
+func checkXLSCompoundFile(content []byte) error {
+	const (
+		headerSize             = 512
+		sectorShiftOffset      = 30
+		directorySectorsOffset = 40
+		fatSectorsOffset       = 44
+		miniFatSectorsOffset   = 64
+		difatSectorsOffset     = 72
+		sectorShiftVersion3    = 9
+		sectorShiftVersion4    = 12
+	)
+	if len(content) < headerSize {
+		return errXLSCorrupted
+	}
+	shift := binary.LittleEndian.Uint16(content[sectorShiftOffset:])
+	if shift != sectorShiftVersion3 && shift != sectorShiftVersion4 {
+		return errXLSCorrupted
+	}
+	sectors := uint64(len(content)) >> shift
+	for _, offset := range []int{directorySectorsOffset, fatSectorsOffset, miniFatSectorsOffset, difatSectorsOffset} {
+		if uint64(binary.LittleEndian.Uint32(content[offset:])) > sectors {
+			return errXLSCorrupted
+		}
+	}
+	return nil
+}
+
+func openXLSWorkbook(content []byte) (*xlsWorkbook, error) {
+	if err := checkXLSCompoundFile(content); err != nil {
+		return nil, err
+	}
+	doc, err := mscfb.New(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	var stream []byte
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if entry.Name == "Workbook" {
+			// the stream is part of the file, so it cannot be larger than the file
+			if stream, err = io.ReadAll(io.LimitReader(entry, int64(len(content)))); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if entry.Name == "Book" {
+			// BIFF5 and older
+			return nil, errXLSUnsupportedVersion
+		}
+	}
+	if stream == nil {
+		return nil, errXLSNoWorkbook
+	}
+
+	wb := &xlsWorkbook{stream: stream, formats: map[uint16]string{}}
+	if err := wb.readGlobals(); err != nil {
+		return nil, err
+	}
+	return wb, nil
+}
+
+// readRecord reads the record at the given offset of the workbook stream, along with its CONTINUE records
+func (wb *xlsWorkbook) readRecord(offset int) (xlsRecord, int, error) {
+	record := xlsRecord{}
+	for first := true; ; first = false {
+		if offset+4 > len(wb.stream) {
+			if first {
+				return record, offset, io.EOF
+			}
+			return record, offset, nil
+		}
+		id := binary.LittleEndian.Uint16(wb.stream[offset:])
+		size := int(binary.LittleEndian.Uint16(wb.stream[offset+2:]))
+		if !first && id != xlsRecordContinue {
+			return record, offset, nil
+		}
+		if offset+4+size > len(wb.stream) {
+			return record, offset, errXLSTruncated
+		}
+		if first {
+			record.id = id
+		}
+		record.segments = append(record.segments, wb.stream[offset+4:offset+4+size])
+		offset += 4 + size
+	}
+}
+
+func (wb *xlsWorkbook) readGlobals() error {
+	record, offset, err := wb.readRecord(0)
+	if err != nil {
+		return err
+	}
+	if err := checkXLSBOF(record); err != nil {
+		return err
+	}
+	for {
+		record, offset, err = wb.readRecord(offset)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r := record.reader()
+		switch record.id {
+		case xlsRecordEOF:
+			return nil
+		case xlsRecordDateMode:
+			mode, err := r.uint16()
+			if err != nil {
+				return err
+			}
+			wb.date1904 = mode == 1
+		case xlsRecordBoundSheet:
+			sheet := xlsSheet{}
+			if sheet.offset, err = r.uint32(); err != nil {
+				return err
+			}
+			if err := r.skip(1); err != nil {
+				return err
+			}
+			if sheet.kind, err = r.byte(); err != nil {
+				return err
+			}
+			cch, err := r.byte()
+			if err != nil {
+				return err
+			}
+			if sheet.name, err = r.unicodeString(int(cch)); err != nil {
+				return err
+			}
+			wb.sheets = append(wb.sheets, sheet)
+		case xlsRecordFormat:
+			id, err := r.uint16()
+			if err != nil {
+				return err
+			}
+			cch, err := r.uint16()
+			if err != nil {
+				return err
+			}
+			if wb.formats[id], err = r.unicodeString(int(cch)); err != nil {
+				return err
+			}
+		case xlsRecordXF:
+			if err := r.skip(2); err != nil {
+				return err
+			}
+			format, err := r.uint16()
+			if err != nil {
+				return err
+			}
+			wb.xfFormats = append(wb.xfFormats, format)
+		case xlsRecordSST:
+			if wb.sst, err = readXLSSharedStrings(r); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func checkXLSBOF(record xlsRecord) error {
+	if record.id != xlsRecordBOF {
+		return errXLSUnsupportedVersion
+	}
+	version, err := record.reader().uint16()
+	if err != nil || version != xlsVersionBIFF8 {
+		return errXLSUnsupportedVersion
+	}
+	return nil
+}
+
+func readXLSSharedStrings(r *xlsRecordReader) ([]string, error) {
+	if err := r.skip(4); err != nil {
+		return nil, err
+	}
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	// the count comes from the file, so it is not trusted for the allocation
+	sst := make([]string, 0, len(r.segments[0])/3)
+	for i := uint32(0); i < count; i++ {
+		s, err := r.richExtendedString()
+		if err != nil {
+			return nil, err
+		}
+		sst = append(sst, s)
+	}
+	return sst, nil
+}
+
+// readSheet returns the cell values of the sheet, as displayed rows of text
+func (wb *xlsWorkbook) readSheet(sheet xlsSheet) ([][]string, error) {
+	record, offset, err := wb.readRecord(int(sheet.offset))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkXLSBOF(record); err != nil {
+		return nil, err
+	}
+
+	var (
+		rows [][]string
+		// the cached result of a string formula is stored in the STRING record that follows it
+		pendingRow, pendingCol = -1, -1
+		width                  int
+	)
+	// the rows and columns come from the file, so the size of the grid is checked before it grows
+	set := func(row, col uint16, value string) error {
+		if value == "" {
+			return nil
+		}
+		if int(row) >= maxSheetRows {
+			return errTooManyRows
+		}
+		if int(col) >= xlsMaxColumns {
+			return errTooManyColumns
+		}
+		if int(col) >= width {
+			width = int(col) + 1
+		}
+		height := len(rows)
+		if int(row) >= height {
+			height = int(row) + 1
+		}
+		if wb.cells+height*width > maxFileCells {
+			return errTooManyCells
+		}
+		for len(rows) <= int(row) {
+			rows = append(rows, nil)
+		}
+		for len(rows[row]) <= int(col) {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][col] = value
+		return nil
+	}
+
+	for {
+		record, offset, err = wb.readRecord(offset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := record.reader()
+		switch record.id {
+		case xlsRecordEOF:
+			wb.cells += len(rows) * width
+			return rows, nil
+		case xlsRecordLabelSST:
+			row, col, _, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			idx, err := r.uint32()
+			if err != nil {
+				return nil, err
+			}
+			if int(idx) >= len(wb.sst) {
+				return nil, fmt.Errorf("invalid shared string %d", idx)
+			}
+			if err := set(row, col, wb.sst[idx]); err != nil {
+				return nil, err
+			}
+		case xlsRecordLabel:
+			row, col, _, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			cch, err := r.uint16()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.unicodeString(int(cch))
+			if err != nil {
+				return nil, err
+			}
+			if err := set(row, col, value); err != nil {
+				return nil, err
+			}
+		case xlsRecordNumber:
+			row, col, xf, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.float64()
+			if err != nil {
+				return nil, err
+			}
+			if err := set(row, col, wb.formatNumber(xf, value)); err != nil {
+				return nil, err
+			}
+		case xlsRecordRK:
+			row, col, xf, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			rk, err := r.uint32()
+			if err != nil {
+				return nil, err
+			}
+			if err := set(row, col, wb.formatNumber(xf, xlsRKValue(rk))); err != nil {
+				return nil, err
+			}
+		case xlsRecordMulRK:
+			row, err := r.uint16()
+			if err != nil {
+				return nil, err
+			}
+			col, err := r.uint16()
+			if err != nil {
+				return nil, err
+			}
+			// each value is 6 bytes, and the record ends with the index of the last column
+			for n := (r.len() - 4 - 2) / 6; n > 0; n-- {
+				xf, err := r.uint16()
+				if err != nil {
+					return nil, err
+				}
+				rk, err := r.uint32()
+				if err != nil {
+					return nil, err
+				}
+				if err := set(row, col, wb.formatNumber(xf, xlsRKValue(rk))); err != nil {
+					return nil, err
+				}
+				col++
+			}
+		case xlsRecordBoolErr:
+			row, col, _, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			isError, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			if isError == 0 {
+				if err := set(row, col, formatXLSBool(value)); err != nil {
+					return nil, err
+				}
+			}
+		case xlsRecordFormula:
+			row, col, xf, err := r.cell()
+			if err != nil {
+				return nil, err
+			}
+			result, err := r.bytes(8)
+			if err != nil {
+				return nil, err
+			}
+			if result[6] != 0xFF || result[7] != 0xFF {
+				if err := set(row, col, wb.formatNumber(xf, math.Float64frombits(binary.LittleEndian.Uint64(result)))); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			switch result[0] {
+			case 0: // string
+				pendingRow, pendingCol = int(row), int(col)
+			case 1: // boolean
+				if err := set(row, col, formatXLSBool(result[2])); err != nil {
+					return nil, err
+				}
+			}
+		case xlsRecordString:
+			if pendingRow < 0 {
+				continue
+			}
+			cch, err := r.uint16()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.unicodeString(int(cch))
+			if err != nil {
+				return nil, err
+			}
+			if err := set(uint16(pendingRow), uint16(pendingCol), value); err != nil {
+				return nil, err
+			}
+			pendingRow, pendingCol = -1, -1
+		}
+	}
+	wb.cells += len(rows) * width
+	return rows, nil
+}
+
+// formatNumber formats the number as displayed by the cell format, which is either a date or a plain number
+func (wb *xlsWorkbook) formatNumber(xf uint16, value float64) string {
+	if int(xf) < len(wb.xfFormats) && wb.isDateFormat(wb.xfFormats[xf]) {
+		return xlsSerialToTime(value, wb.date1904).Format(dateFormat)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func (wb *xlsWorkbook) isDateFormat(id uint16) bool {
+	if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) {
+		return true
+	}
+	format, ok := wb.formats[id]
+	if !ok {
+		return false
+	}
+	return isDateFormatCode(format)
+}
+
+// isDateFormatCode returns true if the number format code displays a date, ignoring literal text and colors
+func isDateFormatCode(code string) bool {
+	var b strings.Builder
+	inQuotes, inBrackets := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '\\':
+			i++
+		case c == '[':
+			inBrackets = true
+		case c == ']':
+			inBrackets = false
+		case inBrackets:
+		default:
+			b.WriteByte(c)
+		}
+	}
+	stripped := strings.ToLower(b.String())
+	return strings.ContainsAny(stripped, "yd")
+}
+
+// xlsSerialToTime converts an Excel serial date. The 1900 date system counts 1900 as a leap year,
+// which is accounted for by starting from 1899-12-30.
+func xlsSerialToTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+}
+
+func xlsRKValue(rk uint32) float64 {
+	var value float64
+	if rk&0x02 != 0 {
+		value = float64(int32(rk) >> 2)
+	} else {
+		value = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		value /= 100
+	}
+	return value
+}
+
+func formatXLSBool(value byte) string {
+	if value != 0 {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// xlsRecordReader reads the data of a record across its CONTINUE segments
+type xlsRecordReader struct {
+	segments [][]byte
+	seg, pos int
+}
+
+func (r *xlsRecordReader) len() int {
+	n := 0
+	for _, s := range r.segments {
+		n += len(s)
+	}
+	return n
+}
+
+func (r *xlsRecordReader) byte() (byte, error) {
+	for r.seg < len(r.segments) && r.pos >= len(r.segments[r.seg]) {
+		r.seg++
+		r.pos = 0
+	}
+	if r.seg >= len(r.segments) {
+		return 0, errXLSTruncated
+	}
+	b := r.segments[r.seg][r.pos]
+	r.pos++
+	return b, nil
+}
+
+// remaining returns the number of bytes of the record that are left to read
+func (r *xlsRecordReader) remaining() int {
+	n := -r.pos
+	for _, s := range r.segments[r.seg:] {
+		n += len(s)
+	}
+	return n
+}
+
+func (r *xlsRecordReader) bytes(n int) ([]byte, error) {
+	// the length comes from the file, so it is checked before the allocation
+	if n > r.remaining() {
+		return nil, errXLSTruncated
+	}
+	b := make([]byte, n)
+	for i := range b {
+		var err error
+		if b[i], err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (r *xlsRecordReader) skip(n int) error {
+	_, err := r.bytes(n)
+	return err
+}
+
+func (r *xlsRecordReader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *xlsRecordReader) uint32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *xlsRecordReader) float64() (float64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+// cell reads the row, column and format index that start every cell record
+func (r *xlsRecordReader) cell() (row, col, xf uint16, err error) {
+	if row, err = r.uint16(); err != nil {
+		return
+	}
+	if col, err = r.uint16(); err != nil {
+		return
+	}
+	xf, err = r.uint16()
+	return
+}
+
+// unicodeString reads the option flags and the characters of a string of cch characters
+func (r *xlsRecordReader) unicodeString(cch int) (string, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	return r.characters(cch, flags)
+}
+
+// richExtendedString reads a shared string, skipping its formatting runs and phonetic data
+func (r *xlsRecordReader) richExtendedString() (string, error) {
+	cch, err := r.uint16()
+	if err != nil {
+		return "", err
+	}
+	flags, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	var runs uint16
+	var extSize uint32
+	if flags&0x08 != 0 {
+		if runs, err = r.uint16(); err != nil {
+			return "", err
+		}
+	}
+	if flags&0x04 != 0 {
+		if extSize, err = r.uint32(); err != nil {
+			return "", err
+		}
+	}
+	s, err := r.characters(int(cch), flags)
+	if err != nil {
+		return "", err
+	}
+	if err := r.skip(4*int(runs) + int(extSize)); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// characters reads cch characters, either compressed to one byte or as UTF-16. A string split across
+// CONTINUE records restarts with a new flags byte, as the encoding may change between segments.
+func (r *xlsRecordReader) characters(cch int, flags byte) (string, error) {
+	highByte := flags&0x01 != 0
+	chars := make([]uint16, 0, cch)
+	for len(chars) < cch {
+		if r.seg < len(r.segments) && r.pos >= len(r.segments[r.seg]) {
+			r.seg++
+			r.pos = 0
+			flags, err := r.byte()
+			if err != nil {
+				return "", err
+			}
+			highByte = flags&0x01 != 0
+		}
+		if highByte {
+			c, err := r.uint16()
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, c)
+		} else {
+			c, err := r.byte()
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, uint16(c))
+		}
+	}
+	return string(utf16.Decode(chars)), nil
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestUnmarshalRecordsFromXLS(t *testing.T) {
+	var records [][]string
+	err := UnmarshalRecordsFromXLS(&records, bytes.NewReader(newTestXLS()))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Full name", "Split ナ名", "Âge"},
+		{"Jane", "2023-01-01", "42"},
+		{"1.5", "2023-01-01", "TRUE"},
+		{"formula text", "3.25", ""},
+		{"", "", ""},
+		{"last", "", ""},
+	}, records)
+}
+
+func TestUnmarshalRecordsFromXLSAcceptsRenamedXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Full name", "Age"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Jane"}))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	var records [][]string
+	require.NoError(t, UnmarshallRecordsFromFile(&records, &buf, "upload.XLS"))
+	assert.Equal(t, [][]string{{"Full name", "Age"}, {"Jane", ""}}, records)
+}
+
+func TestUnmarshalRecordsFromXLSRejectsOtherFiles(t *testing.T) {
+	var records [][]string
+	err := UnmarshalRecordsFromXLS(&records, bytes.NewReader([]byte("Full name,Age\n")))
+	assert.ErrorIs(t, err, errXLSUnsupportedVersion)
+}
+
+func TestUnmarshalSheetsFromXLSBoundsTheSheets(t *testing.T) {
+	label := func(row, col uint16) []byte {
+		return concat(xlsCell(row, col, 0), xlsUint16(1), []byte{0}, []byte("x"))
+	}
+	tests := []struct {
+		name   string
+		labels [][]byte
+		want   error
+	}{
+		{name: "far column", labels: [][]byte{label(0, 0), label(0, 65535)}, want: errTooManyColumns},
+		{name: "far row", labels: [][]byte{label(0, 0), label(65535, 0)}, want: errTooManyRows},
+		{name: "large grid", labels: [][]byte{label(0, 255), label(maxSheetRows-1, 0)}, want: errTooManyCells},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalSheetsFromXLS(bytes.NewReader(newTestXLSWithLabels(tt.labels...)))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestUnmarshalSheetsFromXLSChecksTheCompoundFileHeader(t *testing.T) {
+	content := newTestXLS()
+	binary.LittleEndian.PutUint32(content[44:], 0x0FFFFFFF) // FAT sectors
+	_, err := UnmarshalSheetsFromXLS(bytes.NewReader(content))
+	assert.ErrorIs(t, err, errXLSCorrupted)
+}
+
+func TestUnmarshalSheetsFromXLSRejectsLargeFiles(t *testing.T) {
+	content := append(append([]byte(nil), ole2Signature...), make([]byte, MaxUploadSize)...)
+	_, err := UnmarshalSheetsFromXLS(bytes.NewReader(content))
+	assert.ErrorIs(t, err, errFileTooLarge)
+}
+
+func FuzzUnmarshalSheetsFromXLS(f *testing.F) {
+	f.Add(newTestXLS())
+	f.Add(newTestXLSWithLabels(concat(xlsCell(1, 2, 0), xlsUint16(1), []byte{0}, []byte("x"))))
+	f.Fuzz(func(t *testing.T, content []byte) {
+		_, _ = UnmarshalSheetsFromXLS(bytes.NewReader(content))
+	})
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	assert.True(t, isDateFormatCode("dd/mm/yyyy"))
+	assert.True(t, isDateFormatCode("[$-409]d-mmm-yy;@"))
+	assert.False(t, isDateFormatCode("0.00"))
+	assert.False(t, isDateFormatCode(`0 "days"`))
+	assert.False(t, isDateFormatCode("[Red]#,##0"))
+	assert.False(t, isDateFormatCode("h:mm:ss"))
+}
+
+// newTestXLS builds a BIFF8 workbook with a single sheet, covering the cell records and the
+// shared strings split across CONTINUE records
+func newTestXLS() []byte {
+	var globals bytes.Buffer
+	writeXLSRecord(&globals, xlsRecordBOF, xlsTestBOF(0x0005))
+	writeXLSRecord(&globals, xlsRecordDateMode, xlsUint16(0))
+	writeXLSRecord(&globals, xlsRecordFormat, concat(xlsUint16(164), xlsUnicodeString("dd/mm/yyyy")))
+	for _, format := range []uint16{0, 14, 164} {
+		writeXLSRecord(&globals, xlsRecordXF, concat(xlsUint16(0), xlsUint16(format), make([]byte, 16)))
+	}
+	boundSheetOffset := globals.Len() + 4
+	writeXLSRecord(&globals, xlsRecordBoundSheet, concat(make([]byte, 4), []byte{0, xlsSheetTypeSheet, 6, 0}, []byte("Sheet1")))
+
+	// the second shared string starts compressed, and continues as UTF-16 in the CONTINUE record
+	sst := concat(xlsUint32(3), xlsUint32(3), xlsRichString("Full name"), xlsUint16(8), []byte{0}, []byte("Split "))
+	writeXLSRecord(&globals, xlsRecordSST, sst)
+	writeXLSRecord(&globals, xlsRecordContinue, concat([]byte{1}, utf16Bytes("ナ名"), xlsRichString("Jane")))
+	writeXLSRecord(&globals, xlsRecordEOF, nil)
+
+	sheetOffset := globals.Len()
+	binary.LittleEndian.PutUint32(globals.Bytes()[boundSheetOffset:], uint32(sheetOffset))
+
+	var sheet bytes.Buffer
+	writeXLSRecord(&sheet, xlsRecordBOF, xlsTestBOF(0x0010))
+	writeXLSRecord(&sheet, xlsRecordLabelSST, concat(xlsCell(0, 0, 0), xlsUint32(0)))
+	writeXLSRecord(&sheet, xlsRecordLabelSST, concat(xlsCell(0, 1, 0), xlsUint32(1)))
+	writeXLSRecord(&sheet, xlsRecordLabel, concat(xlsCell(0, 2, 0), xlsUint16(3), []byte{1}, utf16Bytes("Âge")))
+	writeXLSRecord(&sheet, xlsRecordLabelSST, concat(xlsCell(1, 0, 0), xlsUint32(2)))
+	writeXLSRecord(&sheet, xlsRecordNumber, concat(xlsCell(1, 1, 1), xlsFloat64(44927)))
+	writeXLSRecord(&sheet, xlsRecordRK, concat(xlsCell(1, 2, 0), xlsUint32(42<<2|0x02)))
+	writeXLSRecord(&sheet, xlsRecordMulRK, concat(xlsUint16(2), xlsUint16(0),
+		xlsUint16(0), xlsUint32(150<<2|0x03),
+		xlsUint16(2), xlsUint32(44927<<2|0x02),
+		xlsUint16(1)))
+	writeXLSRecord(&sheet, xlsRecordBoolErr, concat(xlsCell(2, 2, 0), []byte{1, 0}))
+	writeXLSRecord(&sheet, xlsRecordFormula, concat(xlsCell(3, 0, 0), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, make([]byte, 6)))
+	writeXLSRecord(&sheet, xlsRecordString, concat(xlsUint16(12), []byte{0}, []byte("formula text")))
+	writeXLSRecord(&sheet, xlsRecordFormula, concat(xlsCell(3, 1, 0), xlsFloat64(3.25), make([]byte, 6)))
+	writeXLSRecord(&sheet, xlsRecordLabel, concat(xlsCell(5, 0, 0), xlsUint16(4), []byte{0}, []byte("last")))
+	writeXLSRecord(&sheet, xlsRecordEOF, nil)
+
+	stream := append(globals.Bytes(), sheet.Bytes()...)
+	return newTestCompoundFile("Workbook", stream)
+}
+
+// newTestXLSWithLabels builds a BIFF8 workbook with a single sheet holding the given LABEL records
+func newTestXLSWithLabels(labels ...[]byte) []byte {
+	var globals bytes.Buffer
+	writeXLSRecord(&globals, xlsRecordBOF, xlsTestBOF(0x0005))
+	boundSheetOffset := globals.Len() + 4
+	writeXLSRecord(&globals, xlsRecordBoundSheet, concat(make([]byte, 4), []byte{0, xlsSheetTypeSheet, 6, 0}, []byte("Sheet1")))
+	writeXLSRecord(&globals, xlsRecordEOF, nil)
+	binary.LittleEndian.PutUint32(globals.Bytes()[boundSheetOffset:], uint32(globals.Len()))
+
+	var sheet bytes.Buffer
+	writeXLSRecord(&sheet, xlsRecordBOF, xlsTestBOF(0x0010))
+	for _, label := range labels {
+		writeXLSRecord(&sheet, xlsRecordLabel, label)
+	}
+	writeXLSRecord(&sheet, xlsRecordEOF, nil)
+
+	return newTestCompoundFile("Workbook", append(globals.Bytes(), sheet.Bytes()...))
+}
+
+// newTestCompoundFile wraps the stream in an OLE2 compound file, made of a FAT sector,
+// a directory sector and the sectors of the stream
+func newTestCompoundFile(name string, stream []byte) []byte {
+	const (
+		sectorSize = 512
+		freeSect   = 0xFFFFFFFF
+		endOfChain = 0xFFFFFFFE
+		fatSect    = 0xFFFFFFFD
+		noStream   = 0xFFFFFFFF
+	)
+	// streams smaller than the cutoff would be stored in the mini stream
+	for len(stream) < 4096 || len(stream)%sectorSize != 0 {
+		stream = append(stream, 0)
+	}
+	streamSectors := len(stream) / sectorSize
+
+	header := make([]byte, sectorSize)
+	copy(header, ole2Signature)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 0x0003)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], 1) // FAT sectors
+	binary.LittleEndian.PutUint32(header[48:], 1) // first directory sector
+	binary.LittleEndian.PutUint32(header[56:], 4096)
+	binary.LittleEndian.PutUint32(header[60:], endOfChain)
+	binary.LittleEndian.PutUint32(header[68:], endOfChain)
+	binary.LittleEndian.PutUint32(header[76:], 0) // the FAT is in sector 0
+	for i := 1; i < 109; i++ {
+		binary.LittleEndian.PutUint32(header[76+4*i:], freeSect)
+	}
+
+	fat := make([]byte, sectorSize)
+	for i := 0; i < sectorSize/4; i++ {
+		binary.LittleEndian.PutUint32(fat[4*i:], freeSect)
+	}
+	binary.LittleEndian.PutUint32(fat[0:], fatSect)
+	binary.LittleEndian.PutUint32(fat[4:], endOfChain)
+	for i := 0; i < streamSectors; i++ {
+		next := uint32(2 + i + 1)
+		if i == streamSectors-1 {
+			next = endOfChain
+		}
+		binary.LittleEndian.PutUint32(fat[4*(2+i):], next)
+	}
+
+	directory := make([]byte, sectorSize)
+	entry := func(i int, name string, kind byte, child uint32, start uint32, size int) {
+		e := directory[128*i : 128*(i+1)]
+		nameBytes := utf16Bytes(name)
+		copy(e, nameBytes)
+		binary.LittleEndian.PutUint16(e[64:], uint16(len(nameBytes)+2))
+		e[66] = kind
+		e[67] = 1 // black
+		binary.LittleEndian.PutUint32(e[68:], noStream)
+		binary.LittleEndian.PutUint32(e[72:], noStream)
+		binary.LittleEndian.PutUint32(e[76:], child)
+		binary.LittleEndian.PutUint32(e[116:], start)
+		binary.LittleEndian.PutUint32(e[120:], uint32(size))
+	}
+	entry(0, "Root Entry", 5, 1, endOfChain, 0)
+	entry(1, name, 2, noStream, 2, len(stream))
+	for i := 2; i < 4; i++ {
+		e := directory[128*i : 128*(i+1)]
+		binary.LittleEndian.PutUint32(e[68:], noStream)
+		binary.LittleEndian.PutUint32(e[72:], noStream)
+		binary.LittleEndian.PutUint32(e[76:], noStream)
+	}
+
+	return concat(header, fat, directory, stream)
+}
+
+func writeXLSRecord(buf *bytes.Buffer, id uint16, data []byte) {
+	buf.Write(xlsUint16(id))
+	buf.Write(xlsUint16(uint16(len(data))))
+	buf.Write(data)
+}
+
+func xlsTestBOF(kind uint16) []byte {
+	return concat(xlsUint16(xlsVersionBIFF8), xlsUint16(kind), make([]byte, 12))
+}
+
+func xlsCell(row, col, xf uint16) []byte {
+	return concat(xlsUint16(row), xlsUint16(col), xlsUint16(xf))
+}
+
+func xlsUnicodeString(s string) []byte {
+	return concat(xlsUint16(uint16(len(s))), []byte{0}, []byte(s))
+}
+
+// xlsRichString is a compressed shared string, with a formatting run to skip
+func xlsRichString(s string) []byte {
+	return concat(xlsUint16(uint16(len(s))), []byte{0x08}, xlsUint16(1), []byte(s), make([]byte, 4))
+}
+
+func xlsUint16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return b
+}
+
+func xlsUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func xlsFloat64(v float64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+	return b
+}
+
+func utf16Bytes(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, xlsUint16(c)...)
+	}
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
@@ -0,0 +1,22 @@
+package api
+
+import "time"
+
+// PendingUpload is a file uploaded by a user whose columns are not mapped yet. The file itself is
+// kept in the blob store until the user maps its columns, or the pending upload expires.
+type PendingUpload struct {
+	ID        string `db:"id"`
+	CountryID string `db:"country_id"`
+	// OwnerID is the id of the user that uploaded the file. Only they can map its columns
+	OwnerID   string     `db:"owner_id"`
+	BlobName  string     `db:"blob_name"`
+	Format    string     `db:"format"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// IsAvailable returns true if the file can still be read at the given time
+func (p *PendingUpload) IsAvailable(now time.Time) bool {
+	return p.DeletedAt == nil && now.Before(p.ExpiresAt)
+}
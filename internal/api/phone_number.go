@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+const (
+	// phoneNumberMaxLength is the maximum number of digits of an E.164 number, calling code included
+	phoneNumberMaxLength = 15
+	// phoneNumberMinNationalLength is the minimum number of digits of a national significant number
+	phoneNumberMinNationalLength = 6
+	// phoneNumberDefaultTrunkPrefix is the prefix dialled before national numbers in most countries
+	phoneNumberDefaultTrunkPrefix = "0"
+)
+
+var (
+	ErrPhoneNumberTooShort           = errors.New("phone number is too short")
+	ErrPhoneNumberTooLong            = errors.New("phone number is too long")
+	ErrPhoneNumberUnknownCallingCode = errors.New("phone number has an unknown calling code")
+	ErrPhoneNumberUnknownRegion      = errors.New("national phone number without a known country")
+)
+
+// phoneNumberMinNationalLengths holds the calling codes whose national numbers are shorter than phoneNumberMinNationalLength
+var phoneNumberMinNationalLengths = map[string]int{
+	"290": 4, // Saint Helena
+	"500": 5, // Falkland Islands
+	"672": 5, // Norfolk Island
+	"676": 5, // Tonga
+	"677": 5, // Solomon Islands
+	"678": 5, // Vanuatu
+	"682": 5, // Cook Islands
+	"683": 4, // Niue
+	"685": 5, // Samoa
+	"686": 5, // Kiribati
+	"688": 5, // Tuvalu
+	"690": 4, // Tokelau
+}
+
+// phoneNumberTrunkPrefixes holds the calling codes whose trunk prefix is not phoneNumberDefaultTrunkPrefix.
+// An empty prefix means that the leading 0 is part of the national number.
+var phoneNumberTrunkPrefixes = map[string]string{
+	"1":   "1", // North American Numbering Plan
+	"7":   "8", // Russia, Kazakhstan
+	"375": "8", // Belarus
+	"39":  "",  // Italy, Vatican City
+	"378": "",  // San Marino
+	"225": "",  // Côte d'Ivoire
+}
+
+var phoneNumberCallingCodes = func() containers.StringSet {
+	ret := containers.NewStringSet()
+	for _, c := range constants.Countries {
+		if c.CallingCode != "" {
+			ret.Add(c.CallingCode)
+		}
+	}
+	return ret
+}()
+
+// PhoneNumber is a phone number split into its international calling code and its national significant number
+type PhoneNumber struct {
+	CallingCode    string
+	NationalNumber string
+}
+
+// E164 returns the phone number in the E.164 format, e.g. +243812345678
+func (p PhoneNumber) E164() string {
+	return "+" + p.CallingCode + p.NationalNumber
+}
+
+func (p PhoneNumber) validate() error {
+	minLength, ok := phoneNumberMinNationalLengths[p.CallingCode]
+	if !ok {
+		minLength = phoneNumberMinNationalLength
+	}
+	if len(p.NationalNumber) < minLength {
+		return ErrPhoneNumberTooShort
+	}
+	if len(p.CallingCode)+len(p.NationalNumber) > phoneNumberMaxLength {
+		return ErrPhoneNumberTooLong
+	}
+	return nil
+}
+
+// ParsePhoneNumber reads a phone number written in the international format, starting with + or 00,
+// or in the national format of the given region, an ISO 3166-1 alpha-2 code.
+// Characters other than digits are ignored.
+func ParsePhoneNumber(phoneNumber string, region string) (PhoneNumber, error) {
+	digits, international := splitPhoneNumber(phoneNumber)
+	if digits == "" {
+		return PhoneNumber{}, ErrPhoneNumberTooShort
+	}
+
+	if international {
+		for i := 1; i <= 3 && i < len(digits); i++ {
+			if phoneNumberCallingCodes.Contains(digits[:i]) {
+				ret := PhoneNumber{CallingCode: digits[:i], NationalNumber: digits[i:]}
+				return ret, ret.validate()
+			}
+		}
+		return PhoneNumber{}, ErrPhoneNumberUnknownCallingCode
+	}
+
+	country, ok := constants.CountriesByAlpha2[strings.ToUpper(region)]
+	if !ok || country.CallingCode == "" {
+		return PhoneNumber{}, ErrPhoneNumberUnknownRegion
+	}
+	callingCode := country.CallingCode
+	trunkPrefix, ok := phoneNumberTrunkPrefixes[callingCode]
+	if !ok {
+		trunkPrefix = phoneNumberDefaultTrunkPrefix
+	}
+
+	ret := PhoneNumber{CallingCode: callingCode, NationalNumber: digits}
+	if trunkPrefix != "" && strings.HasPrefix(digits, trunkPrefix) {
+		ret.NationalNumber = digits[len(trunkPrefix):]
+	} else if strings.HasPrefix(digits, callingCode) && len(digits) >= len(callingCode)+8 {
+		// the calling code was written without the international prefix, e.g. 243812345678
+		ret.NationalNumber = digits[len(callingCode):]
+	}
+	return ret, ret.validate()
+}
+
+// NormalizePhoneNumber returns the phone number in the E.164 format, reading national numbers with the
+// calling code of the given region. Phone numbers that cannot be read keep their digits only, after a +
+// when they are written in the international format.
+func NormalizePhoneNumber(phoneNumber string, region string) string {
+	if p, err := ParsePhoneNumber(phoneNumber, region); err == nil {
+		return p.E164()
+	}
+	digits, international := splitPhoneNumber(phoneNumber)
+	if international && digits != "" {
+		return "+" + digits
+	}
+	return digits
+}
+
+// PhoneNumberSearchTerm returns the digits to look for in normalized phone numbers. The international prefix
+// and the national trunk prefix are left out, so that national and international numbers both match the
+// E.164 form of the number.
+func PhoneNumberSearchTerm(phoneNumber string) string {
+	digits, international := splitPhoneNumber(phoneNumber)
+	if international {
+		return digits
+	}
+	if trimmed := strings.TrimLeft(digits, phoneNumberDefaultTrunkPrefix); trimmed != "" {
+		return trimmed
+	}
+	return digits
+}
+
+// PhoneNumberRegionOfNationality returns the ISO 3166-1 alpha-2 code of a nationality, stored as an
+// ISO 3166-1 alpha-3 code, or an empty string when it is not known.
+func PhoneNumberRegionOfNationality(nationality string) string {
+	return constants.CountriesByCode[strings.ToUpper(trimString(nationality))].ISO3166Alpha2
+}
+
+// splitPhoneNumber returns the digits of the phone number, without its international prefix,
+// and whether it is written in the international format
+func splitPhoneNumber(phoneNumber string) (string, bool) {
+	phoneNumber = trimString(NormalizeDigits(phoneNumber))
+	b := strings.Builder{}
+	for _, c := range phoneNumber {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(phoneNumber, "+") {
+		return digits, true
+	}
+	if strings.HasPrefix(digits, "00") {
+		return digits[2:], true
+	}
+	return digits, false
+}
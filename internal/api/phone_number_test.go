@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	testCases := []struct {
+		phoneNumber string
+		region      string
+		expect      string
+		err         error
+	}{
+		{phoneNumber: "0812345678", region: "CD", expect: "+243812345678"},
+		{phoneNumber: "+243812345678", region: "CD", expect: "+243812345678"},
+		{phoneNumber: "00243 81 234 5678", expect: "+243812345678"},
+		{phoneNumber: "243812345678", region: "cd", expect: "+243812345678"},
+		{phoneNumber: "(081) 234-5678", region: "CD", expect: "+243812345678"},
+		{phoneNumber: "٠٨١٢٣٤٥٦٧٨", region: "CD", expect: "+243812345678"},
+		{phoneNumber: "+1 (212) 555-1234", expect: "+12125551234"},
+		{phoneNumber: "1 212 555 1234", region: "US", expect: "+12125551234"},
+		{phoneNumber: "8 916 123 45 67", region: "RU", expect: "+79161234567"},
+		{phoneNumber: "06 1234 5678", region: "IT", expect: "+390612345678"},
+		{phoneNumber: "4321", region: "NU", expect: "+6834321"},
+		{phoneNumber: "0812", region: "CD", err: ErrPhoneNumberTooShort},
+		{phoneNumber: "n/a", region: "CD", err: ErrPhoneNumberTooShort},
+		{phoneNumber: "+243 81234567890123", err: ErrPhoneNumberTooLong},
+		{phoneNumber: "+999 812345678", err: ErrPhoneNumberUnknownCallingCode},
+		{phoneNumber: "0812345678", err: ErrPhoneNumberUnknownRegion},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.phoneNumber, func(t *testing.T) {
+			p, err := ParsePhoneNumber(tc.phoneNumber, tc.region)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.expect, p.E164())
+			}
+		})
+	}
+}
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	assert.Equal(t, "+243812345678", NormalizePhoneNumber("0812345678", "CD"))
+	assert.Equal(t, NormalizePhoneNumber("0812345678", "CD"), NormalizePhoneNumber("00243 81 234 5678", "CD"))
+	assert.Equal(t, "0812345678", NormalizePhoneNumber("0812345678", ""))
+	assert.Equal(t, "+999812345678", NormalizePhoneNumber("+999 812345678", "CD"))
+	assert.Equal(t, "", NormalizePhoneNumber("", "CD"))
+}
+
+func TestPhoneNumberSearchTerm(t *testing.T) {
+	assert.Equal(t, "812345678", PhoneNumberSearchTerm("0812345678"))
+	assert.Equal(t, "243812345678", PhoneNumberSearchTerm("+243812345678"))
+	assert.Equal(t, "243812345678", PhoneNumberSearchTerm("00243 81 234 5678"))
+	assert.Equal(t, "0", PhoneNumberSearchTerm("0"))
+}
+
+func TestIndividualNormalizePhoneNumbers(t *testing.T) {
+	i := &Individual{Nationality1: "COD", PhoneNumber1: " 0812345678 ", PhoneNumber2: "+256 712 345678"}
+	i.Normalize()
+	assert.Equal(t, "+243812345678", i.NormalizedPhoneNumber1)
+	assert.Equal(t, "+256712345678", i.NormalizedPhoneNumber2)
+	assert.Equal(t, "", i.NormalizedPhoneNumber3)
+
+	// the region of the country comes before the nationality
+	i.PhoneNumber1 = "0712345678"
+	i.NormalizePhoneNumbers("UG")
+	assert.Equal(t, "+256712345678", i.NormalizedPhoneNumber1)
+}
+
+func TestCountryPhoneNumberRegion(t *testing.T) {
+	assert.Equal(t, "CD", (&Country{Code: "cd", Name: "DRC"}).PhoneNumberRegion())
+	assert.Equal(t, "CD", (&Country{Code: "COD"}).PhoneNumberRegion())
+	assert.Equal(t, "UG", (&Country{Code: "uganda", Name: "Uganda"}).PhoneNumberRegion())
+	assert.Equal(t, "", (&Country{Code: "xyz", Name: "Nowhere"}).PhoneNumberRegion())
+}
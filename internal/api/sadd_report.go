@@ -0,0 +1,289 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+// SADDReportOptions configures which services are taken into account when building a SADD report
+type SADDReportOptions struct {
+	// AgeBands are the age bands used to disaggregate the counts
+	AgeBands AgeBands
+	// ServiceCCs restricts the report to services of these core competencies. All services are included when empty
+	ServiceCCs containers.Set[enumTypes.ServiceCC]
+	// DeliveredFrom excludes services delivered before that date
+	DeliveredFrom *time.Time
+	// DeliveredTo excludes services delivered after that date
+	DeliveredTo *time.Time
+	// Donor restricts the report to services funded by that donor
+	Donor string
+	// ProjectName restricts the report to services delivered under that project
+	ProjectName string
+	// IncludeDisability adds the number of persons with disabilities. It must only be set for the users
+	// that can view the health fields
+	IncludeDisability bool
+	// Now is used to compute the age of individuals from their birth date
+	Now time.Time
+}
+
+// SADDSex is the sex category used in SADD reports
+type SADDSex int
+
+const (
+	SADDSexFemale SADDSex = iota
+	SADDSexMale
+	SADDSexOther
+
+	saddSexCount = 3
+)
+
+var saddSexes = []SADDSex{SADDSexFemale, SADDSexMale, SADDSexOther}
+
+func (s SADDSex) String() string {
+	t := locales.GetTranslator()
+	switch s {
+	case SADDSexFemale:
+		return t("option_sex_female")
+	case SADDSexMale:
+		return t("option_sex_male")
+	default:
+		return t("sadd_other_sex")
+	}
+}
+
+func saddSexOf(sex enumTypes.Sex) SADDSex {
+	switch sex {
+	case enumTypes.SexFemale:
+		return SADDSexFemale
+	case enumTypes.SexMale:
+		return SADDSexMale
+	default:
+		return SADDSexOther
+	}
+}
+
+// SADDCounts holds the sex-, age- and disability-disaggregated number of individuals.
+// WithDisability is only counted when the report options include the disabilities.
+type SADDCounts struct {
+	Total           int
+	BySex           [saddSexCount]int
+	ByAgeBandAndSex [][saddSexCount]int
+	UnknownAge      [saddSexCount]int
+	WithDisability  [saddSexCount]int
+}
+
+func newSADDCounts(ageBands AgeBands) *SADDCounts {
+	return &SADDCounts{ByAgeBandAndSex: make([][saddSexCount]int, len(ageBands))}
+}
+
+func (c *SADDCounts) add(individual *Individual, options SADDReportOptions) {
+	sex := saddSexOf(individual.Sex)
+	c.Total++
+	c.BySex[sex]++
+	if options.IncludeDisability && individual.isPersonWithDisability() {
+		c.WithDisability[sex]++
+	}
+	age := individual.ageAt(options.Now)
+	if age == nil {
+		c.UnknownAge[sex]++
+		return
+	}
+	for i, band := range options.AgeBands {
+		if band.Contains(*age) {
+			c.ByAgeBandAndSex[i][sex]++
+			return
+		}
+	}
+	c.UnknownAge[sex]++
+}
+
+// SADDRow is the counts for a single value of a breakdown, e.g. a single office
+type SADDRow struct {
+	Key    string
+	Label  string
+	Counts *SADDCounts
+}
+
+// SADDBreakdown is a table of counts, one row per distinct value of the breakdown
+type SADDBreakdown struct {
+	Name string
+	Rows []SADDRow
+}
+
+// SADDReport is a set of SADD tables computed over the services delivered to individuals
+type SADDReport struct {
+	Options    SADDReportOptions
+	Breakdowns []SADDBreakdown
+	// Total is the number of distinct individuals that received at least one of the services
+	Total *SADDCounts
+}
+
+type saddBreakdownBuilder struct {
+	name    string
+	keyFn   func(individual *Individual, service IndividualService) string
+	labelFn func(key string) string
+	rows    map[string]*SADDCounts
+}
+
+// NewSADDReportOptions returns the report options restricting the services to those matching the
+// service filters of the given list options
+func NewSADDReportOptions(listOptions ListIndividualsOptions, ageBands AgeBands) SADDReportOptions {
+	return SADDReportOptions{
+		AgeBands:      ageBands,
+		ServiceCCs:    listOptions.ServiceCC,
+		DeliveredFrom: listOptions.ServiceDeliveredDateFrom,
+		DeliveredTo:   listOptions.ServiceDeliveredDateTo,
+		Donor:         listOptions.ServiceDonor,
+		ProjectName:   listOptions.ServiceProjectName,
+	}
+}
+
+// NewSADDReport builds the SADD tables by service, office, month of delivery, donor and project.
+// An individual is counted at most once per row, even when they received several matching services.
+func NewSADDReport(individuals []*Individual, options SADDReportOptions) SADDReport {
+	t := locales.GetTranslator()
+	if len(options.AgeBands) == 0 {
+		options.AgeBands = DefaultAgeBands
+	}
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	builders := []*saddBreakdownBuilder{
+		{
+			name: t("sadd_by_service"),
+			keyFn: func(_ *Individual, s IndividualService) string {
+				return string(s.CC)
+			},
+			labelFn: func(key string) string {
+				return enumTypes.ServiceCC(key).String()
+			},
+		}, {
+			name: t("sadd_by_office"),
+			keyFn: func(i *Individual, _ IndividualService) string {
+				return i.CollectionOffice
+			},
+		}, {
+			name: t("sadd_by_month"),
+			keyFn: func(_ *Individual, s IndividualService) string {
+				return s.DeliveredDate.Format("2006-01")
+			},
+		}, {
+			name: t("sadd_by_donor"),
+			keyFn: func(_ *Individual, s IndividualService) string {
+				return s.Donor
+			},
+		}, {
+			name: t("sadd_by_project"),
+			keyFn: func(_ *Individual, s IndividualService) string {
+				return s.ProjectName
+			},
+		},
+	}
+	for _, b := range builders {
+		b.rows = map[string]*SADDCounts{}
+	}
+
+	total := newSADDCounts(options.AgeBands)
+	for _, individual := range individuals {
+		services := options.matchingServices(individual)
+		if len(services) == 0 {
+			continue
+		}
+		total.add(individual, options)
+		for _, b := range builders {
+			seen := containers.NewStringSet()
+			for _, service := range services {
+				key := b.keyFn(individual, service)
+				if seen.Contains(key) {
+					continue
+				}
+				seen.Add(key)
+				if _, ok := b.rows[key]; !ok {
+					b.rows[key] = newSADDCounts(options.AgeBands)
+				}
+				b.rows[key].add(individual, options)
+			}
+		}
+	}
+
+	report := SADDReport{Options: options, Total: total}
+	for _, b := range builders {
+		report.Breakdowns = append(report.Breakdowns, b.build())
+	}
+	return report
+}
+
+func (b *saddBreakdownBuilder) build() SADDBreakdown {
+	keys := make([]string, 0, len(b.rows))
+	for key := range b.rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	breakdown := SADDBreakdown{Name: b.name}
+	for _, key := range keys {
+		label := key
+		if b.labelFn != nil {
+			label = b.labelFn(key)
+		}
+		breakdown.Rows = append(breakdown.Rows, SADDRow{Key: key, Label: label, Counts: b.rows[key]})
+	}
+	return breakdown
+}
+
+func (o SADDReportOptions) matchingServices(individual *Individual) []IndividualService {
+	var ret []IndividualService
+	for _, service := range individual.GetServices() {
+		if service.IsEmpty() || service.DeliveredDate == nil {
+			continue
+		}
+		if !o.ServiceCCs.IsEmpty() && !o.ServiceCCs.Contains(service.CC) {
+			continue
+		}
+		if o.DeliveredFrom != nil && service.DeliveredDate.Before(*o.DeliveredFrom) {
+			continue
+		}
+		if o.DeliveredTo != nil && service.DeliveredDate.After(*o.DeliveredTo) {
+			continue
+		}
+		if o.Donor != "" && o.Donor != service.Donor {
+			continue
+		}
+		if o.ProjectName != "" && o.ProjectName != service.ProjectName {
+			continue
+		}
+		ret = append(ret, service)
+	}
+	return ret
+}
+
+func (i *Individual) isPersonWithDisability() bool {
+	flags := []*bool{
+		i.HasDisability,
+		i.HasVisionDisability,
+		i.HasHearingDisability,
+		i.HasMobilityDisability,
+		i.HasCognitiveDisability,
+		i.HasSelfCareDisability,
+		i.HasCommunicationDisability,
+	}
+	for _, flag := range flags {
+		if flag != nil && *flag {
+			return true
+		}
+	}
+	return false
+}
+
+// ageAt returns the derived age of the individual at the given time, or nil if it is unknown
+func (i *Individual) ageAt(now time.Time) *int {
+	age, _ := i.DerivedAge(now)
+	if age == nil || *age < 0 {
+		return nil
+	}
+	return age
+}
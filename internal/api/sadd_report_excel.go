@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/xuri/excelize/v2"
+)
+
+const maxSheetNameLength = 31
+
+// MarshalSADDReportExcel writes the report as an xlsx workbook, with one sheet per breakdown
+func MarshalSADDReportExcel(w io.Writer, report SADDReport) error {
+	f := excelize.NewFile()
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	header := report.header()
+	usedSheetNames := containers.NewStringSet()
+	for idx, breakdown := range report.Breakdowns {
+		sheetName := uniqueSheetName(breakdown.Name, usedSheetNames)
+		if idx == 0 {
+			f.SetSheetName("Sheet1", sheetName)
+		} else if _, err := f.NewSheet(sheetName); err != nil {
+			return err
+		}
+
+		streamWriter, err := f.NewStreamWriter(sheetName)
+		if err != nil {
+			return err
+		}
+
+		if err := streamWriter.SetRow("A1", stringArrayToInterfaceArray(header)); err != nil {
+			return err
+		}
+
+		for rowIdx, row := range breakdown.Rows {
+			if err := streamWriter.SetRow(fmt.Sprintf("A%d", rowIdx+2), report.row(row.Label, row.Counts)); err != nil {
+				return err
+			}
+		}
+
+		totalLabel := locales.GetTranslator()("sadd_total_distinct")
+		if err := streamWriter.SetRow(fmt.Sprintf("A%d", len(breakdown.Rows)+2), report.row(totalLabel, report.Total)); err != nil {
+			return err
+		}
+
+		if err := streamWriter.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Write(w); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r SADDReport) header() []string {
+	t := locales.GetTranslator()
+	header := []string{t("sadd_group"), t("sadd_total")}
+	for _, sex := range saddSexes {
+		header = append(header, sex.String())
+	}
+	for _, band := range r.Options.AgeBands {
+		for _, sex := range saddSexes {
+			header = append(header, fmt.Sprintf("%s %s", band.Label(), sex.String()))
+		}
+	}
+	for _, sex := range saddSexes {
+		header = append(header, fmt.Sprintf("%s %s", t("sadd_unknown_age"), sex.String()))
+	}
+	if r.Options.IncludeDisability {
+		for _, sex := range saddSexes {
+			header = append(header, fmt.Sprintf("%s %s", t("sadd_with_disability"), sex.String()))
+		}
+	}
+	return header
+}
+
+func (r SADDReport) row(label string, counts *SADDCounts) []interface{} {
+	if label == "" {
+		label = locales.GetTranslator()("unknown")
+	}
+	row := []interface{}{label, counts.Total}
+	for _, sex := range saddSexes {
+		row = append(row, counts.BySex[sex])
+	}
+	for _, bandCounts := range counts.ByAgeBandAndSex {
+		for _, sex := range saddSexes {
+			row = append(row, bandCounts[sex])
+		}
+	}
+	for _, sex := range saddSexes {
+		row = append(row, counts.UnknownAge[sex])
+	}
+	if r.Options.IncludeDisability {
+		for _, sex := range saddSexes {
+			row = append(row, counts.WithDisability[sex])
+		}
+	}
+	return row
+}
+
+// uniqueSheetName returns a valid excel sheet name based on name that is not already in use
+func uniqueSheetName(name string, used containers.StringSet) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = truncateRunes(name, maxSheetNameLength)
+	candidate := name
+	for i := 2; used.Contains(strings.ToLower(candidate)); i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, maxSheetNameLength-len(suffix)) + suffix
+	}
+	used.Add(strings.ToLower(candidate))
+	return candidate
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNewSADDReport(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	january := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	bands := AgeBands{
+		{From: pointers.Int(0), To: pointers.Int(17)},
+		{From: pointers.Int(18)},
+	}
+
+	individuals := []*Individual{
+		{
+			// two shelter services, counted once
+			Sex:                   enumTypes.SexFemale,
+			Age:                   pointers.Int(10),
+			CollectionOffice:      "north",
+			ServiceCC1:            enumTypes.ServiceCCShelter,
+			ServiceDeliveredDate1: &january,
+			ServiceDonor1:         "ECHO",
+			ServiceCC2:            enumTypes.ServiceCCShelter,
+			ServiceDeliveredDate2: &february,
+			ServiceDonor2:         "ECHO",
+		}, {
+			// age computed from birth date
+			Sex:                   enumTypes.SexMale,
+			BirthDate:             pointers.Time(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)),
+			HasVisionDisability:   pointers.Bool(true),
+			CollectionOffice:      "south",
+			ServiceCC1:            enumTypes.ServiceCCWash,
+			ServiceDeliveredDate1: &february,
+			ServiceDonor1:         "SIDA",
+		}, {
+			// unknown sex and age
+			CollectionOffice:      "south",
+			ServiceCC1:            enumTypes.ServiceCCShelter,
+			ServiceDeliveredDate1: &january,
+			ServiceDonor1:         "ECHO",
+		}, {
+			// service not delivered
+			Sex:                   enumTypes.SexFemale,
+			Age:                   pointers.Int(30),
+			ServiceCC1:            enumTypes.ServiceCCShelter,
+			ServiceRequestedDate1: &january,
+		},
+	}
+
+	t.Run("all services", func(t *testing.T) {
+		report := NewSADDReport(individuals, SADDReportOptions{AgeBands: bands, Now: now, IncludeDisability: true})
+
+		assert.Equal(t, 3, report.Total.Total)
+		assert.Equal(t, [3]int{1, 1, 1}, report.Total.BySex)
+		assert.Equal(t, [][3]int{{1, 0, 0}, {0, 1, 0}}, report.Total.ByAgeBandAndSex)
+		assert.Equal(t, [3]int{0, 0, 1}, report.Total.UnknownAge)
+		assert.Equal(t, [3]int{0, 1, 0}, report.Total.WithDisability)
+
+		assert.Len(t, report.Breakdowns, 5)
+
+		byService := report.Breakdowns[0]
+		assert.Len(t, byService.Rows, 2)
+		assert.Equal(t, string(enumTypes.ServiceCCShelter), byService.Rows[0].Key)
+		assert.Equal(t, enumTypes.ServiceCCShelter.String(), byService.Rows[0].Label)
+		assert.Equal(t, 2, byService.Rows[0].Counts.Total)
+		assert.Equal(t, string(enumTypes.ServiceCCWash), byService.Rows[1].Key)
+		assert.Equal(t, 1, byService.Rows[1].Counts.Total)
+
+		byOffice := report.Breakdowns[1]
+		assert.Equal(t, []string{"north", "south"}, rowKeys(byOffice))
+		assert.Equal(t, 2, byOffice.Rows[1].Counts.Total)
+
+		byMonth := report.Breakdowns[2]
+		assert.Equal(t, []string{"2023-01", "2023-02"}, rowKeys(byMonth))
+		assert.Equal(t, 2, byMonth.Rows[0].Counts.Total)
+		assert.Equal(t, 2, byMonth.Rows[1].Counts.Total)
+
+		byDonor := report.Breakdowns[3]
+		assert.Equal(t, []string{"ECHO", "SIDA"}, rowKeys(byDonor))
+		assert.Equal(t, 2, byDonor.Rows[0].Counts.Total)
+	})
+
+	t.Run("restricted to period and donor", func(t *testing.T) {
+		report := NewSADDReport(individuals, SADDReportOptions{
+			AgeBands:      bands,
+			Now:           now,
+			DeliveredFrom: &february,
+			Donor:         "ECHO",
+		})
+		assert.Equal(t, 1, report.Total.Total)
+		assert.Equal(t, [3]int{1, 0, 0}, report.Total.BySex)
+		assert.Equal(t, []string{"2023-02"}, rowKeys(report.Breakdowns[2]))
+	})
+
+	t.Run("without disabilities", func(t *testing.T) {
+		report := NewSADDReport(individuals, SADDReportOptions{AgeBands: bands, Now: now})
+		assert.Equal(t, 3, report.Total.Total)
+		assert.Equal(t, [3]int{0, 0, 0}, report.Total.WithDisability)
+		// group, total, sexes, age bands by sex and unknown age by sex
+		assert.Len(t, report.header(), 2+3+len(bands)*3+3)
+		assert.Len(t, report.row("", report.Total), 2+3+len(bands)*3+3)
+	})
+}
+
+func rowKeys(b SADDBreakdown) []string {
+	var keys []string
+	for _, row := range b.Rows {
+		keys = append(keys, row.Key)
+	}
+	return keys
+}
+
+func TestMarshalSADDReportExcel(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	delivered := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	report := NewSADDReport([]*Individual{
+		{
+			Sex:                   enumTypes.SexFemale,
+			Age:                   pointers.Int(10),
+			ServiceCC1:            enumTypes.ServiceCCShelter,
+			ServiceDeliveredDate1: &delivered,
+		},
+	}, SADDReportOptions{AgeBands: DefaultAgeBands})
+
+	var buf bytes.Buffer
+	assert.NoError(t, MarshalSADDReportExcel(&buf, report))
+
+	f, err := excelize.OpenReader(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, f.GetSheetList(), len(report.Breakdowns))
+
+	rows, err := f.GetRows(f.GetSheetList()[0])
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"Group", "Total", "Female", "Male", "Other or unknown", "0-4 Female"}, rows[0][:6])
+	assert.Equal(t, []string{enumTypes.ServiceCCShelter.String(), "1", "1", "0", "0", "0", "0", "0", "1"}, rows[1][:9])
+	assert.Equal(t, "Total (distinct participants)", rows[2][0])
+}
+
+func TestUniqueSheetName(t *testing.T) {
+	names := []string{}
+	set := containers.NewStringSet()
+	for _, name := range []string{"XXXX", "XXXX", "a/b", "a very long sheet name that does not fit"} {
+		names = append(names, uniqueSheetName(name, set))
+	}
+	assert.Equal(t, []string{"XXXX", "XXXX (2)", "a_b", "a very long sheet name that doe"}, names)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ServiceAccount is a machine client of the application, such as an ETL job or a partner integration.
+// Service accounts have no JWT groups, and only get access through their API tokens.
+type ServiceAccount struct {
+	ID          string    `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	// CreatedBy is the id of the global admin who created the service account
+	CreatedBy string `db:"created_by"`
+}
+
+// APIToken is a secret given to a service account. Only the hash of the secret is stored,
+// so that the secret cannot be recovered from the database.
+type APIToken struct {
+	ID               string         `db:"id"`
+	ServiceAccountID string         `db:"service_account_id"`
+	Name             string         `db:"name"`
+	Hash             string         `db:"token_hash"`
+	Scopes           APITokenScopes `db:"scopes"`
+	ExpiresAt        time.Time      `db:"expires_at"`
+	CreatedAt        time.Time      `db:"created_at"`
+	// CreatedBy is the id of the global admin who issued the token
+	CreatedBy  string     `db:"created_by"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+// IsRevoked returns true if the token was revoked
+func (t APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired returns true if the token is expired at the given time
+func (t APIToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsActive returns true if the token can be used at the given time
+func (t APIToken) IsActive(now time.Time) bool {
+	return !t.IsRevoked() && !t.IsExpired(now)
+}
+
+// APITokenScopes are the countries an API token can access, and what it can do in them.
+// The permission is one of the built-in CountryGroupRoles.
+type APITokenScopes struct {
+	CountryIDs []string `json:"countryIds"`
+	Permission string   `json:"permission"`
+}
+
+// Scan implements sql.Scanner
+func (s *APITokenScopes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into APITokenScopes", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (s APITokenScopes) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// HasCountry returns true if the token can access the country
+func (s APITokenScopes) HasCountry(countryID string) bool {
+	for _, id := range s.CountryIDs {
+		if id == countryID {
+			return true
+		}
+	}
+	return false
+}
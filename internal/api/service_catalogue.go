@@ -0,0 +1,322 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+// ServiceCatalogueEntryKind is the level of an entry in the service catalogue
+type ServiceCatalogueEntryKind string
+
+const (
+	// ServiceCatalogueEntryKindServiceType is a service type, under a core competency
+	ServiceCatalogueEntryKindServiceType ServiceCatalogueEntryKind = "service_type"
+	// ServiceCatalogueEntryKindService is a service, under a service type
+	ServiceCatalogueEntryKindService ServiceCatalogueEntryKind = "service"
+	// ServiceCatalogueEntryKindSubService is a sub-service, under a service
+	ServiceCatalogueEntryKindSubService ServiceCatalogueEntryKind = "sub_service"
+	// ServiceCatalogueEntryKindDonor is a donor
+	ServiceCatalogueEntryKindDonor ServiceCatalogueEntryKind = "donor"
+	// ServiceCatalogueEntryKindProject is a project, under a donor
+	ServiceCatalogueEntryKindProject ServiceCatalogueEntryKind = "project"
+)
+
+// ServiceCatalogueEntryKinds are the kinds of entries, in the order of the individual service fields
+var ServiceCatalogueEntryKinds = []ServiceCatalogueEntryKind{
+	ServiceCatalogueEntryKindServiceType,
+	ServiceCatalogueEntryKindService,
+	ServiceCatalogueEntryKindSubService,
+	ServiceCatalogueEntryKindDonor,
+	ServiceCatalogueEntryKindProject,
+}
+
+// ParentKind returns the kind of the parent of the entries of this kind,
+// or an empty string for the kinds at the top of the catalogue
+func (k ServiceCatalogueEntryKind) ParentKind() ServiceCatalogueEntryKind {
+	switch k {
+	case ServiceCatalogueEntryKindService:
+		return ServiceCatalogueEntryKindServiceType
+	case ServiceCatalogueEntryKindSubService:
+		return ServiceCatalogueEntryKindService
+	case ServiceCatalogueEntryKindProject:
+		return ServiceCatalogueEntryKindDonor
+	default:
+		return ""
+	}
+}
+
+// ChildKind returns the kind of the children of the entries of this kind,
+// or an empty string for the kinds at the bottom of the catalogue
+func (k ServiceCatalogueEntryKind) ChildKind() ServiceCatalogueEntryKind {
+	for _, kind := range ServiceCatalogueEntryKinds {
+		if kind.ParentKind() == k {
+			return kind
+		}
+	}
+	return ""
+}
+
+// IsValid returns true if the kind is known
+func (k ServiceCatalogueEntryKind) IsValid() bool {
+	for _, kind := range ServiceCatalogueEntryKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the kind in the current language
+func (k ServiceCatalogueEntryKind) String() string {
+	t := locales.GetTranslator()
+	switch k {
+	case ServiceCatalogueEntryKindServiceType:
+		return t("service_type")
+	case ServiceCatalogueEntryKindService:
+		return t("service")
+	case ServiceCatalogueEntryKindSubService:
+		return t("service_sub_service")
+	case ServiceCatalogueEntryKindDonor:
+		return t("service_donor")
+	case ServiceCatalogueEntryKindProject:
+		return t("service_project_name")
+	default:
+		return string(k)
+	}
+}
+
+// ServiceCatalogueEntry is a service type, service, sub-service, donor or project of the service catalogue
+type ServiceCatalogueEntry struct {
+	ID   string                    `json:"id"`
+	Kind ServiceCatalogueEntryKind `json:"kind"`
+	// ParentID is the ID of the parent entry. Service types and donors have no parent.
+	ParentID string `json:"parentId,omitempty"`
+	// ServiceCC is the core competency of a service type
+	ServiceCC enumTypes.ServiceCC `json:"serviceCc,omitempty"`
+	// Name is the value recorded in the service fields of the individuals
+	Name string `json:"name"`
+}
+
+// ServiceCatalogue is the catalogue of the services provided in a country, organised as core competency,
+// service type, service and sub-service, along with the registry of the donors and their projects.
+// A level of the catalogue without entries is not managed, and its service field remains free text.
+type ServiceCatalogue struct {
+	Entries []ServiceCatalogueEntry `json:"entries,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (c *ServiceCatalogue) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into ServiceCatalogue", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (c ServiceCatalogue) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// IsEmpty returns true if the catalogue has no entries
+func (c ServiceCatalogue) IsEmpty() bool {
+	return len(c.Entries) == 0
+}
+
+// Manages returns true if the catalogue has entries of the given kind, in which case
+// the matching service fields must hold one of them
+func (c ServiceCatalogue) Manages(kind ServiceCatalogueEntryKind) bool {
+	for _, entry := range c.Entries {
+		if entry.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// EntryByID returns the entry with the given ID
+func (c ServiceCatalogue) EntryByID(id string) (ServiceCatalogueEntry, bool) {
+	for _, entry := range c.Entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return ServiceCatalogueEntry{}, false
+}
+
+// Children returns the entries of the given kind under the parent, in the order they were added.
+// An empty parentID returns the entries of the kind under any parent.
+func (c ServiceCatalogue) Children(kind ServiceCatalogueEntryKind, parentID string) []ServiceCatalogueEntry {
+	var ret []ServiceCatalogueEntry
+	for _, entry := range c.Entries {
+		if entry.Kind == kind && (parentID == "" || entry.ParentID == parentID) {
+			ret = append(ret, entry)
+		}
+	}
+	return ret
+}
+
+// ServiceTypes returns the service types of the core competency, or of all core competencies if cc is empty
+func (c ServiceCatalogue) ServiceTypes(cc enumTypes.ServiceCC) []ServiceCatalogueEntry {
+	var ret []ServiceCatalogueEntry
+	for _, entry := range c.Children(ServiceCatalogueEntryKindServiceType, "") {
+		if cc == enumTypes.ServiceCCNone || entry.ServiceCC == cc {
+			ret = append(ret, entry)
+		}
+	}
+	return ret
+}
+
+// Find returns the entry among candidates whose name matches, ignoring case and surrounding spaces
+func (c ServiceCatalogue) Find(candidates []ServiceCatalogueEntry, name string) (ServiceCatalogueEntry, bool) {
+	name = strings.TrimSpace(name)
+	for _, entry := range candidates {
+		if strings.EqualFold(entry.Name, name) {
+			return entry, true
+		}
+	}
+	return ServiceCatalogueEntry{}, false
+}
+
+// Descendants returns the IDs of the entry and of all the entries below it
+func (c ServiceCatalogue) Descendants(id string) []string {
+	ret := []string{id}
+	for i := 0; i < len(ret); i++ {
+		for _, entry := range c.Entries {
+			if entry.ParentID == ret[i] {
+				ret = append(ret, entry.ID)
+			}
+		}
+	}
+	return ret
+}
+
+// Path returns the entry and its ancestors, starting from the top of the catalogue
+func (c ServiceCatalogue) Path(id string) []ServiceCatalogueEntry {
+	var ret []ServiceCatalogueEntry
+	for id != "" {
+		entry, ok := c.EntryByID(id)
+		if !ok {
+			break
+		}
+		ret = append([]ServiceCatalogueEntry{entry}, ret...)
+		id = entry.ParentID
+	}
+	return ret
+}
+
+// Label returns the names of the entry and its ancestors, e.g. "Shelter › NFI › Kits"
+func (c ServiceCatalogue) Label(id string) string {
+	path := c.Path(id)
+	parts := make([]string, 0, len(path)+1)
+	if len(path) > 0 && path[0].ServiceCC != enumTypes.ServiceCCNone {
+		parts = append(parts, path[0].ServiceCC.String())
+	}
+	for _, entry := range path {
+		parts = append(parts, entry.Name)
+	}
+	return strings.Join(parts, " › ")
+}
+
+// ServiceCatalogueOption is an entry of the catalogue, as shown in the search form
+type ServiceCatalogueOption struct {
+	ID    string
+	Label string
+}
+
+// Options returns all the entries of the catalogue, labelled with their path, services before donors
+func (c ServiceCatalogue) Options() []ServiceCatalogueOption {
+	var ret []ServiceCatalogueOption
+	var walk func(kind ServiceCatalogueEntryKind, parentID string)
+	walk = func(kind ServiceCatalogueEntryKind, parentID string) {
+		for _, entry := range c.Entries {
+			if entry.Kind != kind || entry.ParentID != parentID {
+				continue
+			}
+			ret = append(ret, ServiceCatalogueOption{ID: entry.ID, Label: c.Label(entry.ID)})
+			if childKind := kind.ChildKind(); childKind != "" {
+				walk(childKind, entry.ID)
+			}
+		}
+	}
+	walk(ServiceCatalogueEntryKindServiceType, "")
+	walk(ServiceCatalogueEntryKindDonor, "")
+	return ret
+}
+
+// IndividualServiceFilter matches the individuals having a service slot with the given values.
+// Empty values match any value.
+type IndividualServiceFilter struct {
+	CC          enumTypes.ServiceCC
+	Type        string
+	Service     string
+	SubService  string
+	Donor       string
+	ProjectName string
+}
+
+// Filter returns the filter matching the service slots recorded with the entry, or with any entry below it
+func (c ServiceCatalogue) Filter(id string) (IndividualServiceFilter, bool) {
+	path := c.Path(id)
+	if len(path) == 0 {
+		return IndividualServiceFilter{}, false
+	}
+	var ret IndividualServiceFilter
+	for _, entry := range path {
+		switch entry.Kind {
+		case ServiceCatalogueEntryKindServiceType:
+			ret.CC = entry.ServiceCC
+			ret.Type = entry.Name
+		case ServiceCatalogueEntryKindService:
+			ret.Service = entry.Name
+		case ServiceCatalogueEntryKindSubService:
+			ret.SubService = entry.Name
+		case ServiceCatalogueEntryKindDonor:
+			ret.Donor = entry.Name
+		case ServiceCatalogueEntryKindProject:
+			ret.ProjectName = entry.Name
+		}
+	}
+	return ret, true
+}
+
+// NormalizeIndividual replaces the service values of the individual that match an entry of the catalogue,
+// ignoring case, by the name of the entry, so that the values are recorded consistently.
+// Values that do not match are left as is, to be reported by the validation.
+func (c ServiceCatalogue) NormalizeIndividual(i *Individual) {
+	for _, slot := range i.serviceCatalogueValues() {
+		var typeID string
+		if entry, ok := c.Find(c.ServiceTypes(*slot.cc), *slot.serviceType); ok {
+			*slot.serviceType = entry.Name
+			typeID = entry.ID
+		}
+		var serviceID string
+		if entry, ok := c.Find(c.Children(ServiceCatalogueEntryKindService, typeID), *slot.service); ok {
+			*slot.service = entry.Name
+			serviceID = entry.ID
+		}
+		if entry, ok := c.Find(c.Children(ServiceCatalogueEntryKindSubService, serviceID), *slot.subService); ok {
+			*slot.subService = entry.Name
+		}
+		var donorID string
+		if entry, ok := c.Find(c.Children(ServiceCatalogueEntryKindDonor, ""), *slot.donor); ok {
+			*slot.donor = entry.Name
+			donorID = entry.ID
+		}
+		if entry, ok := c.Find(c.Children(ServiceCatalogueEntryKindProject, donorID), *slot.projectName); ok {
+			*slot.projectName = entry.Name
+		}
+	}
+}
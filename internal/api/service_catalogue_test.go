@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testServiceCatalogue = ServiceCatalogue{Entries: []ServiceCatalogueEntry{
+	{ID: "nfi", Kind: ServiceCatalogueEntryKindServiceType, ServiceCC: enumTypes.ServiceCCShelter, Name: "NFI"},
+	{ID: "kits", Kind: ServiceCatalogueEntryKindService, ParentID: "nfi", Name: "Kits"},
+	{ID: "hygiene", Kind: ServiceCatalogueEntryKindSubService, ParentID: "kits", Name: "Hygiene kit"},
+	{ID: "echo", Kind: ServiceCatalogueEntryKindDonor, Name: "ECHO"},
+	{ID: "p1", Kind: ServiceCatalogueEntryKindProject, ParentID: "echo", Name: "P1"},
+}}
+
+func TestServiceCatalogueEntryKind_ChildKind(t *testing.T) {
+	assert.Equal(t, ServiceCatalogueEntryKindService, ServiceCatalogueEntryKindServiceType.ChildKind())
+	assert.Equal(t, ServiceCatalogueEntryKindSubService, ServiceCatalogueEntryKindService.ChildKind())
+	assert.Equal(t, ServiceCatalogueEntryKind(""), ServiceCatalogueEntryKindSubService.ChildKind())
+	assert.Equal(t, ServiceCatalogueEntryKindProject, ServiceCatalogueEntryKindDonor.ChildKind())
+	assert.Equal(t, ServiceCatalogueEntryKind(""), ServiceCatalogueEntryKindProject.ChildKind())
+}
+
+func TestServiceCatalogue_ScanValue(t *testing.T) {
+	value, err := testServiceCatalogue.Value()
+	require.NoError(t, err)
+	var got ServiceCatalogue
+	require.NoError(t, got.Scan(value))
+	assert.Equal(t, testServiceCatalogue, got)
+
+	var empty ServiceCatalogue
+	require.NoError(t, empty.Scan("{}"))
+	assert.True(t, empty.IsEmpty())
+}
+
+func TestServiceCatalogue_Descendants(t *testing.T) {
+	assert.Equal(t, []string{"nfi", "kits", "hygiene"}, testServiceCatalogue.Descendants("nfi"))
+	assert.Equal(t, []string{"p1"}, testServiceCatalogue.Descendants("p1"))
+}
+
+func TestServiceCatalogue_Options(t *testing.T) {
+	assert.Equal(t, []ServiceCatalogueOption{
+		{ID: "nfi", Label: "Shelter & Settlements › NFI"},
+		{ID: "kits", Label: "Shelter & Settlements › NFI › Kits"},
+		{ID: "hygiene", Label: "Shelter & Settlements › NFI › Kits › Hygiene kit"},
+		{ID: "echo", Label: "ECHO"},
+		{ID: "p1", Label: "ECHO › P1"},
+	}, testServiceCatalogue.Options())
+}
+
+func TestServiceCatalogue_Filter(t *testing.T) {
+	got, ok := testServiceCatalogue.Filter("kits")
+	assert.True(t, ok)
+	assert.Equal(t, IndividualServiceFilter{CC: enumTypes.ServiceCCShelter, Type: "NFI", Service: "Kits"}, got)
+
+	got, ok = testServiceCatalogue.Filter("p1")
+	assert.True(t, ok)
+	assert.Equal(t, IndividualServiceFilter{Donor: "ECHO", ProjectName: "P1"}, got)
+
+	_, ok = testServiceCatalogue.Filter("unknown")
+	assert.False(t, ok)
+}
+
+func TestServiceCatalogue_NormalizeIndividual(t *testing.T) {
+	i := &Individual{
+		ServiceCC1:          enumTypes.ServiceCCShelter,
+		ServiceType1:        " nfi ",
+		Service1:            "KITS",
+		ServiceSubService1:  "hygiene KIT",
+		ServiceDonor1:       "echo",
+		ServiceProjectName1: "p1",
+		ServiceCC2:          enumTypes.ServiceCCShelter,
+		ServiceType2:        "unknown",
+	}
+	testServiceCatalogue.NormalizeIndividual(i)
+	assert.Equal(t, "NFI", i.ServiceType1)
+	assert.Equal(t, "Kits", i.Service1)
+	assert.Equal(t, "Hygiene kit", i.ServiceSubService1)
+	assert.Equal(t, "ECHO", i.ServiceDonor1)
+	assert.Equal(t, "P1", i.ServiceProjectName1)
+	assert.Equal(t, "unknown", i.ServiceType2)
+}
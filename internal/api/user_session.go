@@ -0,0 +1,62 @@
+package api
+
+import (
+	"time"
+
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+// UserSession is a browser session of a user. The session cookie only holds a random token,
+// and the hash of that token is stored with the session, so that sessions can be listed
+// and revoked from the server.
+type UserSession struct {
+	ID     string `db:"id"`
+	Hash   string `db:"token_hash"`
+	UserID string `db:"user_id"`
+	Email  string `db:"email"`
+	// Groups are the JWT groups of the user, as of GroupsRefreshedAt
+	Groups            containers.StringSet `db:"groups"`
+	UserAgent         string               `db:"user_agent"`
+	IPAddress         string               `db:"ip_address"`
+	CreatedAt         time.Time            `db:"created_at"`
+	LastSeenAt        time.Time            `db:"last_seen_at"`
+	GroupsRefreshedAt time.Time            `db:"groups_refreshed_at"`
+	ExpiresAt         time.Time            `db:"expires_at"`
+	RevokedAt         *time.Time           `db:"revoked_at"`
+	// RevokedBy is the id of the user who revoked the session, which is the session's user on logout
+	RevokedBy string `db:"revoked_by"`
+	// Tokens are the encrypted tokens of the built-in OIDC login. They are empty when the users
+	// are authenticated by a proxy, and cleared when the session is revoked.
+	Tokens string `db:"oidc_tokens"`
+	// ImpersonatedUserID is the user whose groups a global admin views the application with, until
+	// ImpersonationExpiresAt. The groups are copied from the latest session of that user.
+	ImpersonatedUserID     string               `db:"impersonated_user_id"`
+	ImpersonatedEmail      string               `db:"impersonated_email"`
+	ImpersonatedGroups     containers.StringSet `db:"impersonated_groups"`
+	ImpersonationExpiresAt *time.Time           `db:"impersonation_expires_at"`
+}
+
+// IsRevoked returns true if the session was revoked
+func (s UserSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// IsExpired returns true if the session is expired at the given time
+func (s UserSession) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// IsActive returns true if the session can be used at the given time
+func (s UserSession) IsActive(now time.Time) bool {
+	return !s.IsRevoked() && !s.IsExpired(now)
+}
+
+// GroupsAreStale returns true if the groups of the session must be fetched again
+func (s UserSession) GroupsAreStale(now time.Time, refreshInterval time.Duration) bool {
+	return !now.Before(s.GroupsRefreshedAt.Add(refreshInterval))
+}
+
+// IsImpersonating returns true if the user views the application as another user at the given time
+func (s UserSession) IsImpersonating(now time.Time) bool {
+	return s.ImpersonatedUserID != "" && s.ImpersonationExpiresAt != nil && now.Before(*s.ImpersonationExpiresAt)
+}
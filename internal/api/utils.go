@@ -68,16 +68,6 @@ func trimString(s string) string {
 	return strings.Trim(s, " \t\n\r")
 }
 
-func NormalizePhoneNumber(phoneNumber string) string {
-	ret := ""
-	for _, c := range phoneNumber {
-		if c >= '0' && c <= '9' {
-			ret += string(c)
-		}
-	}
-	return ret
-}
-
 func normalizeEmail(email string) string {
 	return strings.ToLower(email)
 }
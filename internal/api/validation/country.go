@@ -2,8 +2,11 @@ package validation
 
 import (
 	"regexp"
+	"sort"
 
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/containers"
 	"github.com/nrc-no/notcore/pkg/api/validation"
 )
 
@@ -24,11 +27,20 @@ func validateCountry(path *validation.Path, country *api.Country) validation.Err
 	allErrs := validation.ErrorList{}
 	allErrs = append(allErrs, validateCountryName(country.Name, path.Child("name"))...)
 	allErrs = append(allErrs, validateCountryCode(country.Code, path.Child("code"))...)
-	allErrs = append(allErrs, validateCountryGroup(country.ReadGroup, path.Child("readGroup"))...)
-	allErrs = append(allErrs, validateCountryGroup(country.WriteGroup, path.Child("writeGroup"))...)
+	allErrs = append(allErrs, validateCountryGroups(country.Groups, country.Roles, path.Child("groups"))...)
+	allErrs = append(allErrs, validateCountryValidationRules(country.ValidationRules, path.Child("validationRules"))...)
+	allErrs = append(allErrs, validateServiceCatalogue(country.ServiceCatalogue, path.Child("serviceCatalogue"))...)
+	allErrs = append(allErrs, validateCountryRoles(country.Roles, path.Child("roles"))...)
+	allErrs = append(allErrs, validateCountryOffices(country.Offices, path.Child("offices"))...)
 	return allErrs
 }
 
+// ValidateCountryValidationRules checks that the validation rules of a country only refer to known fields
+// and values, and that the identification number patterns are valid regular expressions
+func ValidateCountryValidationRules(rules api.CountryValidationRules) validation.ErrorList {
+	return validateCountryValidationRules(rules, nil)
+}
+
 var countryNameMaxLength = 255
 var countryNameMinLength = 2
 var allowedCountryNameChars = map[rune]bool{}
@@ -97,3 +109,66 @@ func validateCountryGroup(group string, path *validation.Path) validation.ErrorL
 	}
 	return allErrs
 }
+
+func validateCountryValidationRules(rules api.CountryValidationRules, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	fields := api.ValidationRuleFields()
+	fieldSet := containers.NewStringSet(fields...)
+
+	for i, field := range rules.RequiredFields {
+		if !fieldSet.Contains(field) {
+			allErrs = append(allErrs, validation.NotSupported(path.Child("requiredFields").Index(i), field, fields))
+		} else if rules.IsHidden(field) {
+			allErrs = append(allErrs, validation.Invalid(path.Child("requiredFields").Index(i), field, "field cannot be both required and hidden"))
+		}
+	}
+	for i, field := range rules.HiddenFields {
+		if !fieldSet.Contains(field) {
+			allErrs = append(allErrs, validation.NotSupported(path.Child("hiddenFields").Index(i), field, fields))
+		}
+	}
+
+	var enumNames []string
+	for _, enum := range api.RestrictableEnums() {
+		enumNames = append(enumNames, enum.Name)
+	}
+	for _, name := range sortedKeys(rules.AllowedValues) {
+		enumPath := path.Child("allowedValues").Key(name)
+		enum, ok := api.RestrictableEnumByName(name)
+		if !ok {
+			allErrs = append(allErrs, validation.NotSupported(enumPath, name, enumNames))
+			continue
+		}
+		var values []string
+		for _, v := range enum.Values {
+			values = append(values, v.Value)
+		}
+		valueSet := containers.NewStringSet(values...)
+		for i, value := range rules.AllowedValues[name] {
+			if !valueSet.Contains(value) {
+				allErrs = append(allErrs, validation.NotSupported(enumPath.Index(i), value, values))
+			}
+		}
+	}
+
+	for _, identificationType := range sortedKeys(rules.IdentificationNumberPatterns) {
+		patternPath := path.Child("identificationNumberPatterns").Key(identificationType)
+		if !enumTypes.AllIdentificationTypes().Contains(enumTypes.IdentificationType(identificationType)) {
+			allErrs = append(allErrs, validation.NotSupported(patternPath, identificationType, nil))
+			continue
+		}
+		if _, err := rules.IdentificationNumberRegexp(identificationType); err != nil {
+			allErrs = append(allErrs, validation.Invalid(patternPath, rules.IdentificationNumberPatterns[identificationType], err.Error()))
+		}
+	}
+	return allErrs
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateCountryGroups checks that the JWT groups of a country are in the format of the groups of the tokens,
+// and that they are mapped to built-in roles or to roles of the country, once each
+func ValidateCountryGroups(groups api.CountryGroups, roles api.CountryRoles) validation.ErrorList {
+	return validateCountryGroups(groups, roles, validation.NewPath("groups"))
+}
+
+func validateCountryGroups(groups api.CountryGroups, roles api.CountryRoles, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+
+	validRoles := append([]string{}, api.CountryGroupRoles...)
+	for _, role := range roles.Roles {
+		validRoles = append(validRoles, role.ID)
+	}
+	validRoleSet := containers.NewStringSet(validRoles...)
+
+	seen := containers.NewStringSet()
+	for i, group := range groups {
+		groupPath := path.Index(i)
+		allErrs = append(allErrs, validateCountryGroup(group.Group, groupPath.Child("group"))...)
+		if !validRoleSet.Contains(group.Role) {
+			allErrs = append(allErrs, validation.NotSupported(groupPath.Child("role"), group.Role, validRoles))
+			continue
+		}
+		key := group.Group + "\x00" + group.Role
+		if seen.Contains(key) {
+			allErrs = append(allErrs, validation.Duplicate(groupPath, group.Group))
+		}
+		seen.Add(key)
+	}
+	return allErrs
+}
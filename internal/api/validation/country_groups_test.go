@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCountryGroups(t *testing.T) {
+	groupsPath := validation.NewPath("groups")
+	roles := api.CountryRoles{Roles: []api.CountryRole{
+		{ID: "clerk", Name: "Clerk"},
+	}}
+	tests := []struct {
+		name     string
+		groups   api.CountryGroups
+		wantErrs validation.ErrorList
+	}{
+		{
+			name: "valid",
+			groups: api.CountryGroups{
+				{Group: "nrc-read", Role: api.CountryGroupRoleRead},
+				{Group: "nrc-clerk", Role: "clerk"},
+				{Group: "nrc-clerk", Role: api.CountryGroupRoleRead},
+				{Group: "nrc-other-clerk", Role: "clerk"},
+			},
+		}, {
+			name:   "none",
+			groups: api.CountryGroups{},
+		}, {
+			name: "invalid group",
+			groups: api.CountryGroups{
+				{Group: "", Role: api.CountryGroupRoleRead},
+				{Group: "nrc/clerk", Role: "clerk"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Required(groupsPath.Index(0).Child("group"), ""),
+				validation.Invalid(groupsPath.Index(1).Child("group"), "nrc/clerk", ""),
+			},
+		}, {
+			name: "unknown role",
+			groups: api.CountryGroups{
+				{Group: "nrc-protection", Role: "protection"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.NotSupported(groupsPath.Index(0).Child("role"), "protection", nil),
+			},
+		}, {
+			name: "duplicate",
+			groups: api.CountryGroups{
+				{Group: "nrc-clerk", Role: "clerk"},
+				{Group: "nrc-clerk", Role: "clerk"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Duplicate(groupsPath.Index(1), "nrc-clerk"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateCountryGroups(tt.groups, roles)
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateCountryOffices checks that the offices of a country have a name of their own and at least one group
+func ValidateCountryOffices(offices api.CountryOffices) validation.ErrorList {
+	return validateCountryOffices(offices, nil)
+}
+
+func validateCountryOffices(offices api.CountryOffices, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	officesPath := path.Child("offices")
+	ids := containers.NewStringSet()
+	names := containers.NewStringSet()
+	for i, office := range offices.Offices {
+		officePath := officesPath.Index(i)
+		if office.ID == "" {
+			allErrs = append(allErrs, validation.Required(officePath.Child("id"), "id is required"))
+		} else if ids.Contains(office.ID) {
+			allErrs = append(allErrs, validation.Duplicate(officePath.Child("id"), office.ID))
+		}
+		ids.Add(office.ID)
+
+		if strings.TrimSpace(office.Name) == "" {
+			allErrs = append(allErrs, validation.Required(officePath.Child("name"), "name is required"))
+		} else if len(office.Name) > countryNameMaxLength {
+			allErrs = append(allErrs, validation.TooLongMaxLength(officePath.Child("name"), office.Name, countryNameMaxLength))
+		} else if names.Contains(strings.ToLower(office.Name)) {
+			allErrs = append(allErrs, validation.Duplicate(officePath.Child("name"), office.Name))
+		}
+		names.Add(strings.ToLower(office.Name))
+
+		if office.ReadGroup == "" && office.WriteGroup == "" {
+			allErrs = append(allErrs, validation.Required(officePath.Child("readGroup"), "a read or a write group is required"))
+			continue
+		}
+		if office.ReadGroup != "" {
+			allErrs = append(allErrs, validateCountryGroup(office.ReadGroup, officePath.Child("readGroup"))...)
+		}
+		if office.WriteGroup != "" {
+			allErrs = append(allErrs, validateCountryGroup(office.WriteGroup, officePath.Child("writeGroup"))...)
+		}
+	}
+	return allErrs
+}
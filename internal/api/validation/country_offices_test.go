@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCountryOffices(t *testing.T) {
+	officesPath := validation.NewPath("offices")
+	tests := []struct {
+		name     string
+		offices  []api.CountryOffice
+		wantErrs validation.ErrorList
+	}{
+		{
+			name: "valid",
+			offices: []api.CountryOffice{
+				{ID: "north", Name: "North", ReadGroup: "nrc-north-read", WriteGroup: "nrc-north-write"},
+				{ID: "south", Name: "South", WriteGroup: "nrc-south-write"},
+			},
+		}, {
+			name: "missing id, name and groups",
+			offices: []api.CountryOffice{
+				{Name: " "},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Required(officesPath.Index(0).Child("id"), ""),
+				validation.Required(officesPath.Index(0).Child("name"), ""),
+				validation.Required(officesPath.Index(0).Child("readGroup"), ""),
+			},
+		}, {
+			name: "duplicates",
+			offices: []api.CountryOffice{
+				{ID: "a", Name: "North", ReadGroup: "nrc-north-read"},
+				{ID: "a", Name: "north", ReadGroup: "nrc-north-read"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Duplicate(officesPath.Index(1).Child("id"), "a"),
+				validation.Duplicate(officesPath.Index(1).Child("name"), "north"),
+			},
+		}, {
+			name: "invalid groups",
+			offices: []api.CountryOffice{
+				{ID: "a", Name: "North", ReadGroup: "nrc/north", WriteGroup: "nrc/north"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Invalid(officesPath.Index(0).Child("readGroup"), "nrc/north", ""),
+				validation.Invalid(officesPath.Index(0).Child("writeGroup"), "nrc/north", ""),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateCountryOffices(api.CountryOffices{Offices: tt.offices})
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
+
+func TestValidateIndividualOffice(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	assert.Empty(t, ValidateIndividualOffice(&api.Individual{CollectionOffice: "North"}, []string{"North", "South"}))
+
+	errs := ValidateIndividualOffice(&api.Individual{CollectionOffice: "East"}, []string{"North", "South"})
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, validation.ErrorTypeForbidden, errs[0].Type)
+		assert.Equal(t, "collection_office", errs[0].Field)
+	}
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateCountryRoles checks that the roles of a country have a name of their own,
+// and that they only grant known permissions on known field groups. A role cannot edit or export
+// the fields it cannot view.
+func ValidateCountryRoles(roles api.CountryRoles) validation.ErrorList {
+	return validateCountryRoles(roles, nil)
+}
+
+func validateCountryRoles(roles api.CountryRoles, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	rolesPath := path.Child("roles")
+	ids := containers.NewStringSet()
+	names := containers.NewStringSet()
+	for i, role := range roles.Roles {
+		rolePath := rolesPath.Index(i)
+		if role.ID == "" {
+			allErrs = append(allErrs, validation.Required(rolePath.Child("id"), "id is required"))
+		} else if ids.Contains(role.ID) {
+			allErrs = append(allErrs, validation.Duplicate(rolePath.Child("id"), role.ID))
+		} else if containers.NewStringSet(api.CountryGroupRoles...).Contains(role.ID) {
+			allErrs = append(allErrs, validation.Forbidden(rolePath.Child("id"), "id is reserved for a built-in role"))
+		}
+		ids.Add(role.ID)
+
+		name := strings.TrimSpace(role.Name)
+		if name == "" {
+			allErrs = append(allErrs, validation.Required(rolePath.Child("name"), "name is required"))
+		} else if len(role.Name) > countryNameMaxLength {
+			allErrs = append(allErrs, validation.TooLongMaxLength(rolePath.Child("name"), role.Name, countryNameMaxLength))
+		} else if names.Contains(strings.ToLower(name)) {
+			allErrs = append(allErrs, validation.Duplicate(rolePath.Child("name"), role.Name))
+		}
+		names.Add(strings.ToLower(name))
+
+		allErrs = append(allErrs, validateCountryRolePermissions(role, rolePath.Child("permissions"))...)
+	}
+	return allErrs
+}
+
+func validateCountryRolePermissions(role api.CountryRole, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+
+	validGroups := make([]string, 0, len(auth.FieldGroups))
+	for _, group := range auth.FieldGroups {
+		validGroups = append(validGroups, string(group))
+	}
+	validPerms := make([]string, 0, len(auth.FieldPermissions))
+	for _, perm := range auth.FieldPermissions {
+		validPerms = append(validPerms, string(perm))
+	}
+
+	groupNames := make(map[string]auth.FieldGroup, len(role.Permissions))
+	for group := range role.Permissions {
+		groupNames[string(group)] = group
+	}
+	for _, name := range sortedKeys(groupNames) {
+		group := groupNames[name]
+		groupPath := path.Key(name)
+		if !group.IsValid() {
+			allErrs = append(allErrs, validation.NotSupported(groupPath, name, validGroups))
+			continue
+		}
+		for i, perm := range role.Permissions[group] {
+			if !perm.IsValid() {
+				allErrs = append(allErrs, validation.NotSupported(groupPath.Index(i), perm, validPerms))
+			} else if perm != auth.FieldPermissionView && !role.Has(group, auth.FieldPermissionView) {
+				allErrs = append(allErrs, validation.Forbidden(groupPath.Index(i), "cannot "+string(perm)+" the fields that cannot be viewed"))
+			}
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCountryRoles(t *testing.T) {
+	rolesPath := validation.NewPath("roles")
+	tests := []struct {
+		name     string
+		roles    []api.CountryRole
+		wantErrs validation.ErrorList
+	}{
+		{
+			name: "valid",
+			roles: []api.CountryRole{
+				{ID: "clerk", Name: "Clerk", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+					auth.FieldGroupGeneral: {auth.FieldPermissionView, auth.FieldPermissionEdit},
+				}},
+				{ID: "protection", Name: "Protection officer", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+					auth.FieldGroupGeneral:    {auth.FieldPermissionView},
+					auth.FieldGroupProtection: {auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport},
+				}},
+			},
+		}, {
+			name: "missing id and name",
+			roles: []api.CountryRole{
+				{Name: " "},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Required(rolesPath.Index(0).Child("id"), ""),
+				validation.Required(rolesPath.Index(0).Child("name"), ""),
+			},
+		}, {
+			name: "duplicates",
+			roles: []api.CountryRole{
+				{ID: "a", Name: "Clerk"},
+				{ID: "a", Name: "clerk"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Duplicate(rolesPath.Index(1).Child("id"), "a"),
+				validation.Duplicate(rolesPath.Index(1).Child("name"), "clerk"),
+			},
+		}, {
+			name: "reserved id",
+			roles: []api.CountryRole{
+				{ID: api.CountryGroupRoleWrite, Name: "Clerk"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Forbidden(rolesPath.Index(0).Child("id"), ""),
+			},
+		}, {
+			name: "permissions",
+			roles: []api.CountryRole{
+				{ID: "a", Name: "Clerk", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+					auth.FieldGroupContact: {auth.FieldPermissionExport},
+					auth.FieldGroupHealth:  {auth.FieldPermissionView, "delete"},
+					"finance":              {auth.FieldPermissionView},
+				}},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Forbidden(rolesPath.Index(0).Child("permissions").Key("contact").Index(0), ""),
+				validation.NotSupported(rolesPath.Index(0).Child("permissions").Key("finance"), "finance", nil),
+				validation.NotSupported(rolesPath.Index(0).Child("permissions").Key("health").Index(1), "delete", nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateCountryRoles(api.CountryRoles{Roles: tt.roles})
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
@@ -20,8 +20,10 @@ func ValidCountry() *CountryBuilder {
 			ID:               "id",
 			Code:             "code",
 			Name:             "name",
-			ReadGroup: 			  "read_group",
-			WriteGroup: 		  "write_group",
+			Groups: api.CountryGroups{
+				{Group: "read_group", Role: api.CountryGroupRoleRead},
+				{Group: "write_group", Role: api.CountryGroupRoleWrite},
+			},
 		},
 	}
 }
@@ -41,12 +43,17 @@ func (b *CountryBuilder) WithCode(code string) *CountryBuilder {
 }
 
 func (b *CountryBuilder) WithReadGroup(readGroup string) *CountryBuilder {
-	b.country.ReadGroup = readGroup
+	b.country.Groups[0].Group = readGroup
 	return b
 }
 
 func (b *CountryBuilder) WithWriteGroup(writeGroup string) *CountryBuilder {
-	b.country.WriteGroup = writeGroup
+	b.country.Groups[1].Group = writeGroup
+	return b
+}
+
+func (b *CountryBuilder) WithGroup(group string, role string) *CountryBuilder {
+	b.country.Groups = append(b.country.Groups, api.CountryGroup{Group: group, Role: role})
 	return b
 }
 
@@ -58,8 +65,9 @@ func (b *CountryBuilder) WithID(id string) *CountryBuilder {
 func TestValidateCountry(t *testing.T) {
 	namePath := validation.NewPath("name")
 	codePath := validation.NewPath("code")
-	readGroupPath := validation.NewPath("readGroup")
-	writeGroupPath := validation.NewPath("writeGroup")
+	readGroupPath := validation.NewPath("groups").Index(0).Child("group")
+	writeGroupPath := validation.NewPath("groups").Index(1).Child("group")
+	groupsPath := validation.NewPath("groups")
 	weirdString := string([]byte{0x7f, 0x7f})
 	tests := []struct {
 		name    string
@@ -138,6 +146,30 @@ func TestValidateCountry(t *testing.T) {
 				WithWriteGroup(bigstr(256)).
 				Build(),
 			want: validation.ErrorList{validation.TooLongMaxLength(writeGroupPath, bigstr(256), 255)},
+		}, {
+			name: "several groups with the same role",
+			country: ValidCountry().
+				WithGroup("other_read_group", api.CountryGroupRoleRead).
+				Build(),
+			want: validation.ErrorList{},
+		}, {
+			name: "group with several roles",
+			country: ValidCountry().
+				WithGroup("read_group", api.CountryGroupRoleWrite).
+				Build(),
+			want: validation.ErrorList{},
+		}, {
+			name: "duplicate group",
+			country: ValidCountry().
+				WithGroup("read_group", api.CountryGroupRoleRead).
+				Build(),
+			want: validation.ErrorList{validation.Duplicate(groupsPath.Index(2), "read_group")},
+		}, {
+			name: "unknown role",
+			country: ValidCountry().
+				WithGroup("other_group", "admin").
+				Build(),
+			want: validation.ErrorList{validation.NotSupported(groupsPath.Index(2).Child("role"), "admin", []string{api.CountryGroupRoleRead, api.CountryGroupRoleWrite})},
 		},
 	}
 	for _, tt := range tests {
@@ -189,3 +221,66 @@ func TestValidateCountryList(t *testing.T) {
 func bigstr(n int) string {
 	return strings.Repeat("a", n)
 }
+
+func TestValidateCountryValidationRules(t *testing.T) {
+	rulesPath := validation.NewPath("validationRules")
+	tests := []struct {
+		name   string
+		rules  api.CountryValidationRules
+		expect validation.ErrorList
+	}{
+		{
+			name:   "empty",
+			rules:  api.CountryValidationRules{},
+			expect: validation.ErrorList{},
+		}, {
+			name: "valid",
+			rules: api.CountryValidationRules{
+				RequiredFields:               []string{"last_name", "has_consented_to_rgpd"},
+				HiddenFields:                 []string{"birth_date"},
+				AllowedValues:                map[string][]string{"sex": {"female", "male"}},
+				IdentificationNumberPatterns: map[string]string{"national_id": "[0-9]{9}"},
+			},
+			expect: validation.ErrorList{},
+		}, {
+			name: "unknown field",
+			rules: api.CountryValidationRules{
+				HiddenFields: []string{"id"},
+			},
+			expect: validation.ErrorList{
+				validation.NotSupported(rulesPath.Child("hiddenFields").Index(0), "id", api.ValidationRuleFields()),
+			},
+		}, {
+			name: "required and hidden",
+			rules: api.CountryValidationRules{
+				RequiredFields: []string{"last_name"},
+				HiddenFields:   []string{"last_name"},
+			},
+			expect: validation.ErrorList{
+				validation.Invalid(rulesPath.Child("requiredFields").Index(0), "last_name", "field cannot be both required and hidden"),
+			},
+		}, {
+			name: "unknown value",
+			rules: api.CountryValidationRules{
+				AllowedValues: map[string][]string{"sex": {"unknown"}},
+			},
+			expect: validation.ErrorList{
+				validation.NotSupported(rulesPath.Child("allowedValues").Key("sex").Index(0), "unknown", []string{"female", "male", "other", "prefers_not_to_say"}),
+			},
+		}, {
+			name: "invalid pattern",
+			rules: api.CountryValidationRules{
+				IdentificationNumberPatterns: map[string]string{"passport": "[0-9"},
+			},
+			expect: validation.ErrorList{
+				validation.Invalid(rulesPath.Child("identificationNumberPatterns").Key("passport"), "[0-9", "error parsing regexp: missing closing ]: `[0-9)$`"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, validateCountryValidationRules(tt.rules, rulesPath))
+		})
+	}
+}
+
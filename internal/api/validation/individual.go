@@ -16,6 +16,27 @@ func ValidateIndividual(i *api.Individual) validation.ErrorList {
 	return validateIndividual(i, nil)
 }
 
+// ValidateIndividualPhoneNumbers returns the phone numbers of the individual that cannot be dialled, reading
+// national numbers with the calling code of region. These are warnings: the individual can still be saved.
+func ValidateIndividualPhoneNumbers(i *api.Individual, region string) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	region = i.PhoneNumberRegion(region)
+	fields := []string{
+		constants.DBColumnIndividualPhoneNumber1,
+		constants.DBColumnIndividualPhoneNumber2,
+		constants.DBColumnIndividualPhoneNumber3,
+	}
+	for idx, phoneNumber := range []string{i.PhoneNumber1, i.PhoneNumber2, i.PhoneNumber3} {
+		if phoneNumber == "" {
+			continue
+		}
+		if _, err := api.ParsePhoneNumber(phoneNumber, region); err != nil {
+			allErrs = append(allErrs, validation.Invalid(validation.NewPath(fields[idx]), phoneNumber, err.Error()))
+		}
+	}
+	return allErrs
+}
+
 func ValidateIndividualList(i *api.IndividualList) validation.ErrorList {
 	allErrs := validation.ErrorList{}
 	itemsPath := validation.NewPath("items")
@@ -159,6 +180,7 @@ func validateIndividual(i *api.Individual, p *validation.Path) validation.ErrorL
 	allErrs = append(allErrs, validateIndividualServiceTextField(i.ServiceDonor7, p.Child(constants.DBColumnIndividualServiceDonor7))...)
 	allErrs = append(allErrs, validateIndividualServiceTextField(i.ServiceProjectName7, p.Child(constants.DBColumnIndividualServiceProjectName7))...)
 	allErrs = append(allErrs, validateIndividualServiceTextField(i.ServiceAgentName7, p.Child(constants.DBColumnIndividualServiceAgentName7))...)
+	allErrs = append(allErrs, validateIndividualConsistency(i, p)...)
 	return allErrs
 }
 
@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ageTolerance is the difference in years allowed between the age and the age computed from the birth date,
+// as the age may have been rounded or recorded a while before the collection time
+const ageTolerance = 1
+
+// ValidateIndividualConsistency checks that the fields of the individual agree with each other,
+// e.g. that a male individual is not pregnant. The error details are localized.
+func ValidateIndividualConsistency(i *api.Individual) validation.ErrorList {
+	return validateIndividualConsistency(i, nil)
+}
+
+func validateIndividualConsistency(i *api.Individual, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	allErrs = append(allErrs, validateIndividualPregnancy(i, p)...)
+	allErrs = append(allErrs, validateIndividualAgeAndBirthDate(i, p)...)
+	allErrs = append(allErrs, validateIndividualMinor(i, p)...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasCognitiveDisability, i.CognitiveDisabilityLevel, p.Child(constants.DBColumnIndividualCognitiveDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasCommunicationDisability, i.CommunicationDisabilityLevel, p.Child(constants.DBColumnIndividualCommunicationDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasHearingDisability, i.HearingDisabilityLevel, p.Child(constants.DBColumnIndividualHearingDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasMobilityDisability, i.MobilityDisabilityLevel, p.Child(constants.DBColumnIndividualMobilityDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasSelfCareDisability, i.SelfCareDisabilityLevel, p.Child(constants.DBColumnIndividualSelfCareDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualDisabilityConsistency(i.HasVisionDisability, i.VisionDisabilityLevel, p.Child(constants.DBColumnIndividualVisionDisabilityLevel))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate1, i.ServiceDeliveredDate1, p.Child(constants.DBColumnIndividualServiceDeliveredDate1))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate2, i.ServiceDeliveredDate2, p.Child(constants.DBColumnIndividualServiceDeliveredDate2))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate3, i.ServiceDeliveredDate3, p.Child(constants.DBColumnIndividualServiceDeliveredDate3))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate4, i.ServiceDeliveredDate4, p.Child(constants.DBColumnIndividualServiceDeliveredDate4))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate5, i.ServiceDeliveredDate5, p.Child(constants.DBColumnIndividualServiceDeliveredDate5))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate6, i.ServiceDeliveredDate6, p.Child(constants.DBColumnIndividualServiceDeliveredDate6))...)
+	allErrs = append(allErrs, validateIndividualServiceDates(i.ServiceRequestedDate7, i.ServiceDeliveredDate7, p.Child(constants.DBColumnIndividualServiceDeliveredDate7))...)
+	return allErrs
+}
+
+func validateIndividualPregnancy(i *api.Individual, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if i.IsPregnant != nil && *i.IsPregnant && i.Sex == enumTypes.SexMale {
+		t := locales.GetTranslator()
+		allErrs = append(allErrs, validation.Invalid(p.Child(constants.DBColumnIndividualIsPregnant), *i.IsPregnant, t("error_inconsistent_pregnant_male")))
+	}
+	return allErrs
+}
+
+func validateIndividualAgeAndBirthDate(i *api.Individual, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if i.Age == nil || i.BirthDate == nil {
+		return allErrs
+	}
+	birthDateAge := api.AgeAt(*i.BirthDate, referenceDate(i))
+	if diff := *i.Age - birthDateAge; diff > ageTolerance || diff < -ageTolerance {
+		t := locales.GetTranslator()
+		allErrs = append(allErrs, validation.Invalid(p.Child(constants.DBColumnIndividualAge), *i.Age, t("error_inconsistent_age_birth_date", i.BirthDate.Format("2006-01-02"), birthDateAge)))
+	}
+	return allErrs
+}
+
+func validateIndividualMinor(i *api.Individual, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if i.IsMinor == nil {
+		return allErrs
+	}
+	var age int
+	switch {
+	case i.BirthDate != nil:
+		age = api.AgeAt(*i.BirthDate, referenceDate(i))
+	case i.Age != nil:
+		age = *i.Age
+	default:
+		return allErrs
+	}
+	t := locales.GetTranslator()
+	if *i.IsMinor && age >= api.AdultAge {
+		allErrs = append(allErrs, validation.Invalid(p.Child(constants.DBColumnIndividualIsMinor), *i.IsMinor, t("error_inconsistent_minor_adult", age)))
+	} else if !*i.IsMinor && age < api.AdultAge {
+		allErrs = append(allErrs, validation.Invalid(p.Child(constants.DBColumnIndividualIsMinor), *i.IsMinor, t("error_inconsistent_minor_child", age)))
+	}
+	return allErrs
+}
+
+func validateIndividualDisabilityConsistency(hasDisability *bool, level enumTypes.DisabilityLevel, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if hasDisability == nil || *hasDisability {
+		return allErrs
+	}
+	if level != enumTypes.DisabilityLevelUnspecified && level != enumTypes.DisabilityLevelNone {
+		t := locales.GetTranslator()
+		allErrs = append(allErrs, validation.Invalid(path, level, t("error_inconsistent_disability_level")))
+	}
+	return allErrs
+}
+
+func validateIndividualServiceDates(requested *time.Time, delivered *time.Time, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if requested == nil || delivered == nil {
+		return allErrs
+	}
+	if delivered.Before(*requested) {
+		t := locales.GetTranslator()
+		allErrs = append(allErrs, validation.Invalid(path, delivered.Format("2006-01-02"), t("error_inconsistent_service_dates", requested.Format("2006-01-02"))))
+	}
+	return allErrs
+}
+
+// referenceDate is the date at which the age and the minor flag of the individual were recorded
+func referenceDate(i *api.Individual) time.Time {
+	if i.CollectionTime.IsZero() {
+		return time.Now()
+	}
+	return i.CollectionTime
+}
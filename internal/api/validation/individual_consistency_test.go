@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIndividualConsistency(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	collectionTime := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	birthDate := time.Date(2014, 7, 1, 0, 0, 0, 0, time.UTC)
+	requested := time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC)
+	delivered := time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		individual *api.Individual
+		wantFields []string
+	}{
+		{
+			name: "consistent",
+			individual: &api.Individual{
+				Sex:                   enumTypes.SexFemale,
+				IsPregnant:            pointers.Bool(true),
+				CollectionTime:        collectionTime,
+				BirthDate:             &birthDate,
+				Age:                   pointers.Int(8),
+				IsMinor:               pointers.Bool(true),
+				HasVisionDisability:   pointers.Bool(false),
+				VisionDisabilityLevel: enumTypes.DisabilityLevelNone,
+				ServiceRequestedDate1: &delivered,
+				ServiceDeliveredDate1: &requested,
+			},
+		}, {
+			name:       "empty",
+			individual: &api.Individual{},
+		}, {
+			name:       "pregnant male",
+			individual: &api.Individual{Sex: enumTypes.SexMale, IsPregnant: pointers.Bool(true)},
+			wantFields: []string{constants.DBColumnIndividualIsPregnant},
+		}, {
+			name:       "age contradicts birth date",
+			individual: &api.Individual{CollectionTime: collectionTime, BirthDate: &birthDate, Age: pointers.Int(30)},
+			wantFields: []string{constants.DBColumnIndividualAge},
+		}, {
+			name:       "child not minor",
+			individual: &api.Individual{CollectionTime: collectionTime, Age: pointers.Int(9), IsMinor: pointers.Bool(false)},
+			wantFields: []string{constants.DBColumnIndividualIsMinor},
+		}, {
+			name:       "adult minor from birth date",
+			individual: &api.Individual{CollectionTime: collectionTime, BirthDate: pointers.Time(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)), IsMinor: pointers.Bool(true)},
+			wantFields: []string{constants.DBColumnIndividualIsMinor},
+		}, {
+			name:       "disability level without disability",
+			individual: &api.Individual{HasHearingDisability: pointers.Bool(false), HearingDisabilityLevel: enumTypes.DisabilityLevelSevere},
+			wantFields: []string{constants.DBColumnIndividualHearingDisabilityLevel},
+		}, {
+			name:       "delivered before requested",
+			individual: &api.Individual{ServiceRequestedDate3: &requested, ServiceDeliveredDate3: &delivered},
+			wantFields: []string{constants.DBColumnIndividualServiceDeliveredDate3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateIndividualConsistency(tt.individual)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+				assert.NotEmpty(t, err.Detail)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateIndividualCountryRules checks the individual against the validation rules of its country:
+// required and hidden fields, allowed enum values and identification number patterns. The error details are localized.
+func ValidateIndividualCountryRules(i *api.Individual, rules api.CountryValidationRules) validation.ErrorList {
+	return validateIndividualCountryRules(i, rules, nil)
+}
+
+func validateIndividualCountryRules(i *api.Individual, rules api.CountryValidationRules, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	t := locales.GetTranslator()
+
+	for _, field := range rules.RequiredFields {
+		value, err := i.GetFieldValue(field)
+		if err != nil {
+			continue
+		}
+		if isEmptyFieldValue(value) {
+			allErrs = append(allErrs, validation.Required(p.Child(field), t("error_country_rule_required")))
+		}
+	}
+
+	for _, field := range rules.HiddenFields {
+		value, err := i.GetFieldValue(field)
+		if err != nil {
+			continue
+		}
+		if !isEmptyFieldValue(value) {
+			allErrs = append(allErrs, validation.Forbidden(p.Child(field), t("error_country_rule_hidden")))
+		}
+	}
+
+	for _, enum := range api.RestrictableEnums() {
+		if !rules.IsRestricted(enum.Name) {
+			continue
+		}
+		var allowedLabels []string
+		for _, v := range enum.Values {
+			if rules.IsAllowed(enum.Name, v.Value) {
+				allowedLabels = append(allowedLabels, v.Label())
+			}
+		}
+		for _, field := range enum.Columns {
+			value, err := i.GetFieldValue(field)
+			if err != nil {
+				continue
+			}
+			str := reflect.ValueOf(value).String()
+			if !rules.IsAllowed(enum.Name, str) {
+				allErrs = append(allErrs, validation.Invalid(p.Child(field), str, t("error_country_rule_not_allowed", strings.Join(allowedLabels, ", "))))
+			}
+		}
+	}
+
+	allErrs = append(allErrs, validateIndividualIdentificationNumberPattern(rules, i.IdentificationType1, i.IdentificationNumber1, p.Child(constants.DBColumnIndividualIdentificationNumber1))...)
+	allErrs = append(allErrs, validateIndividualIdentificationNumberPattern(rules, i.IdentificationType2, i.IdentificationNumber2, p.Child(constants.DBColumnIndividualIdentificationNumber2))...)
+	allErrs = append(allErrs, validateIndividualIdentificationNumberPattern(rules, i.IdentificationType3, i.IdentificationNumber3, p.Child(constants.DBColumnIndividualIdentificationNumber3))...)
+	return allErrs
+}
+
+func validateIndividualIdentificationNumberPattern(rules api.CountryValidationRules, identificationType enumTypes.IdentificationType, identificationNumber string, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if identificationNumber == "" {
+		return allErrs
+	}
+	// the patterns are validated when the rules are saved
+	pattern, err := rules.IdentificationNumberRegexp(string(identificationType))
+	if err != nil || pattern == nil {
+		return allErrs
+	}
+	if !pattern.MatchString(identificationNumber) {
+		t := locales.GetTranslator()
+		allErrs = append(allErrs, validation.Invalid(path, identificationNumber, t("error_country_rule_identification_number", identificationType.String())))
+	}
+	return allErrs
+}
+
+// isEmptyFieldValue returns true if a value returned by api.Individual.GetFieldValue is not set.
+// Booleans that cannot be null always have a value.
+func isEmptyFieldValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.IsZero()
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIndividualCountryRules(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	rules := api.CountryValidationRules{
+		RequiredFields: []string{constants.DBColumnIndividualHasConsentedToRGPD, constants.DBColumnIndividualIdentificationNumber1},
+		HiddenFields:   []string{constants.DBColumnIndividualBirthDate},
+		AllowedValues: map[string][]string{
+			"identification_type": {string(enumTypes.IdentificationTypeNational), string(enumTypes.IdentificationTypeUNHCR)},
+		},
+		IdentificationNumberPatterns: map[string]string{
+			string(enumTypes.IdentificationTypeNational): "[0-9]{9}",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		individual *api.Individual
+		wantErrs   validation.ErrorList
+	}{
+		{
+			name: "valid",
+			individual: &api.Individual{
+				HasConsentedToRGPD:    pointers.Bool(false),
+				IdentificationType1:   enumTypes.IdentificationTypeNational,
+				IdentificationNumber1: "123456789",
+				IdentificationType2:   enumTypes.IdentificationTypeUNHCR,
+				IdentificationNumber2: "any format",
+			},
+		}, {
+			name:       "missing required fields",
+			individual: &api.Individual{},
+			wantErrs: validation.ErrorList{
+				validation.Required(validation.NewPath(constants.DBColumnIndividualHasConsentedToRGPD), ""),
+				validation.Required(validation.NewPath(constants.DBColumnIndividualIdentificationNumber1), ""),
+			},
+		}, {
+			name: "hidden field with a value",
+			individual: &api.Individual{
+				HasConsentedToRGPD:    pointers.Bool(true),
+				IdentificationNumber1: "x",
+				BirthDate:             pointers.Time(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			wantErrs: validation.ErrorList{
+				validation.Forbidden(validation.NewPath(constants.DBColumnIndividualBirthDate), ""),
+			},
+		}, {
+			name: "value not allowed",
+			individual: &api.Individual{
+				HasConsentedToRGPD:    pointers.Bool(true),
+				IdentificationType1:   enumTypes.IdentificationTypeUNHCR,
+				IdentificationNumber1: "x",
+				IdentificationType3:   enumTypes.IdentificationTypePassport,
+			},
+			wantErrs: validation.ErrorList{
+				validation.Invalid(validation.NewPath(constants.DBColumnIndividualIdentificationType3), string(enumTypes.IdentificationTypePassport), ""),
+			},
+		}, {
+			name: "identification number does not match the pattern",
+			individual: &api.Individual{
+				HasConsentedToRGPD:    pointers.Bool(true),
+				IdentificationType1:   enumTypes.IdentificationTypeNational,
+				IdentificationNumber1: "123456789-0",
+			},
+			wantErrs: validation.ErrorList{
+				validation.Invalid(validation.NewPath(constants.DBColumnIndividualIdentificationNumber1), "123456789-0", ""),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateIndividualCountryRules(tt.individual, rules)
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+				assert.Equal(t, tt.wantErrs[i].BadValue, err.BadValue)
+				assert.NotEmpty(t, err.Detail)
+			}
+		})
+	}
+}
+
+func TestValidateIndividualCountryRulesWithoutRules(t *testing.T) {
+	assert.Empty(t, ValidateIndividualCountryRules(&api.Individual{}, api.CountryValidationRules{}))
+}
@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateIndividualOffice checks that the individual was collected by one of the given offices,
+// which are the offices a user is restricted to. The error details are localized.
+func ValidateIndividualOffice(i *api.Individual, offices []string) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if containers.NewStringSet(offices...).Contains(i.CollectionOffice) {
+		return allErrs
+	}
+	t := locales.GetTranslator()
+	allErrs = append(allErrs, validation.Forbidden(
+		validation.NewPath(constants.DBColumnIndividualCollectionOffice),
+		t("error_office_not_allowed", strings.Join(offices, ", ")),
+	))
+	return allErrs
+}
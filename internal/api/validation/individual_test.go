@@ -770,3 +770,43 @@ func TestValidateIndividualList(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateIndividualPhoneNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		i      *api.Individual
+		region string
+		want   validation.ErrorList
+	}{
+		{
+			name:   "valid",
+			i:      &api.Individual{PhoneNumber1: "0812345678", PhoneNumber2: "+256712345678"},
+			region: "CD",
+			want:   validation.ErrorList{},
+		}, {
+			name: "nationality as region",
+			i:    &api.Individual{Nationality1: "COD", PhoneNumber1: "0812345678"},
+			want: validation.ErrorList{},
+		}, {
+			name:   "impossible numbers",
+			i:      &api.Individual{PhoneNumber1: "0812", PhoneNumber3: "+999812345678"},
+			region: "CD",
+			want: validation.ErrorList{
+				validation.Invalid(validation.NewPath(constants.DBColumnIndividualPhoneNumber1), "0812", api.ErrPhoneNumberTooShort.Error()),
+				validation.Invalid(validation.NewPath(constants.DBColumnIndividualPhoneNumber3), "+999812345678", api.ErrPhoneNumberUnknownCallingCode.Error()),
+			},
+		}, {
+			name: "unknown region",
+			i:    &api.Individual{PhoneNumber1: "0812345678"},
+			want: validation.ErrorList{
+				validation.Invalid(validation.NewPath(constants.DBColumnIndividualPhoneNumber1), "0812345678", api.ErrPhoneNumberUnknownRegion.Error()),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateIndividualPhoneNumbers(tt.i, tt.region)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
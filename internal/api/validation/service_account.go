@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+const (
+	serviceAccountNameMaxLength        = 255
+	serviceAccountDescriptionMaxLength = 1024
+	// APITokenMaxLifetime is how long an API token can be valid, so that forgotten tokens stop working
+	APITokenMaxLifetime = 365 * 24 * time.Hour
+)
+
+// ValidateServiceAccount checks that the service account has a name that no other service account has
+func ValidateServiceAccount(account *api.ServiceAccount, others []*api.ServiceAccount) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+
+	namePath := validation.NewPath("name")
+	name := strings.TrimSpace(account.Name)
+	if name == "" {
+		allErrs = append(allErrs, validation.Required(namePath, "name is required"))
+	} else if len(account.Name) > serviceAccountNameMaxLength {
+		allErrs = append(allErrs, validation.TooLongMaxLength(namePath, account.Name, serviceAccountNameMaxLength))
+	} else {
+		for _, other := range others {
+			if other.ID != account.ID && strings.EqualFold(strings.TrimSpace(other.Name), name) {
+				allErrs = append(allErrs, validation.Duplicate(namePath, account.Name))
+				break
+			}
+		}
+	}
+
+	if len(account.Description) > serviceAccountDescriptionMaxLength {
+		allErrs = append(allErrs, validation.TooLongMaxLength(validation.NewPath("description"), account.Description, serviceAccountDescriptionMaxLength))
+	}
+	return allErrs
+}
+
+// ValidateAPIToken checks that a new API token has a name, that it is bound to existing countries
+// with a read or write permission, and that it expires within APITokenMaxLifetime
+func ValidateAPIToken(token *api.APIToken, countryIDs containers.StringSet, now time.Time) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+
+	namePath := validation.NewPath("name")
+	if strings.TrimSpace(token.Name) == "" {
+		allErrs = append(allErrs, validation.Required(namePath, "name is required"))
+	} else if len(token.Name) > serviceAccountNameMaxLength {
+		allErrs = append(allErrs, validation.TooLongMaxLength(namePath, token.Name, serviceAccountNameMaxLength))
+	}
+
+	scopesPath := validation.NewPath("scopes")
+	countriesPath := scopesPath.Child("countryIds")
+	if len(token.Scopes.CountryIDs) == 0 {
+		allErrs = append(allErrs, validation.Required(countriesPath, "at least one country is required"))
+	}
+	seen := containers.NewStringSet()
+	for i, countryID := range token.Scopes.CountryIDs {
+		if !countryIDs.Contains(countryID) {
+			allErrs = append(allErrs, validation.NotFound(countriesPath.Index(i), countryID))
+		} else if seen.Contains(countryID) {
+			allErrs = append(allErrs, validation.Duplicate(countriesPath.Index(i), countryID))
+		}
+		seen.Add(countryID)
+	}
+
+	if !containers.NewStringSet(api.CountryGroupRoles...).Contains(token.Scopes.Permission) {
+		allErrs = append(allErrs, validation.NotSupported(scopesPath.Child("permission"), token.Scopes.Permission, api.CountryGroupRoles))
+	}
+
+	expiresAtPath := validation.NewPath("expiresAt")
+	if !token.ExpiresAt.After(now) {
+		allErrs = append(allErrs, validation.Invalid(expiresAtPath, token.ExpiresAt, "must be in the future"))
+	} else if token.ExpiresAt.Sub(now) > APITokenMaxLifetime {
+		allErrs = append(allErrs, validation.Invalid(expiresAtPath, token.ExpiresAt, "must be within a year"))
+	}
+	return allErrs
+}
@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateServiceAccount(t *testing.T) {
+	others := []*api.ServiceAccount{{ID: "1", Name: "Nightly ETL"}}
+	tests := []struct {
+		name     string
+		account  *api.ServiceAccount
+		wantErrs validation.ErrorList
+	}{
+		{
+			name:    "valid",
+			account: &api.ServiceAccount{Name: "Partner integration"},
+		}, {
+			name:     "missing name",
+			account:  &api.ServiceAccount{Name: " "},
+			wantErrs: validation.ErrorList{validation.Required(validation.NewPath("name"), "")},
+		}, {
+			name:     "duplicate name",
+			account:  &api.ServiceAccount{Name: "nightly etl"},
+			wantErrs: validation.ErrorList{validation.Duplicate(validation.NewPath("name"), "")},
+		}, {
+			name:    "same account",
+			account: &api.ServiceAccount{ID: "1", Name: "Nightly ETL"},
+		}, {
+			name:     "description too long",
+			account:  &api.ServiceAccount{Name: "Partner integration", Description: bigstr(1025)},
+			wantErrs: validation.ErrorList{validation.TooLongMaxLength(validation.NewPath("description"), "", 1024)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateServiceAccount(tt.account, others)
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
+
+func TestValidateAPIToken(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	countryIDs := containers.NewStringSet("no", "ke")
+	countriesPath := validation.NewPath("scopes").Child("countryIds")
+	valid := func() *api.APIToken {
+		return &api.APIToken{
+			Name:      "ETL",
+			Scopes:    api.APITokenScopes{CountryIDs: []string{"no"}, Permission: api.CountryGroupRoleRead},
+			ExpiresAt: now.Add(30 * 24 * time.Hour),
+		}
+	}
+	tests := []struct {
+		name     string
+		token    func() *api.APIToken
+		wantErrs validation.ErrorList
+	}{
+		{
+			name:  "valid",
+			token: valid,
+		}, {
+			name: "missing name",
+			token: func() *api.APIToken {
+				token := valid()
+				token.Name = ""
+				return token
+			},
+			wantErrs: validation.ErrorList{validation.Required(validation.NewPath("name"), "")},
+		}, {
+			name: "no countries",
+			token: func() *api.APIToken {
+				token := valid()
+				token.Scopes.CountryIDs = nil
+				return token
+			},
+			wantErrs: validation.ErrorList{validation.Required(countriesPath, "")},
+		}, {
+			name: "unknown and duplicate countries",
+			token: func() *api.APIToken {
+				token := valid()
+				token.Scopes.CountryIDs = []string{"no", "us", "no"}
+				return token
+			},
+			wantErrs: validation.ErrorList{
+				validation.NotFound(countriesPath.Index(1), "us"),
+				validation.Duplicate(countriesPath.Index(2), "no"),
+			},
+		}, {
+			name: "unknown permission",
+			token: func() *api.APIToken {
+				token := valid()
+				token.Scopes.Permission = "admin"
+				return token
+			},
+			wantErrs: validation.ErrorList{validation.NotSupported(validation.NewPath("scopes").Child("permission"), "admin", nil)},
+		}, {
+			name: "expired",
+			token: func() *api.APIToken {
+				token := valid()
+				token.ExpiresAt = now
+				return token
+			},
+			wantErrs: validation.ErrorList{validation.Invalid(validation.NewPath("expiresAt"), nil, "")},
+		}, {
+			name: "expires too late",
+			token: func() *api.APIToken {
+				token := valid()
+				token.ExpiresAt = now.Add(APITokenMaxLifetime + time.Hour)
+				return token
+			},
+			wantErrs: validation.ErrorList{validation.Invalid(validation.NewPath("expiresAt"), nil, "")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateAPIToken(tt.token(), countryIDs, now)
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+)
+
+// ValidateServiceCatalogue checks that the entries of a service catalogue have a name, a known kind and a parent
+// of the right kind, that the service types have a core competency, and that siblings have distinct names
+func ValidateServiceCatalogue(catalogue api.ServiceCatalogue) validation.ErrorList {
+	return validateServiceCatalogue(catalogue, nil)
+}
+
+func validateServiceCatalogue(catalogue api.ServiceCatalogue, path *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	entriesPath := path.Child("entries")
+	ids := containers.NewStringSet()
+	siblings := containers.NewStringSet()
+	for i, entry := range catalogue.Entries {
+		entryPath := entriesPath.Index(i)
+		if entry.ID == "" {
+			allErrs = append(allErrs, validation.Required(entryPath.Child("id"), "id is required"))
+		} else if ids.Contains(entry.ID) {
+			allErrs = append(allErrs, validation.Duplicate(entryPath.Child("id"), entry.ID))
+		}
+		ids.Add(entry.ID)
+
+		if !entry.Kind.IsValid() {
+			validKinds := make([]string, 0, len(api.ServiceCatalogueEntryKinds))
+			for _, kind := range api.ServiceCatalogueEntryKinds {
+				validKinds = append(validKinds, string(kind))
+			}
+			allErrs = append(allErrs, validation.NotSupported(entryPath.Child("kind"), entry.Kind, validKinds))
+			continue
+		}
+
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			allErrs = append(allErrs, validation.Required(entryPath.Child("name"), "name is required"))
+		} else if len(entry.Name) > individualServiceFieldsMaxLength {
+			allErrs = append(allErrs, validation.TooLongMaxLength(entryPath.Child("name"), entry.Name, individualServiceFieldsMaxLength))
+		} else {
+			siblingKey := fmt.Sprintf("%s/%s/%s/%s", entry.Kind, entry.ParentID, entry.ServiceCC, strings.ToLower(name))
+			if siblings.Contains(siblingKey) {
+				allErrs = append(allErrs, validation.Duplicate(entryPath.Child("name"), entry.Name))
+			}
+			siblings.Add(siblingKey)
+		}
+
+		parentKind := entry.Kind.ParentKind()
+		if parentKind == "" && entry.ParentID != "" {
+			allErrs = append(allErrs, validation.Forbidden(entryPath.Child("parentId"), fmt.Sprintf("a %s has no parent", entry.Kind)))
+		} else if parentKind != "" {
+			if parent, ok := catalogue.EntryByID(entry.ParentID); !ok {
+				allErrs = append(allErrs, validation.NotFound(entryPath.Child("parentId"), entry.ParentID))
+			} else if parent.Kind != parentKind {
+				allErrs = append(allErrs, validation.Invalid(entryPath.Child("parentId"), entry.ParentID, fmt.Sprintf("the parent of a %s must be a %s", entry.Kind, parentKind)))
+			}
+		}
+
+		if entry.Kind == api.ServiceCatalogueEntryKindServiceType {
+			if !enumTypes.AllServiceCCs().Contains(entry.ServiceCC) {
+				allErrs = append(allErrs, validation.NotSupported(entryPath.Child("serviceCc"), entry.ServiceCC, serviceCCValues()))
+			}
+		} else if entry.ServiceCC != enumTypes.ServiceCCNone {
+			allErrs = append(allErrs, validation.Forbidden(entryPath.Child("serviceCc"), "only service types have a core competency"))
+		}
+	}
+	return allErrs
+}
+
+func serviceCCValues() []string {
+	items := enumTypes.AllServiceCCs().Items()
+	ret := make([]string, 0, len(items))
+	for _, cc := range items {
+		ret = append(ret, string(cc))
+	}
+	return ret
+}
+
+// ValidateIndividualServiceCatalogue checks that the service fields of the individual hold entries of the service
+// catalogue of its country, under the core competency and the parent entries of the same service slot.
+// The fields of the levels that the catalogue does not manage are not checked. The error details are localized.
+func ValidateIndividualServiceCatalogue(i *api.Individual, catalogue api.ServiceCatalogue) validation.ErrorList {
+	return validateIndividualServiceCatalogue(i, catalogue, nil)
+}
+
+func validateIndividualServiceCatalogue(i *api.Individual, catalogue api.ServiceCatalogue, p *validation.Path) validation.ErrorList {
+	allErrs := validation.ErrorList{}
+	if catalogue.IsEmpty() {
+		return allErrs
+	}
+	for idx, service := range i.GetServices() {
+		n := idx + 1
+		var errs validation.ErrorList
+		var typeID, serviceID, donorID string
+		typeID, errs = validateServiceCatalogueValue(catalogue, api.ServiceCatalogueEntryKindServiceType, catalogue.ServiceTypes(service.CC), service.Type, p.Child(fmt.Sprintf("service_type_%d", n)))
+		allErrs = append(allErrs, errs...)
+		serviceID, errs = validateServiceCatalogueValue(catalogue, api.ServiceCatalogueEntryKindService, catalogue.Children(api.ServiceCatalogueEntryKindService, typeID), service.Service, p.Child(fmt.Sprintf("service_%d", n)))
+		allErrs = append(allErrs, errs...)
+		_, errs = validateServiceCatalogueValue(catalogue, api.ServiceCatalogueEntryKindSubService, catalogue.Children(api.ServiceCatalogueEntryKindSubService, serviceID), service.SubService, p.Child(fmt.Sprintf("service_sub_service_%d", n)))
+		allErrs = append(allErrs, errs...)
+		donorID, errs = validateServiceCatalogueValue(catalogue, api.ServiceCatalogueEntryKindDonor, catalogue.Children(api.ServiceCatalogueEntryKindDonor, ""), service.Donor, p.Child(fmt.Sprintf("service_donor_%d", n)))
+		allErrs = append(allErrs, errs...)
+		_, errs = validateServiceCatalogueValue(catalogue, api.ServiceCatalogueEntryKindProject, catalogue.Children(api.ServiceCatalogueEntryKindProject, donorID), service.ProjectName, p.Child(fmt.Sprintf("service_project_name_%d", n)))
+		allErrs = append(allErrs, errs...)
+	}
+	return allErrs
+}
+
+// validateServiceCatalogueValue checks that the value is the name of one of the candidate entries, and returns the ID
+// of the matching entry. An empty ID is returned when the value is empty or the kind is not managed by the catalogue,
+// in which case the children of any parent are accepted at the next level.
+func validateServiceCatalogueValue(catalogue api.ServiceCatalogue, kind api.ServiceCatalogueEntryKind, candidates []api.ServiceCatalogueEntry, value string, path *validation.Path) (string, validation.ErrorList) {
+	allErrs := validation.ErrorList{}
+	if value == "" || !catalogue.Manages(kind) {
+		return "", allErrs
+	}
+	if entry, ok := catalogue.Find(candidates, value); ok {
+		return entry.ID, allErrs
+	}
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.Name)
+	}
+	t := locales.GetTranslator()
+	if len(names) == 0 {
+		allErrs = append(allErrs, validation.Invalid(path, value, t("error_service_catalogue_no_entries")))
+	} else {
+		allErrs = append(allErrs, validation.Invalid(path, value, t("error_service_catalogue_not_found", strings.Join(names, ", "))))
+	}
+	return "", allErrs
+}
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateServiceCatalogue(t *testing.T) {
+	entriesPath := validation.NewPath("entries")
+	tests := []struct {
+		name     string
+		entries  []api.ServiceCatalogueEntry
+		wantErrs validation.ErrorList
+	}{
+		{
+			name: "valid",
+			entries: []api.ServiceCatalogueEntry{
+				{ID: "nfi", Kind: api.ServiceCatalogueEntryKindServiceType, ServiceCC: enumTypes.ServiceCCShelter, Name: "NFI"},
+				{ID: "kits", Kind: api.ServiceCatalogueEntryKindService, ParentID: "nfi", Name: "Kits"},
+				{ID: "echo", Kind: api.ServiceCatalogueEntryKindDonor, Name: "ECHO"},
+				{ID: "p1", Kind: api.ServiceCatalogueEntryKindProject, ParentID: "echo", Name: "Kits"},
+			},
+		}, {
+			name: "missing name and unknown kind",
+			entries: []api.ServiceCatalogueEntry{
+				{ID: "a", Kind: api.ServiceCatalogueEntryKindDonor, Name: " "},
+				{ID: "b", Kind: "unknown", Name: "b"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Required(entriesPath.Index(0).Child("name"), ""),
+				validation.NotSupported(entriesPath.Index(1).Child("kind"), api.ServiceCatalogueEntryKind("unknown"), nil),
+			},
+		}, {
+			name: "duplicate id and sibling name",
+			entries: []api.ServiceCatalogueEntry{
+				{ID: "a", Kind: api.ServiceCatalogueEntryKindDonor, Name: "ECHO"},
+				{ID: "a", Kind: api.ServiceCatalogueEntryKindDonor, Name: "echo"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Duplicate(entriesPath.Index(1).Child("id"), "a"),
+				validation.Duplicate(entriesPath.Index(1).Child("name"), "echo"),
+			},
+		}, {
+			name: "parents",
+			entries: []api.ServiceCatalogueEntry{
+				{ID: "echo", Kind: api.ServiceCatalogueEntryKindDonor, ParentID: "x", Name: "ECHO"},
+				{ID: "kits", Kind: api.ServiceCatalogueEntryKindService, ParentID: "echo", Name: "Kits"},
+				{ID: "p1", Kind: api.ServiceCatalogueEntryKindProject, ParentID: "unknown", Name: "P1"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.Forbidden(entriesPath.Index(0).Child("parentId"), ""),
+				validation.Invalid(entriesPath.Index(1).Child("parentId"), "echo", ""),
+				validation.NotFound(entriesPath.Index(2).Child("parentId"), "unknown"),
+			},
+		}, {
+			name: "core competencies",
+			entries: []api.ServiceCatalogueEntry{
+				{ID: "nfi", Kind: api.ServiceCatalogueEntryKindServiceType, Name: "NFI"},
+				{ID: "echo", Kind: api.ServiceCatalogueEntryKindDonor, ServiceCC: enumTypes.ServiceCCShelter, Name: "ECHO"},
+			},
+			wantErrs: validation.ErrorList{
+				validation.NotSupported(entriesPath.Index(0).Child("serviceCc"), enumTypes.ServiceCCNone, nil),
+				validation.Forbidden(entriesPath.Index(1).Child("serviceCc"), ""),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateServiceCatalogue(api.ServiceCatalogue{Entries: tt.entries})
+			if !assert.Len(t, errs, len(tt.wantErrs)) {
+				return
+			}
+			for i, err := range errs {
+				assert.Equal(t, tt.wantErrs[i].Type, err.Type)
+				assert.Equal(t, tt.wantErrs[i].Field, err.Field)
+			}
+		})
+	}
+}
+
+func TestValidateIndividualServiceCatalogue(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	catalogue := api.ServiceCatalogue{Entries: []api.ServiceCatalogueEntry{
+		{ID: "nfi", Kind: api.ServiceCatalogueEntryKindServiceType, ServiceCC: enumTypes.ServiceCCShelter, Name: "NFI"},
+		{ID: "kits", Kind: api.ServiceCatalogueEntryKindService, ParentID: "nfi", Name: "Kits"},
+		{ID: "echo", Kind: api.ServiceCatalogueEntryKindDonor, Name: "ECHO"},
+	}}
+	tests := []struct {
+		name       string
+		individual *api.Individual
+		wantFields []string
+	}{
+		{
+			name: "valid",
+			individual: &api.Individual{
+				ServiceCC1:          enumTypes.ServiceCCShelter,
+				ServiceType1:        "nfi",
+				Service1:            "Kits",
+				ServiceSubService1:  "anything",
+				ServiceDonor1:       "ECHO",
+				ServiceProjectName1: "anything",
+			},
+		}, {
+			name: "service type of another core competency",
+			individual: &api.Individual{
+				ServiceCC1:   enumTypes.ServiceCCEducation,
+				ServiceType1: "NFI",
+			},
+			wantFields: []string{"service_type_1"},
+		}, {
+			name: "unknown service and donor",
+			individual: &api.Individual{
+				ServiceCC2:    enumTypes.ServiceCCShelter,
+				ServiceType2:  "NFI",
+				Service2:      "Cash",
+				ServiceDonor2: "BHA",
+			},
+			wantFields: []string{"service_2", "service_donor_2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateIndividualServiceCatalogue(tt.individual, catalogue)
+			var fields []string
+			for _, err := range errs {
+				assert.NotEmpty(t, err.Detail)
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
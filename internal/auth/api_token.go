@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// APITokenPrefix starts the secrets of the API tokens, so that they are easy to recognize
+	// in configuration files and by secret scanners
+	APITokenPrefix = "core_"
+
+	apiTokenSecretLength = 32
+)
+
+// NewAPITokenSecret returns a new random API token secret
+func NewAPITokenSecret() (string, error) {
+	secret, err := randomSecret(apiTokenSecretLength)
+	if err != nil {
+		return "", err
+	}
+	return APITokenPrefix + secret, nil
+}
+
+// IsAPITokenSecret returns true if the value looks like an API token secret
+func IsAPITokenSecret(secret string) bool {
+	return strings.HasPrefix(secret, APITokenPrefix) && len(secret) > len(APITokenPrefix)
+}
+
+// HashAPITokenSecret returns the hash under which the API token secret is stored.
+// The secrets are random, so a fast hash is enough to keep them from being recovered.
+func HashAPITokenSecret(secret string) string {
+	return hashSecret(secret)
+}
+
+func randomSecret(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+// FieldGroup is a group of individual fields that share the same access rules
+type FieldGroup string
+
+const (
+	// FieldGroupGeneral holds the fields that are not in any of the other groups
+	FieldGroupGeneral FieldGroup = "general"
+	// FieldGroupContact holds the address, emails, phone numbers and contact preferences
+	FieldGroupContact FieldGroup = "contact"
+	// FieldGroupIdentification holds the identification types and numbers
+	FieldGroupIdentification FieldGroup = "identification"
+	// FieldGroupProtection holds the protection concerns and the risk flags
+	FieldGroupProtection FieldGroup = "protection"
+	// FieldGroupHealth holds the disabilities and the medical conditions
+	FieldGroupHealth FieldGroup = "health"
+)
+
+// FieldGroups are all the field groups, in the order they are shown in the admin pages
+var FieldGroups = []FieldGroup{
+	FieldGroupGeneral,
+	FieldGroupContact,
+	FieldGroupIdentification,
+	FieldGroupProtection,
+	FieldGroupHealth,
+}
+
+// IsValid returns true if the field group is known
+func (g FieldGroup) IsValid() bool {
+	for _, group := range FieldGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPermission is what a user can do with the fields of a field group
+type FieldPermission string
+
+const (
+	// FieldPermissionView allows to see the fields in the individual page and the list of individuals
+	FieldPermissionView FieldPermission = "view"
+	// FieldPermissionEdit allows to change the fields in the individual page and through uploads
+	FieldPermissionEdit FieldPermission = "edit"
+	// FieldPermissionExport allows to download the fields
+	FieldPermissionExport FieldPermission = "export"
+)
+
+// FieldPermissions are all the field permissions
+var FieldPermissions = []FieldPermission{
+	FieldPermissionView,
+	FieldPermissionEdit,
+	FieldPermissionExport,
+}
+
+// IsValid returns true if the field permission is known
+func (p FieldPermission) IsValid() bool {
+	for _, perm := range FieldPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldGroupPermissions are the permissions of a user on each field group of a country
+type FieldGroupPermissions map[FieldGroup]containers.Set[FieldPermission]
+
+// Add grants the permissions on the field group
+func (p FieldGroupPermissions) Add(group FieldGroup, perms ...FieldPermission) {
+	if p[group] == nil {
+		p[group] = containers.NewSet[FieldPermission]()
+	}
+	p[group].Add(perms...)
+}
+
+// AddAll grants the permissions on all the field groups
+func (p FieldGroupPermissions) AddAll(perms ...FieldPermission) {
+	for _, group := range FieldGroups {
+		p.Add(group, perms...)
+	}
+}
+
+// Contains returns true if the permission is granted on the field group
+func (p FieldGroupPermissions) Contains(group FieldGroup, perm FieldPermission) bool {
+	return p[group].Contains(perm)
+}
+
+// CountryFieldPermissions are the field group permissions of a user, by country id
+type CountryFieldPermissions map[string]FieldGroupPermissions
+
+// Get returns the field group permissions of the country, creating them if needed
+func (p CountryFieldPermissions) Get(countryID string) FieldGroupPermissions {
+	if p[countryID] == nil {
+		p[countryID] = FieldGroupPermissions{}
+	}
+	return p[countryID]
+}
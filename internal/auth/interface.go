@@ -14,6 +14,9 @@ const (
 	PermissionGlobalAdmin Permission = iota
 	PermissionWrite
 	PermissionRead
+	// PermissionEditFields allows to save the changes to the fields of the existing individuals that the field
+	// permissions allow editing, but not to create, upload, delete or deactivate individuals. The write permission implies it.
+	PermissionEditFields
 )
 
 type Interface interface {
@@ -22,24 +25,40 @@ type Interface interface {
 	HasCountryLevelPermission(countryID string, perm Permission) bool
 	HasCountryPermissionWrite(countryID string) bool
 	HasCountryPermissionRead(countryID string) bool
+	// HasFieldPermission returns true if the user can view, edit or export the fields of the group in the country
+	HasFieldPermission(countryID string, group FieldGroup, perm FieldPermission) bool
+	// HasOfficePermission returns true if the user has the permission on the individuals collected by the office in the country
+	HasOfficePermission(countryID string, office string, perm Permission) bool
+	// GetAllowedOffices returns the offices whose individuals the user has the permission on in the country.
+	// It returns false when the permission is granted on the whole country.
+	GetAllowedOffices(countryID string, perm Permission) ([]string, bool)
 }
 
 type permissions struct {
 	// isGlobalAdmin is true if the user has global admin permissions
 	isGlobalAdmin bool
 	countryPermissions CountryPermissions
+	// fieldPermissions are the permissions on the individual field groups, by country id
+	fieldPermissions CountryFieldPermissions
+	// officePermissions are the permissions granted on some offices only, by country id
+	officePermissions CountryOfficePermissions
 	allowedCountryIDs containers.StringSet
 	// allCountryIDs is a list of all country IDs
 	allCountryIDs containers.StringSet
 }
 
-func New(countryPermissions CountryPermissions, allCountryIDs containers.StringSet, isGlobalAdmin bool) Interface {
+func New(countryPermissions CountryPermissions, fieldPermissions CountryFieldPermissions, officePermissions CountryOfficePermissions, allCountryIDs containers.StringSet, isGlobalAdmin bool) Interface {
 	allowedCountryIDs := containers.NewStringSet()
   for k := range countryPermissions{
 		allowedCountryIDs.Add(k)
   }
+	for k := range officePermissions {
+		allowedCountryIDs.Add(k)
+	}
 	p := permissions{
 		countryPermissions: countryPermissions,
+		fieldPermissions:   fieldPermissions,
+		officePermissions:  officePermissions,
 		allowedCountryIDs: allowedCountryIDs,
 		allCountryIDs:     containers.NewStringSet(allCountryIDs.Items()...),
 		isGlobalAdmin:     isGlobalAdmin,
@@ -59,6 +78,8 @@ func (p permissions) HasCountryLevelPermission(countryID string, perm Permission
 		return p.HasCountryPermissionWrite(countryID)
 	case PermissionRead:
 		return p.HasCountryPermissionRead(countryID)
+	case PermissionEditFields:
+		return p.HasCountryPermissionWrite(countryID) || p.countryPermissions[countryID].Contains(PermissionEditFields)
 	default:
 		return false
 	}
@@ -72,13 +93,33 @@ func (p permissions) GetAllowedCountries() containers.StringSet {
 }
 
 func (p permissions) HasCountryPermissionWrite(countryID string) bool {
-	return p.IsGlobalAdmin() || p.countryPermissions[countryID].Contains(PermissionWrite)
+	return p.IsGlobalAdmin() || p.countryPermissions[countryID].Contains(PermissionWrite) || len(p.officePermissions[countryID].Offices(PermissionWrite)) > 0
 }
 
 func (p permissions) HasCountryPermissionRead(countryID string) bool {
-	return p.IsGlobalAdmin() || p.countryPermissions[countryID].Contains(PermissionRead) || p.countryPermissions[countryID].Contains(PermissionWrite) 
+	return p.IsGlobalAdmin() || p.countryPermissions[countryID].Contains(PermissionRead) || p.countryPermissions[countryID].Contains(PermissionWrite) || len(p.officePermissions[countryID].Offices(PermissionRead)) > 0
 }
 
 func (p permissions) IsGlobalAdmin() bool {
 	return p.isGlobalAdmin
 }
+
+func (p permissions) HasFieldPermission(countryID string, group FieldGroup, perm FieldPermission) bool {
+	return p.IsGlobalAdmin() || p.fieldPermissions[countryID].Contains(group, perm)
+}
+
+func (p permissions) HasOfficePermission(countryID string, office string, perm Permission) bool {
+	return p.hasCountryWidePermission(countryID, perm) || p.officePermissions[countryID].Contains(office, perm)
+}
+
+func (p permissions) GetAllowedOffices(countryID string, perm Permission) ([]string, bool) {
+	if p.hasCountryWidePermission(countryID, perm) {
+		return nil, false
+	}
+	return p.officePermissions[countryID].Offices(perm), true
+}
+
+// hasCountryWidePermission returns true if the permission is granted on the whole country, and not only on some offices
+func (p permissions) hasCountryWidePermission(countryID string, perm Permission) bool {
+	return p.IsGlobalAdmin() || hasPermission(p.countryPermissions[countryID], perm)
+}
@@ -34,32 +34,103 @@ func (m *MockInterface) EXPECT() *MockInterfaceMockRecorder {
 	return m.recorder
 }
 
-// CanReadWriteToCountryID mocks base method.
-func (m *MockInterface) CanReadWriteToCountryID(arg0 string) bool {
+// GetAllowedCountries mocks base method.
+func (m *MockInterface) GetAllowedCountries() containers.StringSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllowedCountries")
+	ret0, _ := ret[0].(containers.StringSet)
+	return ret0
+}
+
+// GetAllowedCountries indicates an expected call of GetAllowedCountries.
+func (mr *MockInterfaceMockRecorder) GetAllowedCountries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllowedCountries", reflect.TypeOf((*MockInterface)(nil).GetAllowedCountries))
+}
+
+// GetAllowedOffices mocks base method.
+func (m *MockInterface) GetAllowedOffices(arg0 string, arg1 Permission) ([]string, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CanReadWriteToCountryID", arg0)
+	ret := m.ctrl.Call(m, "GetAllowedOffices", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetAllowedOffices indicates an expected call of GetAllowedOffices.
+func (mr *MockInterfaceMockRecorder) GetAllowedOffices(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllowedOffices", reflect.TypeOf((*MockInterface)(nil).GetAllowedOffices), arg0, arg1)
+}
+
+// HasCountryLevelPermission mocks base method.
+func (m *MockInterface) HasCountryLevelPermission(arg0 string, arg1 Permission) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasCountryLevelPermission", arg0, arg1)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// CanReadWriteToCountryID indicates an expected call of CanReadWriteToCountryID.
-func (mr *MockInterfaceMockRecorder) CanReadWriteToCountryID(arg0 interface{}) *gomock.Call {
+// HasCountryLevelPermission indicates an expected call of HasCountryLevelPermission.
+func (mr *MockInterfaceMockRecorder) HasCountryLevelPermission(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanReadWriteToCountryID", reflect.TypeOf((*MockInterface)(nil).CanReadWriteToCountryID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCountryLevelPermission", reflect.TypeOf((*MockInterface)(nil).HasCountryLevelPermission), arg0, arg1)
 }
 
-// GetAllowedCountries mocks base method.
-func (m *MockInterface) GetAllowedCountries() containers.Set[string] {
+// HasCountryPermissionRead mocks base method.
+func (m *MockInterface) HasCountryPermissionRead(arg0 string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllowedCountries")
-	ret0, _ := ret[0].(containers.Set[string])
+	ret := m.ctrl.Call(m, "HasCountryPermissionRead", arg0)
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// GetAllowedCountries indicates an expected call of GetAllowedCountries.
-func (mr *MockInterfaceMockRecorder) GetAllowedCountries() *gomock.Call {
+// HasCountryPermissionRead indicates an expected call of HasCountryPermissionRead.
+func (mr *MockInterfaceMockRecorder) HasCountryPermissionRead(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllowedCountries", reflect.TypeOf((*MockInterface)(nil).GetAllowedCountries))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCountryPermissionRead", reflect.TypeOf((*MockInterface)(nil).HasCountryPermissionRead), arg0)
+}
+
+// HasCountryPermissionWrite mocks base method.
+func (m *MockInterface) HasCountryPermissionWrite(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasCountryPermissionWrite", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasCountryPermissionWrite indicates an expected call of HasCountryPermissionWrite.
+func (mr *MockInterfaceMockRecorder) HasCountryPermissionWrite(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCountryPermissionWrite", reflect.TypeOf((*MockInterface)(nil).HasCountryPermissionWrite), arg0)
+}
+
+// HasFieldPermission mocks base method.
+func (m *MockInterface) HasFieldPermission(arg0 string, arg1 FieldGroup, arg2 FieldPermission) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasFieldPermission", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasFieldPermission indicates an expected call of HasFieldPermission.
+func (mr *MockInterfaceMockRecorder) HasFieldPermission(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFieldPermission", reflect.TypeOf((*MockInterface)(nil).HasFieldPermission), arg0, arg1, arg2)
+}
+
+// HasOfficePermission mocks base method.
+func (m *MockInterface) HasOfficePermission(arg0, arg1 string, arg2 Permission) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasOfficePermission", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasOfficePermission indicates an expected call of HasOfficePermission.
+func (mr *MockInterfaceMockRecorder) HasOfficePermission(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOfficePermission", reflect.TypeOf((*MockInterface)(nil).HasOfficePermission), arg0, arg1, arg2)
 }
 
 // IsGlobalAdmin mocks base method.
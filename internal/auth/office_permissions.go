@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"github.com/nrc-no/notcore/internal/containers"
+)
+
+// OfficePermissions are the permissions of a user on the individuals collected by each office of a country,
+// by collection office
+type OfficePermissions map[string]containers.Set[Permission]
+
+// Add grants the permissions on the office
+func (p OfficePermissions) Add(office string, perms ...Permission) {
+	if p[office] == nil {
+		p[office] = containers.NewSet[Permission]()
+	}
+	p[office].Add(perms...)
+}
+
+// Contains returns true if the permission is granted on the office.
+// The write permission implies the read permission.
+func (p OfficePermissions) Contains(office string, perm Permission) bool {
+	return hasPermission(p[office], perm)
+}
+
+// Offices returns the offices on which the permission is granted, sorted
+func (p OfficePermissions) Offices(perm Permission) []string {
+	offices := containers.NewStringSet()
+	for office := range p {
+		if p.Contains(office, perm) {
+			offices.Add(office)
+		}
+	}
+	return offices.Items()
+}
+
+// CountryOfficePermissions are the office permissions of a user, by country id
+type CountryOfficePermissions map[string]OfficePermissions
+
+// Get returns the office permissions of the country, creating them if needed
+func (p CountryOfficePermissions) Get(countryID string) OfficePermissions {
+	if p[countryID] == nil {
+		p[countryID] = OfficePermissions{}
+	}
+	return p[countryID]
+}
+
+// hasPermission returns true if the permissions contain perm, the write permission implying the read
+// and edit fields permissions
+func hasPermission(perms containers.Set[Permission], perm Permission) bool {
+	if (perm == PermissionRead || perm == PermissionEditFields) && perms.Contains(PermissionWrite) {
+		return true
+	}
+	return perms.Contains(perm)
+}
@@ -65,6 +65,28 @@ func (s session) GetIssuedAt() time.Time {
 	return s.issuedAt
 }
 
+// ServiceAccountIssuer is the issuer of the sessions of the service accounts
+const ServiceAccountIssuer = "service-account"
+
+// NewServiceAccountSession returns the session of a service account authenticated with an API token.
+// The service account has no groups, its permissions come from the scopes of the token.
+func NewServiceAccountSession(
+	serviceAccountID string,
+	serviceAccountName string,
+	expiration time.Time,
+	issuedAt time.Time,
+) Session {
+	return &session{
+		isAuthenticated: true,
+		userGroups:      []string{},
+		userEmail:       serviceAccountName,
+		issuer:          ServiceAccountIssuer,
+		subject:         serviceAccountID,
+		expiration:      expiration,
+		issuedAt:        issuedAt,
+	}
+}
+
 func NewAuthenticatedSession(
 	userGroups []string,
 	userEmail string,
@@ -0,0 +1,57 @@
+package auth
+
+import "net/http"
+
+const (
+	// SessionCookieName is the name of the cookie holding the token of the user session
+	SessionCookieName = "core-session"
+
+	sessionTokenLength = 32
+)
+
+// NewSessionToken returns a new random token for a user session
+func NewSessionToken() (string, error) {
+	return randomSecret(sessionTokenLength)
+}
+
+// HashSessionToken returns the hash under which the session token is stored,
+// so that the sessions cannot be taken over from the database
+func HashSessionToken(token string) string {
+	return hashSecret(token)
+}
+
+// SetSessionCookie gives the session token to the browser. The cookie has no expiration,
+// the lifetime of the session is tracked on the server.
+func SetSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		// Lax keeps the session when following a link to the application, the forms are protected against CSRF
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie removes the session token from the browser
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// GetSessionToken returns the session token sent by the browser, or an empty string if there is none
+func GetSessionToken(r *http.Request) string {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
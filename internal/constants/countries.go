@@ -31,6 +31,8 @@ type Country struct {
 	IntermediateRegionCode string `json:"intermediateRegionCode"`
 	// Name is the name of the country.
 	Name string `json:"name"`
+	// CallingCode is the international calling code of the country, without the leading "+".
+	CallingCode string `json:"callingCode"`
 }
 
 func init() {
@@ -40,10 +42,12 @@ func init() {
 	}
 	for _, c := range Countries {
 		CountriesByCode[c.ISO3166Alpha3] = c
+		CountriesByAlpha2[c.ISO3166Alpha2] = c
 		CountriesByName[c.Name] = c
 	}
 }
 
 var Countries []Country
 var CountriesByCode = make(map[string]Country)
+var CountriesByAlpha2 = make(map[string]Country)
 var CountriesByName = make(map[string]Country)
@@ -9,6 +9,7 @@ const (
 	FormParamsGetIndividualsAddress                         = "address"
 	FormParamsGetIndividualsAgeFrom                         = "age_from"
 	FormParamsGetIndividualsAgeTo                           = "age_to"
+	FormParamsGetIndividualsAgeBand                         = "age_band"
 	FormParamsGetIndividualsBirthDateFrom                   = "birth_date_from"
 	FormParamsGetIndividualsBirthDateTo                     = "birth_date_to"
 	FormParamsGetIndividualsCognitiveDisabilityLevel        = "cognitive_disability_level"
@@ -83,6 +84,7 @@ const (
 	FormParamsGetIndividualsServiceDonor                    = "service_donor"
 	FormParamsGetIndividualsServiceProjectName              = "service_project_name"
 	FormParamsGetIndividualsServiceAgentName                = "service_agent_name"
+	FormParamsGetIndividualsServiceCatalogueID              = "service_catalogue_id"
 	FormParamsGetIndividualsSex                             = "sex"
 	FormParamsGetIndividualsSkip                            = "skip"
 	FormParamsGetIndividualsSort                            = "sort"
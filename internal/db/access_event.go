@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./access_event_mock.go -package=db . AccessEventRepo
+
+type AccessEventRepo interface {
+	// Record appends the event with a new id. The events can never be updated nor deleted.
+	Record(ctx context.Context, event *api.AccessEvent) error
+	// List returns the events matching the options, the most recent first unless OldestFirst is set
+	List(ctx context.Context, options api.ListAccessEventsOptions) ([]*api.AccessEvent, error)
+}
+
+type accessEventRepo struct {
+	db *sqlx.DB
+}
+
+func NewAccessEventRepo(db *sqlx.DB) AccessEventRepo {
+	return &accessEventRepo{db: db}
+}
+
+func (s accessEventRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (s accessEventRepo) Record(ctx context.Context, event *api.AccessEvent) error {
+	l := s.logger(ctx).With(zap.String("access_event_kind", string(event.Kind)))
+	l.Debug("recording access event")
+
+	const query = `INSERT INTO access_events (id, occurred_at, kind, user_id, user_email, impersonated_user_id, country_id, individual_ids, filter, row_count, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	var args = []interface{}{
+		uuid.New().String(),
+		event.OccurredAt.UTC(),
+		event.Kind,
+		event.UserID,
+		event.UserEmail,
+		event.ImpersonatedUserID,
+		event.CountryID,
+		event.IndividualIDs,
+		event.Filter,
+		event.RowCount,
+		event.RequestID,
+	}
+
+	auditDuration := logDuration(ctx, "record access event")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		l.Error("failed to record access event", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s accessEventRepo) List(ctx context.Context, options api.ListAccessEventsOptions) ([]*api.AccessEvent, error) {
+	l := s.logger(ctx)
+	l.Debug("listing access events")
+
+	query, args := buildListAccessEventsQuery(options)
+
+	auditDuration := logDuration(ctx, "list access events")
+	defer auditDuration()
+
+	var events []*api.AccessEvent
+	if err := s.db.SelectContext(ctx, &events, query, args...); err != nil {
+		l.Error("failed to list access events", zap.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
+
+func buildListAccessEventsQuery(options api.ListAccessEventsOptions) (string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if options.User != "" {
+		p := arg(options.User)
+		clauses = append(clauses, fmt.Sprintf("(user_id = %[1]s OR user_email = %[1]s OR impersonated_user_id = %[1]s)", p))
+	}
+	if options.Kind != "" {
+		clauses = append(clauses, "kind = "+arg(options.Kind))
+	}
+	if options.CountryID != "" {
+		clauses = append(clauses, "country_id = "+arg(options.CountryID))
+	}
+	if options.IndividualID != "" {
+		clauses = append(clauses, arg(options.IndividualID)+" = ANY (individual_ids)")
+	}
+	if options.From != nil {
+		clauses = append(clauses, "occurred_at >= "+arg(options.From.UTC()))
+	}
+	if options.To != nil {
+		clauses = append(clauses, "occurred_at < "+arg(options.To.UTC()))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT * FROM access_events")
+	if len(clauses) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(clauses, " AND "))
+	}
+	if options.OldestFirst {
+		b.WriteString(" ORDER BY occurred_at, id")
+	} else {
+		b.WriteString(" ORDER BY occurred_at DESC, id")
+	}
+	if options.Take > 0 {
+		b.WriteString(" LIMIT " + arg(options.Take))
+	}
+	if options.Skip > 0 {
+		b.WriteString(" OFFSET " + arg(options.Skip))
+	}
+	return b.String(), args
+}
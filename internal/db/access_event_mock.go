@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: AccessEventRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+)
+
+// MockAccessEventRepo is a mock of AccessEventRepo interface.
+type MockAccessEventRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessEventRepoMockRecorder
+}
+
+// MockAccessEventRepoMockRecorder is the mock recorder for MockAccessEventRepo.
+type MockAccessEventRepoMockRecorder struct {
+	mock *MockAccessEventRepo
+}
+
+// NewMockAccessEventRepo creates a new mock instance.
+func NewMockAccessEventRepo(ctrl *gomock.Controller) *MockAccessEventRepo {
+	mock := &MockAccessEventRepo{ctrl: ctrl}
+	mock.recorder = &MockAccessEventRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessEventRepo) EXPECT() *MockAccessEventRepoMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockAccessEventRepo) List(arg0 context.Context, arg1 api.ListAccessEventsOptions) ([]*api.AccessEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]*api.AccessEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAccessEventRepoMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAccessEventRepo)(nil).List), arg0, arg1)
+}
+
+// Record mocks base method.
+func (m *MockAccessEventRepo) Record(arg0 context.Context, arg1 *api.AccessEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAccessEventRepoMockRecorder) Record(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAccessEventRepo)(nil).Record), arg0, arg1)
+}
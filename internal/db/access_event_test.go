@@ -0,0 +1,43 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_buildListAccessEventsQuery(t *testing.T) {
+	from := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		options  api.ListAccessEventsOptions
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "no filters",
+			options: api.ListAccessEventsOptions{},
+			wantSql: `SELECT * FROM access_events ORDER BY occurred_at DESC, id`,
+		}, {
+			name:     "user and participant",
+			options:  api.ListAccessEventsOptions{User: "user@nrc.no", IndividualID: "abc", Take: 50},
+			wantSql:  `SELECT * FROM access_events WHERE (user_id = $1 OR user_email = $1 OR impersonated_user_id = $1) AND $2 = ANY (individual_ids) ORDER BY occurred_at DESC, id LIMIT $3`,
+			wantArgs: []interface{}{"user@nrc.no", "abc", 50},
+		}, {
+			name:     "period, oldest first",
+			options:  api.ListAccessEventsOptions{Kind: api.AccessEventKindDownloadIndividuals, From: &from, To: &to, OldestFirst: true, Take: 10, Skip: 20},
+			wantSql:  `SELECT * FROM access_events WHERE kind = $1 AND occurred_at >= $2 AND occurred_at < $3 ORDER BY occurred_at, id LIMIT $4 OFFSET $5`,
+			wantArgs: []interface{}{api.AccessEventKindDownloadIndividuals, from, to, 10, 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSql, gotArgs := buildListAccessEventsQuery(tt.options)
+			assert.Equal(t, tt.wantSql, gotSql)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
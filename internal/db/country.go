@@ -14,6 +14,17 @@ type CountryRepo interface {
 	GetAll(ctx context.Context) ([]*api.Country, error)
 	GetByID(ctx context.Context, id string) (*api.Country, error)
 	Put(ctx context.Context, country *api.Country) (*api.Country, error)
+	// PutServiceCatalogue replaces the service catalogue of the country. The catalogue is left untouched by Put.
+	PutServiceCatalogue(ctx context.Context, countryID string, catalogue api.ServiceCatalogue) error
+	// PutRoles replaces the roles of the country. The roles are left untouched by Put.
+	PutRoles(ctx context.Context, countryID string, roles api.CountryRoles) error
+	// PutOffices replaces the field offices of the country. The offices are left untouched by Put.
+	PutOffices(ctx context.Context, countryID string, offices api.CountryOffices) error
+	// PutGroups replaces the JWT groups of the country and their roles. The groups are left untouched by Put.
+	PutGroups(ctx context.Context, countryID string, groups api.CountryGroups) error
+	// PutRolesAndGroups replaces the roles and the JWT groups of the country at once, so that no group is left
+	// mapped to a removed role
+	PutRolesAndGroups(ctx context.Context, countryID string, roles api.CountryRoles, groups api.CountryGroups) error
 }
 
 type countryRepo struct {
@@ -42,6 +53,21 @@ func (c countryRepo) GetAll(ctx context.Context) ([]*api.Country, error) {
 		l.Error("failed to get countries", zap.Error(err))
 		return nil, err
 	}
+
+	const groupsQuery = "SELECT * FROM country_groups ORDER BY jwt_group, role"
+
+	var groups api.CountryGroups
+	if err := c.db.SelectContext(ctx, &groups, groupsQuery); err != nil {
+		l.Error("failed to get country groups", zap.Error(err))
+		return nil, err
+	}
+	groupsByCountry := map[string]api.CountryGroups{}
+	for _, group := range groups {
+		groupsByCountry[group.CountryID] = append(groupsByCountry[group.CountryID], group)
+	}
+	for _, country := range countries {
+		country.Groups = groupsByCountry[country.ID]
+	}
 	return countries, nil
 }
 
@@ -60,6 +86,13 @@ func (c countryRepo) GetByID(ctx context.Context, id string) (*api.Country, erro
 		l.Error("failed to get country by id", zap.Error(err))
 		return nil, err
 	}
+
+	const groupsQuery = "SELECT * FROM country_groups WHERE country_id = $1 ORDER BY jwt_group, role"
+
+	if err := c.db.SelectContext(ctx, &country.Groups, groupsQuery, args...); err != nil {
+		l.Error("failed to get country groups", zap.Error(err))
+		return nil, err
+	}
 	return &country, nil
 }
 
@@ -75,13 +108,12 @@ func (c countryRepo) updateCountry(ctx context.Context, country *api.Country) (*
 	l := c.logger(ctx)
 	l.Debug("updating country")
 
-	const query = "UPDATE countries SET code = $2, name = $3, read_group = $4, write_group = $5 WHERE id = $1"
+	const query = "UPDATE countries SET code = $2, name = $3, validation_rules = $4 WHERE id = $1"
 	var args = []interface{}{
 		country.ID,
 		country.Code,
 		country.Name,
-		country.ReadGroup,
-		country.WriteGroup,
+		country.ValidationRules,
 	}
 
 	auditDuration := logDuration(ctx, "update country")
@@ -100,14 +132,13 @@ func (c countryRepo) createCountry(ctx context.Context, country *api.Country) (*
 	l.Debug("creating new country")
 	country.ID = uuid.New().String()
 
-	const query = `INSERT INTO countries (id, code, name, read_group, write_group) VALUES ($1, $2, $3, $4, $5)`
+	const query = `INSERT INTO countries (id, code, name, validation_rules) VALUES ($1, $2, $3, $4)`
 
 	var args = []interface{}{
 		country.ID,
 		country.Code,
 		country.Name,
-		country.ReadGroup,
-		country.WriteGroup,
+		country.ValidationRules,
 	}
 
 	auditDuration := logDuration(ctx, "create country")
@@ -120,3 +151,102 @@ func (c countryRepo) createCountry(ctx context.Context, country *api.Country) (*
 
 	return country, nil
 }
+
+func (c countryRepo) PutServiceCatalogue(ctx context.Context, countryID string, catalogue api.ServiceCatalogue) error {
+	l := c.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("updating service catalogue")
+
+	const query = "UPDATE countries SET service_catalogue = $2 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "update service catalogue")
+	defer auditDuration()
+
+	if _, err := c.db.ExecContext(ctx, query, countryID, catalogue); err != nil {
+		l.Error("failed to update service catalogue", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c countryRepo) PutRoles(ctx context.Context, countryID string, roles api.CountryRoles) error {
+	l := c.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("updating country roles")
+
+	const query = "UPDATE countries SET roles = $2 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "update country roles")
+	defer auditDuration()
+
+	if _, err := c.db.ExecContext(ctx, query, countryID, roles); err != nil {
+		l.Error("failed to update country roles", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c countryRepo) PutOffices(ctx context.Context, countryID string, offices api.CountryOffices) error {
+	l := c.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("updating country offices")
+
+	const query = "UPDATE countries SET offices = $2 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "update country offices")
+	defer auditDuration()
+
+	if _, err := c.db.ExecContext(ctx, query, countryID, offices); err != nil {
+		l.Error("failed to update country offices", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c countryRepo) PutGroups(ctx context.Context, countryID string, groups api.CountryGroups) error {
+	l := c.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("updating country groups")
+
+	auditDuration := logDuration(ctx, "update country groups")
+	defer auditDuration()
+
+	_, err := doInTransaction(ctx, c.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
+		return nil, replaceCountryGroups(ctx, tx, countryID, groups)
+	})
+	if err != nil {
+		l.Error("failed to update country groups", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c countryRepo) PutRolesAndGroups(ctx context.Context, countryID string, roles api.CountryRoles, groups api.CountryGroups) error {
+	l := c.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("updating country roles and groups")
+
+	auditDuration := logDuration(ctx, "update country roles and groups")
+	defer auditDuration()
+
+	_, err := doInTransaction(ctx, c.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
+		if _, err := tx.ExecContext(ctx, "UPDATE countries SET roles = $2 WHERE id = $1", countryID, roles); err != nil {
+			return nil, err
+		}
+		return nil, replaceCountryGroups(ctx, tx, countryID, groups)
+	})
+	if err != nil {
+		l.Error("failed to update country roles and groups", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// replaceCountryGroups replaces the JWT groups of the country within the transaction
+func replaceCountryGroups(ctx context.Context, tx *sqlx.Tx, countryID string, groups api.CountryGroups) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM country_groups WHERE country_id = $1", countryID); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		const query = "INSERT INTO country_groups (country_id, jwt_group, role) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, countryID, group.Group, group.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./export_mock.go -package=db . ExportRepo
+
+type ExportRepo interface {
+	Create(ctx context.Context, export *api.Export) (*api.Export, error)
+	GetByID(ctx context.Context, id string) (*api.Export, error)
+	// GetExpired returns at most limit exports that expired before the given time and were not deleted yet
+	GetExpired(ctx context.Context, before time.Time, limit int) ([]*api.Export, error)
+	MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error
+}
+
+type exportRepo struct {
+	db *sqlx.DB
+}
+
+func NewExportRepo(db *sqlx.DB) ExportRepo {
+	return &exportRepo{db: db}
+}
+
+func (e exportRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (e exportRepo) Create(ctx context.Context, export *api.Export) (*api.Export, error) {
+	l := e.logger(ctx)
+	l.Debug("creating export")
+
+	if export.ID == "" {
+		export.ID = uuid.New().String()
+	}
+	if export.CreatedAt.IsZero() {
+		export.CreatedAt = time.Now().UTC()
+	}
+
+	const query = `INSERT INTO exports (id, country_id, owner_id, owner_email, blob_name, format, filter, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	var args = []interface{}{
+		export.ID,
+		export.CountryID,
+		export.OwnerID,
+		export.OwnerEmail,
+		export.BlobName,
+		export.Format,
+		export.Filter,
+		export.CreatedAt,
+		export.ExpiresAt,
+	}
+
+	auditDuration := logDuration(ctx, "create export")
+	defer auditDuration()
+
+	if _, err := e.db.ExecContext(ctx, query, args...); err != nil {
+		l.Error("failed to create export", zap.Error(err))
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (e exportRepo) GetByID(ctx context.Context, id string) (*api.Export, error) {
+	l := e.logger(ctx).With(zap.String("export_id", id))
+	l.Debug("getting export by id")
+
+	const query = "SELECT * FROM exports WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "get export by id")
+	defer auditDuration()
+
+	var export api.Export
+	if err := e.db.GetContext(ctx, &export, query, id); err != nil {
+		l.Error("failed to get export by id", zap.Error(err))
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (e exportRepo) GetExpired(ctx context.Context, before time.Time, limit int) ([]*api.Export, error) {
+	l := e.logger(ctx)
+	l.Debug("getting expired exports")
+
+	const query = "SELECT * FROM exports WHERE deleted_at IS NULL AND expires_at < $1 ORDER BY expires_at LIMIT $2"
+
+	auditDuration := logDuration(ctx, "get expired exports")
+	defer auditDuration()
+
+	var exports []*api.Export
+	if err := e.db.SelectContext(ctx, &exports, query, before, limit); err != nil {
+		l.Error("failed to get expired exports", zap.Error(err))
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (e exportRepo) MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error {
+	l := e.logger(ctx).With(zap.String("export_id", id))
+	l.Debug("marking export as deleted")
+
+	const query = "UPDATE exports SET deleted_at = $2 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "mark export deleted")
+	defer auditDuration()
+
+	if _, err := e.db.ExecContext(ctx, query, id, deletedAt); err != nil {
+		l.Error("failed to mark export as deleted", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: ExportRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+)
+
+// MockExportRepo is a mock of ExportRepo interface.
+type MockExportRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepoMockRecorder
+}
+
+// MockExportRepoMockRecorder is the mock recorder for MockExportRepo.
+type MockExportRepoMockRecorder struct {
+	mock *MockExportRepo
+}
+
+// NewMockExportRepo creates a new mock instance.
+func NewMockExportRepo(ctrl *gomock.Controller) *MockExportRepo {
+	mock := &MockExportRepo{ctrl: ctrl}
+	mock.recorder = &MockExportRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepo) EXPECT() *MockExportRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockExportRepo) Create(arg0 context.Context, arg1 *api.Export) (*api.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*api.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockExportRepoMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockExportRepo)(nil).Create), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockExportRepo) GetByID(arg0 context.Context, arg1 string) (*api.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*api.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockExportRepoMockRecorder) GetByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockExportRepo)(nil).GetByID), arg0, arg1)
+}
+
+// GetExpired mocks base method.
+func (m *MockExportRepo) GetExpired(arg0 context.Context, arg1 time.Time, arg2 int) ([]*api.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpired", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpired indicates an expected call of GetExpired.
+func (mr *MockExportRepoMockRecorder) GetExpired(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpired", reflect.TypeOf((*MockExportRepo)(nil).GetExpired), arg0, arg1, arg2)
+}
+
+// MarkDeleted mocks base method.
+func (m *MockExportRepo) MarkDeleted(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeleted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeleted indicates an expected call of MarkDeleted.
+func (mr *MockExportRepoMockRecorder) MarkDeleted(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeleted", reflect.TypeOf((*MockExportRepo)(nil).MarkDeleted), arg0, arg1, arg2)
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./import_profile_mock.go -package=db . ImportProfileRepo
+
+type ImportProfileRepo interface {
+	GetAll(ctx context.Context, countryID string) ([]*api.ImportProfile, error)
+	// FindByHeaderSignature returns the most recently updated profile of the country for the given
+	// set of headers, or nil if there is none
+	FindByHeaderSignature(ctx context.Context, countryID string, headerSignature string) (*api.ImportProfile, error)
+	// Put creates the profile, or replaces the profile of the country with the same name
+	Put(ctx context.Context, profile *api.ImportProfile) (*api.ImportProfile, error)
+}
+
+type importProfileRepo struct {
+	db *sqlx.DB
+}
+
+func NewImportProfileRepo(db *sqlx.DB) ImportProfileRepo {
+	return &importProfileRepo{db: db}
+}
+
+func (i importProfileRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (i importProfileRepo) GetAll(ctx context.Context, countryID string) ([]*api.ImportProfile, error) {
+	l := i.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("getting import profiles")
+
+	const query = "SELECT * FROM import_profiles WHERE country_id = $1 ORDER BY name"
+
+	auditDuration := logDuration(ctx, "get import profiles")
+	defer auditDuration()
+
+	var profiles []*api.ImportProfile
+	if err := i.db.SelectContext(ctx, &profiles, query, countryID); err != nil {
+		l.Error("failed to get import profiles", zap.Error(err))
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (i importProfileRepo) FindByHeaderSignature(ctx context.Context, countryID string, headerSignature string) (*api.ImportProfile, error) {
+	l := i.logger(ctx).With(zap.String("country_id", countryID))
+	l.Debug("finding import profile by header signature")
+
+	const query = `SELECT * FROM import_profiles WHERE country_id = $1 AND header_signature = $2
+ORDER BY updated_at DESC LIMIT 1`
+
+	auditDuration := logDuration(ctx, "find import profile by header signature")
+	defer auditDuration()
+
+	var profile api.ImportProfile
+	if err := i.db.GetContext(ctx, &profile, query, countryID, headerSignature); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		l.Error("failed to find import profile", zap.Error(err))
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (i importProfileRepo) Put(ctx context.Context, profile *api.ImportProfile) (*api.ImportProfile, error) {
+	l := i.logger(ctx).With(zap.String("country_id", profile.CountryID))
+	l.Debug("saving import profile", zap.String("name", profile.Name))
+
+	const query = `INSERT INTO import_profiles (id, country_id, name, header_signature, mapping, parse_settings, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+ON CONFLICT (country_id, name) DO UPDATE
+SET header_signature = EXCLUDED.header_signature, mapping = EXCLUDED.mapping,
+    parse_settings = EXCLUDED.parse_settings, updated_at = EXCLUDED.updated_at
+RETURNING *`
+
+	if profile.ID == "" {
+		profile.ID = uuid.New().String()
+	}
+	now := time.Now().UTC()
+
+	var args = []interface{}{
+		profile.ID,
+		profile.CountryID,
+		profile.Name,
+		profile.HeaderSignature,
+		profile.Mapping,
+		profile.Settings,
+		now,
+	}
+
+	auditDuration := logDuration(ctx, "put import profile")
+	defer auditDuration()
+
+	var ret api.ImportProfile
+	if err := i.db.GetContext(ctx, &ret, query, args...); err != nil {
+		l.Error("failed to save import profile", zap.Error(err))
+		return nil, err
+	}
+	return &ret, nil
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: ImportProfileRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+)
+
+// MockImportProfileRepo is a mock of ImportProfileRepo interface.
+type MockImportProfileRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportProfileRepoMockRecorder
+}
+
+// MockImportProfileRepoMockRecorder is the mock recorder for MockImportProfileRepo.
+type MockImportProfileRepoMockRecorder struct {
+	mock *MockImportProfileRepo
+}
+
+// NewMockImportProfileRepo creates a new mock instance.
+func NewMockImportProfileRepo(ctrl *gomock.Controller) *MockImportProfileRepo {
+	mock := &MockImportProfileRepo{ctrl: ctrl}
+	mock.recorder = &MockImportProfileRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportProfileRepo) EXPECT() *MockImportProfileRepoMockRecorder {
+	return m.recorder
+}
+
+// FindByHeaderSignature mocks base method.
+func (m *MockImportProfileRepo) FindByHeaderSignature(arg0 context.Context, arg1, arg2 string) (*api.ImportProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByHeaderSignature", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.ImportProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByHeaderSignature indicates an expected call of FindByHeaderSignature.
+func (mr *MockImportProfileRepoMockRecorder) FindByHeaderSignature(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByHeaderSignature", reflect.TypeOf((*MockImportProfileRepo)(nil).FindByHeaderSignature), arg0, arg1, arg2)
+}
+
+// GetAll mocks base method.
+func (m *MockImportProfileRepo) GetAll(arg0 context.Context, arg1 string) ([]*api.ImportProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
+	ret0, _ := ret[0].([]*api.ImportProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockImportProfileRepoMockRecorder) GetAll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockImportProfileRepo)(nil).GetAll), arg0, arg1)
+}
+
+// Put mocks base method.
+func (m *MockImportProfileRepo) Put(arg0 context.Context, arg1 *api.ImportProfile) (*api.ImportProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", arg0, arg1)
+	ret0, _ := ret[0].(*api.ImportProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockImportProfileRepoMockRecorder) Put(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockImportProfileRepo)(nil).Put), arg0, arg1)
+}
@@ -25,12 +25,16 @@ import (
 
 type IndividualRepo interface {
 	GetAll(ctx context.Context, options api.ListIndividualsOptions) ([]*api.Individual, error)
+	// Iterate returns an iterator over the individuals matching the options, fetched batchSize at a time
+	Iterate(options api.ListIndividualsOptions, batchSize int) api.IndividualIterator
 	GetByID(ctx context.Context, id string) (*api.Individual, error)
 	Put(ctx context.Context, individual *api.Individual, fields containers.StringSet) (*api.Individual, error)
 	PutMany(ctx context.Context, individuals []*api.Individual, fields containers.StringSet) ([]*api.Individual, error)
 	PerformAction(ctx context.Context, id string, action string) error
 	PerformActionMany(ctx context.Context, ids containers.StringSet, action string) error
-	FindDuplicates(ctx context.Context, individuals []*api.Individual, deduplicationConfig deduplication.DeduplicationConfig) ([]containers.Set[int], map[int][]*api.Individual, error) 
+	// FindDuplicates looks for duplicates among the individuals and against the registered individuals of the selected
+	// country. The registered individuals are restricted to the given collection offices, unless offices is nil.
+	FindDuplicates(ctx context.Context, individuals []*api.Individual, deduplicationConfig deduplication.DeduplicationConfig, offices []string) ([]containers.Set[int], map[int][]*api.Individual, error) 
 }
 
 type individualRepo struct {
@@ -47,9 +51,9 @@ type dbDuplicateRet struct {
 	Idx int `db:"idx"`
 }
 
-func (i individualRepo) FindDuplicates(ctx context.Context, individuals []*api.Individual, deduplicationConfig deduplication.DeduplicationConfig) ([]containers.Set[int], map[int][]*api.Individual, error) {
+func (i individualRepo) FindDuplicates(ctx context.Context, individuals []*api.Individual, deduplicationConfig deduplication.DeduplicationConfig, offices []string) ([]containers.Set[int], map[int][]*api.Individual, error) {
 	ret, err := doInTransaction(ctx, i.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
-		fileDuplicates, dbDuplicates, err := i.findDuplicatesInternal(ctx, tx, individuals, deduplicationConfig)
+		fileDuplicates, dbDuplicates, err := i.findDuplicatesInternal(ctx, tx, individuals, deduplicationConfig, offices)
 		return []interface{}{fileDuplicates, dbDuplicates}, err
 	})
 	if err != nil {
@@ -58,7 +62,7 @@ func (i individualRepo) FindDuplicates(ctx context.Context, individuals []*api.I
 	return ret.([]interface{})[0].([]containers.Set[int]), ret.([]interface{})[1].(map[int][]*api.Individual), nil 
 }
 
-func (i individualRepo) findDuplicatesInternal(ctx context.Context, tx *sqlx.Tx, individuals []*api.Individual, config deduplication.DeduplicationConfig) ([]containers.Set[int], map[int][]*api.Individual, error) {
+func (i individualRepo) findDuplicatesInternal(ctx context.Context, tx *sqlx.Tx, individuals []*api.Individual, config deduplication.DeduplicationConfig, offices []string) ([]containers.Set[int], map[int][]*api.Individual, error) {
 	if i.driverName() != "postgres" {
 		return nil, nil, fmt.Errorf("deduplication is only implemented for postgres")
 	}
@@ -83,7 +87,7 @@ func (i individualRepo) findDuplicatesInternal(ctx context.Context, tx *sqlx.Tx,
 		}
 	}
 
-	dbDuplicates, err := findDbDuplicates(ctx, tx, deduplicationTempTableConfig, config, selectedCountryID)
+	dbDuplicates, err := findDbDuplicates(ctx, tx, deduplicationTempTableConfig, config, selectedCountryID, offices)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -94,7 +98,7 @@ func (i individualRepo) findDuplicatesInternal(ctx context.Context, tx *sqlx.Tx,
 	return nil, nil, nil	
 }
 
-func findDbDuplicates(ctx context.Context, tx *sqlx.Tx, deduplicationTempTableConfig *DeduplicationTempTableConfig, config deduplication.DeduplicationConfig, selectedCountryID string) (map[int][]*api.Individual, error) {
+func findDbDuplicates(ctx context.Context, tx *sqlx.Tx, deduplicationTempTableConfig *DeduplicationTempTableConfig, config deduplication.DeduplicationConfig, selectedCountryID string, offices []string) (map[int][]*api.Individual, error) {
 	ret := make([]*dbDuplicateRet, 0)
 
 	// now we look for duplicates in a cross join between the temp table and the individual_registrations table
@@ -103,8 +107,13 @@ func findDbDuplicates(ctx context.Context, tx *sqlx.Tx, deduplicationTempTableCo
 		deduplicationTempTableConfig.columnsOfInterest,
 		config,
 		deduplicationTempTableConfig.schema,
+		offices,
 	)
-	err := tx.SelectContext(ctx, &ret, deduplicationQuery, selectedCountryID)
+	args := []interface{}{selectedCountryID}
+	for _, office := range offices {
+		args = append(args, office)
+	}
+	err := tx.SelectContext(ctx, &ret, deduplicationQuery, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -186,6 +195,7 @@ func prepareDeduplicationTempTable(ctx context.Context, tx *sqlx.Tx, individuals
 		for c := range cols {
 			columnsOfInterest = append(columnsOfInterest, cols[c])
 		}
+		columnsOfInterest = append(columnsOfInterest, d.Config.QueryColumns...)
 	}
 
 	// we create a temp table with the relevant columns
@@ -198,6 +208,7 @@ func prepareDeduplicationTempTable(ctx context.Context, tx *sqlx.Tx, individuals
 	}
 
 	// we insert the data from the upload into the temp table
+	normalizePhoneNumbers(ctx, individuals)
 	err = insertTempIndividuals(ctx, tx, tempTableName, schema, individuals, columnsOfInterest)
 	if err != nil { 
 		return nil, fmt.Errorf("failed to insert into temp table")
@@ -368,7 +379,9 @@ SELECT DISTINCT  ir.birth_date,ir.email_1,ir.email_2,ir.email_3,ir.first_name,ir
 		AND (ti.first_name = ir.first_name AND ti.middle_name = ir.middle_name AND ti.last_name = ir.last_name AND ti.native_name = ir.native_name)
 		AND (ti.email_1 != '' OR ti.email_2 != '' OR ti.email_3 != '' OR ti.first_name != '' OR ti.middle_name != '' OR ti.last_name != '' OR ti.native_name != '')));
 */
-func buildDbDeduplicationQuery(tempTableName string, columnsOfInterest []string, config deduplication.DeduplicationConfig, schema []DBColumn) string {
+// buildDbDeduplicationQuery builds the query looking for the registered duplicates of the individuals in the temp table.
+// The country is the first argument and the offices, when they are not nil, the following ones.
+func buildDbDeduplicationQuery(tempTableName string, columnsOfInterest []string, config deduplication.DeduplicationConfig, schema []DBColumn, offices []string) string {
 	b := &strings.Builder{}
 
 	b.WriteString(fmt.Sprintf("SELECT DISTINCT ti.idx, ir.id, ir.%s", constants.DBColumnIndividualLastName))
@@ -382,6 +395,17 @@ func buildDbDeduplicationQuery(tempTableName string, columnsOfInterest []string,
 	b.WriteString(" FROM individual_registrations ir")
 	b.WriteString(fmt.Sprintf(" CROSS JOIN %s ti", tempTableName))
 	b.WriteString(" WHERE ir.country_id = $1 AND ir.deleted_at IS NULL")
+	if offices != nil {
+		if len(offices) == 0 {
+			b.WriteString(" AND 1 = 0")
+		} else {
+			placeholders := make([]string, len(offices))
+			for i := range offices {
+				placeholders[i] = fmt.Sprintf("$%d", i+2)
+			}
+			b.WriteString(fmt.Sprintf(" AND ir.%s IN (%s)", constants.DBColumnIndividualCollectionOffice, strings.Join(placeholders, ",")))
+		}
+	}
 
 	subQueries := []string{}
 	notEmptyPartialChecks := []string{}
@@ -419,7 +443,11 @@ func buildDbDeduplicationQuery(tempTableName string, columnsOfInterest []string,
 }
 
 func (i individualRepo) driverName() string {
-	d := i.db.DriverName()
+	return getDriverName(i.db)
+}
+
+func getDriverName(db *sqlx.DB) string {
+	d := db.DriverName()
 	if d == "sqlite3" {
 		return "sqlite"
 	} else if d == "postgres" {
@@ -536,6 +564,8 @@ func (i individualRepo) putManyInternal(ctx context.Context, tx *sqlx.Tx, indivi
 	now := time.Now().UTC()
 	nowStr := now.Format(time.RFC3339)
 
+	normalizePhoneNumbers(ctx, individuals)
+
 	fieldsSet := fields.Clone()
 	if fieldsSet.Contains("phone_number_1") {
 		fieldsSet.Add("normalized_phone_number_1")
@@ -614,6 +644,22 @@ func (i individualRepo) putManyInternal(ctx context.Context, tx *sqlx.Tx, indivi
 	return ret, nil
 }
 
+// normalizePhoneNumbers sets the normalized phone numbers of the individuals, reading national numbers
+// with the calling code of their country
+func normalizePhoneNumbers(ctx context.Context, individuals []*api.Individual) {
+	regions := map[string]string{}
+	for _, individual := range individuals {
+		region, ok := regions[individual.CountryID]
+		if !ok {
+			if country, err := utils.GetCountry(ctx, individual.CountryID); err == nil {
+				region = country.PhoneNumberRegion()
+			}
+			regions[individual.CountryID] = region
+		}
+		individual.NormalizePhoneNumbers(region)
+	}
+}
+
 func (i individualRepo) Put(ctx context.Context, individual *api.Individual, fields containers.StringSet) (*api.Individual, error) {
 	ret, err := doInTransaction(ctx, i.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
 		return i.putInternal(ctx, tx, individual, fields)
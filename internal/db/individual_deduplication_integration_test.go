@@ -19,8 +19,6 @@ func Seed(ctx context.Context, sqlDb *sqlx.DB) *api.Country {
 	countryDef := &api.Country{
 		Code: "NO",
 		Name: "Norway",
-		ReadGroup: "read",
-		WriteGroup: "write",
 	}
 	country, err := countryRepo.Put(ctx, countryDef)
 	if err != nil {
@@ -826,7 +824,7 @@ func TestDeduplication(t *testing.T) {
 				t.Fatalf("Failed to seed database: %s", err)
 			}
 
-			fileDupes, dbDupes, err := individualRepo.FindDuplicates(ctx, tt.individuals, tt.deduplicationConfig)
+			fileDupes, dbDupes, err := individualRepo.FindDuplicates(ctx, tt.individuals, tt.deduplicationConfig, nil)
 
 			if err != nil {
 				t.Fatalf("Failed to deduplicate: %s", err)
@@ -849,4 +847,59 @@ func TestDeduplication(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestDeduplicationRestrictedToOffices(t *testing.T) {
+	pool, resource := InitTestDocker("5432")
+	defer pool.Purge(resource)
+
+	ctx := context.Background()
+	sqlDb := OpenDatabaseConnection(ctx, pool, resource, "5432")
+	defer sqlDb.Close()
+
+	RunMigrations(ctx, sqlDb)
+
+	country := Seed(ctx, sqlDb)
+	ctx = utils.WithSelectedCountryID(ctx, country.ID)
+
+	individualRepo := NewIndividualRepo(sqlDb)
+	seed, err := individualRepo.PutMany(ctx, []*api.Individual{
+		{CountryID: country.ID, CollectionOffice: "Oslo", FreeField1: "Lorem ipsum"},
+	}, constants.IndividualDBColumns)
+	if err != nil {
+		t.Fatalf("Failed to seed database: %s", err)
+	}
+
+	config := deduplication.DeduplicationConfig{
+		Operator: deduplication.LOGICAL_OPERATOR_AND,
+		Types: []deduplication.DeduplicationType{
+			deduplication.DeduplicationTypes[deduplication.DeduplicationTypeNameFreeField1],
+		},
+	}
+
+	tests := []struct {
+		name    string
+		offices []string
+		wantIDs []string
+	}{
+		{name: "all offices", offices: nil, wantIDs: []string{seed[0].ID}},
+		{name: "office of the duplicate", offices: []string{"Oslo"}, wantIDs: []string{seed[0].ID}},
+		{name: "other office", offices: []string{"Bergen"}, wantIDs: nil},
+		{name: "no office", offices: []string{}, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			individuals := []*api.Individual{{CountryID: country.ID, FreeField1: "Lorem ipsum"}}
+			_, dbDupes, err := individualRepo.FindDuplicates(ctx, individuals, config, tt.offices)
+			if err != nil {
+				t.Fatalf("Failed to deduplicate: %s", err)
+			}
+
+			var gotIDs []string
+			for _, ind := range dbDupes[0] {
+				gotIDs = append(gotIDs, ind.ID)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"io"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+// defaultIteratorBatchSize is the number of individuals fetched per query when no batch size is given
+const defaultIteratorBatchSize = 1000
+
+// individualIterator pages through the individuals matching the options using keyset pagination
+// on the id column, so that each batch is a cheap, independent query.
+// Individuals are yielded ordered by id; the sort options are ignored.
+type individualIterator struct {
+	repo      individualRepo
+	options   api.ListIndividualsOptions
+	batchSize int
+	lastID    string
+	yielded   int
+	done      bool
+}
+
+func (i individualRepo) Iterate(options api.ListIndividualsOptions, batchSize int) api.IndividualIterator {
+	if batchSize <= 0 {
+		batchSize = defaultIteratorBatchSize
+	}
+	return &individualIterator{repo: i, options: options, batchSize: batchSize}
+}
+
+func (it *individualIterator) Next(ctx context.Context) ([]*api.Individual, error) {
+	if it.done {
+		return nil, io.EOF
+	}
+
+	limit := it.batchSize
+	if it.options.Take != 0 {
+		limit = utils.Min(limit, it.options.Take-it.yielded)
+	}
+	if limit <= 0 {
+		it.done = true
+		return nil, io.EOF
+	}
+
+	ret, err := doInTransaction(ctx, it.repo.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
+		return it.nextInternal(ctx, tx, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := ret.([]*api.Individual)
+	if len(batch) < limit {
+		it.done = true
+	}
+	if len(batch) == 0 {
+		return nil, io.EOF
+	}
+	it.lastID = batch[len(batch)-1].ID
+	it.yielded += len(batch)
+	return batch, nil
+}
+
+func (it *individualIterator) nextInternal(ctx context.Context, tx *sqlx.Tx, limit int) ([]*api.Individual, error) {
+	l := logging.NewLogger(ctx)
+
+	auditDuration := logDuration(ctx, "iterate individuals", zap.Int("limit", limit))
+	defer auditDuration()
+
+	var ret []*api.Individual
+	sql, args := newIterateIndividualsSQLQuery(it.repo.driverName(), it.options, it.lastID, limit).build()
+	if err := tx.SelectContext(ctx, &ret, sql, args...); err != nil {
+		l.Error("failed to iterate individuals", zap.Error(err))
+		return nil, err
+	}
+	return ret, nil
+}
@@ -10,6 +10,8 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	api "github.com/nrc-no/notcore/internal/api"
+	containers "github.com/nrc-no/notcore/internal/containers"
+	deduplication "github.com/nrc-no/notcore/pkg/api/deduplication"
 )
 
 // MockIndividualRepo is a mock of IndividualRepo interface.
@@ -35,6 +37,22 @@ func (m *MockIndividualRepo) EXPECT() *MockIndividualRepoMockRecorder {
 	return m.recorder
 }
 
+// FindDuplicates mocks base method.
+func (m *MockIndividualRepo) FindDuplicates(arg0 context.Context, arg1 []*api.Individual, arg2 deduplication.DeduplicationConfig, arg3 []string) ([]containers.Set[int], map[int][]*api.Individual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicates", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]containers.Set[int])
+	ret1, _ := ret[1].(map[int][]*api.Individual)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindDuplicates indicates an expected call of FindDuplicates.
+func (mr *MockIndividualRepoMockRecorder) FindDuplicates(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockIndividualRepo)(nil).FindDuplicates), arg0, arg1, arg2, arg3)
+}
+
 // GetAll mocks base method.
 func (m *MockIndividualRepo) GetAll(arg0 context.Context, arg1 api.ListIndividualsOptions) ([]*api.Individual, error) {
 	m.ctrl.T.Helper()
@@ -65,8 +83,50 @@ func (mr *MockIndividualRepoMockRecorder) GetByID(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockIndividualRepo)(nil).GetByID), arg0, arg1)
 }
 
+// Iterate mocks base method.
+func (m *MockIndividualRepo) Iterate(arg0 api.ListIndividualsOptions, arg1 int) api.IndividualIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", arg0, arg1)
+	ret0, _ := ret[0].(api.IndividualIterator)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate.
+func (mr *MockIndividualRepoMockRecorder) Iterate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockIndividualRepo)(nil).Iterate), arg0, arg1)
+}
+
+// PerformAction mocks base method.
+func (m *MockIndividualRepo) PerformAction(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PerformAction", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PerformAction indicates an expected call of PerformAction.
+func (mr *MockIndividualRepoMockRecorder) PerformAction(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformAction", reflect.TypeOf((*MockIndividualRepo)(nil).PerformAction), arg0, arg1, arg2)
+}
+
+// PerformActionMany mocks base method.
+func (m *MockIndividualRepo) PerformActionMany(arg0 context.Context, arg1 containers.StringSet, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PerformActionMany", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PerformActionMany indicates an expected call of PerformActionMany.
+func (mr *MockIndividualRepoMockRecorder) PerformActionMany(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformActionMany", reflect.TypeOf((*MockIndividualRepo)(nil).PerformActionMany), arg0, arg1, arg2)
+}
+
 // Put mocks base method.
-func (m *MockIndividualRepo) Put(arg0 context.Context, arg1 *api.Individual, arg2 []string) (*api.Individual, error) {
+func (m *MockIndividualRepo) Put(arg0 context.Context, arg1 *api.Individual, arg2 containers.StringSet) (*api.Individual, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Put", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*api.Individual)
@@ -81,7 +141,7 @@ func (mr *MockIndividualRepoMockRecorder) Put(arg0, arg1, arg2 interface{}) *gom
 }
 
 // PutMany mocks base method.
-func (m *MockIndividualRepo) PutMany(arg0 context.Context, arg1 []*api.Individual, arg2 []string) ([]*api.Individual, error) {
+func (m *MockIndividualRepo) PutMany(arg0 context.Context, arg1 []*api.Individual, arg2 containers.StringSet) ([]*api.Individual, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PutMany", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]*api.Individual)
@@ -94,17 +154,3 @@ func (mr *MockIndividualRepoMockRecorder) PutMany(arg0, arg1, arg2 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutMany", reflect.TypeOf((*MockIndividualRepo)(nil).PutMany), arg0, arg1, arg2)
 }
-
-// SoftDelete mocks base method.
-func (m *MockIndividualRepo) SoftDelete(arg0 context.Context, arg1 string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SoftDelete", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// SoftDelete indicates an expected call of SoftDelete.
-func (mr *MockIndividualRepoMockRecorder) SoftDelete(arg0, arg1 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockIndividualRepo)(nil).SoftDelete), arg0, arg1)
-}
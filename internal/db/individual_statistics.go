@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+type IndividualStatisticsRepo interface {
+	// GetStatistics counts the individuals matching the options. The disabilities are only counted
+	// when withDisability is true, since they are health data.
+	GetStatistics(ctx context.Context, options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) (*api.IndividualStatistics, error)
+}
+
+type individualStatisticsRepo struct {
+	db *sqlx.DB
+}
+
+func NewIndividualStatisticsRepo(db *sqlx.DB) IndividualStatisticsRepo {
+	return &individualStatisticsRepo{db: db}
+}
+
+func (s individualStatisticsRepo) GetStatistics(ctx context.Context, options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) (*api.IndividualStatistics, error) {
+	ret, err := doInTransaction(ctx, s.db, func(ctx context.Context, tx *sqlx.Tx) (interface{}, error) {
+		return s.getStatisticsInternal(ctx, tx, options, ageBands, withDisability)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*api.IndividualStatistics), nil
+}
+
+func (s individualStatisticsRepo) getStatisticsInternal(ctx context.Context, tx *sqlx.Tx, options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) (*api.IndividualStatistics, error) {
+	l := logging.NewLogger(ctx)
+	l.Debug("getting individual statistics", zap.Any("options", options))
+
+	auditDuration := logDuration(ctx, "get individual statistics")
+	defer auditDuration()
+
+	driverName := getDriverName(s.db)
+	stats := &api.IndividualStatistics{}
+
+	sql, args := newIndividualStatisticsSQLQuery(driverName, options, "COUNT(*)").build()
+	if err := tx.GetContext(ctx, &stats.Total, sql, args...); err != nil {
+		l.Error("failed to count individuals", zap.Error(err))
+		return nil, err
+	}
+
+	if withDisability {
+		stats.ByDisability = &api.DisabilityCounts{}
+		sql, args = newIndividualStatisticsSQLQuery(driverName, options, disabilityCountsSQLExpression()).build()
+		if err := tx.GetContext(ctx, stats.ByDisability, sql, args...); err != nil {
+			l.Error("failed to count individuals by disability", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	countBy := []struct {
+		name    string
+		keyExpr string
+		into    interface{}
+	}{
+		{"sex", coalesceEmptySQLExpression(constants.DBColumnIndividualSex), &stats.BySex},
+		{"displacement status", coalesceEmptySQLExpression(constants.DBColumnIndividualDisplacementStatus), &stats.ByDisplacementStatus},
+		{"administrative area", coalesceEmptySQLExpression(constants.DBColumnIndividualCollectionAdministrativeArea1), &stats.ByAdministrativeArea},
+		{"registration month", registrationMonthSQLExpression(driverName), &stats.ByRegistrationMonth},
+	}
+	for _, c := range countBy {
+		sql, args = newIndividualCountBySQLQuery(driverName, options, c.keyExpr).build()
+		if err := tx.SelectContext(ctx, c.into, sql, args...); err != nil {
+			l.Error("failed to count individuals by "+c.name, zap.Error(err))
+			return nil, err
+		}
+	}
+
+	var byAgeBand []api.StatisticsCount
+	sql, args = newIndividualCountBySQLQuery(driverName, options, ageBandSQLExpression(driverName, ageBands)).build()
+	if err := tx.SelectContext(ctx, &byAgeBand, sql, args...); err != nil {
+		l.Error("failed to count individuals by age band", zap.Error(err))
+		return nil, err
+	}
+	stats.ByAgeBand = sortByAgeBands(byAgeBand, ageBands)
+
+	return stats, nil
+}
+
+// sortByAgeBands returns the counts in the order of the given bands, including empty bands.
+// Individuals without a known age are reported last, under an empty key.
+func sortByAgeBands(counts []api.StatisticsCount, ageBands api.AgeBands) []api.StatisticsCount {
+	countByLabel := map[string]int{}
+	for _, c := range counts {
+		countByLabel[c.Key] = c.Count
+	}
+	ret := make([]api.StatisticsCount, 0, len(ageBands)+1)
+	for _, band := range ageBands {
+		label := band.Label()
+		ret = append(ret, api.StatisticsCount{Key: label, Count: countByLabel[label]})
+	}
+	if unknown := countByLabel[""]; unknown > 0 {
+		ret = append(ret, api.StatisticsCount{Key: "", Count: unknown})
+	}
+	return ret
+}
+
+// cachedIndividualStatisticsRepo keeps the statistics in memory for a short while, since computing them
+// requires scanning all the registrations of a country.
+type cachedIndividualStatisticsRepo struct {
+	repo    IndividualStatisticsRepo
+	ttl     time.Duration
+	now     func() time.Time
+	lock    sync.Mutex
+	entries map[string]cachedIndividualStatistics
+}
+
+type cachedIndividualStatistics struct {
+	stats     *api.IndividualStatistics
+	expiresAt time.Time
+}
+
+func NewCachedIndividualStatisticsRepo(repo IndividualStatisticsRepo, ttl time.Duration) IndividualStatisticsRepo {
+	return &cachedIndividualStatisticsRepo{
+		repo:    repo,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cachedIndividualStatistics{},
+	}
+}
+
+func (c *cachedIndividualStatisticsRepo) GetStatistics(ctx context.Context, options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) (*api.IndividualStatistics, error) {
+	key := statisticsCacheKey(options, ageBands, withDisability)
+
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	c.lock.Unlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		return entry.stats, nil
+	}
+
+	stats, err := c.repo.GetStatistics(ctx, options, ageBands, withDisability)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedIndividualStatistics{stats: stats, expiresAt: now.Add(c.ttl)}
+	return stats, nil
+}
+
+func statisticsCacheKey(options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) string {
+	options.Skip = 0
+	options.Take = 0
+	options.Sort = nil
+	return fmt.Sprintf("%s#%s#%t", options.QueryParams(), ageBands.String(), withDisability)
+}
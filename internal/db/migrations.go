@@ -57,6 +57,27 @@ var migrations = []migration{
 	migrationFromFile("033_add_general_vulnerability_fields"),
 	migrationFromFile("034_add_country_read_write_groups"),
 	migrationFromFile("035_add_cc_additional_fields"),
+	migrationFromFile("036_add_exports"),
+	migrationFromFile("037_add_import_profiles"),
+	migrationFromFile("038_add_import_profile_parse_settings"),
+	{name: "039_normalize_phone_numbers_e164", up: normalizePhoneNumbersE164},
+	migrationFromFile("040_add_country_validation_rules"),
+	migrationFromFile("041_add_country_service_catalogue"),
+	migrationFromFile("042_add_country_roles"),
+	migrationFromFile("043_add_country_offices"),
+	migrationFromFile("044_add_country_groups"),
+	migrationFromFile("045_add_service_accounts"),
+	migrationFromFile("046_add_user_sessions"),
+	migrationFromFile("047_add_user_session_tokens"),
+	migrationFromFile("048_add_user_session_impersonation"),
+	migrationFromFile("049_add_access_events"),
+	migrationFromFile("050_drop_country_read_write_groups"),
+	migrationFromFile("051_move_pending_uploads"),
+	migrationFromFile("052_service_account_timestamps_with_time_zone"),
+	migrationFromFile("053_user_session_timestamps_with_time_zone"),
+	migrationFromFile("054_impersonation_expiry_with_time_zone"),
+	migrationFromFile("055_access_event_time_with_time_zone"),
+	migrationFromFile("056_add_read_all_fields_role"),
 }
 
 // Migrate runs the migrations on the database.
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS exports
+(
+    id          uuid                     NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    country_id  uuid                     NOT NULL REFERENCES countries (id),
+    owner_id    varchar(512)             NOT NULL,
+    owner_email varchar(255)             NOT NULL,
+    blob_name   varchar(255)             NOT NULL UNIQUE,
+    format      varchar(16)              NOT NULL,
+    filter      text                     NOT NULL,
+    created_at  timestamp with time zone NOT NULL DEFAULT now(),
+    expires_at  timestamp with time zone NOT NULL,
+    deleted_at  timestamp with time zone NULL
+);
+
+CREATE INDEX IF NOT EXISTS exports_expires_at_idx ON exports (expires_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS import_profiles
+(
+    id               uuid                     NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    country_id       uuid                     NOT NULL REFERENCES countries (id),
+    name             varchar(255)             NOT NULL,
+    header_signature varchar(64)              NOT NULL,
+    mapping          text                     NOT NULL,
+    created_at       timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at       timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (country_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS import_profiles_header_signature_idx ON import_profiles (country_id, header_signature);
+
+ALTER TABLE exports
+    ADD COLUMN IF NOT EXISTS kind varchar(32) NOT NULL DEFAULT 'export';
@@ -0,0 +1,2 @@
+ALTER TABLE import_profiles
+    ADD COLUMN IF NOT EXISTS parse_settings text NOT NULL DEFAULT '{}';
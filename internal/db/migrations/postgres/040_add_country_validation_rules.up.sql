@@ -0,0 +1,2 @@
+ALTER TABLE countries
+    ADD COLUMN IF NOT EXISTS validation_rules text NOT NULL DEFAULT '{}';
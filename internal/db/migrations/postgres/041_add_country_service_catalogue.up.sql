@@ -0,0 +1,2 @@
+ALTER TABLE countries
+    ADD COLUMN IF NOT EXISTS service_catalogue text NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE countries
+    ADD COLUMN IF NOT EXISTS roles text NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE countries
+    ADD COLUMN IF NOT EXISTS offices text NOT NULL DEFAULT '{}';
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS country_groups
+(
+    country_id uuid         NOT NULL REFERENCES countries (id) ON DELETE CASCADE,
+    jwt_group  varchar(255) NOT NULL,
+    role       varchar(255) NOT NULL,
+    PRIMARY KEY (country_id, jwt_group, role)
+);
+
+INSERT INTO country_groups (country_id, jwt_group, role)
+SELECT id, read_group, 'read'
+FROM countries
+WHERE read_group <> ''
+  AND read_group <> 'xxx'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO country_groups (country_id, jwt_group, role)
+SELECT id, write_group, 'write'
+FROM countries
+WHERE write_group <> ''
+  AND write_group <> 'xxx'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO country_groups (country_id, jwt_group, role)
+SELECT c.id, r ->> 'group', r ->> 'id'
+FROM countries c,
+     json_array_elements(c.roles::json -> 'roles') r
+WHERE coalesce(r ->> 'group', '') <> ''
+ON CONFLICT DO NOTHING;
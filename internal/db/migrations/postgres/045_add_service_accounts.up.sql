@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS service_accounts
+(
+    id          uuid         NOT NULL PRIMARY KEY,
+    name        varchar(255) NOT NULL UNIQUE,
+    description text         NOT NULL DEFAULT '',
+    created_at  timestamp    NOT NULL,
+    created_by  varchar(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens
+(
+    id                 uuid         NOT NULL PRIMARY KEY,
+    service_account_id uuid         NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    name               varchar(255) NOT NULL,
+    token_hash         varchar(64)  NOT NULL UNIQUE,
+    scopes             text         NOT NULL DEFAULT '{}',
+    expires_at         timestamp    NOT NULL,
+    created_at         timestamp    NOT NULL,
+    created_by         varchar(255) NOT NULL,
+    revoked_at         timestamp,
+    last_used_at       timestamp
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_service_account_id_idx ON api_tokens (service_account_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_sessions
+(
+    id                  uuid         NOT NULL PRIMARY KEY,
+    token_hash          varchar(64)  NOT NULL UNIQUE,
+    user_id             varchar(255) NOT NULL,
+    email               varchar(255) NOT NULL DEFAULT '',
+    groups              text[]       NOT NULL DEFAULT '{}',
+    user_agent          text         NOT NULL DEFAULT '',
+    ip_address          varchar(255) NOT NULL DEFAULT '',
+    created_at          timestamp    NOT NULL,
+    last_seen_at        timestamp    NOT NULL,
+    groups_refreshed_at timestamp    NOT NULL,
+    expires_at          timestamp    NOT NULL,
+    revoked_at          timestamp,
+    revoked_by          varchar(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS user_sessions_expires_at_idx ON user_sessions (expires_at);
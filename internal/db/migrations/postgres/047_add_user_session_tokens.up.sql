@@ -0,0 +1 @@
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS oidc_tokens text NOT NULL DEFAULT '';
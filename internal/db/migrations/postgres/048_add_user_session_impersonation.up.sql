@@ -0,0 +1,4 @@
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS impersonated_user_id varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS impersonated_email varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS impersonated_groups text[] NOT NULL DEFAULT '{}';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS impersonation_expires_at timestamp;
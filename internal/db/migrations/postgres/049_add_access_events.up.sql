@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS access_events
+(
+    id                   uuid         NOT NULL PRIMARY KEY,
+    occurred_at          timestamp    NOT NULL,
+    kind                 varchar(64)  NOT NULL,
+    user_id              varchar(255) NOT NULL,
+    user_email           varchar(255) NOT NULL DEFAULT '',
+    impersonated_user_id varchar(255) NOT NULL DEFAULT '',
+    country_id           varchar(255) NOT NULL DEFAULT '',
+    individual_ids       text[]       NOT NULL DEFAULT '{}',
+    filter               text         NOT NULL DEFAULT '',
+    row_count            integer      NOT NULL DEFAULT 0,
+    request_id           varchar(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS access_events_occurred_at_idx ON access_events (occurred_at);
+CREATE INDEX IF NOT EXISTS access_events_user_id_idx ON access_events (user_id);
+CREATE INDEX IF NOT EXISTS access_events_individual_ids_idx ON access_events USING GIN (individual_ids);
+
+-- the access events are append-only, so that they can be relied upon when reviewing who accessed the participants
+CREATE OR REPLACE FUNCTION access_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'access_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS access_events_append_only ON access_events;
+CREATE TRIGGER access_events_append_only
+    BEFORE UPDATE OR DELETE ON access_events
+    FOR EACH ROW
+EXECUTE FUNCTION access_events_append_only();
@@ -0,0 +1,32 @@
+-- the columns are already gone on databases that ran 044 before it kept them
+DO
+$$
+    BEGIN
+        IF EXISTS(SELECT 1
+                  FROM information_schema.columns
+                  WHERE table_name = 'countries'
+                    AND column_name = 'read_group') THEN
+            -- keep the columns if a read or write group was not copied to country_groups
+            IF EXISTS(SELECT 1
+                      FROM countries c
+                      WHERE (c.read_group NOT IN ('', 'xxx') AND NOT EXISTS(SELECT 1
+                                                                           FROM country_groups g
+                                                                           WHERE g.country_id = c.id
+                                                                             AND g.jwt_group = c.read_group
+                                                                             AND g.role = 'read'))
+                         OR (c.write_group NOT IN ('', 'xxx') AND NOT EXISTS(SELECT 1
+                                                                            FROM country_groups g
+                                                                            WHERE g.country_id = c.id
+                                                                              AND g.jwt_group = c.write_group
+                                                                              AND g.role = 'write'))) THEN
+                RAISE EXCEPTION 'countries.read_group or write_group is not in country_groups, map the group or clear the column before migrating';
+            END IF;
+
+            ALTER TABLE countries
+                DROP COLUMN read_group;
+
+            ALTER TABLE countries
+                DROP COLUMN write_group;
+        END IF;
+    END
+$$;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS pending_uploads
+(
+    id         uuid                     NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    country_id uuid                     NOT NULL REFERENCES countries (id),
+    owner_id   varchar(512)             NOT NULL,
+    blob_name  varchar(255)             NOT NULL UNIQUE,
+    format     varchar(16)              NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    expires_at timestamp with time zone NOT NULL,
+    deleted_at timestamp with time zone NULL
+);
+
+CREATE INDEX IF NOT EXISTS pending_uploads_expires_at_idx ON pending_uploads (expires_at) WHERE deleted_at IS NULL;
+
+ALTER TABLE exports
+    ADD COLUMN IF NOT EXISTS kind varchar(32) NOT NULL DEFAULT 'export';
+
+INSERT INTO pending_uploads (id, country_id, owner_id, blob_name, format, created_at, expires_at, deleted_at)
+SELECT id, country_id, owner_id, blob_name, format, created_at, expires_at, deleted_at
+FROM exports
+WHERE kind = 'pending_upload'
+ON CONFLICT DO NOTHING;
+
+DELETE
+FROM exports
+WHERE kind = 'pending_upload';
+
+ALTER TABLE exports
+    DROP COLUMN IF EXISTS kind;
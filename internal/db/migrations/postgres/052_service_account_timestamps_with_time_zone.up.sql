@@ -0,0 +1,17 @@
+-- the timestamps were written in UTC, so they are read as UTC while converting them
+SET LOCAL TimeZone = 'UTC';
+
+ALTER TABLE service_accounts
+    ALTER COLUMN created_at TYPE timestamp with time zone;
+
+ALTER TABLE api_tokens
+    ALTER COLUMN expires_at TYPE timestamp with time zone;
+
+ALTER TABLE api_tokens
+    ALTER COLUMN created_at TYPE timestamp with time zone;
+
+ALTER TABLE api_tokens
+    ALTER COLUMN revoked_at TYPE timestamp with time zone;
+
+ALTER TABLE api_tokens
+    ALTER COLUMN last_used_at TYPE timestamp with time zone;
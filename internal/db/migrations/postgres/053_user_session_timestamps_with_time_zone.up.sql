@@ -0,0 +1,17 @@
+-- the timestamps were written in UTC, so they are read as UTC while converting them
+SET LOCAL TimeZone = 'UTC';
+
+ALTER TABLE user_sessions
+    ALTER COLUMN created_at TYPE timestamp with time zone;
+
+ALTER TABLE user_sessions
+    ALTER COLUMN last_seen_at TYPE timestamp with time zone;
+
+ALTER TABLE user_sessions
+    ALTER COLUMN groups_refreshed_at TYPE timestamp with time zone;
+
+ALTER TABLE user_sessions
+    ALTER COLUMN expires_at TYPE timestamp with time zone;
+
+ALTER TABLE user_sessions
+    ALTER COLUMN revoked_at TYPE timestamp with time zone;
@@ -0,0 +1,5 @@
+-- the timestamps were written in UTC, so they are read as UTC while converting them
+SET LOCAL TimeZone = 'UTC';
+
+ALTER TABLE user_sessions
+    ALTER COLUMN impersonation_expires_at TYPE timestamp with time zone;
@@ -0,0 +1,34 @@
+-- the groups with the read role could view all the fields before the roles were added, so the countries whose read
+-- role is mapped to groups get a role viewing and exporting all the fields, mapped to the same groups
+WITH read_countries AS (
+    SELECT c.id AS country_id, gen_random_uuid()::text AS role_id
+    FROM countries c
+    WHERE EXISTS(SELECT 1 FROM country_groups g WHERE g.country_id = c.id AND g.role = 'read')
+),
+     updated_countries AS (
+         UPDATE countries c
+             SET roles = jsonb_set(
+                     c.roles::jsonb,
+                     '{roles}',
+                     coalesce(c.roles::jsonb -> 'roles', '[]'::jsonb) || jsonb_build_array(jsonb_build_object(
+                             'id', r.role_id,
+                             'name', 'Read all fields',
+                             'permissions', jsonb_build_object(
+                                     'general', jsonb_build_array('view', 'export'),
+                                     'contact', jsonb_build_array('view', 'export'),
+                                     'identification', jsonb_build_array('view', 'export'),
+                                     'protection', jsonb_build_array('view', 'export'),
+                                     'health', jsonb_build_array('view', 'export')
+                                 )
+                         ))
+                 )::text
+             FROM read_countries r
+             WHERE c.id = r.country_id
+     )
+INSERT
+INTO country_groups (country_id, jwt_group, role)
+SELECT g.country_id, g.jwt_group, r.role_id
+FROM country_groups g
+         JOIN read_countries r ON r.country_id = g.country_id
+WHERE g.role = 'read'
+ON CONFLICT DO NOTHING;
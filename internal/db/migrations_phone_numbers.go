@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+)
+
+// phoneNumbersMigrationBatchSize is the number of individuals read and updated at once while normalizing
+// the phone numbers, so that the registrations are not all loaded in memory
+const phoneNumbersMigrationBatchSize = 1000
+
+// normalizePhoneNumbersE164 rewrites the normalized phone numbers of the existing individuals in the E.164 form,
+// reading national numbers with the calling code of their country. The individuals are read in batches ordered
+// by id, and only the ones whose normalized phone numbers change are updated.
+func normalizePhoneNumbersE164(ctx context.Context, tx *sqlx.Tx) error {
+	var countries []*api.Country
+	if err := tx.SelectContext(ctx, &countries, "SELECT id, code, name FROM countries"); err != nil {
+		return err
+	}
+	regions := make(map[string]string, len(countries))
+	for _, country := range countries {
+		regions[country.ID] = country.PhoneNumberRegion()
+	}
+
+	const query = `SELECT id, country_id, nationality_1, phone_number_1, phone_number_2, phone_number_3,
+		normalized_phone_number_1, normalized_phone_number_2, normalized_phone_number_3
+		FROM individual_registrations
+		WHERE (phone_number_1 != '' OR phone_number_2 != '' OR phone_number_3 != '') AND id > $1
+		ORDER BY id
+		LIMIT $2`
+
+	afterID := uuid.Nil.String()
+	for {
+		var individuals []*api.Individual
+		if err := tx.SelectContext(ctx, &individuals, query, afterID, phoneNumbersMigrationBatchSize); err != nil {
+			return err
+		}
+		if len(individuals) == 0 {
+			return nil
+		}
+
+		var changed []*api.Individual
+		for _, individual := range individuals {
+			before := [3]string{individual.NormalizedPhoneNumber1, individual.NormalizedPhoneNumber2, individual.NormalizedPhoneNumber3}
+			individual.NormalizePhoneNumbers(regions[individual.CountryID])
+			if before != [3]string{individual.NormalizedPhoneNumber1, individual.NormalizedPhoneNumber2, individual.NormalizedPhoneNumber3} {
+				changed = append(changed, individual)
+			}
+		}
+		if len(changed) > 0 {
+			updateQuery, args := normalizedPhoneNumbersUpdateQuery(changed)
+			if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+				return err
+			}
+		}
+
+		if len(individuals) < phoneNumbersMigrationBatchSize {
+			return nil
+		}
+		afterID = individuals[len(individuals)-1].ID
+	}
+}
+
+// normalizedPhoneNumbersUpdateQuery builds a single statement updating the normalized phone numbers of the individuals
+func normalizedPhoneNumbersUpdateQuery(individuals []*api.Individual) (string, []interface{}) {
+	values := make([]string, 0, len(individuals))
+	args := make([]interface{}, 0, len(individuals)*4)
+	for i, individual := range individuals {
+		n := i * 4
+		values = append(values, fmt.Sprintf("($%d::uuid, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
+		args = append(args,
+			individual.ID,
+			individual.NormalizedPhoneNumber1,
+			individual.NormalizedPhoneNumber2,
+			individual.NormalizedPhoneNumber3,
+		)
+	}
+	query := `UPDATE individual_registrations AS ir
+		SET normalized_phone_number_1 = v.n1, normalized_phone_number_2 = v.n2, normalized_phone_number_3 = v.n3
+		FROM (VALUES ` + strings.Join(values, ", ") + `) AS v (id, n1, n2, n3)
+		WHERE ir.id = v.id`
+	return query, args
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_normalizedPhoneNumbersUpdateQuery(t *testing.T) {
+	gotSql, gotArgs := normalizedPhoneNumbersUpdateQuery([]*api.Individual{
+		{ID: "a", NormalizedPhoneNumber1: "+254712345678"},
+		{ID: "b", NormalizedPhoneNumber2: "+4712345678", NormalizedPhoneNumber3: "+4787654321"},
+	})
+	assert.Equal(t, `UPDATE individual_registrations AS ir
+		SET normalized_phone_number_1 = v.n1, normalized_phone_number_2 = v.n2, normalized_phone_number_3 = v.n3
+		FROM (VALUES ($1::uuid, $2, $3, $4), ($5::uuid, $6, $7, $8)) AS v (id, n1, n2, n3)
+		WHERE ir.id = v.id`, gotSql)
+	assert.Equal(t, []interface{}{
+		"a", "+254712345678", "", "",
+		"b", "", "+4712345678", "+4787654321",
+	}, gotArgs)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./pending_upload_mock.go -package=db . PendingUploadRepo
+
+type PendingUploadRepo interface {
+	Create(ctx context.Context, pendingUpload *api.PendingUpload) (*api.PendingUpload, error)
+	GetByID(ctx context.Context, id string) (*api.PendingUpload, error)
+	// GetExpired returns at most limit pending uploads that expired before the given time and were not deleted yet
+	GetExpired(ctx context.Context, before time.Time, limit int) ([]*api.PendingUpload, error)
+	MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error
+}
+
+type pendingUploadRepo struct {
+	db *sqlx.DB
+}
+
+func NewPendingUploadRepo(db *sqlx.DB) PendingUploadRepo {
+	return &pendingUploadRepo{db: db}
+}
+
+func (p pendingUploadRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (p pendingUploadRepo) Create(ctx context.Context, pendingUpload *api.PendingUpload) (*api.PendingUpload, error) {
+	l := p.logger(ctx)
+	l.Debug("creating pending upload")
+
+	if pendingUpload.ID == "" {
+		pendingUpload.ID = uuid.New().String()
+	}
+	if pendingUpload.CreatedAt.IsZero() {
+		pendingUpload.CreatedAt = time.Now().UTC()
+	}
+
+	const query = `INSERT INTO pending_uploads (id, country_id, owner_id, blob_name, format, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	var args = []interface{}{
+		pendingUpload.ID,
+		pendingUpload.CountryID,
+		pendingUpload.OwnerID,
+		pendingUpload.BlobName,
+		pendingUpload.Format,
+		pendingUpload.CreatedAt,
+		pendingUpload.ExpiresAt,
+	}
+
+	auditDuration := logDuration(ctx, "create pending upload")
+	defer auditDuration()
+
+	if _, err := p.db.ExecContext(ctx, query, args...); err != nil {
+		l.Error("failed to create pending upload", zap.Error(err))
+		return nil, err
+	}
+
+	return pendingUpload, nil
+}
+
+func (p pendingUploadRepo) GetByID(ctx context.Context, id string) (*api.PendingUpload, error) {
+	l := p.logger(ctx).With(zap.String("pending_upload_id", id))
+	l.Debug("getting pending upload by id")
+
+	const query = "SELECT * FROM pending_uploads WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "get pending upload by id")
+	defer auditDuration()
+
+	var pendingUpload api.PendingUpload
+	if err := p.db.GetContext(ctx, &pendingUpload, query, id); err != nil {
+		l.Error("failed to get pending upload by id", zap.Error(err))
+		return nil, err
+	}
+	return &pendingUpload, nil
+}
+
+func (p pendingUploadRepo) GetExpired(ctx context.Context, before time.Time, limit int) ([]*api.PendingUpload, error) {
+	l := p.logger(ctx)
+	l.Debug("getting expired pending uploads")
+
+	const query = "SELECT * FROM pending_uploads WHERE deleted_at IS NULL AND expires_at < $1 ORDER BY expires_at LIMIT $2"
+
+	auditDuration := logDuration(ctx, "get expired pending uploads")
+	defer auditDuration()
+
+	var pendingUploads []*api.PendingUpload
+	if err := p.db.SelectContext(ctx, &pendingUploads, query, before, limit); err != nil {
+		l.Error("failed to get expired pending uploads", zap.Error(err))
+		return nil, err
+	}
+	return pendingUploads, nil
+}
+
+func (p pendingUploadRepo) MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error {
+	l := p.logger(ctx).With(zap.String("pending_upload_id", id))
+	l.Debug("marking pending upload as deleted")
+
+	const query = "UPDATE pending_uploads SET deleted_at = $2 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "mark pending upload deleted")
+	defer auditDuration()
+
+	if _, err := p.db.ExecContext(ctx, query, id, deletedAt); err != nil {
+		l.Error("failed to mark pending upload as deleted", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: PendingUploadRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+)
+
+// MockPendingUploadRepo is a mock of PendingUploadRepo interface.
+type MockPendingUploadRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingUploadRepoMockRecorder
+}
+
+// MockPendingUploadRepoMockRecorder is the mock recorder for MockPendingUploadRepo.
+type MockPendingUploadRepoMockRecorder struct {
+	mock *MockPendingUploadRepo
+}
+
+// NewMockPendingUploadRepo creates a new mock instance.
+func NewMockPendingUploadRepo(ctrl *gomock.Controller) *MockPendingUploadRepo {
+	mock := &MockPendingUploadRepo{ctrl: ctrl}
+	mock.recorder = &MockPendingUploadRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingUploadRepo) EXPECT() *MockPendingUploadRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPendingUploadRepo) Create(arg0 context.Context, arg1 *api.PendingUpload) (*api.PendingUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*api.PendingUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPendingUploadRepoMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPendingUploadRepo)(nil).Create), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockPendingUploadRepo) GetByID(arg0 context.Context, arg1 string) (*api.PendingUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*api.PendingUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPendingUploadRepoMockRecorder) GetByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPendingUploadRepo)(nil).GetByID), arg0, arg1)
+}
+
+// GetExpired mocks base method.
+func (m *MockPendingUploadRepo) GetExpired(arg0 context.Context, arg1 time.Time, arg2 int) ([]*api.PendingUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpired", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.PendingUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpired indicates an expected call of GetExpired.
+func (mr *MockPendingUploadRepoMockRecorder) GetExpired(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpired", reflect.TypeOf((*MockPendingUploadRepo)(nil).GetExpired), arg0, arg1, arg2)
+}
+
+// MarkDeleted mocks base method.
+func (m *MockPendingUploadRepo) MarkDeleted(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeleted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeleted indicates an expected call of MarkDeleted.
+func (mr *MockPendingUploadRepoMockRecorder) MarkDeleted(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeleted", reflect.TypeOf((*MockPendingUploadRepo)(nil).MarkDeleted), arg0, arg1, arg2)
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./service_account_mock.go -package=db . ServiceAccountRepo
+
+type ServiceAccountRepo interface {
+	GetAll(ctx context.Context) ([]*api.ServiceAccount, error)
+	GetByID(ctx context.Context, id string) (*api.ServiceAccount, error)
+	// Create creates the service account with a new id
+	Create(ctx context.Context, account *api.ServiceAccount) (*api.ServiceAccount, error)
+	// GetTokens returns the API tokens of the service account, the most recent first
+	GetTokens(ctx context.Context, serviceAccountID string) ([]*api.APIToken, error)
+	// GetTokenByHash returns the API token with the given hash, or nil if there is none
+	GetTokenByHash(ctx context.Context, hash string) (*api.APIToken, error)
+	// CreateToken creates the API token with a new id
+	CreateToken(ctx context.Context, token *api.APIToken) (*api.APIToken, error)
+	// RevokeToken revokes the API token of the service account. Revoking a revoked token does nothing.
+	RevokeToken(ctx context.Context, serviceAccountID string, tokenID string) error
+	// TouchToken records that the API token was used
+	TouchToken(ctx context.Context, tokenID string, usedAt time.Time) error
+}
+
+type serviceAccountRepo struct {
+	db *sqlx.DB
+}
+
+func NewServiceAccountRepo(db *sqlx.DB) ServiceAccountRepo {
+	return &serviceAccountRepo{db: db}
+}
+
+func (s serviceAccountRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (s serviceAccountRepo) GetAll(ctx context.Context) ([]*api.ServiceAccount, error) {
+	l := s.logger(ctx)
+	l.Debug("getting service accounts")
+
+	const query = "SELECT * FROM service_accounts ORDER BY name"
+
+	auditDuration := logDuration(ctx, "get service accounts")
+	defer auditDuration()
+
+	var accounts []*api.ServiceAccount
+	if err := s.db.SelectContext(ctx, &accounts, query); err != nil {
+		l.Error("failed to get service accounts", zap.Error(err))
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s serviceAccountRepo) GetByID(ctx context.Context, id string) (*api.ServiceAccount, error) {
+	l := s.logger(ctx).With(zap.String("service_account_id", id))
+	l.Debug("getting service account by id")
+
+	const query = "SELECT * FROM service_accounts WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "get service account by id")
+	defer auditDuration()
+
+	var account api.ServiceAccount
+	if err := s.db.GetContext(ctx, &account, query, id); err != nil {
+		l.Error("failed to get service account by id", zap.Error(err))
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (s serviceAccountRepo) Create(ctx context.Context, account *api.ServiceAccount) (*api.ServiceAccount, error) {
+	l := s.logger(ctx)
+	l.Debug("creating service account", zap.String("name", account.Name))
+
+	const query = `INSERT INTO service_accounts (id, name, description, created_at, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *`
+
+	var args = []interface{}{
+		uuid.New().String(),
+		account.Name,
+		account.Description,
+		time.Now().UTC(),
+		account.CreatedBy,
+	}
+
+	auditDuration := logDuration(ctx, "create service account")
+	defer auditDuration()
+
+	var ret api.ServiceAccount
+	if err := s.db.GetContext(ctx, &ret, query, args...); err != nil {
+		l.Error("failed to create service account", zap.Error(err))
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (s serviceAccountRepo) GetTokens(ctx context.Context, serviceAccountID string) ([]*api.APIToken, error) {
+	l := s.logger(ctx).With(zap.String("service_account_id", serviceAccountID))
+	l.Debug("getting api tokens")
+
+	const query = "SELECT * FROM api_tokens WHERE service_account_id = $1 ORDER BY created_at DESC"
+
+	auditDuration := logDuration(ctx, "get api tokens")
+	defer auditDuration()
+
+	var tokens []*api.APIToken
+	if err := s.db.SelectContext(ctx, &tokens, query, serviceAccountID); err != nil {
+		l.Error("failed to get api tokens", zap.Error(err))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s serviceAccountRepo) GetTokenByHash(ctx context.Context, hash string) (*api.APIToken, error) {
+	l := s.logger(ctx)
+	l.Debug("getting api token by hash")
+
+	const query = "SELECT * FROM api_tokens WHERE token_hash = $1"
+
+	auditDuration := logDuration(ctx, "get api token by hash")
+	defer auditDuration()
+
+	var token api.APIToken
+	if err := s.db.GetContext(ctx, &token, query, hash); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		l.Error("failed to get api token by hash", zap.Error(err))
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s serviceAccountRepo) CreateToken(ctx context.Context, token *api.APIToken) (*api.APIToken, error) {
+	l := s.logger(ctx).With(zap.String("service_account_id", token.ServiceAccountID))
+	l.Debug("creating api token", zap.String("name", token.Name))
+
+	const query = `INSERT INTO api_tokens (id, service_account_id, name, token_hash, scopes, expires_at, created_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *`
+
+	var args = []interface{}{
+		uuid.New().String(),
+		token.ServiceAccountID,
+		token.Name,
+		token.Hash,
+		token.Scopes,
+		token.ExpiresAt.UTC(),
+		time.Now().UTC(),
+		token.CreatedBy,
+	}
+
+	auditDuration := logDuration(ctx, "create api token")
+	defer auditDuration()
+
+	var ret api.APIToken
+	if err := s.db.GetContext(ctx, &ret, query, args...); err != nil {
+		l.Error("failed to create api token", zap.Error(err))
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (s serviceAccountRepo) RevokeToken(ctx context.Context, serviceAccountID string, tokenID string) error {
+	l := s.logger(ctx).With(zap.String("service_account_id", serviceAccountID), zap.String("api_token_id", tokenID))
+	l.Debug("revoking api token")
+
+	const query = `UPDATE api_tokens SET revoked_at = $3
+WHERE service_account_id = $1 AND id = $2 AND revoked_at IS NULL`
+
+	auditDuration := logDuration(ctx, "revoke api token")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, serviceAccountID, tokenID, time.Now().UTC()); err != nil {
+		l.Error("failed to revoke api token", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s serviceAccountRepo) TouchToken(ctx context.Context, tokenID string, usedAt time.Time) error {
+	l := s.logger(ctx).With(zap.String("api_token_id", tokenID))
+	l.Debug("touching api token")
+
+	const query = "UPDATE api_tokens SET last_used_at = $2 WHERE id = $1"
+
+	if _, err := s.db.ExecContext(ctx, query, tokenID, usedAt.UTC()); err != nil {
+		l.Error("failed to touch api token", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: ServiceAccountRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+)
+
+// MockServiceAccountRepo is a mock of ServiceAccountRepo interface.
+type MockServiceAccountRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountRepoMockRecorder
+}
+
+// MockServiceAccountRepoMockRecorder is the mock recorder for MockServiceAccountRepo.
+type MockServiceAccountRepoMockRecorder struct {
+	mock *MockServiceAccountRepo
+}
+
+// NewMockServiceAccountRepo creates a new mock instance.
+func NewMockServiceAccountRepo(ctrl *gomock.Controller) *MockServiceAccountRepo {
+	mock := &MockServiceAccountRepo{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountRepo) EXPECT() *MockServiceAccountRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockServiceAccountRepo) Create(arg0 context.Context, arg1 *api.ServiceAccount) (*api.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*api.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockServiceAccountRepoMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockServiceAccountRepo)(nil).Create), arg0, arg1)
+}
+
+// CreateToken mocks base method.
+func (m *MockServiceAccountRepo) CreateToken(arg0 context.Context, arg1 *api.APIToken) (*api.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToken", arg0, arg1)
+	ret0, _ := ret[0].(*api.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateToken indicates an expected call of CreateToken.
+func (mr *MockServiceAccountRepoMockRecorder) CreateToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockServiceAccountRepo)(nil).CreateToken), arg0, arg1)
+}
+
+// GetAll mocks base method.
+func (m *MockServiceAccountRepo) GetAll(arg0 context.Context) ([]*api.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0)
+	ret0, _ := ret[0].([]*api.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockServiceAccountRepoMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockServiceAccountRepo)(nil).GetAll), arg0)
+}
+
+// GetByID mocks base method.
+func (m *MockServiceAccountRepo) GetByID(arg0 context.Context, arg1 string) (*api.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*api.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockServiceAccountRepoMockRecorder) GetByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockServiceAccountRepo)(nil).GetByID), arg0, arg1)
+}
+
+// GetTokenByHash mocks base method.
+func (m *MockServiceAccountRepo) GetTokenByHash(arg0 context.Context, arg1 string) (*api.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenByHash", arg0, arg1)
+	ret0, _ := ret[0].(*api.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenByHash indicates an expected call of GetTokenByHash.
+func (mr *MockServiceAccountRepoMockRecorder) GetTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenByHash", reflect.TypeOf((*MockServiceAccountRepo)(nil).GetTokenByHash), arg0, arg1)
+}
+
+// GetTokens mocks base method.
+func (m *MockServiceAccountRepo) GetTokens(arg0 context.Context, arg1 string) ([]*api.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokens", arg0, arg1)
+	ret0, _ := ret[0].([]*api.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokens indicates an expected call of GetTokens.
+func (mr *MockServiceAccountRepoMockRecorder) GetTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokens", reflect.TypeOf((*MockServiceAccountRepo)(nil).GetTokens), arg0, arg1)
+}
+
+// RevokeToken mocks base method.
+func (m *MockServiceAccountRepo) RevokeToken(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockServiceAccountRepoMockRecorder) RevokeToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockServiceAccountRepo)(nil).RevokeToken), arg0, arg1, arg2)
+}
+
+// TouchToken mocks base method.
+func (m *MockServiceAccountRepo) TouchToken(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchToken indicates an expected call of TouchToken.
+func (mr *MockServiceAccountRepoMockRecorder) TouchToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchToken", reflect.TypeOf((*MockServiceAccountRepo)(nil).TouchToken), arg0, arg1, arg2)
+}
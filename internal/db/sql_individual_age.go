@@ -0,0 +1,34 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/constants"
+)
+
+// yearsSinceSQLExpression returns an expression computing the full years elapsed between the date in the column and now
+func yearsSinceSQLExpression(driverName string, column string) string {
+	if driverName == "sqlite" {
+		return fmt.Sprintf("(CAST(strftime('%%Y', 'now') AS INTEGER) - CAST(strftime('%%Y', %s) AS INTEGER) - (strftime('%%m-%%d', 'now') < strftime('%%m-%%d', %s)))", column, column)
+	}
+	return fmt.Sprintf("CAST(EXTRACT(YEAR FROM AGE(NOW(), %s)) AS INTEGER)", column)
+}
+
+// derivedAgeSQLExpression returns an expression computing the current age of an individual, as api.Individual.DerivedAge does.
+// The age is computed from the birth date when present, otherwise the years elapsed since the collection time are
+// added to the recorded age. The expression is NULL when the age is unknown.
+func derivedAgeSQLExpression(driverName string) string {
+	return fmt.Sprintf("(CASE WHEN %s IS NOT NULL THEN %s WHEN %s IS NOT NULL THEN %s + %s END)",
+		constants.DBColumnIndividualBirthDate,
+		yearsSinceSQLExpression(driverName, constants.DBColumnIndividualBirthDate),
+		constants.DBColumnIndividualAge,
+		constants.DBColumnIndividualAge,
+		yearsSinceSQLExpression(driverName, constants.DBColumnIndividualCollectionTime))
+}
+
+// derivedIsMinorSQLExpression returns an expression computing whether an individual is currently a minor,
+// as api.Individual.DerivedIsMinor does. It falls back to the recorded minor flag when the age is unknown.
+func derivedIsMinorSQLExpression(driverName string) string {
+	return fmt.Sprintf("COALESCE(%s < %d, %s)", derivedAgeSQLExpression(driverName), api.AdultAge, constants.DBColumnIndividualIsMinor)
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_derivedAgeSQLExpression(t *testing.T) {
+	assert.Equal(t,
+		`(CASE WHEN birth_date IS NOT NULL THEN CAST(EXTRACT(YEAR FROM AGE(NOW(), birth_date)) AS INTEGER) WHEN age IS NOT NULL THEN age + CAST(EXTRACT(YEAR FROM AGE(NOW(), collection_time)) AS INTEGER) END)`,
+		derivedAgeSQLExpression("postgres"))
+	assert.Equal(t,
+		`(CASE WHEN birth_date IS NOT NULL THEN (CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', birth_date) AS INTEGER) - (strftime('%m-%d', 'now') < strftime('%m-%d', birth_date))) WHEN age IS NOT NULL THEN age + (CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', collection_time) AS INTEGER) - (strftime('%m-%d', 'now') < strftime('%m-%d', collection_time))) END)`,
+		derivedAgeSQLExpression("sqlite"))
+}
+
+func Test_derivedIsMinorSQLExpression(t *testing.T) {
+	assert.Equal(t, `COALESCE(`+derivedAgeSQLExpression("postgres")+` < 18, is_minor)`, derivedIsMinorSQLExpression("postgres"))
+}
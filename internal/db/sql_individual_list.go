@@ -25,10 +25,44 @@ func newGetAllIndividualsSQLQuery(driverName string, options api.ListIndividuals
 	}
 	qry = qry.
 		writeString("SELECT * FROM individual_registrations WHERE deleted_at IS NULL").
+		withFilters(options).
+
+		// these must be in that order
+		withSort(options.Sort).
+		withOffset(options.Skip).
+		withLimit(options.Take)
+
+	return qry
+}
+
+// newIterateIndividualsSQLQuery builds a query returning the next page of at most limit individuals
+// matching the filters in options, ordered by id and starting after afterID.
+// Sorting and paging options are ignored.
+func newIterateIndividualsSQLQuery(driverName string, options api.ListIndividualsOptions, afterID string, limit int) *getAllIndividualsSQLQuery {
+	qry := &getAllIndividualsSQLQuery{
+		Builder:    &strings.Builder{},
+		driverName: driverName,
+	}
+	qry = qry.
+		writeString("SELECT * FROM individual_registrations WHERE deleted_at IS NULL").
+		withFilters(options)
+	if len(afterID) > 0 {
+		qry.writeString(" AND " + constants.DBColumnIndividualID + " > ").writeArg(afterID)
+	}
+	return qry.
+		withOrderBy(constants.DBColumnIndividualID).
+		withLimit(limit)
+}
+
+// withFilters appends the WHERE clauses matching the given options.
+// Sorting and paging options are ignored.
+func (g *getAllIndividualsSQLQuery) withFilters(options api.ListIndividualsOptions) *getAllIndividualsSQLQuery {
+	return g.
 		withInactive(options.Inactive).
 		withAddress(options.Address).
 		withAgeFrom(options.AgeFrom).
 		withAgeTo(options.AgeTo).
+		withAgeBands(options.AgeBands).
 		withBirthDateFrom(options.BirthDateFrom).
 		withBirthDateTo(options.BirthDateTo).
 		withCognitiveDisabilityLevel(options.CognitiveDisabilityLevel).
@@ -36,6 +70,7 @@ func newGetAllIndividualsSQLQuery(driverName string, options api.ListIndividuals
 		withCollectionAdministrativeArea2(options.CollectionAdministrativeArea2).
 		withCollectionAdministrativeArea3(options.CollectionAdministrativeArea3).
 		withCollectionOffice(options.CollectionOffice).
+		withOffices(options.Offices).
 		withCollectionAgentName(options.CollectionAgentName).
 		withCollectionAgentTitle(options.CollectionAgentTitle).
 		withCollectionTimeFrom(options.CollectionTimeFrom).
@@ -96,17 +131,11 @@ func newGetAllIndividualsSQLQuery(driverName string, options api.ListIndividuals
 		withServiceCC(options.ServiceCC, options.ServiceRequestedDateFrom, options.ServiceRequestedDateTo, options.ServiceDeliveredDateFrom,
 			options.ServiceDeliveredDateTo, options.ServiceType, options.Service, options.ServiceSubService, options.ServiceLocation,
 			options.ServiceDonor, options.ServiceProjectName, options.ServiceAgentName).
+		withServiceCatalogueFilter(options.ServiceCatalogueFilter).
 		withSpokenLanguage(options.SpokenLanguage).
 		withUpdatedAtFrom(options.UpdatedAtFrom).
 		withUpdatedAtTo(options.UpdatedAtTo).
-		withVisionDisabilityLevel(options.VisionDisabilityLevel).
-
-		// these must be in that order
-		withSort(options.Sort).
-		withOffset(options.Skip).
-		withLimit(options.Take)
-
-	return qry
+		withVisionDisabilityLevel(options.VisionDisabilityLevel)
 }
 
 func (g *getAllIndividualsSQLQuery) withInactive(inactive *bool) *getAllIndividualsSQLQuery {
@@ -133,7 +162,7 @@ func (g *getAllIndividualsSQLQuery) withAgeFrom(from *int) *getAllIndividualsSQL
 	if from == nil {
 		return g
 	}
-	g.writeString(" AND " + constants.DBColumnIndividualAge + " >= ").writeArg(*from)
+	g.writeString(" AND " + derivedAgeSQLExpression(g.driverName) + " >= ").writeArg(*from)
 	return g
 }
 
@@ -141,7 +170,31 @@ func (g *getAllIndividualsSQLQuery) withAgeTo(to *int) *getAllIndividualsSQLQuer
 	if to == nil {
 		return g
 	}
-	g.writeString(" AND " + constants.DBColumnIndividualAge + " <= ").writeArg(*to)
+	g.writeString(" AND " + derivedAgeSQLExpression(g.driverName) + " <= ").writeArg(*to)
+	return g
+}
+
+// withAgeBands matches the individuals whose derived age falls into any of the bands
+func (g *getAllIndividualsSQLQuery) withAgeBands(bands api.AgeBands) *getAllIndividualsSQLQuery {
+	if len(bands) == 0 {
+		return g
+	}
+	age := derivedAgeSQLExpression(g.driverName)
+	g.writeString(" AND (")
+	for i, band := range bands {
+		if i != 0 {
+			g.writeString(" OR ")
+		}
+		g.writeString("(" + age + " IS NOT NULL")
+		if band.From != nil {
+			g.writeString(" AND " + age + " >= ").writeArg(*band.From)
+		}
+		if band.To != nil {
+			g.writeString(" AND " + age + " <= ").writeArg(*band.To)
+		}
+		g.writeString(")")
+	}
+	g.writeString(")")
 	return g
 }
 
@@ -203,6 +256,27 @@ func (g *getAllIndividualsSQLQuery) withCollectionOffice(office string) *getAllI
 	return g
 }
 
+// withOffices restricts the individuals to the given collection offices. A nil list does not restrict them,
+// while an empty list matches no individual.
+func (g *getAllIndividualsSQLQuery) withOffices(offices []string) *getAllIndividualsSQLQuery {
+	if offices == nil {
+		return g
+	}
+	if len(offices) == 0 {
+		g.writeString(" AND 1 = 0")
+		return g
+	}
+	g.writeString(" AND " + constants.DBColumnIndividualCollectionOffice + " IN (")
+	for i, office := range offices {
+		if i != 0 {
+			g.writeString(",")
+		}
+		g.writeArg(office)
+	}
+	g.writeString(")")
+	return g
+}
+
 func (g *getAllIndividualsSQLQuery) withCollectionAgentName(name string) *getAllIndividualsSQLQuery {
 	if len(name) == 0 {
 		return g
@@ -596,6 +670,41 @@ func (g *getAllIndividualsSQLQuery) withServiceCC(serviceCC containers.Set[enumT
 	return g
 }
 
+// withServiceCatalogueFilter matches the individuals with a service slot holding the values of the filter
+func (g *getAllIndividualsSQLQuery) withServiceCatalogueFilter(filter *api.IndividualServiceFilter) *getAllIndividualsSQLQuery {
+	if filter == nil {
+		return g
+	}
+	g.writeString(" AND (")
+	for i := 1; i <= 7; i++ {
+		if i != 1 {
+			g.writeString(" OR ")
+		}
+		g.writeString("(TRUE")
+		if filter.CC != enumTypes.ServiceCCNone {
+			g.writeString(fmt.Sprintf(" AND service_cc_%d = ", i)).writeArg(string(filter.CC))
+		}
+		if filter.Type != "" {
+			g.writeString(fmt.Sprintf(" AND service_type_%d = ", i)).writeArg(filter.Type)
+		}
+		if filter.Service != "" {
+			g.writeString(fmt.Sprintf(" AND service_%d = ", i)).writeArg(filter.Service)
+		}
+		if filter.SubService != "" {
+			g.writeString(fmt.Sprintf(" AND service_sub_service_%d = ", i)).writeArg(filter.SubService)
+		}
+		if filter.Donor != "" {
+			g.writeString(fmt.Sprintf(" AND service_donor_%d = ", i)).writeArg(filter.Donor)
+		}
+		if filter.ProjectName != "" {
+			g.writeString(fmt.Sprintf(" AND service_project_name_%d = ", i)).writeArg(filter.ProjectName)
+		}
+		g.writeString(")")
+	}
+	g.writeString(")")
+	return g
+}
+
 func (g *getAllIndividualsSQLQuery) withEngagementContext(engagementContext containers.Set[enumTypes.EngagementContext]) *getAllIndividualsSQLQuery {
 	if engagementContext.IsEmpty() {
 		return g
@@ -655,11 +764,7 @@ func (g *getAllIndividualsSQLQuery) withIsMinor(isMinor *bool) *getAllIndividual
 	if isMinor == nil {
 		return g
 	}
-	if *isMinor {
-		g.writeString(" AND " + constants.DBColumnIndividualIsMinor + " = ").writeArg(true)
-	} else {
-		g.writeString(" AND " + constants.DBColumnIndividualIsMinor + " = ").writeArg(false)
-	}
+	g.writeString(" AND " + derivedIsMinorSQLExpression(g.driverName) + " = ").writeArg(*isMinor)
 	return g
 }
 
@@ -698,7 +803,7 @@ func (g *getAllIndividualsSQLQuery) withPhoneNumber(phoneNumber string) *getAllI
 	if len(phoneNumber) == 0 {
 		return g
 	}
-	normalizedPhoneNumber := api.NormalizePhoneNumber(phoneNumber)
+	normalizedPhoneNumber := api.PhoneNumberSearchTerm(phoneNumber)
 	if g.driverName == "sqlite" {
 		g.writeString(" AND (")
 		g.writeString(" " + constants.DBColumnIndividualNormalizedPhoneNumber1 + " LIKE ").writeArg("%" + normalizedPhoneNumber + "%").writeString(" OR ")
@@ -856,7 +961,15 @@ func (g *getAllIndividualsSQLQuery) withSort(sortTerms api.SortTerms) *getAllInd
 		if i > 0 {
 			g.writeString(", ")
 		}
-		g.writeString(sortTerm.Field)
+		// the age and the minor flag are sorted by their current value rather than the recorded one
+		switch sortTerm.Field {
+		case constants.DBColumnIndividualAge:
+			g.writeString(derivedAgeSQLExpression(g.driverName))
+		case constants.DBColumnIndividualIsMinor:
+			g.writeString(derivedIsMinorSQLExpression(g.driverName))
+		default:
+			g.writeString(sortTerm.Field)
+		}
 		if sortTerm.Direction == api.SortDirectionDescending {
 			g.writeString(" DESC")
 		} else {
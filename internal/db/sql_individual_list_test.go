@@ -18,6 +18,7 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 	}
 	zeroTime := time.Time{}
 	const defaultQuery = `SELECT * FROM individual_registrations WHERE deleted_at IS NULL`
+	derivedAge := derivedAgeSQLExpression("postgres")
 	tests := []struct {
 		name     string
 		args     api.ListIndividualsOptions
@@ -46,13 +47,29 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 		}, {
 			name:     "ageFrom",
 			args:     api.ListIndividualsOptions{AgeFrom: pointers.Int(18)},
-			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND age >= $1`,
+			wantSql:  defaultQuery + ` AND ` + derivedAge + ` >= $1`,
 			wantArgs: []interface{}{18},
 		}, {
 			name:     "ageTo",
 			args:     api.ListIndividualsOptions{AgeTo: pointers.Int(18)},
-			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND age <= $1`,
+			wantSql:  defaultQuery + ` AND ` + derivedAge + ` <= $1`,
 			wantArgs: []interface{}{18},
+		}, {
+			name:     "ageBands",
+			args:     api.ListIndividualsOptions{AgeBands: api.AgeBands{{From: pointers.Int(0), To: pointers.Int(4)}, {From: pointers.Int(60)}}},
+			wantSql:  defaultQuery + ` AND ((` + derivedAge + ` IS NOT NULL AND ` + derivedAge + ` >= $1 AND ` + derivedAge + ` <= $2) OR (` + derivedAge + ` IS NOT NULL AND ` + derivedAge + ` >= $3))`,
+			wantArgs: []interface{}{0, 4, 60},
+		}, {
+			name: "serviceCatalogueFilter",
+			args: api.ListIndividualsOptions{ServiceCatalogueFilter: &api.IndividualServiceFilter{Donor: "ECHO", ProjectName: "P1"}},
+			wantSql: defaultQuery + ` AND ((TRUE AND service_donor_1 = $1 AND service_project_name_1 = $2)` +
+				` OR (TRUE AND service_donor_2 = $3 AND service_project_name_2 = $4)` +
+				` OR (TRUE AND service_donor_3 = $5 AND service_project_name_3 = $6)` +
+				` OR (TRUE AND service_donor_4 = $7 AND service_project_name_4 = $8)` +
+				` OR (TRUE AND service_donor_5 = $9 AND service_project_name_5 = $10)` +
+				` OR (TRUE AND service_donor_6 = $11 AND service_project_name_6 = $12)` +
+				` OR (TRUE AND service_donor_7 = $13 AND service_project_name_7 = $14))`,
+			wantArgs: []interface{}{"ECHO", "P1", "ECHO", "P1", "ECHO", "P1", "ECHO", "P1", "ECHO", "P1", "ECHO", "P1", "ECHO", "P1"},
 		}, {
 			name:     "birthDateFrom",
 			args:     api.ListIndividualsOptions{BirthDateFrom: &someDate},
@@ -96,6 +113,16 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 			args:     api.ListIndividualsOptions{CollectionOffice: "office"},
 			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND collection_office = $1`,
 			wantArgs: []interface{}{"office"},
+		}, {
+			name:     "offices",
+			args:     api.ListIndividualsOptions{Offices: []string{"office1", "office2"}},
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND collection_office IN ($1,$2)`,
+			wantArgs: []interface{}{"office1", "office2"},
+		}, {
+			name:     "offices (none)",
+			args:     api.ListIndividualsOptions{Offices: []string{}},
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND 1 = 0`,
+			wantArgs: nil,
 		}, {
 			name:     "collectionAgentName",
 			args:     api.ListIndividualsOptions{CollectionAgentName: "agent"},
@@ -368,12 +395,12 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 		}, {
 			name:     "isMinor",
 			args:     api.ListIndividualsOptions{IsMinor: pointers.Bool(true)},
-			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND is_minor = $1`,
+			wantSql:  defaultQuery + ` AND COALESCE(` + derivedAge + ` < 18, is_minor) = $1`,
 			wantArgs: []interface{}{true},
 		}, {
 			name:     "isMinor (false)",
 			args:     api.ListIndividualsOptions{IsMinor: pointers.Bool(false)},
-			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND is_minor = $1`,
+			wantSql:  defaultQuery + ` AND COALESCE(` + derivedAge + ` < 18, is_minor) = $1`,
 			wantArgs: []interface{}{false},
 		}, {
 			name:     "mobilityDisabilityLevel",
@@ -390,6 +417,16 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 			args:     api.ListIndividualsOptions{PhoneNumber: "1234567890"},
 			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND (normalized_phone_number_1 ILIKE $1 OR normalized_phone_number_2 ILIKE $1 OR normalized_phone_number_3 ILIKE $1)`,
 			wantArgs: []interface{}{"%1234567890%"},
+		}, {
+			name:     "national phone number",
+			args:     api.ListIndividualsOptions{PhoneNumber: "081 234 5678"},
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND (normalized_phone_number_1 ILIKE $1 OR normalized_phone_number_2 ILIKE $1 OR normalized_phone_number_3 ILIKE $1)`,
+			wantArgs: []interface{}{"%812345678%"},
+		}, {
+			name:     "international phone number",
+			args:     api.ListIndividualsOptions{PhoneNumber: "00243 81 234 5678"},
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND (normalized_phone_number_1 ILIKE $1 OR normalized_phone_number_2 ILIKE $1 OR normalized_phone_number_3 ILIKE $1)`,
+			wantArgs: []interface{}{"%243812345678%"},
 		}, {
 			name:     "preferredContactMehtod",
 			args:     api.ListIndividualsOptions{PreferredContactMethod: "contactMethod"},
@@ -472,6 +509,10 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 				{Field: "full_name", Direction: api.SortDirectionDescending},
 			}},
 			wantSql: `SELECT * FROM individual_registrations WHERE deleted_at IS NULL ORDER BY id ASC, full_name DESC`,
+		}, {
+			name:    "sort (age)",
+			args:    api.ListIndividualsOptions{Sort: api.SortTerms{{Field: "age", Direction: api.SortDirectionAscending}}},
+			wantSql: defaultQuery + ` ORDER BY ` + derivedAge + ` ASC`,
 		},
 	}
 	for _, tt := range tests {
@@ -482,3 +523,41 @@ func Test_newGetAllIndividualsSQLQuery(t *testing.T) {
 		})
 	}
 }
+
+func Test_newIterateIndividualsSQLQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  api.ListIndividualsOptions
+		afterID  string
+		limit    int
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "first batch",
+			options: api.ListIndividualsOptions{},
+			limit:   100,
+			wantSql: `SELECT * FROM individual_registrations WHERE deleted_at IS NULL ORDER BY id LIMIT 100`,
+		}, {
+			name:     "next batch",
+			options:  api.ListIndividualsOptions{CountryID: "abc"},
+			afterID:  "def",
+			limit:    100,
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND country_id = $1 AND id > $2 ORDER BY id LIMIT 100`,
+			wantArgs: []interface{}{"abc", "def"},
+		}, {
+			name:     "ignores paging and sorting",
+			options:  api.ListIndividualsOptions{CountryID: "abc", Skip: 10, Take: 20, Sort: api.SortTerms{{Field: "age"}}},
+			limit:    5,
+			wantSql:  `SELECT * FROM individual_registrations WHERE deleted_at IS NULL AND country_id = $1 ORDER BY id LIMIT 5`,
+			wantArgs: []interface{}{"abc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSql, gotArgs := newIterateIndividualsSQLQuery("postgres", tt.options, tt.afterID, tt.limit).build()
+			assert.Equal(t, tt.wantSql, gotSql)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/constants"
+)
+
+// newIndividualStatisticsSQLQuery builds a query selecting the given expression over all individuals
+// matching the filters in options. Sorting and paging options are ignored.
+func newIndividualStatisticsSQLQuery(driverName string, options api.ListIndividualsOptions, selectExpr string) *getAllIndividualsSQLQuery {
+	qry := &getAllIndividualsSQLQuery{
+		Builder:    &strings.Builder{},
+		driverName: driverName,
+	}
+	return qry.
+		writeString("SELECT " + selectExpr + " FROM individual_registrations WHERE deleted_at IS NULL").
+		withFilters(options)
+}
+
+// newIndividualCountBySQLQuery builds a query counting the individuals matching the filters in options,
+// grouped by the given key expression.
+func newIndividualCountBySQLQuery(driverName string, options api.ListIndividualsOptions, keyExpr string) *getAllIndividualsSQLQuery {
+	return newIndividualStatisticsSQLQuery(driverName, options, keyExpr+" AS key, COUNT(*) AS count").
+		writeString(" GROUP BY 1 ORDER BY 1")
+}
+
+func disabilityCountsSQLExpression() string {
+	columns := []string{
+		constants.DBColumnIndividualHasDisability,
+		constants.DBColumnIndividualHasVisionDisability,
+		constants.DBColumnIndividualHasHearingDisability,
+		constants.DBColumnIndividualHasMobilityDisability,
+		constants.DBColumnIndividualHasCognitiveDisability,
+		constants.DBColumnIndividualHasSelfCareDisability,
+		constants.DBColumnIndividualHasCommunicationDisability,
+	}
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = fmt.Sprintf("COALESCE(SUM(CASE WHEN %s THEN 1 ELSE 0 END), 0) AS %s", column, column)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ageBandSQLExpression returns a CASE expression mapping the derived age of an individual to the label
+// of the band it falls into. Individuals without an age or outside all bands map to an empty string.
+func ageBandSQLExpression(driverName string, bands api.AgeBands) string {
+	age := derivedAgeSQLExpression(driverName)
+	b := &strings.Builder{}
+	b.WriteString("CASE WHEN " + age + " IS NULL THEN ''")
+	for _, band := range bands {
+		var conditions []string
+		if band.From != nil {
+			conditions = append(conditions, fmt.Sprintf("%s >= %d", age, *band.From))
+		}
+		if band.To != nil {
+			conditions = append(conditions, fmt.Sprintf("%s <= %d", age, *band.To))
+		}
+		if len(conditions) == 0 {
+			conditions = append(conditions, "TRUE")
+		}
+		b.WriteString(fmt.Sprintf(" WHEN %s THEN '%s'", strings.Join(conditions, " AND "), band.Label()))
+	}
+	b.WriteString(" ELSE '' END")
+	return b.String()
+}
+
+// registrationMonthSQLExpression returns an expression formatting the registration date of an individual as YYYY-MM.
+// The collection time is used when known, falling back to the time the record was created.
+func registrationMonthSQLExpression(driverName string) string {
+	registrationDate := fmt.Sprintf("COALESCE(%s, %s)", constants.DBColumnIndividualCollectionTime, constants.DBColumnIndividualCreatedAt)
+	if driverName == "sqlite" {
+		return fmt.Sprintf("strftime('%%Y-%%m', %s)", registrationDate)
+	}
+	return fmt.Sprintf("to_char(%s, 'YYYY-MM')", registrationDate)
+}
+
+func coalesceEmptySQLExpression(column string) string {
+	return fmt.Sprintf("COALESCE(%s, '')", column)
+}
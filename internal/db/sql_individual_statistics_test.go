@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/utils/pointers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newIndividualCountBySQLQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  api.ListIndividualsOptions
+		keyExpr  string
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "no filters",
+			options: api.ListIndividualsOptions{},
+			keyExpr: "sex",
+			wantSql: `SELECT sex AS key, COUNT(*) AS count FROM individual_registrations WHERE deleted_at IS NULL GROUP BY 1 ORDER BY 1`,
+		}, {
+			name:     "ignores paging and sorting",
+			options:  api.ListIndividualsOptions{CountryID: "abc", Skip: 10, Take: 20, Sort: api.SortTerms{{Field: "age"}}},
+			keyExpr:  "sex",
+			wantSql:  `SELECT sex AS key, COUNT(*) AS count FROM individual_registrations WHERE deleted_at IS NULL AND country_id = $1 GROUP BY 1 ORDER BY 1`,
+			wantArgs: []interface{}{"abc"},
+		}, {
+			name:     "with filters",
+			options:  api.ListIndividualsOptions{AgeFrom: pointers.Int(18), HasDisability: pointers.Bool(true)},
+			keyExpr:  "sex",
+			wantSql:  `SELECT sex AS key, COUNT(*) AS count FROM individual_registrations WHERE deleted_at IS NULL AND ` + derivedAgeSQLExpression("postgres") + ` >= $1 AND has_disability = $2 GROUP BY 1 ORDER BY 1`,
+			wantArgs: []interface{}{18, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := newIndividualCountBySQLQuery("postgres", tt.options, tt.keyExpr).build()
+			assert.Equal(t, tt.wantSql, sql)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func Test_ageBandSQLExpression(t *testing.T) {
+	bands := api.AgeBands{
+		{From: pointers.Int(0), To: pointers.Int(17)},
+		{From: pointers.Int(18)},
+	}
+	age := derivedAgeSQLExpression("postgres")
+	assert.Equal(t,
+		`CASE WHEN `+age+` IS NULL THEN '' WHEN `+age+` >= 0 AND `+age+` <= 17 THEN '0-17' WHEN `+age+` >= 18 THEN '18+' ELSE '' END`,
+		ageBandSQLExpression("postgres", bands))
+}
+
+func Test_registrationMonthSQLExpression(t *testing.T) {
+	assert.Equal(t, `to_char(COALESCE(collection_time, created_at), 'YYYY-MM')`, registrationMonthSQLExpression("postgres"))
+	assert.Equal(t, `strftime('%Y-%m', COALESCE(collection_time, created_at))`, registrationMonthSQLExpression("sqlite"))
+}
+
+func Test_sortByAgeBands(t *testing.T) {
+	counts := []api.StatisticsCount{
+		{Key: "", Count: 2},
+		{Key: "18+", Count: 5},
+	}
+	bands := api.AgeBands{
+		{From: pointers.Int(0), To: pointers.Int(17)},
+		{From: pointers.Int(18)},
+	}
+	assert.Equal(t, []api.StatisticsCount{
+		{Key: "0-17", Count: 0},
+		{Key: "18+", Count: 5},
+		{Key: "", Count: 2},
+	}, sortByAgeBands(counts, bands))
+}
+
+type countingStatisticsRepo struct {
+	calls int
+}
+
+func (c *countingStatisticsRepo) GetStatistics(ctx context.Context, options api.ListIndividualsOptions, ageBands api.AgeBands, withDisability bool) (*api.IndividualStatistics, error) {
+	c.calls++
+	return &api.IndividualStatistics{Total: c.calls}, nil
+}
+
+func Test_cachedIndividualStatisticsRepo(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	inner := &countingStatisticsRepo{}
+	repo := NewCachedIndividualStatisticsRepo(inner, time.Minute).(*cachedIndividualStatisticsRepo)
+	repo.now = func() time.Time { return now }
+
+	options := api.ListIndividualsOptions{CountryID: "abc"}
+	stats, _ := repo.GetStatistics(ctx, options, api.DefaultAgeBands, false)
+	assert.Equal(t, 1, stats.Total)
+
+	// paging does not affect the cache key
+	options.Take = 20
+	stats, _ = repo.GetStatistics(ctx, options, api.DefaultAgeBands, false)
+	assert.Equal(t, 1, stats.Total)
+
+	// different filters are cached separately
+	stats, _ = repo.GetStatistics(ctx, api.ListIndividualsOptions{CountryID: "def"}, api.DefaultAgeBands, false)
+	assert.Equal(t, 2, stats.Total)
+
+	// the statistics with the disabilities are not shared with the users that cannot view them
+	stats, _ = repo.GetStatistics(ctx, options, api.DefaultAgeBands, true)
+	assert.Equal(t, 3, stats.Total)
+
+	// entries expire
+	now = now.Add(2 * time.Minute)
+	stats, _ = repo.GetStatistics(ctx, options, api.DefaultAgeBands, false)
+	assert.Equal(t, 4, stats.Total)
+}
@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=./user_session_mock.go -package=db . UserSessionRepo
+
+type UserSessionRepo interface {
+	// GetActive returns the sessions that are neither revoked nor expired at the given time, the most recently seen first
+	GetActive(ctx context.Context, now time.Time) ([]*api.UserSession, error)
+	// GetByTokenHash returns the session with the given token hash, or nil if there is none
+	GetByTokenHash(ctx context.Context, hash string) (*api.UserSession, error)
+	// Create creates the session with a new id
+	Create(ctx context.Context, session *api.UserSession) (*api.UserSession, error)
+	// Touch records that the session was used, and extends it until the given time
+	Touch(ctx context.Context, id string, lastSeenAt time.Time, expiresAt time.Time) error
+	// UpdateGroups stores the groups of the session fetched at the given time
+	UpdateGroups(ctx context.Context, id string, groups containers.StringSet, refreshedAt time.Time) error
+	// UpdateTokens stores the encrypted tokens of the built-in OIDC login
+	UpdateTokens(ctx context.Context, id string, tokens string) error
+	// Revoke revokes the session and clears its tokens. Revoking a revoked session does nothing.
+	Revoke(ctx context.Context, id string, revokedBy string) error
+	// GetLatestByUserID returns the most recently seen session of the user, even if it is revoked or expired, or nil if there is none
+	GetLatestByUserID(ctx context.Context, userID string) (*api.UserSession, error)
+	// StartImpersonation lets the user of the session view the application with the groups of another user until the given time
+	StartImpersonation(ctx context.Context, id string, impersonated *api.UserSession, expiresAt time.Time) error
+	// StopImpersonation ends the impersonation of the session, if any
+	StopImpersonation(ctx context.Context, id string) error
+	// GetLastRevocation returns when a session of the user was last revoked, or nil if none was
+	GetLastRevocation(ctx context.Context, userID string) (*time.Time, error)
+}
+
+type userSessionRepo struct {
+	db *sqlx.DB
+}
+
+func NewUserSessionRepo(db *sqlx.DB) UserSessionRepo {
+	return &userSessionRepo{db: db}
+}
+
+func (s userSessionRepo) logger(ctx context.Context) *zap.Logger {
+	return logging.NewLogger(ctx)
+}
+
+func (s userSessionRepo) GetActive(ctx context.Context, now time.Time) ([]*api.UserSession, error) {
+	l := s.logger(ctx)
+	l.Debug("getting active user sessions")
+
+	const query = `SELECT * FROM user_sessions
+WHERE revoked_at IS NULL AND expires_at > $1
+ORDER BY last_seen_at DESC`
+
+	auditDuration := logDuration(ctx, "get active user sessions")
+	defer auditDuration()
+
+	var sessions []*api.UserSession
+	if err := s.db.SelectContext(ctx, &sessions, query, now.UTC()); err != nil {
+		l.Error("failed to get active user sessions", zap.Error(err))
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s userSessionRepo) GetByTokenHash(ctx context.Context, hash string) (*api.UserSession, error) {
+	l := s.logger(ctx)
+	l.Debug("getting user session by token hash")
+
+	const query = "SELECT * FROM user_sessions WHERE token_hash = $1"
+
+	auditDuration := logDuration(ctx, "get user session by token hash")
+	defer auditDuration()
+
+	var session api.UserSession
+	if err := s.db.GetContext(ctx, &session, query, hash); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		l.Error("failed to get user session by token hash", zap.Error(err))
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s userSessionRepo) Create(ctx context.Context, session *api.UserSession) (*api.UserSession, error) {
+	l := s.logger(ctx)
+	l.Debug("creating user session", zap.String("user_id", session.UserID))
+
+	const query = `INSERT INTO user_sessions (id, token_hash, user_id, email, groups, user_agent, ip_address, created_at, last_seen_at, groups_refreshed_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *`
+
+	var args = []interface{}{
+		uuid.New().String(),
+		session.Hash,
+		session.UserID,
+		session.Email,
+		session.Groups,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt.UTC(),
+		session.LastSeenAt.UTC(),
+		session.GroupsRefreshedAt.UTC(),
+		session.ExpiresAt.UTC(),
+	}
+
+	auditDuration := logDuration(ctx, "create user session")
+	defer auditDuration()
+
+	var ret api.UserSession
+	if err := s.db.GetContext(ctx, &ret, query, args...); err != nil {
+		l.Error("failed to create user session", zap.Error(err))
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (s userSessionRepo) Touch(ctx context.Context, id string, lastSeenAt time.Time, expiresAt time.Time) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id))
+	l.Debug("touching user session")
+
+	const query = "UPDATE user_sessions SET last_seen_at = $2, expires_at = $3 WHERE id = $1"
+
+	if _, err := s.db.ExecContext(ctx, query, id, lastSeenAt.UTC(), expiresAt.UTC()); err != nil {
+		l.Error("failed to touch user session", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) UpdateGroups(ctx context.Context, id string, groups containers.StringSet, refreshedAt time.Time) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id))
+	l.Debug("updating user session groups")
+
+	const query = "UPDATE user_sessions SET groups = $2, groups_refreshed_at = $3 WHERE id = $1"
+
+	auditDuration := logDuration(ctx, "update user session groups")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, id, groups, refreshedAt.UTC()); err != nil {
+		l.Error("failed to update user session groups", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) UpdateTokens(ctx context.Context, id string, tokens string) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id))
+	l.Debug("updating user session tokens")
+
+	const query = "UPDATE user_sessions SET oidc_tokens = $2 WHERE id = $1 AND revoked_at IS NULL"
+
+	auditDuration := logDuration(ctx, "update user session tokens")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, id, tokens); err != nil {
+		l.Error("failed to update user session tokens", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) Revoke(ctx context.Context, id string, revokedBy string) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id))
+	l.Debug("revoking user session")
+
+	const query = `UPDATE user_sessions SET revoked_at = $2, revoked_by = $3, oidc_tokens = ''
+WHERE id = $1 AND revoked_at IS NULL`
+
+	auditDuration := logDuration(ctx, "revoke user session")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, id, time.Now().UTC(), revokedBy); err != nil {
+		l.Error("failed to revoke user session", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) GetLatestByUserID(ctx context.Context, userID string) (*api.UserSession, error) {
+	l := s.logger(ctx).With(zap.String("user_id", userID))
+	l.Debug("getting latest user session")
+
+	const query = "SELECT * FROM user_sessions WHERE user_id = $1 ORDER BY last_seen_at DESC LIMIT 1"
+
+	auditDuration := logDuration(ctx, "get latest user session")
+	defer auditDuration()
+
+	var session api.UserSession
+	if err := s.db.GetContext(ctx, &session, query, userID); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		l.Error("failed to get latest user session", zap.Error(err))
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s userSessionRepo) StartImpersonation(ctx context.Context, id string, impersonated *api.UserSession, expiresAt time.Time) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id), zap.String("impersonated_user_id", impersonated.UserID))
+	l.Debug("starting user session impersonation")
+
+	const query = `UPDATE user_sessions
+SET impersonated_user_id = $2, impersonated_email = $3, impersonated_groups = $4, impersonation_expires_at = $5
+WHERE id = $1 AND revoked_at IS NULL`
+
+	auditDuration := logDuration(ctx, "start user session impersonation")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, id, impersonated.UserID, impersonated.Email, impersonated.Groups, expiresAt.UTC()); err != nil {
+		l.Error("failed to start user session impersonation", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) StopImpersonation(ctx context.Context, id string) error {
+	l := s.logger(ctx).With(zap.String("user_session_id", id))
+	l.Debug("stopping user session impersonation")
+
+	const query = `UPDATE user_sessions
+SET impersonated_user_id = '', impersonated_email = '', impersonated_groups = '{}', impersonation_expires_at = NULL
+WHERE id = $1`
+
+	auditDuration := logDuration(ctx, "stop user session impersonation")
+	defer auditDuration()
+
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		l.Error("failed to stop user session impersonation", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s userSessionRepo) GetLastRevocation(ctx context.Context, userID string) (*time.Time, error) {
+	l := s.logger(ctx).With(zap.String("user_id", userID))
+	l.Debug("getting last user session revocation")
+
+	const query = "SELECT MAX(revoked_at) FROM user_sessions WHERE user_id = $1"
+
+	auditDuration := logDuration(ctx, "get last user session revocation")
+	defer auditDuration()
+
+	var revokedAt sql.NullTime
+	if err := s.db.GetContext(ctx, &revokedAt, query, userID); err != nil {
+		l.Error("failed to get last user session revocation", zap.Error(err))
+		return nil, err
+	}
+	if !revokedAt.Valid {
+		return nil, nil
+	}
+	return &revokedAt.Time, nil
+}
@@ -0,0 +1,197 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/nrc-no/notcore/internal/db (interfaces: UserSessionRepo)
+
+// Package db is a generated GoMock package.
+package db
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	api "github.com/nrc-no/notcore/internal/api"
+	containers "github.com/nrc-no/notcore/internal/containers"
+)
+
+// MockUserSessionRepo is a mock of UserSessionRepo interface.
+type MockUserSessionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserSessionRepoMockRecorder
+}
+
+// MockUserSessionRepoMockRecorder is the mock recorder for MockUserSessionRepo.
+type MockUserSessionRepoMockRecorder struct {
+	mock *MockUserSessionRepo
+}
+
+// NewMockUserSessionRepo creates a new mock instance.
+func NewMockUserSessionRepo(ctrl *gomock.Controller) *MockUserSessionRepo {
+	mock := &MockUserSessionRepo{ctrl: ctrl}
+	mock.recorder = &MockUserSessionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserSessionRepo) EXPECT() *MockUserSessionRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUserSessionRepo) Create(arg0 context.Context, arg1 *api.UserSession) (*api.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*api.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserSessionRepoMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserSessionRepo)(nil).Create), arg0, arg1)
+}
+
+// GetActive mocks base method.
+func (m *MockUserSessionRepo) GetActive(arg0 context.Context, arg1 time.Time) ([]*api.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive", arg0, arg1)
+	ret0, _ := ret[0].([]*api.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockUserSessionRepoMockRecorder) GetActive(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockUserSessionRepo)(nil).GetActive), arg0, arg1)
+}
+
+// GetByTokenHash mocks base method.
+func (m *MockUserSessionRepo) GetByTokenHash(arg0 context.Context, arg1 string) (*api.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTokenHash", arg0, arg1)
+	ret0, _ := ret[0].(*api.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTokenHash indicates an expected call of GetByTokenHash.
+func (mr *MockUserSessionRepoMockRecorder) GetByTokenHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTokenHash", reflect.TypeOf((*MockUserSessionRepo)(nil).GetByTokenHash), arg0, arg1)
+}
+
+// GetLastRevocation mocks base method.
+func (m *MockUserSessionRepo) GetLastRevocation(arg0 context.Context, arg1 string) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastRevocation", arg0, arg1)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastRevocation indicates an expected call of GetLastRevocation.
+func (mr *MockUserSessionRepoMockRecorder) GetLastRevocation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastRevocation", reflect.TypeOf((*MockUserSessionRepo)(nil).GetLastRevocation), arg0, arg1)
+}
+
+// GetLatestByUserID mocks base method.
+func (m *MockUserSessionRepo) GetLatestByUserID(arg0 context.Context, arg1 string) (*api.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestByUserID", arg0, arg1)
+	ret0, _ := ret[0].(*api.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestByUserID indicates an expected call of GetLatestByUserID.
+func (mr *MockUserSessionRepoMockRecorder) GetLatestByUserID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestByUserID", reflect.TypeOf((*MockUserSessionRepo)(nil).GetLatestByUserID), arg0, arg1)
+}
+
+// Revoke mocks base method.
+func (m *MockUserSessionRepo) Revoke(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockUserSessionRepoMockRecorder) Revoke(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockUserSessionRepo)(nil).Revoke), arg0, arg1, arg2)
+}
+
+// StartImpersonation mocks base method.
+func (m *MockUserSessionRepo) StartImpersonation(arg0 context.Context, arg1 string, arg2 *api.UserSession, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImpersonation", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartImpersonation indicates an expected call of StartImpersonation.
+func (mr *MockUserSessionRepoMockRecorder) StartImpersonation(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImpersonation", reflect.TypeOf((*MockUserSessionRepo)(nil).StartImpersonation), arg0, arg1, arg2, arg3)
+}
+
+// StopImpersonation mocks base method.
+func (m *MockUserSessionRepo) StopImpersonation(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopImpersonation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopImpersonation indicates an expected call of StopImpersonation.
+func (mr *MockUserSessionRepoMockRecorder) StopImpersonation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopImpersonation", reflect.TypeOf((*MockUserSessionRepo)(nil).StopImpersonation), arg0, arg1)
+}
+
+// Touch mocks base method.
+func (m *MockUserSessionRepo) Touch(arg0 context.Context, arg1 string, arg2, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockUserSessionRepoMockRecorder) Touch(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockUserSessionRepo)(nil).Touch), arg0, arg1, arg2, arg3)
+}
+
+// UpdateGroups mocks base method.
+func (m *MockUserSessionRepo) UpdateGroups(arg0 context.Context, arg1 string, arg2 containers.StringSet, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroups", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroups indicates an expected call of UpdateGroups.
+func (mr *MockUserSessionRepoMockRecorder) UpdateGroups(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroups", reflect.TypeOf((*MockUserSessionRepo)(nil).UpdateGroups), arg0, arg1, arg2, arg3)
+}
+
+// UpdateTokens mocks base method.
+func (m *MockUserSessionRepo) UpdateTokens(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokens", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTokens indicates an expected call of UpdateTokens.
+func (mr *MockUserSessionRepoMockRecorder) UpdateTokens(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokens", reflect.TypeOf((*MockUserSessionRepo)(nil).UpdateTokens), arg0, arg1, arg2)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+// accessEventsPageSize is the number of access events shown per page of the search
+const accessEventsPageSize = 100
+
+// recordAccess records that the user of the request accessed the data of participants.
+// The callers refuse the access when it cannot be recorded, so that the audit log is complete.
+func recordAccess(ctx context.Context, repo db.AccessEventRepo, event api.AccessEvent) error {
+	session, ok := utils.GetSession(ctx)
+	if !ok {
+		return errors.New("couldn't get session")
+	}
+
+	event.OccurredAt = time.Now().UTC()
+	event.UserID = session.GetUserID()
+	event.UserEmail = session.GetUserEmail()
+	event.RequestID = utils.GetRequestID(ctx)
+	if userSession, ok := utils.GetUserSession(ctx); ok && userSession.IsImpersonating(event.OccurredAt) {
+		event.ImpersonatedUserID = userSession.ImpersonatedUserID
+	}
+	if event.IndividualIDs.Set == nil {
+		event.IndividualIDs = containers.NewStringSet()
+	}
+	return repo.Record(ctx, &event)
+}
+
+// individualIDs returns the ids of the individuals, to record which ones were shown
+func individualIDs(individuals []*api.Individual) containers.StringSet {
+	ids := containers.NewStringSet()
+	for _, individual := range individuals {
+		ids.Add(individual.ID)
+	}
+	return ids
+}
+
+// duplicateIDs returns the ids of the existing individuals found as duplicates, to record which ones were shown
+func duplicateIDs(duplicatesInDB map[int][]*api.Individual) containers.StringSet {
+	ids := containers.NewStringSet()
+	for _, duplicates := range duplicatesInDB {
+		for _, duplicate := range duplicates {
+			ids.Add(duplicate.ID)
+		}
+	}
+	return ids
+}
+
+// HandleAccessEvents lets the global admins search who viewed or downloaded the participants
+func HandleAccessEvents(renderer Renderer, repo db.AccessEventRepo) http.Handler {
+
+	const (
+		templateName          = "access_events.gohtml"
+		viewParamEvents       = "Events"
+		viewParamKinds        = "Kinds"
+		viewParamQuery        = "Query"
+		viewParamNextPage     = "NextPage"
+		queryParamUser        = "user"
+		queryParamKind        = "kind"
+		queryParamCountry     = "country"
+		queryParamParticipant = "participant"
+		queryParamFrom        = "from"
+		queryParamTo          = "to"
+		queryParamPage        = "page"
+		dateFormat            = "2006-01-02"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx   = r.Context()
+			l     = logging.NewLogger(ctx)
+			query = r.URL.Query()
+		)
+
+		options := api.ListAccessEventsOptions{
+			User:         strings.TrimSpace(query.Get(queryParamUser)),
+			Kind:         api.AccessEventKind(query.Get(queryParamKind)),
+			CountryID:    query.Get(queryParamCountry),
+			IndividualID: strings.TrimSpace(query.Get(queryParamParticipant)),
+			Take:         accessEventsPageSize,
+		}
+		if options.Kind != "" && !options.Kind.IsValid() {
+			http.Error(w, "invalid kind", http.StatusBadRequest)
+			return
+		}
+		if from := query.Get(queryParamFrom); from != "" {
+			t, err := time.Parse(dateFormat, from)
+			if err != nil {
+				http.Error(w, "invalid from date", http.StatusBadRequest)
+				return
+			}
+			options.From = &t
+		}
+		if to := query.Get(queryParamTo); to != "" {
+			t, err := time.Parse(dateFormat, to)
+			if err != nil {
+				http.Error(w, "invalid to date", http.StatusBadRequest)
+				return
+			}
+			// the to date is included
+			t = t.AddDate(0, 0, 1)
+			options.To = &t
+		}
+		page, _ := strconv.Atoi(query.Get(queryParamPage))
+		if page < 0 {
+			page = 0
+		}
+		options.Skip = page * accessEventsPageSize
+
+		events, err := repo.List(ctx, options)
+		if err != nil {
+			l.Error("failed to list access events", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var nextPage string
+		if len(events) == accessEventsPageSize {
+			next := url.Values{}
+			for k, v := range query {
+				next[k] = v
+			}
+			next.Set(queryParamPage, strconv.Itoa(page+1))
+			nextPage = "/access-events?" + next.Encode()
+		}
+
+		renderer.RenderView(w, r, templateName, viewParams{
+			viewParamEvents:   events,
+			viewParamKinds:    api.AccessEventKinds,
+			viewParamQuery:    query,
+			viewParamNextPage: nextPage,
+		})
+	})
+}
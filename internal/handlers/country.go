@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/pkg/api/validation"
 
 	"github.com/gorilla/mux"
 	"github.com/nrc-no/notcore/internal/db"
@@ -15,30 +18,44 @@ import (
 func HandleCountry(renderer Renderer, repo db.CountryRepo) http.Handler {
 
 	const (
-		templateName             = "country.gohtml"
-		newId                    = "new"
-		pathParamCountryID       = "country_id"
-		viewParamCountry         = "Country"
-		formParamName            = "Name"
-		formParamCode            = "Code"
-		formParamReadGroup 		 	 = "ReadGroup"
-		formParamWriteGroup		 	 = "WriteGroup"
+		templateName                 = "country.gohtml"
+		newId                        = "new"
+		pathParamCountryID           = "country_id"
+		viewParamCountry             = "Country"
+		formParamName                = "Name"
+		formParamCode                = "Code"
+		formParamAction              = "Action"
+		formParamGroup               = "Group"
+		formParamRole                = "Role"
+		actionAddGroup               = "add_group"
+		actionDeleteGroup            = "delete_group"
+		viewParamErrors              = "ValidationErrors"
+		viewParamRuleFields          = "ValidationRuleFields"
+		viewParamEnums               = "RestrictableEnums"
+		viewParamIdentificationTypes = "IdentificationTypes"
+		viewParamGroupRoles          = "GroupRoles"
 	)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		var (
-			ctx       = r.Context()
-			l         = logging.NewLogger(ctx)
-			err       error
-			country   = &api.Country{}
-			countryID = mux.Vars(r)[pathParamCountryID]
-			isNew     = countryID == newId
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			err              error
+			country          = &api.Country{}
+			validationErrors validation.ErrorList
+			countryID        = mux.Vars(r)[pathParamCountryID]
+			isNew            = countryID == newId
 		)
 
 		render := func() {
 			renderer.RenderView(w, r, templateName, viewParams{
-				viewParamCountry: country,
+				viewParamCountry:             country,
+				viewParamErrors:              validationErrors,
+				viewParamRuleFields:          countryValidationRuleFields(),
+				viewParamEnums:               api.RestrictableEnums(),
+				viewParamIdentificationTypes: identificationTypeValues(),
+				viewParamGroupRoles:          api.CountryGroupRoles,
 			})
 		}
 
@@ -56,12 +73,51 @@ func HandleCountry(renderer Renderer, repo db.CountryRepo) http.Handler {
 		}
 
 		if !isNew {
-			country.ID = countryID
+			// the groups, roles and offices of the country are not part of the form, but are shown with it
+			country, err = repo.GetByID(r.Context(), countryID)
+			if err != nil {
+				l.Error("failed to get country", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 		}
+
+		switch r.FormValue(formParamAction) {
+		case actionAddGroup, actionDeleteGroup:
+			if isNew {
+				http.Error(w, "cannot change the groups of a new country", http.StatusBadRequest)
+				return
+			}
+			group := api.CountryGroup{
+				CountryID: country.ID,
+				Group:     strings.TrimSpace(r.FormValue(formParamGroup)),
+				Role:      r.FormValue(formParamRole),
+			}
+			groups := removeCountryGroup(country.Groups, group)
+			if r.FormValue(formParamAction) == actionAddGroup {
+				groups = append(groups, group)
+			}
+			if validationErrors = apivalidation.ValidateCountryGroups(groups, country.Roles); len(validationErrors) > 0 {
+				render()
+				return
+			}
+			if err := repo.PutGroups(r.Context(), country.ID, groups); err != nil {
+				l.Error("failed to put country groups", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/countries/"+country.ID, http.StatusSeeOther)
+			return
+		}
+
 		country.Name = strings.TrimSpace(r.FormValue(formParamName))
 		country.Code = strings.TrimSpace(strings.ToLower(r.FormValue(formParamCode)))
-		country.ReadGroup = strings.TrimSpace(r.FormValue(formParamReadGroup))
-		country.WriteGroup = strings.TrimSpace(r.FormValue(formParamWriteGroup))
+		country.ValidationRules = parseCountryValidationRules(r)
+
+		if validationErrors = apivalidation.ValidateCountryValidationRules(country.ValidationRules); len(validationErrors) > 0 {
+			render()
+			return
+		}
 
 		country, err = repo.Put(r.Context(), country)
 		if err != nil {
@@ -78,3 +134,76 @@ func HandleCountry(renderer Renderer, repo db.CountryRepo) http.Handler {
 
 	})
 }
+
+// removeCountryGroup removes the mapping of the group to the role
+func removeCountryGroup(groups api.CountryGroups, group api.CountryGroup) api.CountryGroups {
+	result := make(api.CountryGroups, 0, len(groups))
+	for _, existing := range groups {
+		if existing.Group != group.Group || existing.Role != group.Role {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
+
+// removeCountryGroupsOfRole removes the mappings of the groups to the role
+func removeCountryGroupsOfRole(groups api.CountryGroups, role string) api.CountryGroups {
+	result := make(api.CountryGroups, 0, len(groups))
+	for _, existing := range groups {
+		if existing.Role != role {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
+
+const (
+	formParamRequiredFields             = "RequiredFields"
+	formParamHiddenFields               = "HiddenFields"
+	formParamAllowedValuesPrefix        = "AllowedValues."
+	formParamIdentificationNumberPrefix = "IdentificationNumberPattern."
+)
+
+// countryValidationRuleField is a field that a country can require or hide
+type countryValidationRuleField struct {
+	Column string
+	Label  string
+}
+
+func countryValidationRuleFields() []countryValidationRuleField {
+	fields := api.ValidationRuleFields()
+	ret := make([]countryValidationRuleField, 0, len(fields))
+	for _, column := range fields {
+		ret = append(ret, countryValidationRuleField{Column: column, Label: api.ColumnLabel(column)})
+	}
+	return ret
+}
+
+// identificationTypeValues returns the identification types that can have an identification number pattern
+func identificationTypeValues() []api.RestrictableEnumValue {
+	enum, _ := api.RestrictableEnumByName("identification_type")
+	return enum.Values
+}
+
+// parseCountryValidationRules reads the validation rules from the country form. An enum whose values
+// are all allowed, or none, is not restricted.
+func parseCountryValidationRules(r *http.Request) api.CountryValidationRules {
+	rules := api.CountryValidationRules{
+		RequiredFields:               r.Form[formParamRequiredFields],
+		HiddenFields:                 r.Form[formParamHiddenFields],
+		AllowedValues:                map[string][]string{},
+		IdentificationNumberPatterns: map[string]string{},
+	}
+	for _, enum := range api.RestrictableEnums() {
+		allowed := r.Form[formParamAllowedValuesPrefix+enum.Name]
+		if len(allowed) > 0 && len(allowed) < len(enum.Values) {
+			rules.AllowedValues[enum.Name] = allowed
+		}
+	}
+	for _, identificationType := range enumTypes.AllIdentificationTypes().Items() {
+		if pattern := strings.TrimSpace(r.FormValue(formParamIdentificationNumberPrefix + string(identificationType))); pattern != "" {
+			rules.IdentificationNumberPatterns[string(identificationType)] = pattern
+		}
+	}
+	return rules
+}
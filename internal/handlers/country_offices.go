@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nrc-no/notcore/internal/api"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"go.uber.org/zap"
+)
+
+// HandleCountryOffices shows the field offices of a country and their groups, and adds, updates or removes them
+func HandleCountryOffices(renderer Renderer, repo db.CountryRepo) http.Handler {
+
+	const (
+		templateName        = "country_offices.gohtml"
+		pathParamCountryID  = "country_id"
+		viewParamCountry    = "Country"
+		viewParamErrors     = "ValidationErrors"
+		viewParamOffices    = "Offices"
+		formParamAction     = "Action"
+		formParamID         = "ID"
+		formParamName       = "Name"
+		formParamReadGroup  = "ReadGroup"
+		formParamWriteGroup = "WriteGroup"
+		actionSave          = "save"
+		actionDelete        = "delete"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			validationErrors validation.ErrorList
+			countryID        = mux.Vars(r)[pathParamCountryID]
+		)
+
+		country, err := repo.GetByID(ctx, countryID)
+		if err != nil {
+			l.Error("failed to get country", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		offices := country.Offices
+
+		render := func() {
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamCountry: country,
+				viewParamErrors:  validationErrors,
+				viewParamOffices: offices.Offices,
+			})
+		}
+
+		if r.Method == http.MethodGet {
+			render()
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch r.FormValue(formParamAction) {
+		case actionSave:
+			offices = putCountryOffice(offices, api.CountryOffice{
+				ID:         r.FormValue(formParamID),
+				Name:       strings.TrimSpace(r.FormValue(formParamName)),
+				ReadGroup:  strings.TrimSpace(r.FormValue(formParamReadGroup)),
+				WriteGroup: strings.TrimSpace(r.FormValue(formParamWriteGroup)),
+			})
+		case actionDelete:
+			offices = removeCountryOffice(offices, r.FormValue(formParamID))
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+
+		if validationErrors = apivalidation.ValidateCountryOffices(offices); len(validationErrors) > 0 {
+			render()
+			return
+		}
+
+		if err := repo.PutOffices(ctx, country.ID, offices); err != nil {
+			l.Error("failed to put country offices", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/countries/"+country.ID+"/offices", http.StatusSeeOther)
+	})
+}
+
+// putCountryOffice replaces the office with the same id, or adds the office with a new id when it has none
+func putCountryOffice(offices api.CountryOffices, office api.CountryOffice) api.CountryOffices {
+	result := make([]api.CountryOffice, 0, len(offices.Offices)+1)
+	if office.ID == "" {
+		office.ID = uuid.New().String()
+		result = append(result, offices.Offices...)
+		return api.CountryOffices{Offices: append(result, office)}
+	}
+	for _, existing := range offices.Offices {
+		if existing.ID == office.ID {
+			existing = office
+		}
+		result = append(result, existing)
+	}
+	return api.CountryOffices{Offices: result}
+}
+
+// removeCountryOffice removes the office with the given id
+func removeCountryOffice(offices api.CountryOffices, id string) api.CountryOffices {
+	result := make([]api.CountryOffice, 0, len(offices.Offices))
+	for _, office := range offices.Offices {
+		if office.ID != id {
+			result = append(result, office)
+		}
+	}
+	return api.CountryOffices{Offices: result}
+}
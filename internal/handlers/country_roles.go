@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nrc-no/notcore/internal/api"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"go.uber.org/zap"
+)
+
+// HandleCountryRoles shows the roles of a country and their permissions on the individual field groups,
+// and adds, updates or removes them
+func HandleCountryRoles(renderer Renderer, repo db.CountryRepo) http.Handler {
+
+	const (
+		templateName           = "country_roles.gohtml"
+		pathParamCountryID     = "country_id"
+		viewParamCountry       = "Country"
+		viewParamErrors        = "ValidationErrors"
+		viewParamRoles         = "Roles"
+		viewParamFieldGroups   = "FieldGroups"
+		viewParamPermissions   = "FieldPermissions"
+		formParamAction        = "Action"
+		formParamID            = "ID"
+		formParamName          = "Name"
+		formParamPermissionsOf = "Permissions."
+		actionSave             = "save"
+		actionDelete           = "delete"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			validationErrors validation.ErrorList
+			countryID        = mux.Vars(r)[pathParamCountryID]
+		)
+
+		country, err := repo.GetByID(ctx, countryID)
+		if err != nil {
+			l.Error("failed to get country", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		roles := country.Roles
+
+		render := func() {
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamCountry:     country,
+				viewParamErrors:      validationErrors,
+				viewParamRoles:       roles.Roles,
+				viewParamFieldGroups: auth.FieldGroups,
+				viewParamPermissions: auth.FieldPermissions,
+			})
+		}
+
+		if r.Method == http.MethodGet {
+			render()
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		groups := country.Groups
+		action := r.FormValue(formParamAction)
+
+		switch action {
+		case actionSave:
+			role := api.CountryRole{
+				ID:          r.FormValue(formParamID),
+				Name:        strings.TrimSpace(r.FormValue(formParamName)),
+				Permissions: map[auth.FieldGroup][]auth.FieldPermission{},
+			}
+			for _, group := range auth.FieldGroups {
+				for _, perm := range r.Form[formParamPermissionsOf+string(group)] {
+					role.Permissions[group] = append(role.Permissions[group], auth.FieldPermission(perm))
+				}
+			}
+			roles = putCountryRole(roles, role)
+		case actionDelete:
+			roles = removeCountryRole(roles, r.FormValue(formParamID))
+			groups = removeCountryGroupsOfRole(groups, r.FormValue(formParamID))
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+
+		if validationErrors = apivalidation.ValidateCountryRoles(roles); len(validationErrors) > 0 {
+			render()
+			return
+		}
+
+		if action == actionDelete {
+			// the groups mapped to the removed role are removed along with it
+			if err := repo.PutRolesAndGroups(ctx, country.ID, roles, groups); err != nil {
+				l.Error("failed to put country roles and groups", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if err := repo.PutRoles(ctx, country.ID, roles); err != nil {
+			l.Error("failed to put country roles", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/countries/"+country.ID+"/roles", http.StatusSeeOther)
+	})
+}
+
+// putCountryRole replaces the role with the same id, or adds the role with a new id when it has none
+func putCountryRole(roles api.CountryRoles, role api.CountryRole) api.CountryRoles {
+	result := make([]api.CountryRole, 0, len(roles.Roles)+1)
+	if role.ID == "" {
+		role.ID = uuid.New().String()
+		result = append(result, roles.Roles...)
+		return api.CountryRoles{Roles: append(result, role)}
+	}
+	for _, existing := range roles.Roles {
+		if existing.ID == role.ID {
+			existing = role
+		}
+		result = append(result, existing)
+	}
+	return api.CountryRoles{Roles: result}
+}
+
+// removeCountryRole removes the role with the given id
+func removeCountryRole(roles api.CountryRoles, id string) api.CountryRoles {
+	result := make([]api.CountryRole, 0, len(roles.Roles))
+	for _, role := range roles.Roles {
+		if role.ID != id {
+			result = append(result, role)
+		}
+	}
+	return api.CountryRoles{Roles: result}
+}
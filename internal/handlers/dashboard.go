@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+func HandleDashboard(renderer Renderer, repo db.IndividualStatisticsRepo) http.Handler {
+
+	const (
+		templateName          = "dashboard.gohtml"
+		viewParamStatistics   = "Statistics"
+		viewParamOptions      = "Options"
+		viewParamSearchAction = "SearchAction"
+		viewParamAgeBands     = "AgeBands"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx     = r.Context()
+			l       = logging.NewLogger(ctx)
+			options api.ListIndividualsOptions
+		)
+
+		selectedCountryID, err := utils.GetSelectedCountryID(ctx)
+		if err != nil {
+			l.Error("failed to get selected country id", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := api.NewIndividualListFromURLValues(r.Form, &options); err != nil {
+			l.Error("failed to parse options", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		// the filters cannot look at the fields the user cannot view, and the disabilities are health data
+		fieldAccess := api.NewIndividualFieldAccess(authIntf, selectedCountryID)
+		options.RestrictToViewableFields(fieldAccess)
+		withDisability := fieldAccess.CanView(constants.DBColumnIndividualHasDisability)
+
+		options.CountryID = selectedCountryID
+		if err := restrictToOffices(ctx, &options, auth.PermissionRead); err != nil {
+			l.Error("failed to restrict options to offices", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if err := resolveServiceCatalogueFilter(ctx, &options); err != nil {
+			l.Error("failed to resolve service catalogue filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.Skip = 0
+		options.Take = 0
+		options.Sort = nil
+
+		stats, err := repo.GetStatistics(ctx, options, api.DefaultAgeBands, withDisability)
+		if err != nil {
+			l.Error("failed to get statistics", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderer.RenderView(w, r, templateName, map[string]interface{}{
+			viewParamStatistics:   stats,
+			viewParamOptions:      options,
+			viewParamSearchAction: "/countries/" + selectedCountryID + "/dashboard",
+			viewParamAgeBands:     api.DefaultAgeBands.String(),
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	queryParamExport    = "export"
+	queryParamExpires   = "expires"
+	queryParamSignature = "signature"
+)
+
+var (
+	errInvalidDownloadLink = fmt.Errorf("invalid download link")
+	errExpiredDownloadLink = fmt.Errorf("download link expired")
+)
+
+// DownloadLinkSigner signs export download links so that they can only be used by the user
+// that requested the export, and only until the export expires
+type DownloadLinkSigner struct {
+	key []byte
+	// TTL is how long exports can be downloaded after they were created
+	TTL time.Duration
+	now func() time.Time
+}
+
+func NewDownloadLinkSigner(key []byte, ttl time.Duration) *DownloadLinkSigner {
+	return &DownloadLinkSigner{key: key, TTL: ttl, now: time.Now}
+}
+
+// Link returns the download link of the export for the given user
+func (s *DownloadLinkSigner) Link(countryID, exportID, userID string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	params := url.Values{}
+	params.Set(queryParamExport, exportID)
+	params.Set(queryParamExpires, strconv.FormatInt(expires, 10))
+	params.Set(queryParamSignature, s.signature(exportID, userID, expires))
+	return fmt.Sprintf("/countries/%s/participants/download?%s", countryID, params.Encode())
+}
+
+// Verify checks that the link parameters were signed for the given user and have not expired
+func (s *DownloadLinkSigner) Verify(params url.Values, userID string) (string, error) {
+	exportID := params.Get(queryParamExport)
+	expires, err := strconv.ParseInt(params.Get(queryParamExpires), 10, 64)
+	if err != nil || exportID == "" {
+		return "", errInvalidDownloadLink
+	}
+	signature, err := hex.DecodeString(params.Get(queryParamSignature))
+	if err != nil {
+		return "", errInvalidDownloadLink
+	}
+	expected, _ := hex.DecodeString(s.signature(exportID, userID, expires))
+	if !hmac.Equal(signature, expected) {
+		return "", errInvalidDownloadLink
+	}
+	if !s.now().Before(time.Unix(expires, 0)) {
+		return "", errExpiredDownloadLink
+	}
+	return exportID, nil
+}
+
+func (s *DownloadLinkSigner) signature(exportID, userID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d", exportID, userID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
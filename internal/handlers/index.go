@@ -28,7 +28,7 @@ func HandleHome(renderer Renderer) http.Handler {
 		if authInterface.IsGlobalAdmin() || len(allowedCountryIDs) != 1 {
 			http.Redirect(w, r, "/countries", http.StatusTemporaryRedirect)
 		} else {
-			http.Redirect(w, r, fmt.Sprintf("/countries/%s/participants", allowedCountryIDs[0]), http.StatusTemporaryRedirect)
+			http.Redirect(w, r, fmt.Sprintf("/countries/%s/dashboard", allowedCountryIDs[0]), http.StatusTemporaryRedirect)
 		}
 	})
 }
@@ -1,16 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"net/http"
+	"strings"
 
 	"github.com/nrc-no/notcore/pkg/api/deduplication"
 
 	"github.com/gorilla/mux"
 	"github.com/nrc-no/notcore/internal/api"
 	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/locales"
 	"github.com/nrc-no/notcore/internal/logging"
@@ -23,7 +27,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
+func HandleIndividual(renderer Renderer, repo db.IndividualRepo, accessEventRepo db.AccessEventRepo) http.Handler {
 
 	const (
 		templateName                        = "individual.gohtml"
@@ -50,6 +54,11 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 
 		render := func() {
 			individualForm.SetErrors(validationErrors)
+			if individual != nil {
+				if phoneNumberAlert := phoneNumberWarningAlert(ctx, individual); phoneNumberAlert != nil {
+					alerts = append(alerts, *phoneNumberAlert)
+				}
+			}
 			renderer.RenderView(w, r, templateName, viewParams{
 				"form":              individualForm,
 				"Individual":        individual,
@@ -94,7 +103,29 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 			individual.CountryID = selectedCountryID
 		}
 
-		individualForm, err = views.NewIndividualForm(individual)
+		country, err := utils.GetCountry(ctx, selectedCountryID)
+		if err != nil {
+			l.Error("failed to get selected country", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		fieldAccess := api.NewIndividualFieldAccess(authIntf, selectedCountryID)
+
+		// users restricted to some offices only access the individuals collected by these offices
+		if !isNew && !authIntf.HasOfficePermission(selectedCountryID, individual.CollectionOffice, auth.PermissionRead) {
+			l.Warn("user trying to access individual of another office", zap.String("individual_id", individual.ID))
+			http.Error(w, fmt.Sprintf("individual not found: %v", individual.ID), http.StatusNotFound)
+			return
+		}
+
+		individualForm, err = views.NewIndividualForm(individual, country.ValidationRules, country.ServiceCatalogue, fieldAccess)
 		if err != nil {
 			l.Error("failed to create individual form", zap.Error(err))
 			http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -103,10 +134,34 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 
 		// Render the form if GET
 		if r.Method == http.MethodGet {
+			if !isNew {
+				if err := recordAccess(ctx, accessEventRepo, api.AccessEvent{
+					Kind:          api.AccessEventKindViewIndividual,
+					CountryID:     selectedCountryID,
+					IndividualIDs: containers.NewStringSet(individual.ID),
+					RowCount:      1,
+				}); err != nil {
+					l.Error("failed to record access", zap.Error(err))
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+					return
+				}
+			}
 			render()
 			return
 		}
 
+		if isNew && !authIntf.HasCountryPermissionWrite(selectedCountryID) {
+			l.Warn("user trying to create an individual without the write permission")
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !isNew && !authIntf.HasOfficePermission(selectedCountryID, individual.CollectionOffice, auth.PermissionEditFields) {
+			l.Warn("user trying to update individual of an office they cannot write to", zap.String("individual_id", individual.ID))
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
 		// Parse the form
 		if err := r.ParseForm(); err != nil {
 			l.Error("failed to parse form", zap.Error(err))
@@ -125,11 +180,17 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 		individual.CountryID = selectedCountryID
 
 		individual.Normalize()
+		country.ServiceCatalogue.NormalizeIndividual(individual)
 
 		warningIcon := "exclamation-triangle"
 
 		// Validate the individual
 		validationErrors = apivalidation.ValidateIndividual(individual)
+		validationErrors = append(validationErrors, apivalidation.ValidateIndividualCountryRules(individual, country.ValidationRules)...)
+		validationErrors = append(validationErrors, apivalidation.ValidateIndividualServiceCatalogue(individual, country.ServiceCatalogue)...)
+		if offices, scoped := authIntf.GetAllowedOffices(selectedCountryID, auth.PermissionEditFields); scoped {
+			validationErrors = append(validationErrors, apivalidation.ValidateIndividualOffice(individual, offices)...)
+		}
 		if len(validationErrors) > 0 {
 			alerts = append(alerts, alert.Alert{
 				Type:        bootstrap.StyleDanger,
@@ -157,8 +218,20 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 				render()
 			}
 
+			if forbiddenTypes := forbiddenDeduplicationTypes(deduplicationConfig, fieldAccess); len(forbiddenTypes) > 0 {
+				alerts = append(alerts, alert.Alert{
+					Type:        bootstrap.StyleDanger,
+					Title:       t("error_forbidden_deduplication_types", strings.Join(forbiddenTypes, ", ")),
+					Icon:        warningIcon,
+					Dismissible: true,
+				})
+				render()
+				return
+			}
+
 			if len(deduplicationConfig.Types) > 0 {
-				duplicatesInFile, duplicatesInDB, err := repo.FindDuplicates(ctx, []*api.Individual{individual}, deduplicationConfig)
+				// the duplicates are only looked for among the participants the user can read
+				duplicatesInFile, duplicatesInDB, err := repo.FindDuplicates(ctx, []*api.Individual{individual}, deduplicationConfig, readableOffices(authIntf, selectedCountryID))
 
 				if err != nil {
 					alerts = append(alerts, alert.Alert{
@@ -183,6 +256,17 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 				}
 
 				if duplicatesInDB != nil { 
+					duplicates := duplicateIDs(duplicatesInDB)
+					if err := recordAccess(ctx, accessEventRepo, api.AccessEvent{
+						Kind:          api.AccessEventKindViewDuplicates,
+						CountryID:     selectedCountryID,
+						IndividualIDs: duplicates,
+						RowCount:      duplicates.Len(),
+					}); err != nil {
+						l.Error("failed to record access", zap.Error(err))
+						http.Error(w, "internal server error", http.StatusInternalServerError)
+						return
+					}
 					for _, dType := range deduplicationConfig.Types {
 						for _, field := range dType.Config.Columns {
 							value, err := individual.GetFieldValue(field)
@@ -205,7 +289,9 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 			}
 
 			// Save the individual
-			individual, err = repo.Put(ctx, individual, constants.IndividualDBColumns)
+			// Save the fields the user can edit, the others keep their stored values
+			editableColumns := containers.NewStringSet(fieldAccess.Columns(constants.IndividualDBColumns.Items(), auth.FieldPermissionEdit)...)
+			individual, err = repo.Put(ctx, individual, editableColumns)
 			if err != nil {
 				l.Error("failed to put individual", zap.Error(err))
 				err = apierrs.ErrorFrom(err)
@@ -226,3 +312,34 @@ func HandleIndividual(renderer Renderer, repo db.IndividualRepo) http.Handler {
 		}
 	})
 }
+
+// phoneNumberWarningAlert returns an alert listing the phone numbers of the individual that cannot be dialled,
+// or nil when there are none or the user cannot view them
+func phoneNumberWarningAlert(ctx context.Context, individual *api.Individual) *alert.Alert {
+	authIntf, err := utils.GetAuthContext(ctx)
+	if err != nil || !api.NewIndividualFieldAccess(authIntf, individual.CountryID).CanView(constants.DBColumnIndividualPhoneNumber1) {
+		return nil
+	}
+
+	region := ""
+	if country, err := utils.GetCountry(ctx, individual.CountryID); err == nil {
+		region = country.PhoneNumberRegion()
+	}
+	warnings := apivalidation.ValidateIndividualPhoneNumbers(individual, region)
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	t := locales.GetTranslator()
+	items := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		items = append(items, "<li>"+template.HTMLEscapeString(t("warning_phone_number", t(warning.Field), warning.BadValue, warning.Detail))+"</li>")
+	}
+	return &alert.Alert{
+		Type:        bootstrap.StyleWarning,
+		Title:       t("warning_phone_numbers"),
+		Icon:        "exclamation-triangle",
+		Content:     template.HTML("<ul class=\"mb-0\">" + strings.Join(items, "") + "</ul>"),
+		Dismissible: true,
+	}
+}
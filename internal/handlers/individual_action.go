@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/logging"
 	"github.com/nrc-no/notcore/internal/utils"
@@ -45,6 +46,19 @@ func HandleIndividualAction(repo db.IndividualRepo, action string) http.Handler
 			return
 		}
 
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !authIntf.HasOfficePermission(countryID, individual.CollectionOffice, auth.PermissionWrite) {
+			l.Warn("user trying to "+action+" individual of an office they cannot write to", zap.String("individual_id", individual.ID))
+			http.Error(w, fmt.Sprintf("individual not found: %v", individual.ID), http.StatusNotFound)
+			return
+		}
+
 		if err := repo.PerformAction(ctx, individual.ID, action); err != nil {
 			l.Error("failed to delete individual", zap.Error(err))
 			http.Error(w, err.Error(), http.StatusInternalServerError)
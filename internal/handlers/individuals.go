@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/constants"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/logging"
@@ -11,7 +12,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func HandleIndividuals(renderer Renderer, repo db.IndividualRepo) http.Handler {
+func HandleIndividuals(renderer Renderer, repo db.IndividualRepo, accessEventRepo db.AccessEventRepo) http.Handler {
 
 	const (
 		templateName         = "individuals.gohtml"
@@ -81,7 +82,21 @@ func HandleIndividuals(renderer Renderer, repo db.IndividualRepo) http.Handler {
 			})
 		}
 
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		getAllOptions.RestrictToViewableFields(api.NewIndividualFieldAccess(authIntf, selectedCountryID))
+
 		getAllOptions.CountryID = selectedCountryID
+		getAllOptions.RestrictToOffices(authIntf, selectedCountryID, auth.PermissionRead)
+		if err := resolveServiceCatalogueFilter(ctx, &getAllOptions); err != nil {
+			l.Error("failed to resolve service catalogue filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		individuals, err = repo.GetAll(ctx, getAllOptions)
 		if err != nil {
 			l.Error("failed to get individuals", zap.Error(err))
@@ -89,6 +104,18 @@ func HandleIndividuals(renderer Renderer, repo db.IndividualRepo) http.Handler {
 			return
 		}
 
+		if err := recordAccess(ctx, accessEventRepo, api.AccessEvent{
+			Kind:          api.AccessEventKindListIndividuals,
+			CountryID:     selectedCountryID,
+			IndividualIDs: individualIDs(individuals),
+			Filter:        getAllOptions.EncodeFilter(),
+			RowCount:      len(individuals),
+		}); err != nil {
+			l.Error("failed to record access", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
 		render()
 
 	})
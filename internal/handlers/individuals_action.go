@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/containers"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/locales"
@@ -56,6 +57,17 @@ func HandleIndividualsAction(renderer Renderer, repo db.IndividualRepo, action s
 			return
 		}
 		options.CountryID = countryID
+		// users restricted to some offices can only act on the individuals of the offices they can write to
+		if err := restrictToOffices(ctx, &options, auth.PermissionWrite); err != nil {
+			l.Error("failed to restrict options to offices", zap.Error(err))
+			renderError(t("error_action_execution", action), nil)
+			return
+		}
+		if err := resolveServiceCatalogueFilter(ctx, &options); err != nil {
+			l.Error("failed to resolve service catalogue filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		individuals, err := repo.GetAll(ctx, options)
 		if err != nil {
@@ -76,6 +88,19 @@ func HandleIndividualsAction(renderer Renderer, repo db.IndividualRepo, action s
 			return
 		}
 
+		if options.Offices != nil {
+			if outsideIndividualIds := validateIndividualsInOffices(individualIds, individuals, options.Offices); len(outsideIndividualIds) > 0 {
+				var errors []error
+				for _, individualId := range outsideIndividualIds {
+					errors = append(errors, fmt.Errorf(individualId))
+				}
+				l.Warn("user trying to "+action+" individuals of offices they cannot write to", zap.Strings("individual_ids", outsideIndividualIds))
+				renderError(t("error_action_execution", action),
+					[]api.FileError{{Message: t("error_action_outside_offices"), Err: errors}})
+				return
+			}
+		}
+
 		if err := repo.PerformActionMany(ctx, individualIds, action); err != nil {
 			l.Error("failed to "+action+" individuals", zap.Error(err))
 			renderError(t("error_action_failed_detail", action), nil)
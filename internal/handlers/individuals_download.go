@@ -1,18 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path"
-	"strings"
+	"strconv"
+	"sync/atomic"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/google/uuid"
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/storage"
 	"github.com/nrc-no/notcore/internal/utils"
 	"go.uber.org/zap"
 )
@@ -22,39 +24,8 @@ func generateUniqueDownloadFileNameForCountryAndExtension(selectedCountryID stri
 	return fileName
 }
 
-func assertValidFileNameForCountry(fileName, wantCountryID string) (string, string, error) {
-	parts := strings.Split(fileName, "_")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-	countryID, err := uuid.Parse(parts[0])
-	if err != nil {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-	if countryID.String() != wantCountryID {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-
-	secondParts := strings.Split(parts[1], ".")
-	if len(secondParts) != 2 {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-
-	ext := secondParts[1]
-	if !isValidFileExtension(ext) {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-
-	_, err = uuid.Parse(secondParts[0])
-	if err != nil {
-		return "", "", fmt.Errorf("invalid file name")
-	}
-
-	return "download." + ext, ext, nil
-}
-
 func isValidFileExtension(ext string) bool {
-	return ext == "csv" || ext == "xlsx"
+	return ext == "csv" || ext == "xlsx" || ext == "ods"
 }
 
 func setContentTypeForExtension(w http.ResponseWriter, ext string) {
@@ -63,26 +34,37 @@ func setContentTypeForExtension(w http.ResponseWriter, ext string) {
 		w.Header().Set("Content-Type", "text/csv")
 	case "xlsx":
 		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	case "ods":
+		w.Header().Set("Content-Type", "application/vnd.oasis.opendocument.spreadsheet")
 	}
 }
 
-type cleanupResponseWriter struct {
-	http.ResponseWriter
-	req    *http.Request
-	onDone func()
+// countingIterator counts the individuals it yields, to record how many were downloaded
+type countingIterator struct {
+	api.IndividualIterator
+	count int64
 }
 
-func (cw *cleanupResponseWriter) watchForDone() {
-	<-cw.req.Context().Done()
-	cw.onDone()
+func (it *countingIterator) Next(ctx context.Context) ([]*api.Individual, error) {
+	batch, err := it.IndividualIterator.Next(ctx)
+	atomic.AddInt64(&it.count, int64(len(batch)))
+	return batch, err
 }
 
+// HandleDownload exports the individuals matching the request filters to the blob store, records the export
+// and the access to the individuals, and redirects to a download link signed for the requesting user.
+// The link expires with the export.
 func HandleDownload(
 	userRepo db.IndividualRepo,
-	azureStorageClient *azblob.Client,
-	containerName string,
+	exportRepo db.ExportRepo,
+	accessEventRepo db.AccessEventRepo,
+	blobStore storage.BlobStore,
+	linkSigner *DownloadLinkSigner,
 ) http.Handler {
-	const queryParamFormat = "format"
+	const (
+		queryParamFormat = "format"
+		exportBatchSize  = 1000
+	)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -98,60 +80,74 @@ func HandleDownload(
 			return
 		}
 
-		file := r.URL.Query().Get("file")
-		if file != "" {
-			resultFileName, resultFileExtension, err := assertValidFileNameForCountry(file, selectedCountryID)
-			if err != nil {
-				l.Error("invalid file name", zap.Error(err))
-				http.Error(w, "invalid file name: "+err.Error(), http.StatusBadRequest)
+		session, ok := utils.GetSession(ctx)
+		if !ok {
+			l.Error("failed to get session")
+			http.Error(w, "couldn't get session", http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Query().Has(queryParamExport) {
+			exportID, err := linkSigner.Verify(r.URL.Query(), session.GetUserID())
+			if errors.Is(err, errExpiredDownloadLink) {
+				l.Warn("expired download link", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusGone)
+				return
+			} else if err != nil {
+				l.Warn("invalid download link", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusForbidden)
 				return
 			}
 
-			downloadStreamOptions := &azblob.DownloadStreamOptions{}
-			downloadStream, err := azureStorageClient.DownloadStream(ctx, containerName, file, downloadStreamOptions)
+			export, err := exportRepo.GetByID(ctx, exportID)
 			if err != nil {
-				l.Error("failed to download file", zap.Error(err))
-				http.Error(w, "failed to download file: "+err.Error(), http.StatusInternalServerError)
+				l.Error("failed to get export", zap.Error(err))
+				http.Error(w, "export not found", http.StatusNotFound)
 				return
 			}
-			defer downloadStream.Body.Close()
-
-			tempFile, err := saveStreamToTempFile(downloadStream.Body)
-			if err != nil {
-				l.Error("failed to save stream to file", zap.Error(err))
-				http.Error(w, "failed to save stream to file: "+err.Error(), http.StatusInternalServerError)
+			if export.CountryID != selectedCountryID || export.OwnerID != session.GetUserID() {
+				l.Warn("export does not belong to the user", zap.String("export_id", exportID))
+				http.Error(w, errInvalidDownloadLink.Error(), http.StatusForbidden)
+				return
+			}
+			if !export.IsAvailable(linkSigner.now()) {
+				l.Warn("export expired", zap.String("export_id", exportID))
+				http.Error(w, errExpiredDownloadLink.Error(), http.StatusGone)
 				return
 			}
 
-			// The temporary file may be served in chunks, so we only want to close and remove it after it's done.
-			onDone := func() {
-				err := tempFile.Close()
-				if err != nil {
+			blob, err := blobStore.Download(ctx, export.BlobName)
+			if errors.Is(err, storage.ErrBlobNotFound) {
+				l.Warn("file not found", zap.String("file", export.BlobName))
+				http.Error(w, "file not found", http.StatusNotFound)
+				return
+			} else if err != nil {
+				l.Error("failed to download file", zap.Error(err))
+				http.Error(w, "failed to download file: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer func() {
+				if err := blob.Close(); err != nil {
 					l.Error("failed to close file", zap.Error(err))
 				}
-
-				err = os.Remove(tempFile.Name())
-				if err != nil {
-					l.Error("failed to remove file", zap.Error(err))
-				}
+			}()
+
+			setContentTypeForExtension(w, export.Format)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "download."+export.Format))
+			w.Header().Set("Last-Modified", blob.LastModified().UTC().Format(http.TimeFormat))
+			w.Header().Set("Cache-Control", "private, no-store")
+			if size := blob.Size(); size >= 0 {
+				w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
 			}
-
-			cw := &cleanupResponseWriter{
-				ResponseWriter: w,
-				req:            r,
-				onDone:         onDone,
+			if _, err := io.Copy(w, blob); err != nil {
+				l.Error("failed to write file", zap.Error(err))
 			}
 
-			go cw.watchForDone()
-
-			setContentTypeForExtension(w, resultFileExtension)
-			http.ServeContent(cw, r, resultFileName, *downloadStream.LastModified, tempFile)
-
 			return
 		}
 
 		// the file was not created yet, so we need to create it, and redirect
-		// the request to the same endpoint, but with the file query param.
+		// the request to the signed download link of the export.
 
 		if err := r.ParseForm(); err != nil {
 			l.Error("failed to parse form", zap.Error(err))
@@ -166,7 +162,24 @@ func HandleDownload(
 			return
 		}
 
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "couldn't get auth context: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// only the fields the user can export are written, and filters cannot look at the fields they cannot view
+		fieldAccess := api.NewIndividualFieldAccess(authIntf, selectedCountryID)
+		getAllOptions.RestrictToViewableFields(fieldAccess)
+		columns := fieldAccess.FileColumns(auth.FieldPermissionExport)
+
 		getAllOptions.CountryID = selectedCountryID
+		getAllOptions.RestrictToOffices(authIntf, selectedCountryID, auth.PermissionRead)
+		if err := resolveServiceCatalogueFilter(ctx, &getAllOptions); err != nil {
+			l.Error("failed to resolve service catalogue filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		getAllOptions.Take = 0
 		getAllOptions.Skip = 0
 
@@ -181,76 +194,65 @@ func HandleDownload(
 			return
 		}
 
-		ret, err := userRepo.GetAll(ctx, getAllOptions)
-		if err != nil {
-			l.Error("failed to get individuals", zap.Error(err))
-			http.Error(w, "failed to get records: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+		individuals := &countingIterator{IndividualIterator: userRepo.Iterate(getAllOptions, exportBatchSize)}
 
 		fileName := generateUniqueDownloadFileNameForCountryAndExtension(selectedCountryID, format)
 
-		downloadFile, err := os.Create(path.Join("/tmp", fileName))
-		if err != nil {
-			l.Error("failed to create temp file", zap.Error(err))
-			http.Error(w, "failed to create temp file: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer func() {
-			if err := downloadFile.Close(); err != nil {
-				l.Error("failed to close temp file", zap.Error(err))
-			}
-			if err := os.Remove(downloadFile.Name()); err != nil {
-				l.Error("failed to remove temp file", zap.Error(err))
+		// the individuals are streamed from the database, through the encoder, to the blob store,
+		// so that only a single batch is held in memory at any time
+		pipeReader, pipeWriter := io.Pipe()
+		go func() {
+			var err error
+			switch format {
+			case "xlsx":
+				err = api.StreamIndividualsExcel(ctx, pipeWriter, individuals, columns)
+			case "csv":
+				err = api.StreamIndividualsCSV(ctx, pipeWriter, individuals, columns)
+			case "ods":
+				err = api.StreamIndividualsODS(ctx, pipeWriter, individuals, columns)
 			}
+			pipeWriter.CloseWithError(err)
 		}()
 
-		if format == "xlsx" {
-			if err := api.MarshalIndividualsExcel(downloadFile, ret); err != nil {
-				l.Error("failed to write xlsx", zap.Error(err))
-				http.Error(w, "failed to write xlsx: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-
+		if err := blobStore.Upload(ctx, fileName, pipeReader); err != nil {
+			_ = pipeReader.CloseWithError(err)
+			l.Error("failed to upload file", zap.Error(err))
+			http.Error(w, "failed to upload file: "+err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		if format == "csv" {
-			if err := api.MarshalIndividualsCSV(downloadFile, ret); err != nil {
-				l.Error("failed to write csv", zap.Error(err))
-				http.Error(w, "failed to write csv: "+err.Error(), http.StatusInternalServerError)
-				return
+		now := linkSigner.now().UTC()
+		export, err := exportRepo.Create(ctx, &api.Export{
+			CountryID:  selectedCountryID,
+			OwnerID:    session.GetUserID(),
+			OwnerEmail: session.GetUserEmail(),
+			BlobName:   fileName,
+			Format:     format,
+			Filter:     getAllOptions.EncodeFilter(),
+			CreatedAt:  now,
+			ExpiresAt:  now.Add(linkSigner.TTL),
+		})
+		if err != nil {
+			l.Error("failed to record export", zap.Error(err))
+			if err := blobStore.Delete(ctx, fileName); err != nil {
+				l.Error("failed to delete unrecorded export file", zap.Error(err))
 			}
+			http.Error(w, "failed to record export: "+err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		_, err = azureStorageClient.UploadFile(ctx, containerName, fileName, downloadFile, nil)
-		if err != nil {
-			l.Error("failed to upload file", zap.Error(err))
-			http.Error(w, "failed to upload file: "+err.Error(), http.StatusInternalServerError)
+		if err := recordAccess(ctx, accessEventRepo, api.AccessEvent{
+			Kind:      api.AccessEventKindDownloadIndividuals,
+			CountryID: selectedCountryID,
+			Filter:    export.Filter,
+			RowCount:  int(atomic.LoadInt64(&individuals.count)),
+		}); err != nil {
+			l.Error("failed to record access", zap.Error(err))
+			http.Error(w, "failed to record access: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		redirectPath := fmt.Sprintf("/countries/%s/participants/download?file=%s",
-			selectedCountryID,
-			path.Base(fileName),
-		)
+		redirectPath := linkSigner.Link(selectedCountryID, export.ID, session.GetUserID(), export.ExpiresAt)
 		http.Redirect(w, r, redirectPath, http.StatusSeeOther)
 	})
 }
-
-func saveStreamToTempFile(stream io.ReadCloser) (*os.File, error) {
-	tmpfile, err := os.CreateTemp("/tmp", "")
-	if err != nil {
-		return nil, err
-	}
-
-	if _, err := io.Copy(tmpfile, stream); err != nil {
-		return nil, err
-	}
-
-	// Seek back to the start of the temporary file
-	if _, err := tmpfile.Seek(0, 0); err != nil {
-		return nil, err
-	}
-
-	return tmpfile, nil
-}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/internal/storage"
+	"github.com/nrc-no/notcore/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDownload(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	ctrl := gomock.NewController(t)
+	countryID := uuid.New().String()
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := db.NewMockIndividualRepo(ctrl)
+	repo.EXPECT().Iterate(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(options api.ListIndividualsOptions, _ int) api.IndividualIterator {
+			assert.Equal(t, countryID, options.CountryID)
+			return api.NewIndividualSliceIterator([]*api.Individual{{ID: "1", CountryID: countryID, FullName: "John Doe"}})
+		})
+
+	var export *api.Export
+	exportRepo := db.NewMockExportRepo(ctrl)
+	exportRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, e *api.Export) (*api.Export, error) {
+			e.ID = uuid.New().String()
+			export = e
+			return e, nil
+		})
+	exportRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, id string) (*api.Export, error) {
+			require.Equal(t, export.ID, id)
+			return export, nil
+		}).AnyTimes()
+
+	accessEventRepo := db.NewMockAccessEventRepo(ctrl)
+	accessEventRepo.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, e *api.AccessEvent) error {
+			assert.Equal(t, api.AccessEventKindDownloadIndividuals, e.Kind)
+			assert.Equal(t, "issuer:alice", e.UserID)
+			assert.Equal(t, countryID, e.CountryID)
+			assert.Equal(t, export.Filter, e.Filter)
+			assert.Equal(t, 1, e.RowCount)
+			return nil
+		})
+
+	blobStore := storage.NewMemoryBlobStore()
+	signer := NewDownloadLinkSigner([]byte("secret"), time.Hour)
+	signer.now = func() time.Time { return now }
+	handler := HandleDownload(repo, exportRepo, accessEventRepo, blobStore, signer)
+
+	fieldPermissions := auth.CountryFieldPermissions{}
+	fieldPermissions.Get(countryID).AddAll(auth.FieldPermissionView, auth.FieldPermissionExport)
+	authIntf := auth.New(
+		auth.CountryPermissions{countryID: containers.NewSet(auth.PermissionRead)},
+		fieldPermissions,
+		auth.CountryOfficePermissions{},
+		containers.NewStringSet(countryID),
+		false,
+	)
+
+	serve := func(target string, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		ctx := utils.WithSelectedCountryID(req.Context(), countryID)
+		ctx = utils.WithSession(ctx, auth.NewAuthenticatedSession(nil, userID+"@example.com", "issuer", userID, now.Add(time.Hour), now))
+		ctx = utils.WithAuthContext(ctx, authIntf)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	// exporting records the export and redirects to a signed link
+	rec := serve("/countries/"+countryID+"/participants/download?format=csv", "alice")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.NotNil(t, export)
+	assert.Equal(t, "issuer:alice", export.OwnerID)
+	assert.Equal(t, countryID, export.CountryID)
+	assert.Equal(t, "csv", export.Format)
+	assert.True(t, strings.HasPrefix(export.BlobName, countryID+"_"))
+	assert.Equal(t, now.Add(time.Hour), export.ExpiresAt)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, export.ID, location.Query().Get("export"))
+
+	// the owner can download the file
+	rec = serve(location.String(), "alice")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "John Doe")
+
+	// other users cannot use the link
+	rec = serve(location.String(), "bob")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// tampered links are rejected
+	tampered := location.Query()
+	tampered.Set("expires", "99999999999")
+	rec = serve(location.Path+"?"+tampered.Encode(), "alice")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// the link expires with the export
+	signer.now = func() time.Time { return now.Add(2 * time.Hour) }
+	rec = serve(location.String(), "alice")
+	assert.Equal(t, http.StatusGone, rec.Code)
+}
+
+func TestHandleDownload_exportsPermittedFields(t *testing.T) {
+	locales.LoadTranslations()
+	locales.Init()
+
+	ctrl := gomock.NewController(t)
+	countryID := uuid.New().String()
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := db.NewMockIndividualRepo(ctrl)
+	repo.EXPECT().Iterate(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(options api.ListIndividualsOptions, _ int) api.IndividualIterator {
+			assert.Nil(t, options.IsChildAtRisk, "filters on fields that cannot be viewed are dropped")
+			return api.NewIndividualSliceIterator([]*api.Individual{{
+				ID:           "1",
+				CountryID:    countryID,
+				FullName:     "John Doe",
+				PhoneNumber1: "+4712345678",
+			}})
+		})
+	var blobName string
+	exportRepo := db.NewMockExportRepo(ctrl)
+	exportRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, e *api.Export) (*api.Export, error) {
+			e.ID = uuid.New().String()
+			blobName = e.BlobName
+			return e, nil
+		})
+
+	accessEventRepo := db.NewMockAccessEventRepo(ctrl)
+	accessEventRepo.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+
+	blobStore := storage.NewMemoryBlobStore()
+	signer := NewDownloadLinkSigner([]byte("secret"), time.Hour)
+	signer.now = func() time.Time { return now }
+	handler := HandleDownload(repo, exportRepo, accessEventRepo, blobStore, signer)
+
+	// the user can view the contact details but only export the general fields
+	fieldPermissions := auth.CountryFieldPermissions{}
+	fieldPermissions.Get(countryID).Add(auth.FieldGroupGeneral, auth.FieldPermissionView, auth.FieldPermissionExport)
+	fieldPermissions.Get(countryID).Add(auth.FieldGroupContact, auth.FieldPermissionView)
+	authIntf := auth.New(
+		auth.CountryPermissions{countryID: containers.NewSet(auth.PermissionRead)},
+		fieldPermissions,
+		auth.CountryOfficePermissions{},
+		containers.NewStringSet(countryID),
+		false,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/countries/"+countryID+"/participants/download?format=csv&is_child_at_risk=true", nil)
+	ctx := utils.WithSelectedCountryID(req.Context(), countryID)
+	ctx = utils.WithSession(ctx, auth.NewAuthenticatedSession(nil, "alice@example.com", "issuer", "alice", now.Add(time.Hour), now))
+	ctx = utils.WithAuthContext(ctx, authIntf)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(ctx))
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+
+	blob, err := blobStore.Download(ctx, blobName)
+	require.NoError(t, err)
+	defer blob.Close()
+	content, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "John Doe")
+	assert.NotContains(t, string(content), "+4712345678")
+	assert.NotContains(t, string(content), locales.GetTranslator()(constants.FileColumnIndividualPhoneNumber1))
+}
+
+func TestDownloadLinkSigner(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewDownloadLinkSigner([]byte("secret"), time.Hour)
+	signer.now = func() time.Time { return now }
+
+	link, err := url.Parse(signer.Link("country", "export", "user", now.Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, "/countries/country/participants/download", link.Path)
+
+	exportID, err := signer.Verify(link.Query(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "export", exportID)
+
+	_, err = signer.Verify(link.Query(), "other")
+	assert.ErrorIs(t, err, errInvalidDownloadLink)
+
+	_, err = NewDownloadLinkSigner([]byte("other secret"), time.Hour).Verify(link.Query(), "user")
+	assert.ErrorIs(t, err, errInvalidDownloadLink)
+
+	_, err = signer.Verify(url.Values{queryParamExport: {"export"}}, "user")
+	assert.ErrorIs(t, err, errInvalidDownloadLink)
+
+	signer.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = signer.Verify(link.Query(), "user")
+	assert.ErrorIs(t, err, errExpiredDownloadLink)
+}
@@ -1,30 +1,58 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/nrc-no/notcore/internal/api"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/constants"
 	"github.com/nrc-no/notcore/internal/containers"
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/locales"
 	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/storage"
 	"github.com/nrc-no/notcore/internal/utils"
 	"github.com/nrc-no/notcore/pkg/api/deduplication"
+	"github.com/nrc-no/notcore/pkg/api/validation"
 	"go.uber.org/zap"
 )
 
 var UPLOAD_LIMIT = 10000
 
-func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Handler {
+// pendingUploadTTL is how long an uploaded file is kept while the user maps its columns
+const pendingUploadTTL = 1 * time.Hour
+
+// maxUploadRequestSize is the size of the largest upload request, made of the file and the other form fields.
+// Files between api.MaxUploadSize and this size are rejected with an error shown to the user.
+const maxUploadRequestSize = 2 * api.MaxUploadSize
+
+func HandleUpload(
+	renderer Renderer,
+	individualRepo db.IndividualRepo,
+	importProfileRepo db.ImportProfileRepo,
+	pendingUploadRepo db.PendingUploadRepo,
+	accessEventRepo db.AccessEventRepo,
+	blobStore storage.BlobStore,
+) http.Handler {
 
 	const (
 		templateName                        = "error.gohtml"
+		mappingTemplateName                 = "upload_mapping.gohtml"
 		formParamFile                       = "file"
 		formParamDeduplicationType          = "deduplicationType"
 		formParamDeduplicationLogicOperator = "deduplicationLogicOperator"
+		formParamPendingUpload              = "pendingUpload"
+		formParamImportProfileName          = "importProfileName"
+		formParamColumnPrefix               = "column-"
+		formParamDateOrder                  = "dateOrder"
+		formParamLocale                     = "locale"
 	)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -50,6 +78,8 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			})
 		}
 
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadRequestSize)
+
 		// todo: find sensible max memory value
 		maxMemory := int64(1024 * 1024 * 1024)
 		if err := r.ParseMultipartForm(maxMemory); err != nil {
@@ -58,27 +88,201 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			return
 		}
 
-		filename := r.MultipartForm.File[formParamFile][0].Filename
+		selectedCountryID, err := utils.GetSelectedCountryID(ctx)
+		if err != nil {
+			l.Error("failed to get selected country id", zap.Error(err))
+			renderError(t("error_no_selected_country"), nil)
+			return
+		}
 
-		formFile, _, err := r.FormFile(formParamFile)
+		country, err := utils.GetCountry(ctx, selectedCountryID)
 		if err != nil {
-			l.Error("failed to get form file", zap.Error(err))
-			renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
+			l.Error("failed to get selected country", zap.Error(err))
+			renderError(t("error_no_selected_country"), nil)
 			return
 		}
 
-		var individuals []*api.Individual
-		var fields []string
-		var records [][]string
-		fileErrors := []api.FileError{}
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "couldn't get auth context", http.StatusInternalServerError)
+			return
+		}
+		fieldAccess := api.NewIndividualFieldAccess(authIntf, selectedCountryID)
 
-		err = api.UnmarshallRecordsFromFile(&records, formFile, filename)
+		session, ok := utils.GetSession(ctx)
+		if !ok {
+			l.Error("failed to get session")
+			http.Error(w, "couldn't get session", http.StatusInternalServerError)
+			return
+		}
+
+		settings, err := api.NewTabularParseSettings(r.FormValue(formParamDateOrder), r.FormValue(formParamLocale))
 		if err != nil {
-			l.Error("failed to parse file", zap.Error(err))
+			l.Warn("invalid parse settings", zap.Error(err))
 			renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
 			return
 		}
 
+		var (
+			records [][]string
+			// rawRecords are the records before their columns were mapped
+			rawRecords [][]string
+			// recordSources are the sheet and row of the file each record after the header was read from
+			recordSources []api.RecordSource
+			mapping       api.ColumnMapping
+			pendingUpload *api.PendingUpload
+			profileName   string
+			filename      string
+			content       []byte
+		)
+
+		renderMapping := func(dateWarnings []api.DateAmbiguityWarning) {
+			dateOrder := settings.DateOrder
+			if len(dateWarnings) > 0 {
+				dateOrder = dateWarnings[0].Guess
+			}
+			renderer.RenderView(w, r, mappingTemplateName, map[string]interface{}{
+				"PendingUpload":              pendingUpload.ID,
+				"Rows":                       newUploadColumnMappingRows(api.ResolveHeaders(rawRecords[0]), rawRecords, mapping),
+				"Columns":                    uploadColumnChoices(),
+				"SelectedDeduplicationTypes": r.MultipartForm.Value[formParamDeduplicationType],
+				"DeduplicationLogicOperator": r.FormValue(formParamDeduplicationLogicOperator),
+				"DateWarnings":               dateWarnings,
+				"DateOrder":                  dateOrder,
+				"Locale":                     settings.Locale,
+				"ImportProfileName":          profileName,
+				"Options":                    api.ListIndividualsOptions{CountryID: selectedCountryID},
+			})
+		}
+
+		if pendingUploadID := r.FormValue(formParamPendingUpload); pendingUploadID != "" {
+			// the user mapped the columns of a file uploaded previously
+			pendingUpload, err = pendingUploadRepo.GetByID(ctx, pendingUploadID)
+			if err != nil ||
+				pendingUpload.CountryID != selectedCountryID ||
+				pendingUpload.OwnerID != session.GetUserID() ||
+				!pendingUpload.IsAvailable(time.Now()) {
+				l.Warn("invalid pending upload", zap.String("pending_upload", pendingUploadID), zap.Error(err))
+				renderError(t("error_pending_upload"), nil)
+				return
+			}
+
+			if rawRecords, recordSources, err = readPendingUpload(ctx, blobStore, pendingUpload); err != nil {
+				l.Error("failed to read pending upload", zap.Error(err))
+				renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
+				return
+			}
+
+			mapping = api.ColumnMapping{}
+			for i, header := range rawRecords[0] {
+				mapping.Set(header, r.FormValue(fmt.Sprintf("%s%d", formParamColumnPrefix, i)))
+			}
+
+			if records, err = api.ApplyColumnMapping(rawRecords, mapping); err != nil {
+				renderError(t("error_failed_to_parse_file"), []api.FileError{{Message: t("error_unknown_column"), Err: []error{err}}})
+				return
+			}
+
+			profileName = strings.TrimSpace(r.FormValue(formParamImportProfileName))
+		} else {
+			filename = r.MultipartForm.File[formParamFile][0].Filename
+
+			formFile, fileHeader, err := r.FormFile(formParamFile)
+			if err != nil {
+				l.Error("failed to get form file", zap.Error(err))
+				renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
+				return
+			}
+			defer formFile.Close()
+			if fileHeader.Size > api.MaxUploadSize {
+				l.Warn("uploaded file is too large", zap.Int64("size", fileHeader.Size))
+				renderError(t("error_upload_too_large", api.MaxUploadSize>>20), nil)
+				return
+			}
+
+			content, err = io.ReadAll(formFile)
+			if err != nil {
+				l.Error("failed to read form file", zap.Error(err))
+				renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
+				return
+			}
+
+			sheets, err := api.UnmarshalSheetsFromFile(bytes.NewReader(content), filename)
+			if err != nil {
+				l.Error("failed to parse file", zap.Error(err))
+				renderError(t("error_failed_to_parse_file_v0", err.Error()), nil)
+				return
+			}
+
+			var fileErrors []api.FileError
+			if rawRecords, recordSources, fileErrors = api.RecordsFromSheets(sheets); fileErrors != nil {
+				renderError(t("error_failed_to_parse_file"), fileErrors)
+				return
+			}
+			records = rawRecords
+
+			if api.HasUnknownHeaders(api.ResolveHeaders(rawRecords[0])) {
+				profile, err := importProfileRepo.FindByHeaderSignature(ctx, selectedCountryID, api.HeaderSignature(rawRecords[0]))
+				if err != nil {
+					l.Error("failed to find import profile", zap.Error(err))
+				}
+
+				if profile != nil && profile.Mapping.Covers(rawRecords[0]) {
+					l.Info("mapping columns with import profile", zap.String("import_profile", profile.Name))
+					mapping = profile.Mapping
+					settings = settings.WithDefaults(profile.Settings)
+					if records, err = api.ApplyColumnMapping(rawRecords, mapping); err != nil {
+						renderError(t("error_failed_to_parse_file"), []api.FileError{{Message: t("error_unknown_column"), Err: []error{err}}})
+						return
+					}
+				} else {
+					// keep the file while the user maps its columns
+					if pendingUpload, err = savePendingUpload(ctx, pendingUploadRepo, blobStore, session, selectedCountryID, filename, content); err != nil {
+						l.Error("failed to save pending upload", zap.Error(err))
+						renderError(t("error_upload_fail", err.Error()), nil)
+						return
+					}
+					renderMapping(nil)
+					return
+				}
+			}
+		}
+
+		records, dateWarnings := api.NormalizeTabularRecords(records, settings)
+		if len(dateWarnings) > 0 {
+			// the order of the dates is a guess, so the user confirms it before anything is imported
+			if pendingUpload == nil {
+				if pendingUpload, err = savePendingUpload(ctx, pendingUploadRepo, blobStore, session, selectedCountryID, filename, content); err != nil {
+					l.Error("failed to save pending upload", zap.Error(err))
+					renderError(t("error_upload_fail", err.Error()), nil)
+					return
+				}
+			}
+			renderMapping(dateWarnings)
+			return
+		}
+
+		if pendingUpload != nil {
+			if profileName != "" {
+				_, err := importProfileRepo.Put(ctx, &api.ImportProfile{
+					CountryID:       selectedCountryID,
+					Name:            profileName,
+					HeaderSignature: api.HeaderSignature(mapping.Headers()),
+					Mapping:         mapping,
+					Settings:        settings,
+				})
+				if err != nil {
+					l.Error("failed to save import profile", zap.Error(err))
+				}
+			}
+			deletePendingUpload(ctx, pendingUploadRepo, blobStore, pendingUpload)
+		}
+
+		var individuals []*api.Individual
+		var fields []string
+		fileErrors := []api.FileError{}
+
 		colMapping, fileErrors := api.GetColumnMapping(records[0], &fields)
 
 		if fileErrors != nil {
@@ -93,10 +297,53 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			return
 		}
 
+		fileErrors = validateIndividualsConsistency(individuals, recordSources)
+		if len(fileErrors) > 0 {
+			renderError(t("error_inconsistent_participants", len(fileErrors)), fileErrors)
+			return
+		}
+
+		if fileErrors = validateRequiredColumns(colMapping, country.ValidationRules); len(fileErrors) > 0 {
+			renderError(t("error_failed_to_parse_file"), fileErrors)
+			return
+		}
+
+		if fileErrors = validateEditableColumns(colMapping, fieldAccess); len(fileErrors) > 0 {
+			renderError(t("error_failed_to_parse_file"), fileErrors)
+			return
+		}
+
+		fileErrors = validateIndividualsCountryRules(individuals, recordSources, country.ValidationRules)
+		if len(fileErrors) > 0 {
+			renderError(t("error_country_rules_participants", len(fileErrors)), fileErrors)
+			return
+		}
+
+		fileErrors = validateIndividualsServiceCatalogue(individuals, recordSources, country.ServiceCatalogue)
+		if len(fileErrors) > 0 {
+			renderError(t("error_service_catalogue_participants", len(fileErrors)), fileErrors)
+			return
+		}
+
+		// users restricted to some offices can only upload the individuals of the offices they can write to
+		offices, officeScoped := authIntf.GetAllowedOffices(selectedCountryID, auth.PermissionWrite)
+		if officeScoped {
+			fileErrors = validateIndividualsOffices(individuals, recordSources, offices)
+			if len(fileErrors) > 0 {
+				renderError(t("error_offices_participants", len(fileErrors)), fileErrors)
+				return
+			}
+		}
+
 		deduplicationTypes := r.MultipartForm.Value[formParamDeduplicationType]
 		deduplicationLogicOperator := deduplication.LogicOperator(r.MultipartForm.Value[formParamDeduplicationLogicOperator][0])
 		deduplicationConfig, err := deduplication.GetDeduplicationConfig(deduplicationTypes, deduplicationLogicOperator)
 
+		if forbiddenTypes := forbiddenDeduplicationTypes(deduplicationConfig, fieldAccess); len(forbiddenTypes) > 0 {
+			renderError(t("error_forbidden_deduplication_types", strings.Join(forbiddenTypes, ", ")), nil)
+			return
+		}
+
 		mandatoryColumns := []string{constants.DBColumnIndividualLastName}
 		var idColumnExistsInFile bool
 		if _, idColumnExistsInFile = colMapping[constants.DBColumnIndividualID]; idColumnExistsInFile {
@@ -123,13 +370,6 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			}
 		}
 
-		selectedCountryID, err := utils.GetSelectedCountryID(ctx)
-		if err != nil {
-			l.Error("failed to get selected country id", zap.Error(err))
-			renderError(t("error_no_selected_country"), nil)
-			return
-		}
-
 		fieldSet := containers.NewStringSet(fields...)
 		fieldSet.Add("country_id")
 
@@ -156,8 +396,23 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			return
 		}
 
+		if officeScoped && individualIds.Len() > 0 {
+			updatedIndividualIds := containers.NewStringSet()
+			for _, existingIndividual := range existingIndividuals {
+				if individualIds.Contains(existingIndividual.ID) {
+					updatedIndividualIds.Add(existingIndividual.ID)
+				}
+			}
+			if outsideIndividualIds := validateIndividualsInOffices(updatedIndividualIds, existingIndividuals, offices); len(outsideIndividualIds) > 0 {
+				l.Warn("user trying to update individuals of offices they cannot write to", zap.Strings("individual_ids", outsideIndividualIds))
+				renderError(t("error_participants_outside_offices", strings.Join(outsideIndividualIds, ",")), nil)
+				return
+			}
+		}
+
 		if len(deduplicationConfig.Types) > 0 {
-			duplicatesInFile, duplicatesInDB, err := individualRepo.FindDuplicates(ctx, individuals, deduplicationConfig)
+			// the duplicates are only looked for among the participants the user can read, since their values are shown
+			duplicatesInFile, duplicatesInDB, err := individualRepo.FindDuplicates(ctx, individuals, deduplicationConfig, readableOffices(authIntf, selectedCountryID))
 			
 			if err != nil {
 				renderError(t("error_deduplication_fail", err.Error()), nil)
@@ -177,6 +432,17 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 			if duplicatesInDB != nil {
 				dbDuplicationErrors := api.FormatDbDeduplicationErrors(duplicatesInDB, individuals, deduplicationConfig)
 				if len(dbDuplicationErrors) > 0 {
+					duplicates := duplicateIDs(duplicatesInDB)
+					if err := recordAccess(ctx, accessEventRepo, api.AccessEvent{
+						Kind:          api.AccessEventKindViewDuplicates,
+						CountryID:     selectedCountryID,
+						IndividualIDs: duplicates,
+						RowCount:      duplicates.Len(),
+					}); err != nil {
+						l.Error("failed to record access", zap.Error(err))
+						renderError(t("error_upload_fail", err.Error()), nil)
+						return
+					}
 					ids := []string{}
 					for _, d := range duplicatesInDB {
 						for _, dd := range d {
@@ -205,3 +471,107 @@ func HandleUpload(renderer Renderer, individualRepo db.IndividualRepo) http.Hand
 		return
 	})
 }
+
+// validateIndividualsConsistency returns an error for each uploaded individual whose fields contradict each other
+func validateIndividualsConsistency(individuals []*api.Individual, sources []api.RecordSource) []api.FileError {
+	return validateIndividualRows(individuals, sources, "error_row_inconsistent", apivalidation.ValidateIndividualConsistency)
+}
+
+// validateIndividualsCountryRules returns an error for each uploaded individual that breaks the validation rules of the country
+func validateIndividualsCountryRules(individuals []*api.Individual, sources []api.RecordSource, rules api.CountryValidationRules) []api.FileError {
+	return validateIndividualRows(individuals, sources, "error_row_country_rules", func(individual *api.Individual) validation.ErrorList {
+		return apivalidation.ValidateIndividualCountryRules(individual, rules)
+	})
+}
+
+// validateIndividualsServiceCatalogue returns an error for each uploaded individual whose service fields are not
+// in the service catalogue of the country. The values matching an entry except for the case are replaced by the entry.
+func validateIndividualsServiceCatalogue(individuals []*api.Individual, sources []api.RecordSource, catalogue api.ServiceCatalogue) []api.FileError {
+	return validateIndividualRows(individuals, sources, "error_row_service_catalogue", func(individual *api.Individual) validation.ErrorList {
+		catalogue.NormalizeIndividual(individual)
+		return apivalidation.ValidateIndividualServiceCatalogue(individual, catalogue)
+	})
+}
+
+// validateIndividualsOffices returns an error for each uploaded individual collected by another office than the given ones
+func validateIndividualsOffices(individuals []*api.Individual, sources []api.RecordSource, offices []string) []api.FileError {
+	return validateIndividualRows(individuals, sources, "error_row_offices", func(individual *api.Individual) validation.ErrorList {
+		return apivalidation.ValidateIndividualOffice(individual, offices)
+	})
+}
+
+// validateIndividualRows returns an error for each uploaded individual that fails the given validation,
+// titled with the translation of rowMessageKey for the sheet and row the individual was read from
+func validateIndividualRows(individuals []*api.Individual, sources []api.RecordSource, rowMessageKey string, validate func(individual *api.Individual) validation.ErrorList) []api.FileError {
+	t := locales.GetTranslator()
+	var fileErrors []api.FileError
+	for idx, individual := range individuals {
+		errs := validate(individual)
+		if len(errs) == 0 {
+			continue
+		}
+		rowErrors := make([]error, 0, len(errs))
+		for _, err := range errs {
+			rowErrors = append(rowErrors, fmt.Errorf("%s: %s", api.ColumnLabel(err.Field), err.Detail))
+		}
+		fileErrors = append(fileErrors, api.FileError{
+			Message: rowMessage(t, rowMessageKey, sources, idx),
+			Err:     rowErrors,
+		})
+	}
+	return fileErrors
+}
+
+// rowMessage translates rowMessageKey for the sheet and row the individual at the given index was read from
+func rowMessage(t locales.Translator, rowMessageKey string, sources []api.RecordSource, idx int) string {
+	if idx >= len(sources) {
+		return t(rowMessageKey, idx+2)
+	}
+	source := sources[idx]
+	if source.Sheet == "" {
+		return t(rowMessageKey, source.Row)
+	}
+	return t("error_in_sheet", source.Sheet, t(rowMessageKey, source.Row))
+}
+
+// validateRequiredColumns returns an error if the file lacks columns that the country requires
+func validateRequiredColumns(colMapping map[string]int, rules api.CountryValidationRules) []api.FileError {
+	t := locales.GetTranslator()
+	var missing []error
+	for _, field := range rules.RequiredFields {
+		if _, ok := colMapping[field]; !ok {
+			missing = append(missing, errors.New(api.ColumnLabel(field)))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []api.FileError{{Message: t("error_missing_required_columns"), Err: missing}}
+}
+
+// forbiddenDeduplicationTypes returns the labels of the deduplication types comparing columns that the user cannot view
+func forbiddenDeduplicationTypes(config deduplication.DeduplicationConfig, access api.IndividualFieldAccess) []string {
+	t := locales.GetTranslator()
+	var forbidden []string
+	for _, dType := range config.Types {
+		if !access.CanDeduplicate(dType) {
+			forbidden = append(forbidden, t(dType.Label))
+		}
+	}
+	return forbidden
+}
+
+// validateEditableColumns returns an error if the file has columns that the user cannot edit
+func validateEditableColumns(colMapping map[string]int, access api.IndividualFieldAccess) []api.FileError {
+	t := locales.GetTranslator()
+	var forbidden []error
+	for _, field := range constants.IndividualDBColumns.Items() {
+		if _, ok := colMapping[field]; ok && !access.CanEdit(field) {
+			forbidden = append(forbidden, errors.New(api.ColumnLabel(field)))
+		}
+	}
+	if len(forbidden) == 0 {
+		return nil
+	}
+	return []api.FileError{{Message: t("error_forbidden_columns"), Err: forbidden}}
+}
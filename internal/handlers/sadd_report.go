@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+// HandleSADDReport exports the sex-, age- and disability-disaggregated counts of the individuals
+// matching the request filters, as an xlsx workbook with one sheet per breakdown.
+// The service filters of the request also restrict which services are counted.
+func HandleSADDReport(repo db.IndividualRepo) http.Handler {
+	const queryParamAgeBands = "age_bands"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx     = r.Context()
+			l       = logging.NewLogger(ctx)
+			options api.ListIndividualsOptions
+		)
+
+		selectedCountryID, err := utils.GetSelectedCountryID(ctx)
+		if err != nil {
+			l.Error("failed to get selected country id", zap.Error(err))
+			http.Error(w, "couldn't get selected country id: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := api.NewIndividualListFromURLValues(r.Form, &options); err != nil {
+			l.Error("failed to parse options", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		authIntf, err := utils.GetAuthContext(ctx)
+		if err != nil {
+			l.Error("failed to get auth context", zap.Error(err))
+			http.Error(w, "couldn't get auth context: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// the filters cannot look at the fields the user cannot view, and the disabilities are health data
+		fieldAccess := api.NewIndividualFieldAccess(authIntf, selectedCountryID)
+		options.RestrictToViewableFields(fieldAccess)
+		withDisability := fieldAccess.CanView(constants.DBColumnIndividualHasDisability)
+
+		options.CountryID = selectedCountryID
+		if err := restrictToOffices(ctx, &options, auth.PermissionRead); err != nil {
+			l.Error("failed to restrict options to offices", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if err := resolveServiceCatalogueFilter(ctx, &options); err != nil {
+			l.Error("failed to resolve service catalogue filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.Skip = 0
+		options.Take = 0
+		options.Sort = nil
+
+		ageBands := api.DefaultAgeBands
+		if value := r.Form.Get(queryParamAgeBands); value != "" {
+			if ageBands, err = api.ParseAgeBands(value); err != nil {
+				l.Error("invalid age bands", zap.Error(err))
+				http.Error(w, "invalid age bands: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		individuals, err := repo.GetAll(ctx, options)
+		if err != nil {
+			l.Error("failed to get individuals", zap.Error(err))
+			http.Error(w, "failed to get records: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		reportOptions := api.NewSADDReportOptions(options, ageBands)
+		reportOptions.IncludeDisability = withDisability
+		report := api.NewSADDReport(individuals, reportOptions)
+
+		fileName := fmt.Sprintf("sadd_report_%s.xlsx", time.Now().Format("2006-01-02"))
+		setContentTypeForExtension(w, "xlsx")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		if err := api.MarshalSADDReportExcel(w, report); err != nil {
+			l.Error("failed to write report", zap.Error(err))
+			http.Error(w, "failed to write report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nrc-no/notcore/internal/api"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"go.uber.org/zap"
+)
+
+// apiTokenLifetimes are the number of days an API token can be issued for
+var apiTokenLifetimes = []int{30, 90, 180, 365}
+
+// HandleServiceAccounts lists the service accounts and creates new ones
+func HandleServiceAccounts(renderer Renderer, repo db.ServiceAccountRepo) http.Handler {
+
+	const (
+		templateName         = "service_accounts.gohtml"
+		viewParamAccounts    = "ServiceAccounts"
+		viewParamAccount     = "ServiceAccount"
+		viewParamErrors      = "ValidationErrors"
+		formParamName        = "Name"
+		formParamDescription = "Description"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			validationErrors validation.ErrorList
+			account          = &api.ServiceAccount{}
+		)
+
+		accounts, err := repo.GetAll(ctx)
+		if err != nil {
+			l.Error("failed to get service accounts", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		render := func() {
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamAccounts: accounts,
+				viewParamAccount:  account,
+				viewParamErrors:   validationErrors,
+			})
+		}
+
+		if r.Method == http.MethodGet {
+			render()
+			return
+		}
+
+		session, ok := utils.GetSession(ctx)
+		if !ok {
+			l.Error("failed to get session")
+			http.Error(w, "couldn't get session", http.StatusInternalServerError)
+			return
+		}
+
+		account.Name = strings.TrimSpace(r.FormValue(formParamName))
+		account.Description = strings.TrimSpace(r.FormValue(formParamDescription))
+		account.CreatedBy = session.GetUserID()
+
+		if validationErrors = apivalidation.ValidateServiceAccount(account, accounts); len(validationErrors) > 0 {
+			render()
+			return
+		}
+
+		account, err = repo.Create(ctx, account)
+		if err != nil {
+			l.Error("failed to create service account", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		l.Info("created service account", zap.String("service_account_id", account.ID), zap.String("name", account.Name))
+		http.Redirect(w, r, "/service-accounts/"+account.ID, http.StatusSeeOther)
+	})
+}
+
+// HandleServiceAccount shows a service account and its API tokens, and issues or revokes them.
+// The secret of a new token is only shown in the response that issues it.
+func HandleServiceAccount(renderer Renderer, repo db.ServiceAccountRepo) http.Handler {
+
+	const (
+		templateName              = "service_account.gohtml"
+		pathParamServiceAccountID = "service_account_id"
+		viewParamAccount          = "ServiceAccount"
+		viewParamTokens           = "Tokens"
+		viewParamToken            = "Token"
+		viewParamSecret           = "Secret"
+		viewParamCountries        = "Countries"
+		viewParamPermissions      = "Permissions"
+		viewParamLifetimes        = "Lifetimes"
+		viewParamNow              = "Now"
+		viewParamErrors           = "ValidationErrors"
+		formParamAction           = "Action"
+		formParamTokenID          = "TokenID"
+		formParamName             = "Name"
+		formParamCountryIDs       = "CountryIDs"
+		formParamPermission       = "Permission"
+		formParamLifetime         = "Lifetime"
+		actionIssueToken          = "issue_token"
+		actionRevokeToken         = "revoke_token"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			validationErrors validation.ErrorList
+			accountID        = mux.Vars(r)[pathParamServiceAccountID]
+			token            = &api.APIToken{Scopes: api.APITokenScopes{Permission: api.CountryGroupRoleRead}}
+			secret           string
+			now              = time.Now().UTC()
+		)
+
+		account, err := repo.GetByID(ctx, accountID)
+		if err != nil {
+			l.Error("failed to get service account", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		countries, err := utils.GetCountries(ctx)
+		if err != nil {
+			l.Error("failed to get countries", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		render := func() {
+			tokens, err := repo.GetTokens(ctx, account.ID)
+			if err != nil {
+				l.Error("failed to get api tokens", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamAccount:     account,
+				viewParamTokens:      tokens,
+				viewParamToken:       token,
+				viewParamSecret:      secret,
+				viewParamCountries:   countries,
+				viewParamPermissions: api.CountryGroupRoles,
+				viewParamLifetimes:   apiTokenLifetimes,
+				viewParamNow:         now,
+				viewParamErrors:      validationErrors,
+			})
+		}
+
+		if r.Method == http.MethodGet {
+			render()
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch r.FormValue(formParamAction) {
+		case actionIssueToken:
+			session, ok := utils.GetSession(ctx)
+			if !ok {
+				l.Error("failed to get session")
+				http.Error(w, "couldn't get session", http.StatusInternalServerError)
+				return
+			}
+
+			token.ServiceAccountID = account.ID
+			token.Name = strings.TrimSpace(r.FormValue(formParamName))
+			token.Scopes = api.APITokenScopes{
+				CountryIDs: r.Form[formParamCountryIDs],
+				Permission: r.FormValue(formParamPermission),
+			}
+			if days, err := strconv.Atoi(r.FormValue(formParamLifetime)); err == nil {
+				token.ExpiresAt = now.AddDate(0, 0, days)
+			}
+			token.CreatedBy = session.GetUserID()
+
+			countryIDs := containers.NewStringSet()
+			for _, country := range countries {
+				countryIDs.Add(country.ID)
+			}
+			if validationErrors = apivalidation.ValidateAPIToken(token, countryIDs, now); len(validationErrors) > 0 {
+				render()
+				return
+			}
+
+			newSecret, err := auth.NewAPITokenSecret()
+			if err != nil {
+				l.Error("failed to generate api token", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			token.Hash = auth.HashAPITokenSecret(newSecret)
+
+			issued, err := repo.CreateToken(ctx, token)
+			if err != nil {
+				l.Error("failed to create api token", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			l.Info("issued api token",
+				zap.String("service_account_id", account.ID),
+				zap.String("api_token_id", issued.ID),
+				zap.Strings("country_ids", issued.Scopes.CountryIDs),
+				zap.String("permission", issued.Scopes.Permission),
+				zap.Time("expires_at", issued.ExpiresAt))
+
+			// the form is reset, and the secret is shown this once
+			token = &api.APIToken{Scopes: api.APITokenScopes{Permission: api.CountryGroupRoleRead}}
+			secret = newSecret
+			render()
+
+		case actionRevokeToken:
+			tokenID := r.FormValue(formParamTokenID)
+			if err := repo.RevokeToken(ctx, account.ID, tokenID); err != nil {
+				l.Error("failed to revoke api token", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			l.Info("revoked api token", zap.String("service_account_id", account.ID), zap.String("api_token_id", tokenID))
+			http.Redirect(w, r, "/service-accounts/"+account.ID, http.StatusSeeOther)
+
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/api/enumTypes"
+	apivalidation "github.com/nrc-no/notcore/internal/api/validation"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/pkg/api/validation"
+	"go.uber.org/zap"
+)
+
+// HandleServiceCatalogue shows the service catalogue and the donor registry of a country,
+// and adds or removes their entries
+func HandleServiceCatalogue(renderer Renderer, repo db.CountryRepo) http.Handler {
+
+	const (
+		templateName        = "service_catalogue.gohtml"
+		pathParamCountryID  = "country_id"
+		viewParamCountry    = "Country"
+		viewParamErrors     = "ValidationErrors"
+		viewParamServices   = "Services"
+		viewParamDonors     = "Donors"
+		viewParamServiceCCs = "ServiceCCs"
+		formParamAction     = "Action"
+		formParamID         = "ID"
+		formParamKind       = "Kind"
+		formParamParentID   = "ParentID"
+		formParamServiceCC  = "ServiceCC"
+		formParamName       = "Name"
+		actionAdd           = "add"
+		actionDelete        = "delete"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx              = r.Context()
+			l                = logging.NewLogger(ctx)
+			validationErrors validation.ErrorList
+			countryID        = mux.Vars(r)[pathParamCountryID]
+		)
+
+		country, err := repo.GetByID(ctx, countryID)
+		if err != nil {
+			l.Error("failed to get country", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		render := func() {
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamCountry:    country,
+				viewParamErrors:     validationErrors,
+				viewParamServices:   serviceCatalogueRows(country.ServiceCatalogue, api.ServiceCatalogueEntryKindServiceType),
+				viewParamDonors:     serviceCatalogueRows(country.ServiceCatalogue, api.ServiceCatalogueEntryKindDonor),
+				viewParamServiceCCs: enumTypes.AllServiceCCs().Items(),
+			})
+		}
+
+		if r.Method == http.MethodGet {
+			render()
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		catalogue := country.ServiceCatalogue
+		switch r.FormValue(formParamAction) {
+		case actionAdd:
+			catalogue.Entries = append(catalogue.Entries, api.ServiceCatalogueEntry{
+				ID:        uuid.New().String(),
+				Kind:      api.ServiceCatalogueEntryKind(r.FormValue(formParamKind)),
+				ParentID:  r.FormValue(formParamParentID),
+				ServiceCC: enumTypes.ServiceCC(r.FormValue(formParamServiceCC)),
+				Name:      strings.TrimSpace(r.FormValue(formParamName)),
+			})
+		case actionDelete:
+			catalogue = removeServiceCatalogueEntry(catalogue, r.FormValue(formParamID))
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+
+		if validationErrors = apivalidation.ValidateServiceCatalogue(catalogue); len(validationErrors) > 0 {
+			render()
+			return
+		}
+
+		if err := repo.PutServiceCatalogue(ctx, country.ID, catalogue); err != nil {
+			l.Error("failed to put service catalogue", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/countries/"+country.ID+"/service-catalogue", http.StatusSeeOther)
+	})
+}
+
+// serviceCatalogueRow is an entry of the service catalogue, as listed on the service catalogue page
+type serviceCatalogueRow struct {
+	Entry api.ServiceCatalogueEntry
+	// Indent is the indentation of the entry, in rem
+	Indent int
+	// ChildKind is the kind of the entries that can be added under the entry, if any
+	ChildKind api.ServiceCatalogueEntryKind
+}
+
+// serviceCatalogueRows returns the entries of the given kind followed, each, by the entries below it
+func serviceCatalogueRows(catalogue api.ServiceCatalogue, kind api.ServiceCatalogueEntryKind) []serviceCatalogueRow {
+	var rows []serviceCatalogueRow
+	var walk func(kind api.ServiceCatalogueEntryKind, parentID string, depth int)
+	walk = func(kind api.ServiceCatalogueEntryKind, parentID string, depth int) {
+		for _, entry := range catalogue.Entries {
+			if entry.Kind != kind || entry.ParentID != parentID {
+				continue
+			}
+			childKind := kind.ChildKind()
+			rows = append(rows, serviceCatalogueRow{Entry: entry, Indent: depth * 2, ChildKind: childKind})
+			if childKind != "" {
+				walk(childKind, entry.ID, depth+1)
+			}
+		}
+	}
+	walk(kind, "", 0)
+	return rows
+}
+
+// removeServiceCatalogueEntry removes the entry and all the entries below it
+func removeServiceCatalogueEntry(catalogue api.ServiceCatalogue, id string) api.ServiceCatalogue {
+	removed := map[string]bool{}
+	for _, descendant := range catalogue.Descendants(id) {
+		removed[descendant] = true
+	}
+	entries := make([]api.ServiceCatalogueEntry, 0, len(catalogue.Entries))
+	for _, entry := range catalogue.Entries {
+		if !removed[entry.ID] {
+			entries = append(entries, entry)
+		}
+	}
+	return api.ServiceCatalogue{Entries: entries}
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"bytes"
 	"github.com/nrc-no/notcore/internal/api"
 	"github.com/nrc-no/notcore/internal/locales"
 	"github.com/nrc-no/notcore/pkg/api/deduplication"
 	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/logging"
@@ -105,6 +107,8 @@ type RequestContext struct {
 	SelectedCountry *api.Country
 	// Session is the current user session
 	Session auth.Session
+	// UserSession is the server-side session of the user. May be nil, such as for the service accounts
+	UserSession *api.UserSession
 	// AvailableLocales is a list of the available locales
 	AvailableLocales map[string]string
 
@@ -112,14 +116,35 @@ type RequestContext struct {
 	EnableBetaFeatures bool
 }
 
+// IsImpersonating returns true if a global admin views the application as another user
+func (r RequestContext) IsImpersonating() bool {
+	return r.UserSession != nil && r.UserSession.IsImpersonating(time.Now())
+}
+
 func (r RequestContext) HasSelectedCountryWritePermission() bool {
 	return r.Auth.HasCountryPermissionWrite(r.SelectedCountryID())
 }
 
+// HasSelectedCountryEditPermission returns true if the user can save the fields of the existing individuals
+// of the selected country, which the roles allow without the write permission
+func (r RequestContext) HasSelectedCountryEditPermission() bool {
+	return r.Auth.HasCountryLevelPermission(r.SelectedCountryID(), auth.PermissionEditFields)
+}
+
 func (r RequestContext) HasSelectedCountryReadPermission() bool {
 	return r.Auth.HasCountryPermissionRead(r.SelectedCountryID())
 }
 
+// FieldAccess returns the access of the user to the individual fields of the selected country
+func (r RequestContext) FieldAccess() api.IndividualFieldAccess {
+	return api.NewIndividualFieldAccess(r.Auth, r.SelectedCountryID())
+}
+
+// CanViewField returns true if the user can view the individual column in the selected country
+func (r RequestContext) CanViewField(column string) bool {
+	return r.FieldAccess().CanView(column)
+}
+
 func (r RequestContext) SelectedCountryID() string {
 	if r.SelectedCountry == nil {
 		return ""
@@ -127,6 +152,10 @@ func (r RequestContext) SelectedCountryID() string {
 	return r.SelectedCountry.ID
 }
 
+// CSRFTokenPlaceholder stands for the CSRF token of the request in the templates.
+// It is replaced by the token when the view is rendered, since the templates are shared by all requests.
+const CSRFTokenPlaceholder = "__csrf_token__"
+
 // viewParams is a map of key/value pairs that can be used to render a view.
 type viewParams map[string]interface{}
 
@@ -201,6 +230,8 @@ func renderView(
 		return
 	}
 
+	userSession, _ := utils.GetUserSession(ctx)
+
 	var selectedCountry *api.Country
 	if len(selectedCountryID) != 0 {
 		for _, c := range countries {
@@ -219,15 +250,25 @@ func renderView(
 		Countries:          countries,
 		SelectedCountry:    selectedCountry,
 		Session:            session,
+		UserSession:        userSession,
 		AvailableLocales:   availableLocales,
 		EnableBetaFeatures: enableBetaFeatures,
 	}
 	vd[vd.RequestContextKey()] = rc
 	vd["DeduplicationTypes"] = deduplication.DeduplicationTypes
+	vd["UploadDateOrders"] = api.AllDateOrders()
+	vd["UploadLocales"] = api.TabularLocales()
 	vd["CurrentLang"] = locales.CurrentLang.String()
 	vd["IsRTL"] = locales.CurrentLang.String() == "ar"
 
-	if err := templates[tmpl].ExecuteTemplate(w, "base", vd); err != nil {
+	var buf bytes.Buffer
+	if err := templates[tmpl].ExecuteTemplate(&buf, "base", vd); err != nil {
 		l.Error("failed to execute template", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	out := bytes.ReplaceAll(buf.Bytes(), []byte(CSRFTokenPlaceholder), []byte(utils.GetCSRFToken(ctx)))
+	if _, err := w.Write(out); err != nil {
+		l.Error("failed to write view", zap.Error(err))
 	}
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/storage"
+	"go.uber.org/zap"
+)
+
+// maxUploadColumnSamples is the number of values shown for each column when mapping the columns of a file
+const maxUploadColumnSamples = 3
+
+// uploadColumnMappingRow is a header of an uploaded file, as shown on the column mapping page
+type uploadColumnMappingRow struct {
+	api.HeaderMapping
+	Samples []string
+	// Selected is the db column preselected for the header
+	Selected string
+	// SelectedSuggestion is true if the preselected column is one of the suggestions
+	SelectedSuggestion bool
+}
+
+// uploadColumnChoice is a column that headers can be mapped to
+type uploadColumnChoice struct {
+	Column     string
+	FileColumn string
+}
+
+// newUploadColumnMappingRows returns the rows of the column mapping page. The columns are preselected
+// from the mapping, if any, or from the known headers and the best suggestions.
+func newUploadColumnMappingRows(headers []api.HeaderMapping, records [][]string, mapping api.ColumnMapping) []uploadColumnMappingRow {
+	rows := make([]uploadColumnMappingRow, len(headers))
+	for i, header := range headers {
+		row := uploadColumnMappingRow{HeaderMapping: header, Selected: header.Column}
+		if column, ok := mapping.Get(header.Header); ok {
+			row.Selected = column
+		} else if !header.IsKnown() && len(header.Suggestions) > 0 {
+			row.Selected = header.Suggestions[0].Column
+		}
+		for _, suggestion := range header.Suggestions {
+			if suggestion.Column == row.Selected {
+				row.SelectedSuggestion = true
+			}
+		}
+		for _, record := range records[1:] {
+			if len(row.Samples) >= maxUploadColumnSamples {
+				break
+			}
+			if header.Index < len(record) && strings.TrimSpace(record[header.Index]) != "" {
+				row.Samples = append(row.Samples, record[header.Index])
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func uploadColumnChoices() []uploadColumnChoice {
+	choices := make([]uploadColumnChoice, 0, len(constants.IndividualFileColumns))
+	for _, fileColumn := range constants.IndividualFileColumns {
+		choices = append(choices, uploadColumnChoice{Column: constants.IndividualFileToDBMap[fileColumn], FileColumn: fileColumn})
+	}
+	return choices
+}
+
+// savePendingUpload keeps an uploaded file in the blob store until its columns are mapped
+func savePendingUpload(
+	ctx context.Context,
+	pendingUploadRepo db.PendingUploadRepo,
+	blobStore storage.BlobStore,
+	session auth.Session,
+	countryID string,
+	filename string,
+	content []byte,
+) (*api.PendingUpload, error) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	blobName := generateUniqueDownloadFileNameForCountryAndExtension(countryID, ext)
+	if err := blobStore.Upload(ctx, blobName, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	pendingUpload, err := pendingUploadRepo.Create(ctx, &api.PendingUpload{
+		CountryID: countryID,
+		OwnerID:   session.GetUserID(),
+		BlobName:  blobName,
+		Format:    ext,
+		CreatedAt: now,
+		ExpiresAt: now.Add(pendingUploadTTL),
+	})
+	if err != nil {
+		_ = blobStore.Delete(ctx, blobName)
+		return nil, err
+	}
+	return pendingUpload, nil
+}
+
+// readPendingUpload parses the records of a file kept while its columns are mapped, and where they were read from
+func readPendingUpload(ctx context.Context, blobStore storage.BlobStore, pendingUpload *api.PendingUpload) ([][]string, []api.RecordSource, error) {
+	blob, err := blobStore.Download(ctx, pendingUpload.BlobName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer blob.Close()
+
+	sheets, err := api.UnmarshalSheetsFromFile(blob, "upload."+pendingUpload.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, sources, fileErrors := api.RecordsFromSheets(sheets)
+	if fileErrors != nil {
+		// the file was already read successfully before it was kept
+		return nil, nil, errors.New(fileErrors[0].Message)
+	}
+	return records, sources, nil
+}
+
+// deletePendingUpload removes a file that was kept while its columns were mapped.
+// Failures are only logged, since the janitor deletes the file once it expires anyway
+func deletePendingUpload(ctx context.Context, pendingUploadRepo db.PendingUploadRepo, blobStore storage.BlobStore, pendingUpload *api.PendingUpload) {
+	l := logging.NewLogger(ctx)
+	if err := blobStore.Delete(ctx, pendingUpload.BlobName); err != nil {
+		l.Warn("failed to delete pending upload", zap.Error(err))
+		return
+	}
+	if err := pendingUploadRepo.MarkDeleted(ctx, pendingUpload.ID, time.Now().UTC()); err != nil {
+		l.Warn("failed to mark pending upload as deleted", zap.Error(err))
+	}
+}
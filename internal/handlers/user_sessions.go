@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+// impersonationLifetime is how long a global admin views the application as another user
+const impersonationLifetime = 30 * time.Minute
+
+// HandleUserSessions lists the active sessions of the users, and revokes them.
+// Revoking the sessions of a user logs them out of every browser.
+// Global admins can also view the application as one of the users, with the groups of their latest
+// session, to reproduce what they see. Nothing can be changed while doing so.
+func HandleUserSessions(renderer Renderer, repo db.UserSessionRepo) http.Handler {
+
+	const (
+		templateName       = "user_sessions.gohtml"
+		viewParamSessions  = "Sessions"
+		viewParamCurrentID = "CurrentSessionID"
+		formParamAction    = "Action"
+		formParamID        = "ID"
+		formParamUserID    = "UserID"
+		actionRevoke       = "revoke"
+		actionRevokeUser   = "revoke_user"
+		actionImpersonate  = "impersonate"
+		userSessionsURL    = "/sessions"
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx = r.Context()
+			l   = logging.NewLogger(ctx)
+		)
+
+		sessions, err := repo.GetActive(ctx, time.Now().UTC())
+		if err != nil {
+			l.Error("failed to get user sessions", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			var currentID string
+			if current, ok := utils.GetUserSession(ctx); ok {
+				currentID = current.ID
+			}
+			renderer.RenderView(w, r, templateName, viewParams{
+				viewParamSessions:  sessions,
+				viewParamCurrentID: currentID,
+			})
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Error("failed to parse form", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		session, ok := utils.GetSession(ctx)
+		if !ok {
+			l.Error("failed to get session")
+			http.Error(w, "couldn't get session", http.StatusInternalServerError)
+			return
+		}
+
+		switch r.FormValue(formParamAction) {
+		case actionRevoke:
+			id := r.FormValue(formParamID)
+			if err := repo.Revoke(ctx, id, session.GetUserID()); err != nil {
+				l.Error("failed to revoke user session", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			l.Info("revoked user session", zap.String("user_session_id", id))
+
+		case actionRevokeUser:
+			userID := r.FormValue(formParamUserID)
+			for _, s := range sessions {
+				if s.UserID != userID {
+					continue
+				}
+				if err := repo.Revoke(ctx, s.ID, session.GetUserID()); err != nil {
+					l.Error("failed to revoke user session", zap.Error(err))
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				l.Info("revoked user session", zap.String("user_session_id", s.ID), zap.String("user_id", userID))
+			}
+
+		case actionImpersonate:
+			userID := r.FormValue(formParamUserID)
+			current, ok := utils.GetUserSession(ctx)
+			if !ok {
+				l.Error("failed to get user session")
+				http.Error(w, "couldn't get user session", http.StatusInternalServerError)
+				return
+			}
+			if userID == current.UserID {
+				http.Error(w, "cannot view as yourself", http.StatusBadRequest)
+				return
+			}
+			impersonated, err := repo.GetLatestByUserID(ctx, userID)
+			if err != nil {
+				l.Error("failed to get user session", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if impersonated == nil {
+				http.Error(w, "user not found", http.StatusNotFound)
+				return
+			}
+			if err := repo.StartImpersonation(ctx, current.ID, impersonated, time.Now().Add(impersonationLifetime)); err != nil {
+				l.Error("failed to start impersonation", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			l.Info("started impersonation",
+				zap.String("impersonated_user", impersonated.UserID),
+				zap.Strings("impersonated_groups", impersonated.Groups.Items()))
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, userSessionsURL, http.StatusSeeOther)
+	})
+}
+
+// HandleStopImpersonation ends the impersonation of the current session, and sends the global admin back to the sessions.
+// It is not restricted to the global admins, since they have the permissions of the impersonated user until it ends.
+func HandleStopImpersonation(repo db.UserSessionRepo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx = r.Context()
+			l   = logging.NewLogger(ctx)
+		)
+
+		userSession, ok := utils.GetUserSession(ctx)
+		if !ok {
+			l.Error("failed to get user session")
+			http.Error(w, "couldn't get user session", http.StatusInternalServerError)
+			return
+		}
+
+		if err := repo.StopImpersonation(ctx, userSession.ID); err != nil {
+			l.Error("failed to stop impersonation", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		l.Info("stopped impersonation", zap.String("impersonated_user", userSession.ImpersonatedUserID))
+
+		http.Redirect(w, r, "/sessions", http.StatusSeeOther)
+	})
+}
+
+// HandleLogout revokes the session of the user, and sends them to the logout URL,
+// where the session of the authenticating proxy can be ended as well
+func HandleLogout(repo db.UserSessionRepo, logoutURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var (
+			ctx = r.Context()
+			l   = logging.NewLogger(ctx)
+		)
+
+		if userSession, ok := utils.GetUserSession(ctx); ok {
+			if err := repo.Revoke(ctx, userSession.ID, userSession.UserID); err != nil {
+				l.Error("failed to revoke user session", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			l.Info("logged out", zap.String("user_session_id", userSession.ID))
+		}
+
+		auth.ClearSessionCookie(w)
+		http.Redirect(w, r, logoutURL, http.StatusSeeOther)
+	})
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
 	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/utils"
 )
 
 func validateIndividualsExistInCountry(individualIds containers.StringSet, existingIndividuals []*api.Individual, expectedCountryId string) []string {
@@ -30,3 +34,55 @@ func validateIndividualsExistInCountry(individualIds containers.StringSet, exist
 
 	return invalidIndividualIds.Items()
 }
+
+// resolveServiceCatalogueFilter resolves the service catalogue entry selected in the options
+// against the catalogue of the country of the options
+func resolveServiceCatalogueFilter(ctx context.Context, options *api.ListIndividualsOptions) error {
+	if options.ServiceCatalogueID == "" {
+		return nil
+	}
+	country, err := utils.GetCountry(ctx, options.CountryID)
+	if err != nil {
+		return err
+	}
+	return options.ResolveServiceCatalogueFilter(country.ServiceCatalogue)
+}
+
+// validateIndividualsInOffices returns the ids of the individuals that are not among the existing individuals,
+// or that were collected by another office than the given ones
+func validateIndividualsInOffices(individualIds containers.StringSet, existingIndividuals []*api.Individual, offices []string) []string {
+	officeSet := containers.NewStringSet(offices...)
+	existingIndividualIdMap := map[string]*api.Individual{}
+	for _, individual := range existingIndividuals {
+		existingIndividualIdMap[individual.ID] = individual
+	}
+
+	invalidIndividualIds := containers.NewStringSet()
+	for _, individualId := range individualIds.Items() {
+		existingIndividual, ok := existingIndividualIdMap[individualId]
+		if !ok || !officeSet.Contains(existingIndividual.CollectionOffice) {
+			invalidIndividualIds.Add(individualId)
+		}
+	}
+	return invalidIndividualIds.Items()
+}
+
+// restrictToOffices restricts the options to the offices on which the user has the permission in the country of the options
+func restrictToOffices(ctx context.Context, options *api.ListIndividualsOptions, perm auth.Permission) error {
+	authIntf, err := utils.GetAuthContext(ctx)
+	if err != nil {
+		return err
+	}
+	options.RestrictToOffices(authIntf, options.CountryID, perm)
+	return nil
+}
+
+// readableOffices returns the offices whose participants the user can read,
+// or nil when the user can read the participants of the whole country
+func readableOffices(authIntf auth.Interface, countryID string) []string {
+	offices, scoped := authIntf.GetAllowedOffices(countryID, auth.PermissionRead)
+	if !scoped {
+		return nil
+	}
+	return append([]string{}, offices...)
+}
@@ -89,29 +89,13 @@ func GetDBColumns(values []string) ([]string, error) {
 	dbCols := make([]string, len(values))
 	unknownColumns := []string{}
 	for i, v := range values {
-		foundKey := false
 		val := strings.Trim(v, " \t\n\r")
-		for _, c := range constants.IndividualFileColumns {
-			for _, lang := range AvailableLangs.Items() {
-				tra := l.TranslateFrom(c, lang)
-				if tra == val {
-					dbCols[i] = constants.IndividualFileToDBMap[c]
-					foundKey = true
-					continue
-				}
-			}
-			if foundKey {
-				break
-			}
-		}
-		if !foundKey {
-			if constants.IndividualDBColumns.Contains(val) {
-				dbCols[i] = val
-			} else if val == "" {
-				unknownColumns = append(unknownColumns, l.Translate("empty_string"))
-			} else {
-				unknownColumns = append(unknownColumns, val)
-			}
+		if dbCol, ok := GetDBColumn(val); ok {
+			dbCols[i] = dbCol
+		} else if val == "" {
+			unknownColumns = append(unknownColumns, l.Translate("empty_string"))
+		} else {
+			unknownColumns = append(unknownColumns, val)
 		}
 	}
 	if len(unknownColumns) == 0 {
@@ -120,6 +104,37 @@ func GetDBColumns(values []string) ([]string, error) {
 	return nil, fmt.Errorf(l.Translate("error_unknown_columns", strings.Join(unknownColumns, ", ")))
 }
 
+// GetDBColumn returns the db column of a file header, which is either the translated name
+// of the column in any of the available languages, or the db column name itself
+func GetDBColumn(value string) (string, bool) {
+	val := strings.Trim(value, " \t\n\r")
+	for _, c := range constants.IndividualFileColumns {
+		for _, lang := range AvailableLangs.Items() {
+			if l.TranslateFrom(c, lang) == val {
+				return constants.IndividualFileToDBMap[c], true
+			}
+		}
+	}
+	if constants.IndividualDBColumns.Contains(val) {
+		return val, true
+	}
+	return "", false
+}
+
+// GetColumnNames returns the names of a file column in all the available languages
+func GetColumnNames(fileColumn string) []string {
+	return GetTranslations(fileColumn)
+}
+
+// GetTranslations returns the translations of a message in all the available languages
+func GetTranslations(id string) []string {
+	translations := make([]string, 0, AvailableLangs.Len())
+	for _, lang := range AvailableLangs.Items() {
+		translations = append(translations, l.TranslateFrom(id, lang))
+	}
+	return translations
+}
+
 type Interface interface {
 	Translate(id string, args ...interface{}) string
 	TranslateCount(id string, ct int, args ...interface{}) string
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"time"
 
 	"github.com/nrc-no/notcore/internal/utils"
 	"go.uber.org/zap"
@@ -23,6 +24,16 @@ func NewLogger(ctx context.Context) *zap.Logger {
 			fields = append(fields, zap.String("user", session.GetUserID()))
 		}
 
+		// the requests of the global admins viewing the application as another user are tagged with that user
+		if userSession, ok := utils.GetUserSession(ctx); ok && userSession.IsImpersonating(time.Now()) {
+			fields = append(fields, zap.String("impersonating", userSession.ImpersonatedUserID))
+		}
+
+		// the service accounts are recorded with the token they used
+		if token, ok := utils.GetAPIToken(ctx); ok {
+			fields = append(fields, zap.String("api_token", token.ID))
+		}
+
 	}
 	return l.With(fields...)
 }
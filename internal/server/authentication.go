@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/sessions"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/server/middleware"
+)
+
+// authentication is how the users are authenticated in the configured auth mode
+type authentication struct {
+	tokenSource     middleware.TokenSource
+	idTokenVerifier middleware.IDTokenVerifier
+	fetchGroups     middleware.GroupsFetcher
+	// oidcLogin is only set in the oidc auth mode
+	oidcLogin *middleware.OIDCLogin
+	// devLogin is only set in the dev auth mode
+	devLogin *middleware.DevLogin
+	// logoutURL is where the users are sent after logging out
+	logoutURL string
+}
+
+func (o Options) newAuthentication(
+	ctx context.Context,
+	userSessionRepo db.UserSessionRepo,
+	sessionStore sessions.Store,
+	blockKey []byte,
+) (*authentication, error) {
+
+	// the development login needs no identity provider
+	if o.authMode() == AuthModeDev {
+		devLogin, err := middleware.NewDevLogin(o.DevUsersFile, userSessionRepo, o.SessionGroupsRefreshInterval)
+		if err != nil {
+			return nil, err
+		}
+		return &authentication{
+			tokenSource:     devLogin,
+			idTokenVerifier: devLogin,
+			fetchGroups:     devLogin.Groups,
+			devLogin:        devLogin,
+			logoutURL:       o.logoutURL(),
+		}, nil
+	}
+
+	// create the oidc provider
+	oidcProvider, err := oidc.NewProvider(ctx, o.OIDCIssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// create the id token verifier
+	oidcVerifier := oidcProvider.Verifier(&oidc.Config{
+		ClientID:             o.OAuthClientID,
+		SupportedSigningAlgs: []string{oidc.RS256},
+	})
+
+	authn := &authentication{
+		tokenSource: middleware.NewHeaderTokenSource(
+			o.IdTokenAuthHeaderName,
+			o.IdTokenAuthHeaderFormat,
+			o.AccessTokenHeaderName,
+			o.AccessTokenHeaderFormat,
+		),
+		idTokenVerifier: middleware.NewIDTokenVerifier(oidcVerifier),
+		fetchGroups:     middleware.NewUserInfoGroupsFetcher(oidcProvider),
+		logoutURL:       o.logoutURL(),
+	}
+
+	if o.authMode() == AuthModeOIDC {
+		// the tokens of the built-in login are encrypted with a key derived from the session block key
+		tokenCipher, err := auth.NewTokenCipher(deriveKey(blockKey, "oidc tokens"))
+		if err != nil {
+			return nil, err
+		}
+		authn.oidcLogin = middleware.NewOIDCLogin(
+			oidcProvider,
+			authn.idTokenVerifier,
+			o.OAuthClientID,
+			o.OAuthClientSecret,
+			o.OAuthRedirectURL,
+			o.OAuthScopes,
+			userSessionRepo,
+			tokenCipher,
+			sessionStore,
+			o.SessionGroupsRefreshInterval,
+		)
+		authn.tokenSource = authn.oidcLogin
+		if authn.logoutURL, err = authn.oidcLogin.EndSessionURL(authn.logoutURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return authn, nil
+}
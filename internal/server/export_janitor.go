@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/storage"
+	"go.uber.org/zap"
+)
+
+// exportJanitor periodically deletes the files of expired exports and pending uploads from the blob store.
+// The records are kept, and marked as deleted.
+type exportJanitor struct {
+	exportRepo        db.ExportRepo
+	pendingUploadRepo db.PendingUploadRepo
+	blobStore         storage.BlobStore
+	interval          time.Duration
+	batchSize         int
+	now               func() time.Time
+}
+
+func newExportJanitor(exportRepo db.ExportRepo, pendingUploadRepo db.PendingUploadRepo, blobStore storage.BlobStore, interval time.Duration) *exportJanitor {
+	return &exportJanitor{
+		exportRepo:        exportRepo,
+		pendingUploadRepo: pendingUploadRepo,
+		blobStore:         blobStore,
+		interval:          interval,
+		batchSize:         100,
+		now:               time.Now,
+	}
+}
+
+// run sweeps expired exports every interval, until the context is cancelled
+func (j *exportJanitor) run(ctx context.Context) {
+	l := logging.NewLogger(ctx)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if deleted, err := j.sweep(ctx); err != nil {
+			l.Error("failed to delete expired exports", zap.Error(err))
+		} else if deleted > 0 {
+			l.Info("deleted expired exports", zap.Int("count", deleted))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expiredBlob is the file of an expired export or pending upload
+type expiredBlob struct {
+	id       string
+	blobName string
+}
+
+// sweep deletes the files of all the exports and pending uploads that have expired, and returns how many were deleted
+func (j *exportJanitor) sweep(ctx context.Context) (int, error) {
+	deletedExports, err := j.sweepExpired(ctx, func(now time.Time) ([]expiredBlob, error) {
+		exports, err := j.exportRepo.GetExpired(ctx, now, j.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		blobs := make([]expiredBlob, len(exports))
+		for i, export := range exports {
+			blobs[i] = expiredBlob{id: export.ID, blobName: export.BlobName}
+		}
+		return blobs, nil
+	}, j.exportRepo.MarkDeleted)
+	if err != nil {
+		return deletedExports, err
+	}
+
+	deletedPendingUploads, err := j.sweepExpired(ctx, func(now time.Time) ([]expiredBlob, error) {
+		pendingUploads, err := j.pendingUploadRepo.GetExpired(ctx, now, j.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		blobs := make([]expiredBlob, len(pendingUploads))
+		for i, pendingUpload := range pendingUploads {
+			blobs[i] = expiredBlob{id: pendingUpload.ID, blobName: pendingUpload.BlobName}
+		}
+		return blobs, nil
+	}, j.pendingUploadRepo.MarkDeleted)
+	return deletedExports + deletedPendingUploads, err
+}
+
+// sweepExpired deletes the expired files returned in batches by getExpired, and marks them as deleted
+func (j *exportJanitor) sweepExpired(
+	ctx context.Context,
+	getExpired func(now time.Time) ([]expiredBlob, error),
+	markDeleted func(ctx context.Context, id string, deletedAt time.Time) error,
+) (int, error) {
+	deleted := 0
+	for {
+		now := j.now().UTC()
+		blobs, err := getExpired(now)
+		if err != nil {
+			return deleted, err
+		}
+		for _, blob := range blobs {
+			if err := j.blobStore.Delete(ctx, blob.blobName); err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+				return deleted, err
+			}
+			if err := markDeleted(ctx, blob.id, now); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+		if len(blobs) < j.batchSize {
+			return deleted, nil
+		}
+	}
+}
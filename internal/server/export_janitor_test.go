@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJanitorSweep(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	blobStore := storage.NewMemoryBlobStore()
+	require.NoError(t, blobStore.Upload(ctx, "a.csv", strings.NewReader("a")))
+	require.NoError(t, blobStore.Upload(ctx, "c.csv", strings.NewReader("c")))
+	require.NoError(t, blobStore.Upload(ctx, "d.xlsx", strings.NewReader("d")))
+
+	exportRepo := db.NewMockExportRepo(ctrl)
+	gomock.InOrder(
+		exportRepo.EXPECT().GetExpired(gomock.Any(), now, 2).Return([]*api.Export{
+			{ID: "a", BlobName: "a.csv"},
+			// already removed from the store
+			{ID: "b", BlobName: "b.csv"},
+		}, nil),
+		exportRepo.EXPECT().GetExpired(gomock.Any(), now, 2).Return([]*api.Export{}, nil),
+	)
+	exportRepo.EXPECT().MarkDeleted(gomock.Any(), "a", now).Return(nil)
+	exportRepo.EXPECT().MarkDeleted(gomock.Any(), "b", now).Return(nil)
+
+	pendingUploadRepo := db.NewMockPendingUploadRepo(ctrl)
+	pendingUploadRepo.EXPECT().GetExpired(gomock.Any(), now, 2).Return([]*api.PendingUpload{
+		{ID: "d", BlobName: "d.xlsx"},
+	}, nil)
+	pendingUploadRepo.EXPECT().MarkDeleted(gomock.Any(), "d", now).Return(nil)
+
+	janitor := newExportJanitor(exportRepo, pendingUploadRepo, blobStore, time.Minute)
+	janitor.batchSize = 2
+	janitor.now = func() time.Time { return now }
+
+	deleted, err := janitor.sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	_, err = blobStore.Download(ctx, "a.csv")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	_, err = blobStore.Download(ctx, "d.xlsx")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	_, err = blobStore.Download(ctx, "c.csv")
+	assert.NoError(t, err)
+}
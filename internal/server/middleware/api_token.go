@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// APITokenHeader is the name of the header holding the API token of the service accounts
+	APITokenHeader = "X-API-Token"
+
+	// apiTokenTouchInterval is how often the last use of an API token is recorded
+	apiTokenTouchInterval = time.Minute
+)
+
+// APITokenAuthentication authenticates the service accounts with the API token of the APITokenHeader header.
+// Requests without the header are left to the Authentication middleware. Requests with an unknown, expired or
+// revoked token are refused without redirecting to the login page, since service accounts cannot log in.
+func APITokenAuthentication(repo db.ServiceAccountRepo) func(handler http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			ctx := r.Context()
+			l := logging.NewLogger(ctx)
+
+			secret := strings.TrimSpace(r.Header.Get(APITokenHeader))
+			if len(secret) == 0 {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if !auth.IsAPITokenSecret(secret) {
+				l.Warn("malformed api token")
+				http.Error(w, "invalid api token", http.StatusUnauthorized)
+				return
+			}
+
+			token, err := repo.GetTokenByHash(ctx, auth.HashAPITokenSecret(secret))
+			if err != nil {
+				l.Error("failed to get api token", zap.Error(err))
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			if token == nil {
+				l.Warn("unknown api token")
+				http.Error(w, "invalid api token", http.StatusUnauthorized)
+				return
+			}
+
+			now := time.Now().UTC()
+			if !token.IsActive(now) {
+				l.Warn("inactive api token",
+					zap.String("api_token", token.ID),
+					zap.Bool("revoked", token.IsRevoked()),
+					zap.Bool("expired", token.IsExpired(now)))
+				http.Error(w, "invalid api token", http.StatusUnauthorized)
+				return
+			}
+
+			account, err := repo.GetByID(ctx, token.ServiceAccountID)
+			if err != nil {
+				l.Error("failed to get service account", zap.Error(err))
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			accountSession := auth.NewServiceAccountSession(account.ID, account.Name, token.ExpiresAt, token.CreatedAt)
+			ctx = utils.WithSession(ctx, accountSession)
+			ctx = utils.WithAPIToken(ctx, token)
+			r = r.WithContext(ctx)
+
+			l = logging.NewLogger(ctx)
+			l.Info("authenticated service account", zap.String("service_account", account.Name))
+
+			if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval {
+				if err := repo.TouchToken(ctx, token.ID, now); err != nil {
+					l.Warn("failed to record the use of the api token", zap.Error(err))
+				}
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseAPITokenPermissions returns the permissions of a service account from the scopes of its API token.
+// The token gets the read or write permission on the countries of its scopes that still exist,
+// on all their fields, and is never a global admin.
+func parseAPITokenPermissions(token *api.APIToken, allCountryIDs containers.StringSet) auth.Interface {
+	countryPermissions := auth.CountryPermissions{}
+	fieldPermissions := auth.CountryFieldPermissions{}
+
+	for _, countryID := range token.Scopes.CountryIDs {
+		if !allCountryIDs.Contains(countryID) {
+			continue
+		}
+		switch token.Scopes.Permission {
+		case api.CountryGroupRoleRead:
+			countryPermissions[countryID] = containers.NewSet(auth.PermissionRead)
+			fieldPermissions.Get(countryID).AddAll(auth.FieldPermissionView, auth.FieldPermissionExport)
+		case api.CountryGroupRoleWrite:
+			countryPermissions[countryID] = containers.NewSet(auth.PermissionWrite)
+			fieldPermissions.Get(countryID).AddAll(auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport)
+		}
+	}
+
+	return auth.New(countryPermissions, fieldPermissions, auth.CountryOfficePermissions{}, allCountryIDs, false)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenAuthentication(t *testing.T) {
+	const secret = auth.APITokenPrefix + "secret"
+	hash := auth.HashAPITokenSecret(secret)
+	account := &api.ServiceAccount{ID: "account", Name: "Nightly ETL"}
+	activeToken := func() *api.APIToken {
+		return &api.APIToken{
+			ID:               "token",
+			ServiceAccountID: account.ID,
+			ExpiresAt:        time.Now().Add(time.Hour),
+		}
+	}
+	revokedAt := time.Now().Add(-time.Minute)
+	lastUsedAt := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name       string
+		header     string
+		setup      func(repo *db.MockServiceAccountRepo)
+		wantStatus int
+		wantToken  bool
+	}{
+		{
+			name:       "no token",
+			header:     "",
+			setup:      func(repo *db.MockServiceAccountRepo) {},
+			wantStatus: http.StatusOK,
+		}, {
+			name:       "malformed token",
+			header:     "secret",
+			setup:      func(repo *db.MockServiceAccountRepo) {},
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:   "unknown token",
+			header: secret,
+			setup: func(repo *db.MockServiceAccountRepo) {
+				repo.EXPECT().GetTokenByHash(gomock.Any(), hash).Return(nil, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:   "revoked token",
+			header: secret,
+			setup: func(repo *db.MockServiceAccountRepo) {
+				token := activeToken()
+				token.RevokedAt = &revokedAt
+				repo.EXPECT().GetTokenByHash(gomock.Any(), hash).Return(token, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:   "expired token",
+			header: secret,
+			setup: func(repo *db.MockServiceAccountRepo) {
+				token := activeToken()
+				token.ExpiresAt = time.Now().Add(-time.Minute)
+				repo.EXPECT().GetTokenByHash(gomock.Any(), hash).Return(token, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		}, {
+			name:   "active token",
+			header: secret,
+			setup: func(repo *db.MockServiceAccountRepo) {
+				repo.EXPECT().GetTokenByHash(gomock.Any(), hash).Return(activeToken(), nil)
+				repo.EXPECT().GetByID(gomock.Any(), account.ID).Return(account, nil)
+				repo.EXPECT().TouchToken(gomock.Any(), "token", gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantToken:  true,
+		}, {
+			name:   "recently used token",
+			header: secret,
+			setup: func(repo *db.MockServiceAccountRepo) {
+				token := activeToken()
+				token.LastUsedAt = &lastUsedAt
+				repo.EXPECT().GetTokenByHash(gomock.Any(), hash).Return(token, nil)
+				repo.EXPECT().GetByID(gomock.Any(), account.ID).Return(account, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantToken:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := db.NewMockServiceAccountRepo(ctrl)
+			tt.setup(repo)
+
+			var gotToken bool
+			var gotUserID string
+			handler := APITokenAuthentication(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, gotToken = utils.GetAPIToken(r.Context())
+				if session, ok := utils.GetSession(r.Context()); ok {
+					gotUserID = session.GetUserID()
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "http://testing", nil)
+			if tt.header != "" {
+				req.Header.Set(APITokenHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantToken, gotToken)
+			if tt.wantToken {
+				assert.Equal(t, auth.ServiceAccountIssuer+":"+account.ID, gotUserID)
+			}
+		})
+	}
+}
+
+func Test_parseAPITokenPermissions(t *testing.T) {
+	allCountryIDs := containers.NewStringSet("1", "2", "3")
+
+	read := parseAPITokenPermissions(&api.APIToken{Scopes: api.APITokenScopes{
+		CountryIDs: []string{"1", "deleted"},
+		Permission: api.CountryGroupRoleRead,
+	}}, allCountryIDs)
+	assert.False(t, read.IsGlobalAdmin())
+	assert.Equal(t, []string{"1"}, read.GetAllowedCountries().Items())
+	assert.True(t, read.HasCountryPermissionRead("1"))
+	assert.False(t, read.HasCountryPermissionWrite("1"))
+	assert.False(t, read.HasCountryPermissionRead("2"))
+	assert.True(t, read.HasFieldPermission("1", auth.FieldGroupProtection, auth.FieldPermissionExport))
+	assert.False(t, read.HasFieldPermission("1", auth.FieldGroupProtection, auth.FieldPermissionEdit))
+
+	write := parseAPITokenPermissions(&api.APIToken{Scopes: api.APITokenScopes{
+		CountryIDs: []string{"1", "2"},
+		Permission: api.CountryGroupRoleWrite,
+	}}, allCountryIDs)
+	assert.False(t, write.IsGlobalAdmin())
+	assert.True(t, write.HasCountryPermissionRead("2"))
+	assert.True(t, write.HasCountryPermissionWrite("2"))
+	assert.False(t, write.HasCountryPermissionRead("3"))
+	assert.True(t, write.HasFieldPermission("2", auth.FieldGroupHealth, auth.FieldPermissionEdit))
+}
@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,8 +9,8 @@ import (
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
-	"github.com/gorilla/sessions"
 	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/logging"
 	"github.com/nrc-no/notcore/internal/utils"
 	"go.uber.org/zap"
@@ -24,6 +23,8 @@ type TokenClaims struct {
 	Email string `json:"email"`
 	Iat   int64  `json:"iat"`
 	Exp   int64  `json:"exp"`
+	// Nonce is only checked by the built-in OIDC login
+	Nonce string `json:"nonce"`
 }
 
 const AuthHeaderFormatJWT = "jwt"
@@ -51,17 +52,79 @@ type IDToken interface {
 	Claims(v interface{}) error
 }
 
-func Authentication(
+// TokenSource gives the raw ID and access tokens of the user making the request
+type TokenSource interface {
+	Tokens(w http.ResponseWriter, r *http.Request) (idToken string, accessToken string, err error)
+}
+
+// errMissingToken is returned by a TokenSource when the request carries no token
+var errMissingToken = errors.New("missing token")
+
+type headerTokenSource struct {
+	idTokenHeaderName       string
+	idTokenHeaderFormat     string
+	accessTokenHeaderName   string
+	accessTokenHeaderFormat string
+}
+
+// NewHeaderTokenSource returns a TokenSource reading the tokens from the headers set by the authenticating proxy
+func NewHeaderTokenSource(
 	idTokenHeaderName,
 	idTokenHeaderFormat,
 	accessTokenHeaderName,
 	accessTokenHeaderFormat string,
-	provider *oidc.Provider,
+) TokenSource {
+	return headerTokenSource{
+		idTokenHeaderName:       idTokenHeaderName,
+		idTokenHeaderFormat:     idTokenHeaderFormat,
+		accessTokenHeaderName:   accessTokenHeaderName,
+		accessTokenHeaderFormat: accessTokenHeaderFormat,
+	}
+}
+
+func (s headerTokenSource) Tokens(w http.ResponseWriter, r *http.Request) (string, string, error) {
+	if len(r.Header.Get(s.idTokenHeaderName)) == 0 {
+		return "", "", errMissingToken
+	}
+	idToken, err := parseAuthHeader(r, s.idTokenHeaderName, s.idTokenHeaderFormat)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid id token header: %w", err)
+	}
+	accessToken, err := parseAuthHeader(r, s.accessTokenHeaderName, s.accessTokenHeaderFormat)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid access token header: %w", err)
+	}
+	return idToken, accessToken, nil
+}
+
+// GroupsFetcher returns the current groups of the user with the given access token
+type GroupsFetcher func(ctx context.Context, accessToken string) ([]string, error)
+
+// NewUserInfoGroupsFetcher returns a GroupsFetcher reading the groups claim of the user info endpoint of the provider
+func NewUserInfoGroupsFetcher(provider *oidc.Provider) GroupsFetcher {
+	return func(ctx context.Context, accessToken string) ([]string, error) {
+		return getUserInfoGroups(ctx, provider, accessToken)
+	}
+}
+
+// Authentication authenticates the users with the ID token given by the token source, which reads
+// the headers set by the authenticating proxy, or the tokens of the built-in OIDC login.
+//
+// The users get a server-side session, stored by the userSessionRepo, which holds their groups.
+// The groups are fetched with fetchGroups, usually from the user info endpoint of the provider, when the session is created,
+// and again every groupsRefreshInterval, so that changes of group membership are applied without
+// logging out. Revoked sessions are sent back to the login URL.
+func Authentication(
+	tokenSource TokenSource,
 	idTokenVerifier IDTokenVerifier,
-	sessionStore *sessions.CookieStore,
+	fetchGroups GroupsFetcher,
+	userSessionRepo db.UserSessionRepo,
+	groupsRefreshInterval time.Duration,
 	loginURL string,
 ) func(handler http.Handler) http.Handler {
 
+	sessionResolver := newUserSessionResolver(fetchGroups, userSessionRepo, groupsRefreshInterval)
+
 	redirectToLogin := func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
 	}
@@ -72,59 +135,52 @@ func Authentication(
 			ctx := r.Context()
 			l := logging.NewLogger(ctx)
 
-			authPayload := r.Header.Get(idTokenHeaderName)
-			if len(authPayload) == 0 {
-				l.Warn("missing authentication header")
-				redirectToLogin(w, r)
+			// the service accounts are already authenticated by APITokenAuthentication
+			if _, ok := utils.GetAPIToken(ctx); ok {
+				h.ServeHTTP(w, r)
 				return
 			}
 
-			var rawIdToken string
-
-			rawIdToken, err := parseAuthHeader(r, idTokenHeaderName, idTokenHeaderFormat)
-			if err != nil {
-				l.Warn("invalid authentication header", zap.Error(err))
+			rawIdToken, accessToken, err := tokenSource.Tokens(w, r)
+			if errors.Is(err, errUserSessionRevoked) {
+				l.Info("user session was revoked")
+				auth.ClearSessionCookie(w)
+				redirectToLogin(w, r)
+				return
+			} else if errors.Is(err, errMissingToken) {
+				l.Warn("missing authentication token")
+				redirectToLogin(w, r)
+				return
+			} else if err != nil {
+				l.Warn("invalid authentication token", zap.Error(err))
 				redirectToLogin(w, r)
 				return
 			}
 
-			idToken, err := idTokenVerifier.Verify(ctx, rawIdToken)
+			tokenClaims, err := verifyIDToken(ctx, idTokenVerifier, rawIdToken)
 			if err != nil {
 				l.Warn("failed to verify token", zap.Error(err))
 				redirectToLogin(w, r)
 				return
 			}
 
-			var tokenParts = strings.Split(rawIdToken, ".")
-			var claimsPart = tokenParts[1]
-
-			var tokenClaims TokenClaims
-			if err := idToken.Claims(&tokenClaims); err != nil {
-				l.Warn("failed to extract claims from token",
-					zap.Error(err),
-					zap.String("token", claimsPart))
+			userSession, sessionToken, err := sessionResolver.resolve(r, tokenClaims, accessToken)
+			if errors.Is(err, errUserSessionRevoked) {
+				l.Info("user session was revoked")
+				auth.ClearSessionCookie(w)
 				redirectToLogin(w, r)
 				return
-			}
-
-			if err := validateTokenClaims(tokenClaims); err != nil {
-				l.Warn("failed to validate token claims",
-					zap.Error(err),
-					zap.String("token", claimsPart),
-				)
+			} else if err != nil {
+				l.Warn("failed to get user session", zap.Error(err))
 				redirectToLogin(w, r)
 				return
 			}
-
-			userInfoClaims, err := getUserInfoClaims(sessionStore, provider, r, w, accessTokenHeaderName, accessTokenHeaderFormat)
-			if err != nil {
-				l.Warn("failed to get user info claims", zap.Error(err))
-				redirectToLogin(w, r)
-				return
+			if sessionToken != "" {
+				auth.SetSessionCookie(w, sessionToken)
 			}
 
-			userSession := auth.NewAuthenticatedSession(
-				userInfoClaims.Groups,
+			session := auth.NewAuthenticatedSession(
+				userSession.Groups.Items(),
 				tokenClaims.Email,
 				tokenClaims.Iss,
 				tokenClaims.Sub,
@@ -132,7 +188,8 @@ func Authentication(
 				time.Unix(tokenClaims.Iat, 0),
 			)
 
-			ctx = utils.WithSession(ctx, userSession)
+			ctx = utils.WithSession(ctx, session)
+			ctx = utils.WithUserSession(ctx, userSession)
 			r = r.WithContext(ctx)
 
 			h.ServeHTTP(w, r)
@@ -140,6 +197,24 @@ func Authentication(
 	}
 }
 
+// verifyIDToken verifies the raw ID token and returns its validated claims
+func verifyIDToken(ctx context.Context, verifier IDTokenVerifier, rawIDToken string) (TokenClaims, error) {
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return TokenClaims{}, err
+	}
+
+	var tokenClaims TokenClaims
+	if err := idToken.Claims(&tokenClaims); err != nil {
+		return TokenClaims{}, fmt.Errorf("failed to extract claims from token: %w", err)
+	}
+
+	if err := validateTokenClaims(tokenClaims); err != nil {
+		return TokenClaims{}, fmt.Errorf("failed to validate token claims: %w", err)
+	}
+	return tokenClaims, nil
+}
+
 type userInfoClaims struct {
 	Groups []string `json:"groups"`
 }
@@ -164,35 +239,10 @@ func validateTokenClaims(claims TokenClaims) error {
 	return nil
 }
 
-func getUserInfoClaims(
-	sessionStore sessions.Store,
-	provider *oidc.Provider,
-	r *http.Request,
-	w http.ResponseWriter,
-	accessTokenHeaderName,
-	accessTokenHeaderFormat string,
-) (*userInfoClaims, error) {
-
-	ctx := r.Context()
-
-	cookieSession, _ := sessionStore.Get(r, "core-session")
-
-	userInfoInterface, ok := cookieSession.Values["oidc_userinfo"]
-	if ok {
-		userInfoBytesRead, ok := userInfoInterface.([]byte)
-		userInfo := userInfoClaims{}
-		json.Unmarshal(userInfoBytesRead, &userInfo)
-		if ok {
-			return &userInfo, nil
-		}
-	}
+// getUserInfoGroups returns the groups of the user from the user info endpoint of the provider
+func getUserInfoGroups(ctx context.Context, provider *oidc.Provider, accessToken string) ([]string, error) {
 
-	rawAccessToken, err := parseAuthHeader(r, accessTokenHeaderName, accessTokenHeaderFormat)
-	if err != nil {
-		return nil, err
-	}
-
-	userInfo, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: rawAccessToken}))
+	userInfo, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}))
 	if err != nil {
 		return nil, err
 	}
@@ -206,16 +256,7 @@ func getUserInfoClaims(
 		return nil, fmt.Errorf("missing groups claim")
 	}
 
-	infoBytesWrite, err := json.Marshal(info)
-	if err != nil {
-		return nil, err
-	}
-	cookieSession.Values["oidc_userinfo"] = infoBytesWrite
-	if err := cookieSession.Save(r, w); err != nil {
-		return nil, err
-	}
-
-	return &info, nil
+	return info.Groups, nil
 }
 
 func parseAuthHeader(r *http.Request, headerName, headerFormat string) (string, error) {
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/sessions"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// CSRFFormField is the name of the form field holding the CSRF token
+	CSRFFormField = "csrf_token"
+	// CSRFHeader is the name of the header holding the CSRF token, for requests that are not form posts
+	CSRFHeader = "X-CSRF-Token"
+
+	csrfSessionName = "core-csrf"
+	csrfSessionKey  = "token"
+	csrfTokenLength = 32
+)
+
+// CSRF protects the state-changing requests against cross-site request forgery.
+//
+// A random secret is kept for each browser in an encrypted session cookie, and the requests
+// that are not GET, HEAD, OPTIONS or TRACE must send it back in the CSRFFormField form field
+// or the CSRFHeader header. The token given to the views is masked with a new random pad for
+// every response, so that it cannot be recovered from compressed responses. The requests
+// must also come from the same origin, as told by the Sec-Fetch-Site, Origin and Referer headers.
+// The requests of the service accounts, authenticated by APITokenAuthentication, are not checked.
+func CSRF(sessionStore sessions.Store) func(handler http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			l := logging.NewLogger(ctx)
+
+			// the service accounts send their API token in a header, which other sites cannot make the browser send
+			if _, ok := utils.GetAPIToken(ctx); ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			session, err := sessionStore.Get(r, csrfSessionName)
+			if err != nil {
+				// the cookie is invalid or expired, a new secret is issued below
+				l.Debug("failed to decode csrf session", zap.Error(err))
+			}
+
+			secret, ok := session.Values[csrfSessionKey].([]byte)
+			if !ok || len(secret) != csrfTokenLength {
+				secret, err = randomBytes(csrfTokenLength)
+				if err != nil {
+					l.Error("failed to generate csrf secret", zap.Error(err))
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+					return
+				}
+				session.Values[csrfSessionKey] = secret
+				ok = false
+			}
+
+			if !ok || isSafeMethod(r.Method) {
+				// saving on safe requests extends the lifetime of the cookie while the user is browsing
+				if err := session.Save(r, w); err != nil {
+					l.Error("failed to save csrf session", zap.Error(err))
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			token, err := maskCSRFToken(secret)
+			if err != nil {
+				l.Error("failed to mask csrf token", zap.Error(err))
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			r = r.WithContext(utils.WithCSRFToken(ctx, token))
+
+			if isSafeMethod(r.Method) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if err := checkSameOrigin(r); err != nil {
+				l.Warn("rejected cross-site request", zap.Error(err))
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			if !ok || !validCSRFToken(secret, requestCSRFToken(r)) {
+				l.Warn("rejected request with a missing or invalid csrf token")
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkSameOrigin returns an error if the headers set by the browser show that the request
+// comes from another site. Requests without any of these headers are left to the token check.
+func checkSameOrigin(r *http.Request) error {
+	switch site := r.Header.Get("Sec-Fetch-Site"); site {
+	case "", "same-origin", "none":
+	default:
+		return fmt.Errorf("request is %s", site)
+	}
+
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return nil
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid origin %q", source)
+	}
+	if host := requestHost(r); !strings.EqualFold(u.Host, host) {
+		return fmt.Errorf("origin %q does not match host %q", u.Host, host)
+	}
+	return nil
+}
+
+// requestHost returns the host the browser sent the request to, which is forwarded by the proxy if any
+func requestHost(r *http.Request) string {
+	if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+		return host
+	}
+	return r.Host
+}
+
+func requestCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeader); token != "" {
+		return token
+	}
+	return r.FormValue(CSRFFormField)
+}
+
+// maskCSRFToken returns a random pad followed by the secret xor'ed with the pad
+func maskCSRFToken(secret []byte) (string, error) {
+	pad, err := randomBytes(len(secret))
+	if err != nil {
+		return "", err
+	}
+	masked := make([]byte, 2*len(secret))
+	copy(masked, pad)
+	for i := range secret {
+		masked[len(secret)+i] = secret[i] ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked), nil
+}
+
+func unmaskCSRFToken(token string) ([]byte, error) {
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(masked) != 2*csrfTokenLength {
+		return nil, errors.New("invalid csrf token length")
+	}
+	secret := make([]byte, csrfTokenLength)
+	for i := range secret {
+		secret[i] = masked[csrfTokenLength+i] ^ masked[i]
+	}
+	return secret, nil
+}
+
+func validCSRFToken(secret []byte, token string) bool {
+	if token == "" {
+		return false
+	}
+	got, err := unmaskCSRFToken(token)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(secret, got) == 1
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRF(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+
+	var token string
+	handler := CSRF(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = utils.GetCSRFToken(r.Context())
+	}))
+
+	// a first page view issues the secret cookie and a token for the forms
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://core.example.org/countries", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.NotEmpty(t, token)
+	formToken := token
+
+	// tokens are masked differently on every response
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "https://core.example.org/countries", nil)
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, formToken, token)
+	otherToken := token
+
+	post := func(form url.Values, headers map[string]string, withCookie bool) int {
+		req := httptest.NewRequest(http.MethodPost, "https://core.example.org/countries/new", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if withCookie {
+			req.AddCookie(cookies[0])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		headers    map[string]string
+		withCookie bool
+		want       int
+	}{
+		{
+			name:       "same origin with token",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Origin": "https://core.example.org", "Sec-Fetch-Site": "same-origin"},
+			withCookie: true,
+			want:       http.StatusOK,
+		}, {
+			name:       "token of another response",
+			form:       url.Values{CSRFFormField: {otherToken}},
+			withCookie: true,
+			want:       http.StatusOK,
+		}, {
+			name:       "token in header",
+			headers:    map[string]string{CSRFHeader: formToken, "Referer": "https://core.example.org/countries"},
+			withCookie: true,
+			want:       http.StatusOK,
+		}, {
+			name:       "proxied host",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Origin": "https://core.nrc.no", "X-Forwarded-Host": "core.nrc.no"},
+			withCookie: true,
+			want:       http.StatusOK,
+		}, {
+			name:       "missing token",
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name:       "invalid token",
+			form:       url.Values{CSRFFormField: {"invalid"}},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name: "missing cookie",
+			form: url.Values{CSRFFormField: {formToken}},
+			want: http.StatusForbidden,
+		}, {
+			name:       "cross-site origin",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Origin": "https://attacker.example.com"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name:       "cross-site referer",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Referer": "https://attacker.example.com/page"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name:       "null origin",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Origin": "null"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name:       "cross-site fetch metadata",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Sec-Fetch-Site": "cross-site"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		}, {
+			name:       "same-site fetch metadata",
+			form:       url.Values{CSRFFormField: {formToken}},
+			headers:    map[string]string{"Sec-Fetch-Site": "same-site"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, post(tt.form, tt.headers, tt.withCookie))
+		})
+	}
+}
+
+func TestCSRF_serviceAccount(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	handler := CSRF(store)(nextHandler())
+
+	// the requests of the service accounts have no cookie and no csrf token
+	req := httptest.NewRequest(http.MethodPost, "https://core.example.org/countries/1/participants/upload", nil)
+	req = req.WithContext(utils.WithAPIToken(req.Context(), &api.APIToken{ID: "token"}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func Test_maskCSRFToken(t *testing.T) {
+	secret, err := randomBytes(csrfTokenLength)
+	require.NoError(t, err)
+	token, err := maskCSRFToken(secret)
+	require.NoError(t, err)
+	got, err := unmaskCSRFToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, secret, got)
+	assert.True(t, validCSRFToken(secret, token))
+
+	other, err := randomBytes(csrfTokenLength)
+	require.NoError(t, err)
+	assert.False(t, validCSRFToken(other, token))
+	assert.False(t, validCSRFToken(secret, ""))
+}
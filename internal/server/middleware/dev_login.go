@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DevLoginPath is where the development login lists the users
+	DevLoginPath = "/dev/login"
+
+	devIssuer          = "dev"
+	devFormParamUserID = "UserID"
+)
+
+// DevUser is a user of the development login
+type DevUser struct {
+	ID     string   `yaml:"id"`
+	Email  string   `yaml:"email"`
+	Name   string   `yaml:"name"`
+	Groups []string `yaml:"groups"`
+}
+
+type devUsersFile struct {
+	Users []DevUser `yaml:"users"`
+}
+
+// LoadDevUsers reads the users of the development login from a YAML file
+func LoadDevUsers(path string) ([]DevUser, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file devUsersFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("invalid dev users file %s: %w", path, err)
+	}
+	if len(file.Users) == 0 {
+		return nil, fmt.Errorf("dev users file %s has no users", path)
+	}
+	ids := map[string]bool{}
+	for i, user := range file.Users {
+		if user.ID == "" || user.Email == "" {
+			return nil, fmt.Errorf("dev user %d of %s must have an id and an email", i, path)
+		}
+		if ids[user.ID] {
+			return nil, fmt.Errorf("dev user %s is duplicated in %s", user.ID, path)
+		}
+		ids[user.ID] = true
+	}
+	return file.Users, nil
+}
+
+// DevLogin lets the developers sign in as any user of a YAML file, without an identity provider.
+// It is a TokenSource, an IDTokenVerifier and a GroupsFetcher for Authentication, so that the users
+// get the same sessions and permissions as in production. It must only be served on a loopback address.
+type DevLogin struct {
+	usersFile       string
+	repo            db.UserSessionRepo
+	sessionResolver userSessionResolver
+}
+
+func NewDevLogin(usersFile string, userSessionRepo db.UserSessionRepo, groupsRefreshInterval time.Duration) (*DevLogin, error) {
+	if _, err := LoadDevUsers(usersFile); err != nil {
+		return nil, err
+	}
+	d := &DevLogin{
+		usersFile: usersFile,
+		repo:      userSessionRepo,
+	}
+	d.sessionResolver = newUserSessionResolver(d.Groups, userSessionRepo, groupsRefreshInterval)
+	return d, nil
+}
+
+// HandleLogin lists the users, and signs in the one that is picked
+func (d *DevLogin) HandleLogin() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.NewLogger(r.Context())
+
+		users, err := LoadDevUsers(d.usersFile)
+		if err != nil {
+			l.Error("failed to load dev users", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			if err := devLoginTemplate.Execute(w, users); err != nil {
+				l.Error("failed to render dev login", zap.Error(err))
+			}
+			return
+		}
+
+		if err := checkSameOrigin(r); err != nil {
+			l.Warn("rejected cross-site request", zap.Error(err))
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		idToken, err := d.Verify(r.Context(), r.FormValue(devFormParamUserID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var claims TokenClaims
+		if err := idToken.Claims(&claims); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, sessionToken, err := d.sessionResolver.create(r, claims, claims.Sub)
+		if err != nil {
+			l.Error("failed to create user session", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		l.Info("signed in with the dev login", zap.String("dev_user", claims.Sub))
+		auth.SetSessionCookie(w, sessionToken)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+}
+
+// Tokens returns the id of the user of the session as both the ID and access token
+func (d *DevLogin) Tokens(w http.ResponseWriter, r *http.Request) (string, string, error) {
+	sessionToken := auth.GetSessionToken(r)
+	if sessionToken == "" {
+		return "", "", errMissingToken
+	}
+
+	userSession, err := d.repo.GetByTokenHash(r.Context(), auth.HashSessionToken(sessionToken))
+	if err != nil {
+		return "", "", err
+	}
+	if userSession == nil || !strings.HasPrefix(userSession.UserID, devIssuer+":") {
+		return "", "", errMissingToken
+	}
+	if userSession.IsRevoked() {
+		return "", "", errUserSessionRevoked
+	}
+	if userSession.IsExpired(time.Now()) {
+		return "", "", errMissingToken
+	}
+
+	userID := strings.TrimPrefix(userSession.UserID, devIssuer+":")
+	return userID, userID, nil
+}
+
+// Verify returns the claims of the user with the given id, as the identity provider would
+func (d *DevLogin) Verify(ctx context.Context, userID string) (IDToken, error) {
+	user, err := d.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return devIDToken{claims: TokenClaims{
+		Iss:   devIssuer,
+		Sub:   user.ID,
+		Email: user.Email,
+		Iat:   now.Unix(),
+		Exp:   now.Add(userSessionLifetime).Unix(),
+	}}, nil
+}
+
+// Groups returns the groups of the user with the given id. The file is read again,
+// so that changing the groups of a user takes effect when the groups of the session are refreshed.
+func (d *DevLogin) Groups(ctx context.Context, userID string) ([]string, error) {
+	user, err := d.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Groups, nil
+}
+
+func (d *DevLogin) user(userID string) (DevUser, error) {
+	users, err := LoadDevUsers(d.usersFile)
+	if err != nil {
+		return DevUser{}, err
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return DevUser{}, errors.New("unknown dev user")
+}
+
+type devIDToken struct {
+	claims TokenClaims
+}
+
+func (t devIDToken) Claims(v interface{}) error {
+	b, err := json.Marshal(t.claims)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+var devLoginTemplate = template.Must(template.New("dev_login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>Core - Development login</title>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <link rel="stylesheet" href="/static/theme.min.css">
+</head>
+<body>
+<main class="container mt-5" style="max-width: 40rem">
+    <h1 class="mb-2">Development login</h1>
+    <p class="text-muted">This login is only for development. Pick the user to sign in as.</p>
+    <div class="list-group">
+        {{range .}}
+            <form method="post" action="` + DevLoginPath + `" class="list-group-item">
+                <input type="hidden" name="` + devFormParamUserID + `" value="{{.ID}}">
+                <button type="submit" class="btn btn-link p-0 text-start">
+                    <div class="fw-bold">{{if .Name}}{{.Name}}{{else}}{{.ID}}{{end}}</div>
+                    <div class="small text-muted">{{.Email}}</div>
+                </button>
+                <div>
+                    {{range .Groups}}<span class="badge bg-secondary me-1">{{.}}</span>{{end}}
+                </div>
+            </form>
+        {{end}}
+    </div>
+</main>
+</body>
+</html>
+`))
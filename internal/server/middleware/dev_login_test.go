@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const devUsersYAML = `
+users:
+  - id: admin
+    email: admin@not.nrc.no
+    name: Global admin
+    groups:
+      - global-admin
+  - id: reader
+    email: reader@not.nrc.no
+    groups:
+      - country-a-read
+`
+
+func writeDevUsers(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "dev-users.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadDevUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+		wantIDs []string
+	}{
+		{
+			name:    "valid",
+			content: devUsersYAML,
+			wantIDs: []string{"admin", "reader"},
+		}, {
+			name:    "no users",
+			content: "users: []",
+			wantErr: true,
+		}, {
+			name:    "missing email",
+			content: "users:\n  - id: admin\n",
+			wantErr: true,
+		}, {
+			name:    "duplicated id",
+			content: "users:\n  - id: admin\n    email: a@nrc.no\n  - id: admin\n    email: b@nrc.no\n",
+			wantErr: true,
+		}, {
+			name:    "invalid yaml",
+			content: "users: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := LoadDevUsers(writeDevUsers(t, tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var ids []string
+			for _, user := range users {
+				ids = append(ids, user.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestDevLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := db.NewMockUserSessionRepo(ctrl)
+	login, err := NewDevLogin(writeDevUsers(t, devUsersYAML), repo, 5*time.Minute)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("verify gives the claims of the user", func(t *testing.T) {
+		idToken, err := login.Verify(ctx, "admin")
+		require.NoError(t, err)
+		var claims TokenClaims
+		require.NoError(t, idToken.Claims(&claims))
+		assert.Equal(t, devIssuer, claims.Iss)
+		assert.Equal(t, "admin", claims.Sub)
+		assert.Equal(t, "admin@not.nrc.no", claims.Email)
+
+		_, err = login.Verify(ctx, "unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("groups of the user", func(t *testing.T) {
+		groups, err := login.Groups(ctx, "reader")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"country-a-read"}, groups)
+	})
+
+	t.Run("signing in creates a session", func(t *testing.T) {
+		repo.EXPECT().GetLastRevocation(gomock.Any(), "dev:reader").Return(nil, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, s *api.UserSession) (*api.UserSession, error) {
+				assert.Equal(t, "reader@not.nrc.no", s.Email)
+				assert.ElementsMatch(t, []string{"country-a-read"}, s.Groups.Items())
+				return s, nil
+			})
+
+		form := url.Values{devFormParamUserID: {"reader"}}
+		r := httptest.NewRequest(http.MethodPost, DevLoginPath, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		login.HandleLogin().ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, auth.SessionCookieName, cookies[0].Name)
+	})
+
+	t.Run("cross-site sign in is refused", func(t *testing.T) {
+		form := url.Values{devFormParamUserID: {"admin"}}
+		r := httptest.NewRequest(http.MethodPost, DevLoginPath, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Sec-Fetch-Site", "cross-site")
+		w := httptest.NewRecorder()
+		login.HandleLogin().ServeHTTP(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("tokens are the user of the session", func(t *testing.T) {
+		const token = "token"
+		hash := auth.HashSessionToken(token)
+		revokedAt := time.Now().Add(-time.Minute)
+		tests := []struct {
+			name    string
+			session *api.UserSession
+			wantErr error
+			wantID  string
+		}{
+			{
+				name:    "unknown session",
+				wantErr: errMissingToken,
+			}, {
+				name:    "session of another issuer",
+				session: &api.UserSession{UserID: "https://issuer:admin", ExpiresAt: time.Now().Add(time.Hour)},
+				wantErr: errMissingToken,
+			}, {
+				name:    "revoked session",
+				session: &api.UserSession{UserID: "dev:admin", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+				wantErr: errUserSessionRevoked,
+			}, {
+				name:    "expired session",
+				session: &api.UserSession{UserID: "dev:admin", ExpiresAt: time.Now().Add(-time.Minute)},
+				wantErr: errMissingToken,
+			}, {
+				name:    "active session",
+				session: &api.UserSession{UserID: "dev:admin", ExpiresAt: time.Now().Add(time.Hour)},
+				wantID:  "admin",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(tt.session, nil)
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: token})
+
+				idToken, accessToken, err := login.Tokens(httptest.NewRecorder(), r)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantID, idToken)
+				assert.Equal(t, tt.wantID, accessToken)
+			})
+		}
+	})
+}
@@ -16,14 +16,7 @@ func RequestLogging(h http.Handler) http.Handler {
 		l := logging.NewLogger(r.Context())
 		url := *r.URL
 
-		host, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			host = r.RemoteAddr
-		}
-
-		if r.Header.Get("X-Forwarded-For") != "" {
-			host = r.Header.Get("X-Forwarded-For")
-		}
+		host := clientIP(r)
 
 		start := time.Now().UTC()
 		path := r.URL.Path
@@ -76,6 +69,18 @@ func RequestLogging(h http.Handler) http.Handler {
 	})
 }
 
+// clientIP returns the address of the client, which is forwarded by the proxy if any
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return forwarded
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	header     http.Header
@@ -0,0 +1,359 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/sessions"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"github.com/nrc-no/notcore/internal/utils"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OIDCLoginPath is where the built-in OIDC login starts
+	OIDCLoginPath = "/oidc/login"
+	// OIDCCallbackPath is where the identity provider sends the users back, which the redirect URL must point to
+	OIDCCallbackPath = "/oidc/callback"
+
+	oidcLoginSessionName = "core-oidc-login"
+	oidcLoginStateKey    = "state"
+	oidcLoginNonceKey    = "nonce"
+	oidcLoginVerifierKey = "verifier"
+	// oidcLoginMaxAge is how long the user has to sign in with the identity provider
+	oidcLoginMaxAge = 10 * time.Minute
+	// oidcTokenRefreshMargin is how long before its expiration the ID token is refreshed
+	oidcTokenRefreshMargin = 1 * time.Minute
+)
+
+// oidcTokens are the tokens of the built-in OIDC login, stored encrypted with the user session
+type oidcTokens struct {
+	IDToken      string `json:"idToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	// Expiry is the expiration of the ID token
+	Expiry time.Time `json:"expiry"`
+}
+
+// OIDCLogin is the built-in authorization code flow with PKCE, for the deployments without
+// an authenticating proxy. The tokens are stored encrypted with the session of the user,
+// and OIDCLogin gives them to Authentication as a TokenSource.
+type OIDCLogin struct {
+	config          oauth2.Config
+	provider        *oidc.Provider
+	idTokenVerifier IDTokenVerifier
+	repo            db.UserSessionRepo
+	cipher          *auth.TokenCipher
+	sessionStore    sessions.Store
+	sessionResolver userSessionResolver
+}
+
+func NewOIDCLogin(
+	provider *oidc.Provider,
+	idTokenVerifier IDTokenVerifier,
+	clientID string,
+	clientSecret string,
+	redirectURL string,
+	scopes []string,
+	userSessionRepo db.UserSessionRepo,
+	cipher *auth.TokenCipher,
+	sessionStore sessions.Store,
+	groupsRefreshInterval time.Duration,
+) *OIDCLogin {
+	return &OIDCLogin{
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+		provider:        provider,
+		idTokenVerifier: idTokenVerifier,
+		repo:            userSessionRepo,
+		cipher:          cipher,
+		sessionStore:    sessionStore,
+		sessionResolver: newUserSessionResolver(NewUserInfoGroupsFetcher(provider), userSessionRepo, groupsRefreshInterval),
+	}
+}
+
+// HandleLogin sends the user to the identity provider, with a new state, nonce and PKCE verifier
+func (o *OIDCLogin) HandleLogin() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.NewLogger(r.Context())
+
+		state := oauth2.GenerateVerifier()
+		nonce := oauth2.GenerateVerifier()
+		verifier := oauth2.GenerateVerifier()
+
+		session, _ := o.sessionStore.Get(r, oidcLoginSessionName)
+		// the identity provider redirects back from another site, which strict cookies are not sent to
+		session.Options = &sessions.Options{
+			Path:     "/",
+			MaxAge:   int(oidcLoginMaxAge.Seconds()),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		}
+		session.Values[oidcLoginStateKey] = state
+		session.Values[oidcLoginNonceKey] = nonce
+		session.Values[oidcLoginVerifierKey] = verifier
+		if err := session.Save(r, w); err != nil {
+			l.Error("failed to save oidc login session", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		authURL := o.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+}
+
+// HandleCallback exchanges the authorization code for the tokens, and creates the session of the user
+func (o *OIDCLogin) HandleCallback() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logging.NewLogger(ctx)
+		query := r.URL.Query()
+
+		loginSession, _ := o.sessionStore.Get(r, oidcLoginSessionName)
+		state, _ := loginSession.Values[oidcLoginStateKey].(string)
+		nonce, _ := loginSession.Values[oidcLoginNonceKey].(string)
+		verifier, _ := loginSession.Values[oidcLoginVerifierKey].(string)
+
+		// the login can only be completed once
+		loginSession.Options = &sessions.Options{Path: "/", MaxAge: -1, HttpOnly: true, Secure: true}
+		if err := loginSession.Save(r, w); err != nil {
+			l.Error("failed to clear oidc login session", zap.Error(err))
+		}
+
+		if errCode := query.Get("error"); errCode != "" {
+			l.Warn("identity provider returned an error",
+				zap.String("error", errCode),
+				zap.String("error_description", query.Get("error_description")))
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+
+		if state == "" || query.Get("state") != state {
+			l.Warn("invalid oidc login state")
+			http.Redirect(w, r, OIDCLoginPath, http.StatusFound)
+			return
+		}
+
+		token, err := o.config.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(verifier))
+		if err != nil {
+			l.Warn("failed to exchange authorization code", zap.Error(err))
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+
+		tokens, claims, err := o.verifyTokens(ctx, token, nonce)
+		if err != nil {
+			l.Warn("failed to verify tokens", zap.Error(err))
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+
+		userSession, sessionToken, err := o.sessionResolver.create(r, claims, tokens.AccessToken)
+		if err != nil {
+			l.Warn("failed to create user session", zap.Error(err))
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+
+		if err := o.storeTokens(ctx, userSession, tokens); err != nil {
+			l.Error("failed to store tokens", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		auth.SetSessionCookie(w, sessionToken)
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+// HandleRefresh refreshes the tokens of the session with the refresh token. It is polled by
+// the browser every --token-refresh-interval, which keeps the tokens valid while the application is open.
+func (o *OIDCLogin) HandleRefresh() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logging.NewLogger(ctx)
+
+		userSession, ok := utils.GetUserSession(ctx)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		tokens, err := o.loadTokens(userSession)
+		if err != nil {
+			l.Warn("failed to load tokens", zap.Error(err))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := o.refresh(ctx, userSession, tokens); err != nil {
+			l.Warn("failed to refresh tokens", zap.Error(err))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Tokens returns the tokens stored with the session of the browser, refreshed if the ID token is about to expire
+func (o *OIDCLogin) Tokens(w http.ResponseWriter, r *http.Request) (string, string, error) {
+	ctx := r.Context()
+
+	sessionToken := auth.GetSessionToken(r)
+	if sessionToken == "" {
+		return "", "", errMissingToken
+	}
+
+	userSession, err := o.repo.GetByTokenHash(ctx, auth.HashSessionToken(sessionToken))
+	if err != nil {
+		return "", "", err
+	}
+	if userSession == nil {
+		return "", "", errMissingToken
+	}
+	if userSession.IsRevoked() {
+		return "", "", errUserSessionRevoked
+	}
+	if userSession.IsExpired(time.Now()) || userSession.Tokens == "" {
+		return "", "", errMissingToken
+	}
+
+	tokens, err := o.loadTokens(userSession)
+	if err != nil {
+		return "", "", err
+	}
+
+	if time.Until(tokens.Expiry) < oidcTokenRefreshMargin {
+		if tokens, err = o.refresh(ctx, userSession, tokens); err != nil {
+			return "", "", err
+		}
+	}
+
+	return tokens.IDToken, tokens.AccessToken, nil
+}
+
+// EndSessionURL returns where to send the users after logging out. It is the end session endpoint
+// of the provider if it has one, which sends them back to the given URL if it is absolute.
+func (o *OIDCLogin) EndSessionURL(postLogoutURL string) (string, error) {
+	var metadata struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := o.provider.Claims(&metadata); err != nil {
+		return "", err
+	}
+	if metadata.EndSessionEndpoint == "" {
+		return postLogoutURL, nil
+	}
+
+	u, err := url.Parse(metadata.EndSessionEndpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("client_id", o.config.ClientID)
+	if post, err := url.Parse(postLogoutURL); err == nil && post.IsAbs() {
+		q.Set("post_logout_redirect_uri", postLogoutURL)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// refresh gets new tokens with the refresh token, and stores them with the session
+func (o *OIDCLogin) refresh(ctx context.Context, userSession *api.UserSession, tokens oidcTokens) (oidcTokens, error) {
+	if tokens.RefreshToken == "" {
+		return oidcTokens{}, errors.New("missing refresh token")
+	}
+
+	// the expiry is set in the past, so that the token source always refreshes
+	token, err := o.config.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: tokens.RefreshToken,
+		Expiry:       time.Unix(1, 0),
+	}).Token()
+	if err != nil {
+		return oidcTokens{}, err
+	}
+
+	refreshed, claims, err := o.verifyTokens(ctx, token, "")
+	if err != nil {
+		return oidcTokens{}, err
+	}
+	if fmt.Sprintf("%s:%s", claims.Iss, claims.Sub) != userSession.UserID {
+		return oidcTokens{}, errors.New("refreshed tokens belong to another user")
+	}
+	if refreshed.RefreshToken == "" {
+		// the provider does not rotate the refresh tokens
+		refreshed.RefreshToken = tokens.RefreshToken
+	}
+
+	if err := o.storeTokens(ctx, userSession, refreshed); err != nil {
+		return oidcTokens{}, err
+	}
+	return refreshed, nil
+}
+
+// verifyTokens verifies the ID token of the token response, and its nonce if one is given
+func (o *OIDCLogin) verifyTokens(ctx context.Context, token *oauth2.Token, nonce string) (oidcTokens, TokenClaims, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return oidcTokens{}, TokenClaims{}, errors.New("missing id token")
+	}
+
+	claims, err := verifyIDToken(ctx, o.idTokenVerifier, rawIDToken)
+	if err != nil {
+		return oidcTokens{}, TokenClaims{}, err
+	}
+
+	if nonce != "" && claims.Nonce != nonce {
+		return oidcTokens{}, TokenClaims{}, errors.New("invalid nonce")
+	}
+
+	return oidcTokens{
+		IDToken:      rawIDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       time.Unix(claims.Exp, 0),
+	}, claims, nil
+}
+
+func (o *OIDCLogin) loadTokens(userSession *api.UserSession) (oidcTokens, error) {
+	plaintext, err := o.cipher.Decrypt(userSession.Tokens)
+	if err != nil {
+		return oidcTokens{}, err
+	}
+	var tokens oidcTokens
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return oidcTokens{}, err
+	}
+	return tokens, nil
+}
+
+func (o *OIDCLogin) storeTokens(ctx context.Context, userSession *api.UserSession, tokens oidcTokens) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	encrypted, err := o.cipher.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	userSession.Tokens = encrypted
+	return o.repo.UpdateTokens(ctx, userSession.ID, encrypted)
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCLogin_Tokens(t *testing.T) {
+	const token = "token"
+	hash := auth.HashSessionToken(token)
+	cipher, err := auth.NewTokenCipher(make([]byte, 32))
+	require.NoError(t, err)
+
+	plaintext, err := json.Marshal(oidcTokens{
+		IDToken:      "id-token",
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		Expiry:       time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt(plaintext)
+	require.NoError(t, err)
+
+	activeSession := func() *api.UserSession {
+		return &api.UserSession{
+			ID:        "session",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Tokens:    encrypted,
+		}
+	}
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name            string
+		cookie          string
+		setup           func(repo *db.MockUserSessionRepo)
+		wantErr         error
+		wantIDToken     string
+		wantAccessToken string
+	}{
+		{
+			name:    "no cookie",
+			setup:   func(repo *db.MockUserSessionRepo) {},
+			wantErr: errMissingToken,
+		}, {
+			name:   "unknown session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(nil, nil)
+			},
+			wantErr: errMissingToken,
+		}, {
+			name:   "revoked session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.RevokedAt = &revokedAt
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+			},
+			wantErr: errUserSessionRevoked,
+		}, {
+			name:   "expired session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+			},
+			wantErr: errMissingToken,
+		}, {
+			name:   "session without tokens",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.Tokens = ""
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+			},
+			wantErr: errMissingToken,
+		}, {
+			name:   "active session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(activeSession(), nil)
+			},
+			wantIDToken:     "id-token",
+			wantAccessToken: "access-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := db.NewMockUserSessionRepo(ctrl)
+			tt.setup(repo)
+
+			login := &OIDCLogin{repo: repo, cipher: cipher}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: tt.cookie})
+			}
+
+			idToken, accessToken, err := login.Tokens(httptest.NewRecorder(), r)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIDToken, idToken)
+			assert.Equal(t, tt.wantAccessToken, accessToken)
+		})
+	}
+}
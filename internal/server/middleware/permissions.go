@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/nrc-no/notcore/internal/api"
 	"github.com/nrc-no/notcore/internal/auth"
@@ -17,7 +18,15 @@ type ParsedPermissions struct {
 	CountryPermissions auth.CountryPermissions 
 }
 
-// permissionMiddleware will compute the user's permissions and add them to the context
+// StopImpersonationPath is where the global admins stop viewing the application as another user
+const StopImpersonationPath = "/impersonation/stop"
+
+// LogoutPath is where the users log out
+const LogoutPath = "/logout"
+
+// permissionMiddleware will compute the user's permissions and add them to the context.
+// A global admin impersonating another user gets the read permissions of that user's groups instead
+// of their own, and can only make the requests that change nothing, besides stopping the impersonation.
 func ComputePermissions(
 	jwtGroups utils.JwtGroupOptions,
 ) func(handler http.Handler) http.Handler {
@@ -47,8 +56,35 @@ func ComputePermissions(
 				allCountryIDs.Add(c.ID)
 			}
 
-			perms := parsePermissions(allCountries, jwtGroups, session.GetUserGroups())
-			authIntf := auth.New(perms.CountryPermissions, allCountryIDs, perms.IsGlobalAdmin)
+			if token, ok := utils.GetAPIToken(ctx); ok {
+				r = r.WithContext(utils.WithAuthContext(ctx, parseAPITokenPermissions(token, allCountryIDs)))
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			userGroups := session.GetUserGroups()
+			userSession, impersonating := utils.GetUserSession(ctx)
+			impersonating = impersonating && userSession.IsImpersonating(time.Now())
+			if impersonating {
+				l.Info("impersonated request",
+					zap.String("impersonated_user", userSession.ImpersonatedUserID),
+					zap.String("method", r.Method),
+					zap.String("uri", r.RequestURI))
+				if !isReadOnlyRequest(r) {
+					l.Warn("refused a change while impersonating")
+					http.Error(w, "changes are not allowed while viewing as another user", http.StatusForbidden)
+					return
+				}
+				userGroups = userSession.ImpersonatedGroups.Items()
+			}
+
+			perms := parsePermissions(allCountries, jwtGroups, userGroups)
+			fieldPerms := parseFieldPermissions(allCountries, userGroups)
+			officePerms := parseOfficePermissions(allCountries, userGroups)
+			if impersonating {
+				removeWritePermissions(perms, fieldPerms, officePerms)
+			}
+			authIntf := auth.New(perms.CountryPermissions, fieldPerms, officePerms, allCountryIDs, perms.IsGlobalAdmin)
 			r = r.WithContext(utils.WithAuthContext(ctx, authIntf))
 			h.ServeHTTP(w, r)
 
@@ -56,6 +92,40 @@ func ComputePermissions(
 	}
 }
 
+// isReadOnlyRequest returns true if the request changes nothing, or only ends the impersonation or the session
+func isReadOnlyRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return r.URL.Path == StopImpersonationPath || r.URL.Path == LogoutPath
+}
+
+// removeWritePermissions leaves only the read permissions, for the global admins viewing the application
+// as another user. The write permissions imply the read permissions, so they are replaced by them.
+func removeWritePermissions(perms *ParsedPermissions, fieldPerms auth.CountryFieldPermissions, officePerms auth.CountryOfficePermissions) {
+	for _, countryPerms := range perms.CountryPermissions {
+		if countryPerms.Contains(auth.PermissionWrite) {
+			countryPerms.Remove(auth.PermissionWrite)
+			countryPerms.Add(auth.PermissionRead)
+		}
+		countryPerms.Remove(auth.PermissionEditFields)
+	}
+	for _, groupPerms := range fieldPerms {
+		for _, fieldGroupPerms := range groupPerms {
+			fieldGroupPerms.Remove(auth.FieldPermissionEdit)
+		}
+	}
+	for _, countryOfficePerms := range officePerms {
+		for _, officePerms := range countryOfficePerms {
+			if officePerms.Contains(auth.PermissionWrite) {
+				officePerms.Remove(auth.PermissionWrite)
+				officePerms.Add(auth.PermissionRead)
+			}
+		}
+	}
+}
+
 // parsePermissions will retrieve the country ids from the user's groups
 // and determine if the user is a global admin
 func parsePermissions(allCountries []*api.Country, jwtGroups utils.JwtGroupOptions, userGroups []string) *ParsedPermissions {
@@ -63,18 +133,31 @@ func parsePermissions(allCountries []*api.Country, jwtGroups utils.JwtGroupOptio
 	userGroupsSet := containers.NewStringSet(userGroups...)
 
 	for _, c := range allCountries {
-		if userGroupsSet.Contains(c.ReadGroup) {
-			if countryPermissions[c.ID] == nil {
-				countryPermissions[c.ID] = containers.NewSet[auth.Permission]()
-			}
-			countryPermissions[c.ID].Add(auth.PermissionRead)
+		roles := c.Groups.Roles(userGroupsSet)
+		perms := containers.NewSet[auth.Permission]()
+
+		if roles.Contains(api.CountryGroupRoleRead) {
+			perms.Add(auth.PermissionRead)
 		}
 
-		if userGroupsSet.Contains(c.WriteGroup) {
-			if countryPermissions[c.ID] == nil {
-				countryPermissions[c.ID] = containers.NewSet[auth.Permission]()
+		if roles.Contains(api.CountryGroupRoleWrite) {
+			perms.Add(auth.PermissionWrite)
+		}
+
+		// a role gives access to the country, and lets save the fields it can edit. Only the write role
+		// lets create, upload, delete or deactivate individuals.
+		for _, role := range c.Roles.Roles {
+			if !roles.Contains(role.ID) {
+				continue
+			}
+			perms.Add(auth.PermissionRead)
+			if role.CanEdit() {
+				perms.Add(auth.PermissionEditFields)
 			}
-			countryPermissions[c.ID].Add(auth.PermissionWrite)
+		}
+
+		if !perms.IsEmpty() {
+			countryPermissions[c.ID] = perms
 		}
 	}
 
@@ -85,3 +168,76 @@ func parsePermissions(allCountries []*api.Country, jwtGroups utils.JwtGroupOptio
 		CountryPermissions: countryPermissions,
 	}
 }
+
+// readRoleFieldGroups are the field groups that the groups with the read role of a country, and the read groups
+// of its offices, can view and export. The protection, health and identification fields are only granted by the roles
+// of the country. The read groups mapped before the roles were added are also mapped to a role viewing all the fields
+// by the 056_add_read_all_fields_role migration, so that they keep seeing them.
+var readRoleFieldGroups = []auth.FieldGroup{auth.FieldGroupGeneral, auth.FieldGroupContact}
+
+// parseFieldPermissions will retrieve the permissions on the individual field groups from the user's groups.
+// The groups with the read role of a country can view and export the general and contact fields, and the groups with
+// the write role can view, edit and export all the fields. The read and write groups of the offices of the country do
+// the same. The roles of the country add their own permissions on top of these.
+func parseFieldPermissions(allCountries []*api.Country, userGroups []string) auth.CountryFieldPermissions {
+	fieldPermissions := auth.CountryFieldPermissions{}
+	userGroupsSet := containers.NewStringSet(userGroups...)
+
+	for _, c := range allCountries {
+		roles := c.Groups.Roles(userGroupsSet)
+
+		if roles.Contains(api.CountryGroupRoleRead) {
+			addReadRoleFieldPermissions(fieldPermissions.Get(c.ID))
+		}
+
+		if roles.Contains(api.CountryGroupRoleWrite) {
+			fieldPermissions.Get(c.ID).AddAll(auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport)
+		}
+
+		for _, office := range c.Offices.Offices {
+			if office.ReadGroup != "" && userGroupsSet.Contains(office.ReadGroup) {
+				addReadRoleFieldPermissions(fieldPermissions.Get(c.ID))
+			}
+			if office.WriteGroup != "" && userGroupsSet.Contains(office.WriteGroup) {
+				fieldPermissions.Get(c.ID).AddAll(auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport)
+			}
+		}
+
+		for _, role := range c.Roles.Roles {
+			if !roles.Contains(role.ID) {
+				continue
+			}
+			for group, perms := range role.FieldPermissions() {
+				fieldPermissions.Get(c.ID).Add(group, perms.Items()...)
+			}
+		}
+	}
+
+	return fieldPermissions
+}
+
+func addReadRoleFieldPermissions(permissions auth.FieldGroupPermissions) {
+	for _, group := range readRoleFieldGroups {
+		permissions.Add(group, auth.FieldPermissionView, auth.FieldPermissionExport)
+	}
+}
+
+// parseOfficePermissions will retrieve the permissions on the offices of the countries from the user's groups.
+// They only restrict the user in the countries where the user has no country-wide permission.
+func parseOfficePermissions(allCountries []*api.Country, userGroups []string) auth.CountryOfficePermissions {
+	officePermissions := auth.CountryOfficePermissions{}
+	userGroupsSet := containers.NewStringSet(userGroups...)
+
+	for _, c := range allCountries {
+		for _, office := range c.Offices.Offices {
+			if office.ReadGroup != "" && userGroupsSet.Contains(office.ReadGroup) {
+				officePermissions.Get(c.ID).Add(office.Name, auth.PermissionRead)
+			}
+			if office.WriteGroup != "" && userGroupsSet.Contains(office.WriteGroup) {
+				officePermissions.Get(c.ID).Add(office.Name, auth.PermissionWrite)
+			}
+		}
+	}
+
+	return officePermissions
+}
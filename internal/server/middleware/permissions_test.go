@@ -1,30 +1,42 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/nrc-no/notcore/internal/api"
 	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/constants"
 	"github.com/nrc-no/notcore/internal/containers"
 	"github.com/nrc-no/notcore/internal/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
 	country1 = api.Country{
 		ID:           "1",
-		ReadGroup:    "nrc-country-1-read",
-		WriteGroup:   "nrc-country-1-write",
+		Groups:       api.CountryGroups{
+			{Group: "nrc-country-1-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-1-write", Role: api.CountryGroupRoleWrite},
+		},
 	}
 	country2 = api.Country{
 		ID:           "2",
-		ReadGroup:    "nrc-country-2-read",
-		WriteGroup:   "nrc-country-2-write",
+		Groups:       api.CountryGroups{
+			{Group: "nrc-country-2-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-2-write", Role: api.CountryGroupRoleWrite},
+		},
 	}
 	country3 = api.Country{
 		ID:           "3",
-		ReadGroup:    "nrc-country-1-read",
-		WriteGroup:   "nrc-country-1-write",
+		Groups:       api.CountryGroups{
+			{Group: "nrc-country-1-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-1-write", Role: api.CountryGroupRoleWrite},
+		},
 	}
 )
 
@@ -83,7 +95,7 @@ func Test_parsePermissions(t *testing.T) {
 				jwtGroups: jwtGroups,
 				userGroups: []string{
 					jwtGroups.GlobalAdmin,
-					country1.ReadGroup,
+					"nrc-country-1-read",
 				},
 			},
 			want: &ParsedPermissions{
@@ -117,7 +129,7 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
+					"nrc-country-1-read",
 				},
 			},
 			want: &ParsedPermissions{
@@ -137,7 +149,7 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.WriteGroup,
+					"nrc-country-1-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -157,8 +169,8 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country1.WriteGroup,
+					"nrc-country-1-read",
+					"nrc-country-1-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -195,7 +207,7 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
+					"nrc-country-1-read",
 				},
 			},
 			want: &ParsedPermissions{
@@ -216,7 +228,7 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.WriteGroup,
+					"nrc-country-1-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -237,8 +249,8 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country1.WriteGroup,
+					"nrc-country-1-read",
+					"nrc-country-1-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -260,8 +272,8 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country2.ReadGroup,
+					"nrc-country-1-read",
+					"nrc-country-2-read",
 				},
 			},
 			want: &ParsedPermissions{
@@ -285,8 +297,8 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.WriteGroup,
-					country2.WriteGroup,
+					"nrc-country-1-write",
+					"nrc-country-2-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -310,10 +322,10 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country1.WriteGroup,
-					country2.ReadGroup,
-					country2.WriteGroup,
+					"nrc-country-1-read",
+					"nrc-country-1-write",
+					"nrc-country-2-read",
+					"nrc-country-2-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -339,8 +351,8 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country2.WriteGroup,
+					"nrc-country-1-read",
+					"nrc-country-2-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -364,9 +376,9 @@ func Test_parsePermissions(t *testing.T) {
 				},
 				jwtGroups: jwtGroups,
 				userGroups: []string{
-					country1.ReadGroup,
-					country1.WriteGroup,
-					country2.WriteGroup,
+					"nrc-country-1-read",
+					"nrc-country-1-write",
+					"nrc-country-2-write",
 				},
 			},
 			want: &ParsedPermissions{
@@ -391,3 +403,322 @@ func Test_parsePermissions(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePermissions_roles(t *testing.T) {
+	country := api.Country{
+		ID: "4",
+		Groups: api.CountryGroups{
+			{Group: "nrc-country-4-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-4-write", Role: api.CountryGroupRoleWrite},
+			{Group: "nrc-country-4-viewer", Role: "viewer"},
+			{Group: "nrc-country-4-clerk", Role: "clerk"},
+			{Group: "nrc-country-4-clerk-trainee", Role: "viewer"},
+			{Group: "nrc-country-4-clerk-trainee", Role: "clerk"},
+		},
+		Roles: api.CountryRoles{Roles: []api.CountryRole{
+			{ID: "viewer", Name: "Viewer", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+				auth.FieldGroupGeneral: {auth.FieldPermissionView},
+			}},
+			{ID: "clerk", Name: "Clerk", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+				auth.FieldGroupGeneral: {auth.FieldPermissionView, auth.FieldPermissionEdit},
+				auth.FieldGroupContact: {auth.FieldPermissionView},
+			}},
+		}},
+	}
+	allCountries := []*api.Country{&country}
+
+	got := parsePermissions(allCountries, utils.JwtGroupOptions{}, []string{"nrc-country-4-viewer"})
+	if !reflect.DeepEqual(got.CountryPermissions, auth.CountryPermissions{country.ID: containers.NewSet(auth.PermissionRead)}) {
+		t.Errorf("parsePermissions() = %v", got.CountryPermissions)
+	}
+
+	got = parsePermissions(allCountries, utils.JwtGroupOptions{}, []string{"nrc-country-4-clerk"})
+	if !reflect.DeepEqual(got.CountryPermissions, auth.CountryPermissions{country.ID: containers.NewSet(auth.PermissionRead, auth.PermissionEditFields)}) {
+		t.Errorf("parsePermissions() = %v", got.CountryPermissions)
+	}
+
+	got = parsePermissions(allCountries, utils.JwtGroupOptions{}, []string{"nrc-country-4-clerk-trainee"})
+	if !reflect.DeepEqual(got.CountryPermissions, auth.CountryPermissions{country.ID: containers.NewSet(auth.PermissionRead, auth.PermissionEditFields)}) {
+		t.Errorf("parsePermissions() = %v", got.CountryPermissions)
+	}
+
+	got = parsePermissions(allCountries, utils.JwtGroupOptions{}, []string{"nrc-country-4-unknown"})
+	if !reflect.DeepEqual(got.CountryPermissions, auth.CountryPermissions{}) {
+		t.Errorf("parsePermissions() = %v", got.CountryPermissions)
+	}
+}
+
+func Test_parsePermissions_editOnlyRoleCannotManageIndividuals(t *testing.T) {
+	country := api.Country{
+		ID: "4",
+		Groups: api.CountryGroups{
+			{Group: "nrc-country-4-health", Role: "health"},
+			{Group: "nrc-country-4-write", Role: api.CountryGroupRoleWrite},
+		},
+		Roles: api.CountryRoles{Roles: []api.CountryRole{
+			{ID: "health", Name: "Health officer", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+				auth.FieldGroupHealth: {auth.FieldPermissionView, auth.FieldPermissionEdit},
+			}},
+		}},
+	}
+	allCountries := []*api.Country{&country}
+	newAuth := func(groups ...string) auth.Interface {
+		perms := parsePermissions(allCountries, utils.JwtGroupOptions{}, groups)
+		return auth.New(perms.CountryPermissions, parseFieldPermissions(allCountries, groups), parseOfficePermissions(allCountries, groups), containers.NewStringSet(country.ID), false)
+	}
+
+	healthOfficer := newAuth("nrc-country-4-health")
+	assert.True(t, healthOfficer.HasCountryLevelPermission(country.ID, auth.PermissionEditFields))
+	assert.True(t, healthOfficer.HasOfficePermission(country.ID, "North", auth.PermissionEditFields))
+	assert.True(t, healthOfficer.HasFieldPermission(country.ID, auth.FieldGroupHealth, auth.FieldPermissionEdit))
+	assert.False(t, healthOfficer.HasFieldPermission(country.ID, auth.FieldGroupGeneral, auth.FieldPermissionEdit))
+	// deleting, deactivating, activating and uploading individuals require the write permission
+	assert.False(t, healthOfficer.HasCountryLevelPermission(country.ID, auth.PermissionWrite))
+	assert.False(t, healthOfficer.HasCountryPermissionWrite(country.ID))
+	assert.False(t, healthOfficer.HasOfficePermission(country.ID, "North", auth.PermissionWrite))
+
+	writer := newAuth("nrc-country-4-write")
+	assert.True(t, writer.HasCountryLevelPermission(country.ID, auth.PermissionWrite))
+	assert.True(t, writer.HasCountryLevelPermission(country.ID, auth.PermissionEditFields))
+	assert.True(t, writer.HasOfficePermission(country.ID, "North", auth.PermissionEditFields))
+}
+
+func Test_parseFieldPermissions(t *testing.T) {
+	country := api.Country{
+		ID: "4",
+		Groups: api.CountryGroups{
+			{Group: "nrc-country-4-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-4-write", Role: api.CountryGroupRoleWrite},
+			{Group: "nrc-country-4-viewer", Role: "viewer"},
+			{Group: "nrc-country-4-protection", Role: "protection"},
+			{Group: "nrc-country-4-protection-lead", Role: "viewer"},
+			{Group: "nrc-country-4-protection-lead", Role: "protection"},
+		},
+		Roles: api.CountryRoles{Roles: []api.CountryRole{
+			{ID: "viewer", Name: "Viewer", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+				auth.FieldGroupGeneral: {auth.FieldPermissionView},
+			}},
+			{ID: "protection", Name: "Protection", Permissions: map[auth.FieldGroup][]auth.FieldPermission{
+				auth.FieldGroupProtection: {auth.FieldPermissionView, auth.FieldPermissionEdit},
+			}},
+		}},
+	}
+	allCountries := []*api.Country{&country}
+
+	all := func(perms ...auth.FieldPermission) auth.FieldGroupPermissions {
+		groupPermissions := auth.FieldGroupPermissions{}
+		groupPermissions.AddAll(perms...)
+		return groupPermissions
+	}
+	roles := auth.FieldGroupPermissions{}
+	roles.Add(auth.FieldGroupGeneral, auth.FieldPermissionView)
+	roles.Add(auth.FieldGroupProtection, auth.FieldPermissionView, auth.FieldPermissionEdit)
+	reader := auth.FieldGroupPermissions{}
+	reader.Add(auth.FieldGroupGeneral, auth.FieldPermissionView, auth.FieldPermissionExport)
+	reader.Add(auth.FieldGroupContact, auth.FieldPermissionView, auth.FieldPermissionExport)
+	protectionReader := auth.FieldGroupPermissions{}
+	protectionReader.Add(auth.FieldGroupGeneral, auth.FieldPermissionView, auth.FieldPermissionExport)
+	protectionReader.Add(auth.FieldGroupContact, auth.FieldPermissionView, auth.FieldPermissionExport)
+	protectionReader.Add(auth.FieldGroupProtection, auth.FieldPermissionView, auth.FieldPermissionEdit)
+
+	tests := []struct {
+		name       string
+		userGroups []string
+		want       auth.CountryFieldPermissions
+	}{
+		{
+			name:       "no groups",
+			userGroups: []string{},
+			want:       auth.CountryFieldPermissions{},
+		}, {
+			name:       "read group",
+			userGroups: []string{"nrc-country-4-read"},
+			want:       auth.CountryFieldPermissions{country.ID: reader},
+		}, {
+			name:       "read group with a role",
+			userGroups: []string{"nrc-country-4-read", "nrc-country-4-protection"},
+			want:       auth.CountryFieldPermissions{country.ID: protectionReader},
+		}, {
+			name:       "write group",
+			userGroups: []string{"nrc-country-4-write"},
+			want:       auth.CountryFieldPermissions{country.ID: all(auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport)},
+		}, {
+			name:       "union of the roles",
+			userGroups: []string{"nrc-country-4-viewer", "nrc-country-4-protection"},
+			want:       auth.CountryFieldPermissions{country.ID: roles},
+		}, {
+			name:       "group with several roles",
+			userGroups: []string{"nrc-country-4-protection-lead"},
+			want:       auth.CountryFieldPermissions{country.ID: roles},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFieldPermissions(allCountries, tt.userGroups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldPermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseFieldPermissions_readOnlyUserCannotViewSensitiveFields(t *testing.T) {
+	country := api.Country{
+		ID: "4",
+		Groups: api.CountryGroups{
+			{Group: "nrc-country-4-read", Role: api.CountryGroupRoleRead},
+		},
+		Offices: api.CountryOffices{Offices: []api.CountryOffice{
+			{ID: "north", Name: "North", ReadGroup: "nrc-north-read"},
+		}},
+	}
+	allCountries := []*api.Country{&country}
+
+	for _, userGroup := range []string{"nrc-country-4-read", "nrc-north-read"} {
+		t.Run(userGroup, func(t *testing.T) {
+			userGroups := []string{userGroup}
+			permissions := auth.New(
+				parsePermissions(allCountries, utils.JwtGroupOptions{}, userGroups).CountryPermissions,
+				parseFieldPermissions(allCountries, userGroups),
+				parseOfficePermissions(allCountries, userGroups),
+				containers.NewStringSet(country.ID),
+				false,
+			)
+			access := api.NewIndividualFieldAccess(permissions, country.ID)
+
+			assert.True(t, access.CanView(constants.DBColumnIndividualFullName))
+			assert.True(t, access.CanView(constants.DBColumnIndividualPhoneNumber1))
+			for _, group := range []auth.FieldGroup{auth.FieldGroupProtection, auth.FieldGroupHealth, auth.FieldGroupIdentification} {
+				for _, column := range api.IndividualFieldGroupColumns(group) {
+					assert.False(t, access.CanView(column), column)
+					assert.False(t, access.CanExport(column), column)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseOfficePermissions(t *testing.T) {
+	country := api.Country{
+		ID: "5",
+		Groups: api.CountryGroups{
+			{Group: "nrc-country-5-read", Role: api.CountryGroupRoleRead},
+			{Group: "nrc-country-5-write", Role: api.CountryGroupRoleWrite},
+		},
+		Offices: api.CountryOffices{Offices: []api.CountryOffice{
+			{ID: "north", Name: "North", ReadGroup: "nrc-north-read", WriteGroup: "nrc-north-write"},
+			{ID: "south", Name: "South", WriteGroup: "nrc-south-write"},
+		}},
+	}
+	allCountries := []*api.Country{&country}
+
+	officePermissions := func(office string, perms ...auth.Permission) auth.CountryOfficePermissions {
+		p := auth.CountryOfficePermissions{}
+		p.Get(country.ID).Add(office, perms...)
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		userGroups []string
+		want       auth.CountryOfficePermissions
+	}{
+		{
+			name:       "country-wide group only",
+			userGroups: []string{"nrc-country-5-read"},
+			want:       auth.CountryOfficePermissions{},
+		}, {
+			name:       "office read group",
+			userGroups: []string{"nrc-north-read"},
+			want:       officePermissions("North", auth.PermissionRead),
+		}, {
+			name:       "office read and write groups",
+			userGroups: []string{"nrc-north-read", "nrc-north-write"},
+			want:       officePermissions("North", auth.PermissionRead, auth.PermissionWrite),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOfficePermissions(allCountries, tt.userGroups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOfficePermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePermissions_impersonation(t *testing.T) {
+	jwtGroups := utils.JwtGroupOptions{GlobalAdmin: "global-admin"}
+	inAnHour := time.Now().Add(time.Hour)
+	anHourAgo := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		expiresAt       *time.Time
+		wantStatus      int
+		wantGlobalAdmin bool
+		wantRead        bool
+		wantWrite       bool
+	}{
+		{
+			name:       "impersonated user can read",
+			method:     http.MethodGet,
+			path:       "/countries/1/participants",
+			expiresAt:  &inAnHour,
+			wantStatus: http.StatusOK,
+			wantRead:   true,
+		}, {
+			name:       "changes are refused",
+			method:     http.MethodPost,
+			path:       "/countries/1/participants/delete",
+			expiresAt:  &inAnHour,
+			wantStatus: http.StatusForbidden,
+		}, {
+			name:       "impersonation can be stopped",
+			method:     http.MethodPost,
+			path:       StopImpersonationPath,
+			expiresAt:  &inAnHour,
+			wantStatus: http.StatusOK,
+			wantRead:   true,
+		}, {
+			name:            "expired impersonation",
+			method:          http.MethodPost,
+			path:            "/countries/1/participants/delete",
+			expiresAt:       &anHourAgo,
+			wantStatus:      http.StatusOK,
+			wantGlobalAdmin: true,
+			wantRead:        true,
+			wantWrite:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := utils.WithSession(context.Background(), auth.NewAuthenticatedSession(
+				[]string{jwtGroups.GlobalAdmin}, "admin@nrc.no", "issuer", "admin", inAnHour, time.Now()))
+			ctx = utils.WithCountries(ctx, []*api.Country{&country1})
+			ctx = utils.WithUserSession(ctx, &api.UserSession{
+				ID:                     "session",
+				UserID:                 "issuer:admin",
+				ImpersonatedUserID:     "issuer:user",
+				ImpersonatedGroups:     containers.NewStringSet("nrc-country-1-write"),
+				ImpersonationExpiresAt: tt.expiresAt,
+			})
+
+			var authIntf auth.Interface
+			handler := ComputePermissions(jwtGroups)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authIntf, _ = utils.GetAuthContext(r.Context())
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil).WithContext(ctx))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.wantGlobalAdmin, authIntf.IsGlobalAdmin())
+			assert.Equal(t, tt.wantRead, authIntf.HasCountryPermissionRead(country1.ID))
+			assert.Equal(t, tt.wantWrite, authIntf.HasCountryPermissionWrite(country1.ID))
+			assert.Equal(t, tt.wantWrite, authIntf.HasFieldPermission(country1.ID, auth.FieldGroupGeneral, auth.FieldPermissionEdit))
+		})
+	}
+}
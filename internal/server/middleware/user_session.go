@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// userSessionLifetime is how long a session lasts without being used
+	userSessionLifetime = 1 * time.Hour
+	// userSessionTouchInterval is how often the use of a session is recorded
+	userSessionTouchInterval = 1 * time.Minute
+)
+
+// errUserSessionRevoked is returned when the session of the user, or the token it would be created from, was revoked
+var errUserSessionRevoked = errors.New("user session was revoked")
+
+// userSessionResolver finds the server-side session of an authenticated user, or creates it
+type userSessionResolver struct {
+	repo db.UserSessionRepo
+	// groupsRefreshInterval is how often the groups of the user are fetched again from the identity provider
+	groupsRefreshInterval time.Duration
+	fetchGroups           GroupsFetcher
+	now                   func() time.Time
+}
+
+func newUserSessionResolver(fetchGroups GroupsFetcher, repo db.UserSessionRepo, groupsRefreshInterval time.Duration) userSessionResolver {
+	return userSessionResolver{
+		repo:                  repo,
+		groupsRefreshInterval: groupsRefreshInterval,
+		fetchGroups:           fetchGroups,
+		now:                   time.Now,
+	}
+}
+
+// resolve returns the session of the user with the given token claims. The token of the session
+// is returned when a new session was created, and must be given to the browser.
+//
+// The session sent by the browser is only used if it belongs to the same user. A new session is only
+// created if the ID token was issued after the last revocation of a session of the user, so that
+// a revoked session cannot be recreated from the ID token it was created from.
+func (s userSessionResolver) resolve(r *http.Request, claims TokenClaims, accessToken string) (*api.UserSession, string, error) {
+	ctx := r.Context()
+	now := s.now()
+	userID := fmt.Sprintf("%s:%s", claims.Iss, claims.Sub)
+
+	if token := auth.GetSessionToken(r); token != "" {
+		session, err := s.repo.GetByTokenHash(ctx, auth.HashSessionToken(token))
+		if err != nil {
+			return nil, "", err
+		}
+		if session != nil && session.UserID == userID {
+			if session.IsRevoked() {
+				return nil, "", errUserSessionRevoked
+			}
+			if !session.IsExpired(now) {
+				if err := s.refresh(r, session, accessToken, now); err != nil {
+					return nil, "", err
+				}
+				return session, "", nil
+			}
+		}
+	}
+
+	return s.create(r, claims, accessToken)
+}
+
+// create creates a new session for the user with the given token claims, and returns it with its token
+func (s userSessionResolver) create(r *http.Request, claims TokenClaims, accessToken string) (*api.UserSession, string, error) {
+	ctx := r.Context()
+	l := logging.NewLogger(ctx)
+	now := s.now()
+	userID := fmt.Sprintf("%s:%s", claims.Iss, claims.Sub)
+
+	lastRevocation, err := s.repo.GetLastRevocation(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if lastRevocation != nil && !time.Unix(claims.Iat, 0).After(*lastRevocation) {
+		return nil, "", errUserSessionRevoked
+	}
+
+	groups, err := s.fetchGroups(ctx, accessToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := auth.NewSessionToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	session, err := s.repo.Create(ctx, &api.UserSession{
+		Hash:              auth.HashSessionToken(token),
+		UserID:            userID,
+		Email:             claims.Email,
+		Groups:            containers.NewStringSet(groups...),
+		UserAgent:         r.UserAgent(),
+		IPAddress:         clientIP(r),
+		CreatedAt:         now,
+		LastSeenAt:        now,
+		GroupsRefreshedAt: now,
+		ExpiresAt:         now.Add(userSessionLifetime),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	l.Info("created user session", zap.String("user_session_id", session.ID))
+	return session, token, nil
+}
+
+// refresh fetches the groups of the session again if they are stale, and extends the session
+func (s userSessionResolver) refresh(r *http.Request, session *api.UserSession, accessToken string, now time.Time) error {
+	ctx := r.Context()
+
+	if session.GroupsAreStale(now, s.groupsRefreshInterval) {
+		groups, err := s.fetchGroups(ctx, accessToken)
+		if err != nil {
+			return err
+		}
+		session.Groups = containers.NewStringSet(groups...)
+		session.GroupsRefreshedAt = now
+		if err := s.repo.UpdateGroups(ctx, session.ID, session.Groups, now); err != nil {
+			return err
+		}
+	}
+
+	if now.Sub(session.LastSeenAt) >= userSessionTouchInterval {
+		session.LastSeenAt = now
+		session.ExpiresAt = now.Add(userSessionLifetime)
+		if err := s.repo.Touch(ctx, session.ID, session.LastSeenAt, session.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/auth"
+	"github.com/nrc-no/notcore/internal/containers"
+	"github.com/nrc-no/notcore/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserSessionResolver(t *testing.T) {
+	const token = "token"
+	hash := auth.HashSessionToken(token)
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	claims := TokenClaims{
+		Iss:   "issuer",
+		Sub:   "subject",
+		Email: "user@nrc.no",
+		Iat:   now.Add(-10 * time.Minute).Unix(),
+	}
+	const userID = "issuer:subject"
+	activeSession := func() *api.UserSession {
+		return &api.UserSession{
+			ID:                "session",
+			UserID:            userID,
+			Groups:            containers.NewStringSet("old"),
+			LastSeenAt:        now.Add(-10 * time.Second),
+			GroupsRefreshedAt: now.Add(-time.Minute),
+			ExpiresAt:         now.Add(time.Hour),
+		}
+	}
+	revokedAt := now.Add(-time.Minute)
+	errFetch := errors.New("user info is unavailable")
+
+	tests := []struct {
+		name        string
+		cookie      string
+		fetchErr    error
+		setup       func(repo *db.MockUserSessionRepo)
+		wantErr     error
+		wantGroups  []string
+		wantCreated bool
+	}{
+		{
+			name:   "active session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(activeSession(), nil)
+			},
+			wantGroups: []string{"old"},
+		}, {
+			name:   "stale groups are fetched again",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.GroupsRefreshedAt = now.Add(-5 * time.Minute)
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+				repo.EXPECT().UpdateGroups(gomock.Any(), "session", containers.NewStringSet("new"), now).Return(nil)
+			},
+			wantGroups: []string{"new"},
+		}, {
+			name:     "failing to fetch stale groups",
+			cookie:   token,
+			fetchErr: errFetch,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.GroupsRefreshedAt = now.Add(-5 * time.Minute)
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+			},
+			wantErr: errFetch,
+		}, {
+			name:   "session is extended",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.LastSeenAt = now.Add(-2 * time.Minute)
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+				repo.EXPECT().Touch(gomock.Any(), "session", now, now.Add(userSessionLifetime)).Return(nil)
+			},
+			wantGroups: []string{"old"},
+		}, {
+			name:   "revoked session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.RevokedAt = &revokedAt
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+			},
+			wantErr: errUserSessionRevoked,
+		}, {
+			name:   "session of another user",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.UserID = "issuer:other"
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+				repo.EXPECT().GetLastRevocation(gomock.Any(), userID).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, s *api.UserSession) (*api.UserSession, error) { return s, nil })
+			},
+			wantGroups:  []string{"new"},
+			wantCreated: true,
+		}, {
+			name:   "expired session",
+			cookie: token,
+			setup: func(repo *db.MockUserSessionRepo) {
+				session := activeSession()
+				session.ExpiresAt = now.Add(-time.Minute)
+				repo.EXPECT().GetByTokenHash(gomock.Any(), hash).Return(session, nil)
+				repo.EXPECT().GetLastRevocation(gomock.Any(), userID).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, s *api.UserSession) (*api.UserSession, error) { return s, nil })
+			},
+			wantGroups:  []string{"new"},
+			wantCreated: true,
+		}, {
+			name: "no session",
+			setup: func(repo *db.MockUserSessionRepo) {
+				repo.EXPECT().GetLastRevocation(gomock.Any(), userID).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, s *api.UserSession) (*api.UserSession, error) {
+						assert.Equal(t, userID, s.UserID)
+						assert.Equal(t, "user@nrc.no", s.Email)
+						assert.Equal(t, now.Add(userSessionLifetime), s.ExpiresAt)
+						assert.NotEmpty(t, s.Hash)
+						return s, nil
+					})
+			},
+			wantGroups:  []string{"new"},
+			wantCreated: true,
+		}, {
+			name: "token issued after the last revocation",
+			setup: func(repo *db.MockUserSessionRepo) {
+				before := now.Add(-time.Hour)
+				repo.EXPECT().GetLastRevocation(gomock.Any(), userID).Return(&before, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, s *api.UserSession) (*api.UserSession, error) { return s, nil })
+			},
+			wantGroups:  []string{"new"},
+			wantCreated: true,
+		}, {
+			name: "token issued before the last revocation",
+			setup: func(repo *db.MockUserSessionRepo) {
+				repo.EXPECT().GetLastRevocation(gomock.Any(), userID).Return(&revokedAt, nil)
+			},
+			wantErr: errUserSessionRevoked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := db.NewMockUserSessionRepo(ctrl)
+			tt.setup(repo)
+
+			resolver := userSessionResolver{
+				repo:                  repo,
+				groupsRefreshInterval: 5 * time.Minute,
+				fetchGroups: func(ctx context.Context, accessToken string) ([]string, error) {
+					assert.Equal(t, "access-token", accessToken)
+					return []string{"new"}, tt.fetchErr
+				},
+				now: func() time.Time { return now },
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: tt.cookie})
+			}
+
+			session, newToken, err := resolver.resolve(r, claims, "access-token")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantGroups, session.Groups.Items())
+			if tt.wantCreated {
+				assert.NotEmpty(t, newToken)
+				assert.Equal(t, auth.HashSessionToken(newToken), session.Hash)
+			} else {
+				assert.Empty(t, newToken)
+			}
+		})
+	}
+}
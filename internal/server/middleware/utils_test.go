@@ -25,6 +25,8 @@ func configureDummyContextMiddleware(
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authIntf := auth.New(
 				countryPermissions,
+				fieldPermissions(countryPermissions),
+				auth.CountryOfficePermissions{},
 				allCountryIDs,
 				isGlobalAdmin,
 			)
@@ -38,3 +40,17 @@ func configureDummyContextMiddleware(
 		})
 	}
 }
+
+// fieldPermissions grants the field permissions that the read and write groups of the countries have
+func fieldPermissions(countryPermissions auth.CountryPermissions) auth.CountryFieldPermissions {
+	fieldPermissions := auth.CountryFieldPermissions{}
+	for countryID, perms := range countryPermissions {
+		if perms.Contains(auth.PermissionRead) {
+			fieldPermissions.Get(countryID).AddAll(auth.FieldPermissionView, auth.FieldPermissionExport)
+		}
+		if perms.Contains(auth.PermissionWrite) {
+			fieldPermissions.Get(countryID).AddAll(auth.FieldPermissionView, auth.FieldPermissionEdit, auth.FieldPermissionExport)
+		}
+	}
+	return fieldPermissions
+}
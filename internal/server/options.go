@@ -14,9 +14,13 @@ import (
 
 type Options struct {
 	Address                      string
+	AuthMode                     string
+	DevUsersFile                 string
 	DatabaseDriver               string
 	DatabaseDSN                  string
 	LoginURL                     string
+	LogoutURL                    string
+	SessionGroupsRefreshInterval time.Duration
 	JwtGroups                    utils.JwtGroupOptions
 	IdTokenAuthHeaderName        string
 	IdTokenAuthHeaderFormat      string
@@ -24,6 +28,9 @@ type Options struct {
 	AccessTokenHeaderFormat      string
 	OIDCIssuerURL                string
 	OAuthClientID                string
+	OAuthClientSecret            string
+	OAuthRedirectURL             string
+	OAuthScopes                  []string
 	TokenRefreshURL              string
 	TokenRefreshInterval         time.Duration
 	HashKey1                     string
@@ -36,8 +43,25 @@ type Options struct {
 	UserAssignedIdentityClientId string
 	AzuriteAccountName           string
 	AzuriteAccountKey            string
+	BlobStorageBackend           string
+	BlobStorageLocalDir          string
 }
 
+const (
+	// AuthModeProxy expects the ID and access tokens in the headers set by an authenticating proxy
+	AuthModeProxy = "proxy"
+	// AuthModeOIDC signs the users in with the built-in authorization code flow
+	AuthModeOIDC = "oidc"
+	// AuthModeDev lets the developers pick a user from a file. It is only allowed on a loopback address
+	AuthModeDev = "dev"
+)
+
+const (
+	BlobStorageBackendAzure  = "azure"
+	BlobStorageBackendLocal  = "local"
+	BlobStorageBackendMemory = "memory"
+)
+
 var jwtGroupRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+(?: +[A-Za-z0-9_-]+)*$`)
 
 func (o Options) validate() error {
@@ -47,25 +71,28 @@ func (o Options) validate() error {
 	if err := o.validateDatabase(); err != nil {
 		return err
 	}
-	if err := o.validateTokenRefresh(); err != nil {
+	if err := o.validateSession(); err != nil {
 		return err
 	}
 	if err := o.validateJwtGroups(); err != nil {
 		return err
 	}
-	if err := o.validateAuthHeader(); err != nil {
-		return err
-	}
-	if err := o.validateOIDCIssuerURL(); err != nil {
-		return err
-	}
-	if err := o.validateOAuthClientID(); err != nil {
+	if err := o.validateAuthMode(); err != nil {
 		return err
 	}
-	if err := o.validateAzureBlobStorageURL(); err != nil {
-		return err
+	// the development login needs neither an identity provider nor a token refresh
+	if o.authMode() != AuthModeDev {
+		if err := o.validateTokenRefresh(); err != nil {
+			return err
+		}
+		if err := o.validateOIDCIssuerURL(); err != nil {
+			return err
+		}
+		if err := o.validateOAuthClientID(); err != nil {
+			return err
+		}
 	}
-	if err := o.validateDownloadsContainerName(); err != nil {
+	if err := o.validateBlobStorage(); err != nil {
 		return err
 	}
 	return nil
@@ -108,6 +135,26 @@ func (o Options) validateTokenRefresh() error {
 	return nil
 }
 
+func (o Options) validateSession() error {
+	if len(o.LogoutURL) != 0 {
+		if err := o.validateRequiredURLOption(o.LogoutURL, "Logout URL"); err != nil {
+			return err
+		}
+	}
+	if o.SessionGroupsRefreshInterval <= 0 {
+		return fmt.Errorf("session groups refresh interval must be positive")
+	}
+	return nil
+}
+
+// logoutURL returns where the users are sent after logging out, defaulting to the login URL
+func (o Options) logoutURL() string {
+	if len(o.LogoutURL) == 0 {
+		return o.loginURL()
+	}
+	return o.LogoutURL
+}
+
 func (o Options) validateJwtGroups() error {
 	if len(o.JwtGroups.GlobalAdmin) == 0 {
 		return fmt.Errorf("JWT group global admin is required")
@@ -118,6 +165,87 @@ func (o Options) validateJwtGroups() error {
 	return nil
 }
 
+// authMode returns the configured authentication mode, defaulting to proxy
+func (o Options) authMode() string {
+	if len(o.AuthMode) == 0 {
+		return AuthModeProxy
+	}
+	return o.AuthMode
+}
+
+func (o Options) validateAuthMode() error {
+	switch o.authMode() {
+	case AuthModeProxy:
+		return o.validateAuthHeader()
+	case AuthModeOIDC:
+		return o.validateOIDCLogin()
+	case AuthModeDev:
+		return o.validateDevLogin()
+	default:
+		return fmt.Errorf("auth mode is invalid. must be one of: %s, %s, %s", AuthModeProxy, AuthModeOIDC, AuthModeDev)
+	}
+}
+
+func (o Options) validateDevLogin() error {
+	if len(o.DevUsersFile) == 0 {
+		return fmt.Errorf("dev users file is required")
+	}
+	host, _, err := net.SplitHostPort(o.Address)
+	if err != nil {
+		return fmt.Errorf("address is invalid: %w", err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("dev auth mode is only allowed on a loopback address, such as 127.0.0.1:8080")
+	}
+	return nil
+}
+
+// isLoopbackHost returns whether the host only accepts connections from the same machine.
+// An empty host listens on all the interfaces, so it is not a loopback host.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (o Options) validateOIDCLogin() error {
+	if err := o.validateRequiredURLOption(o.OAuthRedirectURL, "OAuth Redirect URL"); err != nil {
+		return err
+	}
+	redirectURL, _ := url.Parse(o.OAuthRedirectURL)
+	if !redirectURL.IsAbs() || redirectURL.Path != middleware.OIDCCallbackPath {
+		return fmt.Errorf("OAuth Redirect URL must be an absolute URL with the path %s", middleware.OIDCCallbackPath)
+	}
+	if o.tokenRefreshPath() == "" {
+		return fmt.Errorf("Refresh URL must have a path")
+	}
+	return nil
+}
+
+// loginURL returns where the users are sent to sign in, defaulting to the built-in login of the oidc and dev modes
+func (o Options) loginURL() string {
+	if len(o.LoginURL) == 0 {
+		switch o.authMode() {
+		case AuthModeOIDC:
+			return middleware.OIDCLoginPath
+		case AuthModeDev:
+			return middleware.DevLoginPath
+		}
+	}
+	return o.LoginURL
+}
+
+// tokenRefreshPath returns the path of the token refresh URL, which the built-in OIDC login serves
+func (o Options) tokenRefreshPath() string {
+	u, err := url.Parse(o.TokenRefreshURL)
+	if err != nil || u.Path == "/" {
+		return ""
+	}
+	return u.Path
+}
+
 func (o Options) validateAuthHeader() error {
 	if !isValidRFC7230HeaderName(o.IdTokenAuthHeaderName) {
 		return fmt.Errorf("auth header name is invalid")
@@ -145,6 +273,37 @@ func (o Options) validateOAuthClientID() error {
 	return nil
 }
 
+// blobStorageBackend returns the configured blob storage backend, defaulting to azure
+func (o Options) blobStorageBackend() string {
+	if len(o.BlobStorageBackend) == 0 {
+		return BlobStorageBackendAzure
+	}
+	return o.BlobStorageBackend
+}
+
+func (o Options) validateBlobStorage() error {
+	switch o.blobStorageBackend() {
+	case BlobStorageBackendAzure:
+		if err := o.validateAzureBlobStorageURL(); err != nil {
+			return err
+		}
+		if err := o.validateDownloadsContainerName(); err != nil {
+			return err
+		}
+	case BlobStorageBackendLocal:
+		if len(o.BlobStorageLocalDir) == 0 {
+			return fmt.Errorf("Blob Storage Local Dir is required")
+		}
+	case BlobStorageBackendMemory:
+	default:
+		return fmt.Errorf("blob storage backend is invalid. must be one of: %s, %s, %s",
+			BlobStorageBackendAzure,
+			BlobStorageBackendLocal,
+			BlobStorageBackendMemory)
+	}
+	return nil
+}
+
 func (o Options) validateAzureBlobStorageURL() error {
 	if len(o.AzureBlobStorageURL) == 0 {
 		return fmt.Errorf("Azure Blob Storage URL is required")
@@ -4,10 +4,8 @@ import (
 	"github.com/nrc-no/notcore/internal/locales"
 	"github.com/nrc-no/notcore/internal/utils"
 	"net/http"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
-
-	"github.com/coreos/go-oidc/v3/oidc"
 	gorillahandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
@@ -15,6 +13,7 @@ import (
 	"github.com/nrc-no/notcore/internal/db"
 	"github.com/nrc-no/notcore/internal/handlers"
 	"github.com/nrc-no/notcore/internal/server/middleware"
+	"github.com/nrc-no/notcore/internal/storage"
 	"github.com/nrc-no/notcore/web"
 )
 
@@ -22,19 +21,23 @@ func buildRouter(
 	healthzRepo db.HealthzRepo,
 	individualRepo db.IndividualRepo,
 	countryRepo db.CountryRepo,
+	serviceAccountRepo db.ServiceAccountRepo,
+	userSessionRepo db.UserSessionRepo,
+	accessEventRepo db.AccessEventRepo,
+	individualStatisticsRepo db.IndividualStatisticsRepo,
 	jwtGroups utils.JwtGroupOptions,
-	idTokenAuthHeaderName string,
-	idTokenAuthHeaderFormat string,
-	accessTokenHeaderName string,
-	accessTokenHeaderFormat string,
+	authn *authentication,
+	tokenRefreshPath string,
 	loginURL string,
+	groupsRefreshInterval time.Duration,
 	enableBetaFeatures bool,
-	provider *oidc.Provider,
-	idTokenVerifier middleware.IDTokenVerifier,
-	sessionStore *sessions.CookieStore,
+	sessionStore sessions.Store,
 	tpl templates,
-	azureBlobClient *azblob.Client,
-	containerName string,
+	exportRepo db.ExportRepo,
+	importProfileRepo db.ImportProfileRepo,
+	pendingUploadRepo db.PendingUploadRepo,
+	blobStore storage.BlobStore,
+	downloadLinkSigner *handlers.DownloadLinkSigner,
 ) *mux.Router {
 
 	r := mux.NewRouter()
@@ -56,11 +59,22 @@ func buildRouter(
 	)
 	healthzRouter.Path("").Handler(handlers.HandleHealth(healthzRepo))
 
+	// the built-in OIDC login is reached before the users are authenticated
+	if oidcLogin := authn.oidcLogin; oidcLogin != nil {
+		r.Path(middleware.OIDCLoginPath).Methods(http.MethodGet).Handler(withMiddleware(oidcLogin.HandleLogin(), noCache, middleware.RequestLogging))
+		r.Path(middleware.OIDCCallbackPath).Methods(http.MethodGet).Handler(withMiddleware(oidcLogin.HandleCallback(), noCache, middleware.RequestLogging))
+	}
+	if devLogin := authn.devLogin; devLogin != nil {
+		r.Path(middleware.DevLoginPath).Methods(http.MethodGet, http.MethodPost).Handler(withMiddleware(devLogin.HandleLogin(), noCache, middleware.RequestLogging))
+	}
+
 	webRouter := r.PathPrefix("").Subrouter()
 	webRouter.Use(
 		noCache,
 		middleware.RequestLogging,
-		middleware.Authentication(idTokenAuthHeaderName, idTokenAuthHeaderFormat, accessTokenHeaderName, accessTokenHeaderFormat, provider, idTokenVerifier, sessionStore, loginURL),
+		middleware.APITokenAuthentication(serviceAccountRepo),
+		middleware.CSRF(sessionStore),
+		middleware.Authentication(authn.tokenSource, authn.idTokenVerifier, authn.fetchGroups, userSessionRepo, groupsRefreshInterval, loginURL),
 		middleware.PrefetchCountries(countryRepo),
 		middleware.ComputePermissions(jwtGroups),
 		middleware.SelectedCountry(),
@@ -70,25 +84,80 @@ func buildRouter(
 	countriesRouter := webRouter.PathPrefix("/countries").Subrouter()
 	countriesRouter.Path("").Handler(handlers.HandleCountries(renderer))
 
+	serviceAccountsRouter := webRouter.PathPrefix("/service-accounts").Subrouter()
+	serviceAccountsRouter.Path("").Handler(withMiddleware(
+		handlers.HandleServiceAccounts(renderer, serviceAccountRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+	serviceAccountsRouter.Path("/{service_account_id}").Handler(withMiddleware(
+		handlers.HandleServiceAccount(renderer, serviceAccountRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	webRouter.Path("/sessions").Handler(withMiddleware(
+		handlers.HandleUserSessions(renderer, userSessionRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	webRouter.Path("/access-events").Methods(http.MethodGet).Handler(withMiddleware(
+		handlers.HandleAccessEvents(renderer, accessEventRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	if authn.oidcLogin != nil {
+		webRouter.Path(tokenRefreshPath).Methods(http.MethodGet).Handler(authn.oidcLogin.HandleRefresh())
+	}
+
+	webRouter.Path(middleware.StopImpersonationPath).Methods(http.MethodPost).Handler(handlers.HandleStopImpersonation(userSessionRepo))
+
+	webRouter.Path(middleware.LogoutPath).Methods(http.MethodPost).Handler(handlers.HandleLogout(userSessionRepo, authn.logoutURL))
+
 	countryRouter := countriesRouter.PathPrefix("/{country_id}").Subrouter()
 	countryRouter.Path("").Handler(withMiddleware(
 		handlers.HandleCountry(renderer, countryRepo),
 		middleware.HasGlobalAdminPermission(),
 	))
 
+	countryRouter.Path("/service-catalogue").Handler(withMiddleware(
+		handlers.HandleServiceCatalogue(renderer, countryRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	countryRouter.Path("/roles").Handler(withMiddleware(
+		handlers.HandleCountryRoles(renderer, countryRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	countryRouter.Path("/offices").Handler(withMiddleware(
+		handlers.HandleCountryOffices(renderer, countryRepo),
+		middleware.HasGlobalAdminPermission(),
+	))
+
+	countryRouter.Path("/dashboard").Methods(http.MethodGet).Handler(withMiddleware(
+		handlers.HandleDashboard(renderer, individualStatisticsRepo),
+		middleware.EnsureSelectedCountry(),
+		middleware.HasCountryPermission(auth.PermissionRead),
+	))
+
+	countryRouter.Path("/reports/sadd").Methods(http.MethodGet).Handler(withMiddleware(
+		handlers.HandleSADDReport(individualRepo),
+		middleware.EnsureSelectedCountry(),
+		middleware.HasCountryPermission(auth.PermissionRead),
+	))
+
 	individualsRouter := countryRouter.PathPrefix("/participants").Subrouter()
 	individualsRouter.Path("").Methods(http.MethodGet).Handler(withMiddleware(
-		handlers.HandleIndividuals(renderer, individualRepo),
+		handlers.HandleIndividuals(renderer, individualRepo, accessEventRepo),
 		middleware.EnsureSelectedCountry(),
 		middleware.HasCountryPermission(auth.PermissionRead),
 	))
 	individualsRouter.Path("/upload").Methods(http.MethodPost).Handler(withMiddleware(
-		handlers.HandleUpload(renderer, individualRepo),
+		handlers.HandleUpload(renderer, individualRepo, importProfileRepo, pendingUploadRepo, accessEventRepo, blobStore),
 		middleware.EnsureSelectedCountry(),
 		middleware.HasCountryPermission(auth.PermissionWrite),
 	))
 	individualsRouter.Path("/download").Methods(http.MethodGet).Handler(withMiddleware(
-		handlers.HandleDownload(individualRepo, azureBlobClient, containerName),
+		handlers.HandleDownload(individualRepo, exportRepo, accessEventRepo, blobStore, downloadLinkSigner),
 		middleware.EnsureSelectedCountry(),
 		middleware.HasCountryPermission(auth.PermissionRead),
 	))
@@ -110,14 +179,14 @@ func buildRouter(
 
 	individualRouter := individualsRouter.PathPrefix("/{individual_id}").Subrouter()
 	individualRouter.Path("").Methods(http.MethodGet).Handler(withMiddleware(
-		handlers.HandleIndividual(renderer, individualRepo),
+		handlers.HandleIndividual(renderer, individualRepo, accessEventRepo),
 		middleware.EnsureSelectedCountry(),
 		middleware.HasCountryPermission(auth.PermissionRead),
 	))
 	individualRouter.Path("").Methods(http.MethodPost).Handler(withMiddleware(
-		handlers.HandleIndividual(renderer, individualRepo),
+		handlers.HandleIndividual(renderer, individualRepo, accessEventRepo),
 		middleware.EnsureSelectedCountry(),
-		middleware.HasCountryPermission(auth.PermissionWrite),
+		middleware.HasCountryPermission(auth.PermissionEditFields),
 	))
 	individualRouter.Path("/delete").Methods(http.MethodPost).Handler(withMiddleware(
 		handlers.HandleIndividualAction(individualRepo, db.DeleteAction),
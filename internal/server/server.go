@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/hex"
 	"net"
 	"net/http"
@@ -10,19 +12,28 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nrc-no/notcore/internal/db"
+	"github.com/nrc-no/notcore/internal/handlers"
 	"github.com/nrc-no/notcore/internal/locales"
 	"github.com/nrc-no/notcore/internal/logging"
-	"github.com/nrc-no/notcore/internal/server/middleware"
+	"github.com/nrc-no/notcore/internal/storage"
 	"go.uber.org/zap"
 )
 
+const (
+	// statisticsCacheTTL is how long the dashboard statistics are kept in memory
+	statisticsCacheTTL = 1 * time.Minute
+	// exportTTL is how long exported files can be downloaded before they are deleted
+	exportTTL = 24 * time.Hour
+	// exportJanitorInterval is how often expired exports are deleted
+	exportJanitorInterval = 10 * time.Minute
+)
+
 type AzuriteOptions struct {
 	accountName   string
 	accountKey    string
@@ -66,29 +77,35 @@ func (o Options) New(ctx context.Context) (*Server, error) {
 	// create the country db repository
 	countryRepo := db.NewCountryRepo(sqlDb)
 
+	// create the service account db repository
+	serviceAccountRepo := db.NewServiceAccountRepo(sqlDb)
+
+	// create the user session db repository
+	userSessionRepo := db.NewUserSessionRepo(sqlDb)
+	accessEventRepo := db.NewAccessEventRepo(sqlDb)
+
+	// create the individual statistics db repository. Statistics are cached briefly
+	// since they require scanning all the registrations of a country
+	individualStatisticsRepo := db.NewCachedIndividualStatisticsRepo(db.NewIndividualStatisticsRepo(sqlDb), statisticsCacheTTL)
+
+	// create the export db repository
+	exportRepo := db.NewExportRepo(sqlDb)
+
+	// create the import profile db repository
+	importProfileRepo := db.NewImportProfileRepo(sqlDb)
+
+	// create the pending upload db repository
+	pendingUploadRepo := db.NewPendingUploadRepo(sqlDb)
+
 	s := &Server{address: o.Address}
 
 	// parse html templates
-	tpl, err := parseTemplates(o.LoginURL, o.TokenRefreshURL, o.TokenRefreshInterval)
+	tpl, err := parseTemplates(o.loginURL(), o.TokenRefreshURL, o.TokenRefreshInterval, o.authMode() == AuthModeOIDC)
 	if err != nil {
 		l.Error("failed to parse templates", zap.Error(err))
 		return nil, err
 	}
 
-	// create the oidc provider
-	oidcProvider, err := oidc.NewProvider(ctx, o.OIDCIssuerURL)
-	if err != nil {
-		l.Error("failed to get oidc provider", zap.Error(err))
-		return nil, err
-	}
-
-	// create the id token verifier
-	oidcVerifier := oidcProvider.Verifier(&oidc.Config{
-		ClientID:             o.OAuthClientID,
-		SupportedSigningAlgs: []string{oidc.RS256},
-	})
-	idTokenVerifier := middleware.NewIDTokenVerifier(oidcVerifier)
-
 	hashKey1, err := hex.DecodeString(o.HashKey1)
 	if err != nil {
 		l.Error("failed to decode hash key 1", zap.Error(err))
@@ -113,17 +130,17 @@ func (o Options) New(ctx context.Context) (*Server, error) {
 		return nil, err
 	}
 
-	azuriteOptions := AzuriteOptions{
-		accountName:   o.AzuriteAccountName,
-		accountKey:    o.AzuriteAccountKey,
-		containerName: o.DownloadsContainerName,
-	}
-	azureBlobClient, err := getAzureBlobStorageClient(ctx, o.AzureBlobStorageURL, azuriteOptions, o.UserAssignedIdentityClientId)
+	blobStore, err := o.getBlobStore(ctx)
 	if err != nil {
-		l.Error("failed to get azure blob storage client", zap.Error(err))
+		l.Error("failed to get blob store", zap.Error(err))
 		return nil, err
 	}
+	s.exportJanitor = newExportJanitor(exportRepo, pendingUploadRepo, blobStore, exportJanitorInterval)
+
+	// download links are signed with a key derived from the session hash key
+	downloadLinkSigner := handlers.NewDownloadLinkSigner(deriveKey(hashKey1, "export download links"), exportTTL)
 
+	// the cookie store only holds the CSRF secret, the user sessions are stored in the database
 	sessionStore := sessions.NewCookieStore(
 		hashKey1,
 		blockKey1,
@@ -135,33 +152,44 @@ func (o Options) New(ctx context.Context) (*Server, error) {
 	sessionStore.Options.Secure = true
 	sessionStore.Options.SameSite = http.SameSiteStrictMode
 
+	authn, err := o.newAuthentication(ctx, userSessionRepo, sessionStore, blockKey1)
+	if err != nil {
+		l.Error("failed to set up authentication", zap.Error(err))
+		return nil, err
+	}
+
 	// build the router
 	s.router = buildRouter(
 		healthzRepo,
 		individualRepo,
 		countryRepo,
+		serviceAccountRepo,
+		userSessionRepo,
+		accessEventRepo,
+		individualStatisticsRepo,
 		o.JwtGroups,
-		o.IdTokenAuthHeaderName,
-		o.IdTokenAuthHeaderFormat,
-		o.AccessTokenHeaderName,
-		o.AccessTokenHeaderFormat,
-		o.LoginURL,
+		authn,
+		o.tokenRefreshPath(),
+		o.loginURL(),
+		o.SessionGroupsRefreshInterval,
 		o.EnableBetaFeatures,
-		oidcProvider,
-		idTokenVerifier,
 		sessionStore,
 		tpl,
-		azureBlobClient,
-		o.DownloadsContainerName,
+		exportRepo,
+		importProfileRepo,
+		pendingUploadRepo,
+		blobStore,
+		downloadLinkSigner,
 	)
 
 	return s, nil
 }
 
 type Server struct {
-	address  string
-	listener net.Listener
-	router   *mux.Router
+	address       string
+	listener      net.Listener
+	router        *mux.Router
+	exportJanitor *exportJanitor
 }
 
 func (s *Server) Start(ctx context.Context) error {
@@ -176,6 +204,10 @@ func (s *Server) Start(ctx context.Context) error {
 
 	l.Info("listening on " + s.listener.Addr().String())
 
+	if s.exportJanitor != nil {
+		go s.exportJanitor.run(ctx)
+	}
+
 	go func() {
 		<-ctx.Done()
 		l.Info("stopping server")
@@ -189,6 +221,34 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// getBlobStore returns the blob store for the configured backend
+func (o Options) getBlobStore(ctx context.Context) (storage.BlobStore, error) {
+	switch o.blobStorageBackend() {
+	case BlobStorageBackendLocal:
+		return storage.NewLocalBlobStore(o.BlobStorageLocalDir)
+	case BlobStorageBackendMemory:
+		return storage.NewMemoryBlobStore(), nil
+	default:
+		azuriteOptions := AzuriteOptions{
+			accountName:   o.AzuriteAccountName,
+			accountKey:    o.AzuriteAccountKey,
+			containerName: o.DownloadsContainerName,
+		}
+		azureBlobClient, err := getAzureBlobStorageClient(ctx, o.AzureBlobStorageURL, azuriteOptions, o.UserAssignedIdentityClientId)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewAzureBlobStore(azureBlobClient, o.DownloadsContainerName), nil
+	}
+}
+
+// deriveKey derives a key dedicated to the given purpose from a secret key
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
 func getAzureBlobStorageClient(ctx context.Context, storageUrl string, azuriteOptions AzuriteOptions, userAssignedIdentityClientId string) (*azblob.Client, error) {
 	isLocalEnvironment := strings.Contains(storageUrl, "localhost") || strings.Contains(storageUrl, "127.0.0.1")
 
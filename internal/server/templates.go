@@ -4,9 +4,14 @@ import (
 	"errors"
 	"github.com/nrc-no/notcore/internal/locales"
 	"html/template"
+	"io/fs"
+	"path"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/nrc-no/notcore/internal/handlers"
+	"github.com/nrc-no/notcore/internal/server/middleware"
 	"github.com/nrc-no/notcore/web"
 )
 
@@ -16,6 +21,7 @@ func parseTemplates(
 	loginURL string,
 	refreshURL string,
 	tokenRefreshInterval time.Duration,
+	tokenRefreshEnabled bool,
 ) (templates, error) {
 	t := make(templates)
 	entries, err := web.Content.ReadDir("templates")
@@ -61,6 +67,9 @@ func parseTemplates(
 			"tokenRefreshInterval": func() time.Duration {
 				return tokenRefreshInterval
 			},
+			"tokenRefreshEnabled": func() bool {
+				return tokenRefreshEnabled
+			},
 			"time": func() TimeFunctions {
 				return TimeFunctions{}
 			},
@@ -91,11 +100,49 @@ func parseTemplates(
 			"translate": func(id string, args ...interface{}) string {
 				return locales.GetLocales().Translate(id, args...)
 			},
+			"csrfField": func() template.HTML {
+				return csrfField
+			},
 		})
-		t[name], err = tpl.ParseFS(web.Content, "templates/**.gohtml", "templates/"+name)
+		t[name], err = parseTemplateFiles(tpl, "templates/**.gohtml", "templates/"+name)
 		if err != nil {
 			return nil, err
 		}
 	}
 	return t, nil
 }
+
+// csrfField is the hidden field holding the CSRF token, which is filled in when the view is rendered
+var csrfField = template.HTML(`<input type="hidden" name="` + middleware.CSRFFormField + `" value="` + handlers.CSRFTokenPlaceholder + `">`)
+
+// postFormTagRegex matches the opening tags of the forms posted to the server
+var postFormTagRegex = regexp.MustCompile(`(?is)<form\b[^>]*\bmethod\s*=\s*["']?post\b[^>]*>`)
+
+// injectCSRFField adds the CSRF field at the start of every form of the template that is posted to the server
+func injectCSRFField(src string) string {
+	return postFormTagRegex.ReplaceAllString(src, "$0{{csrfField}}")
+}
+
+// parseTemplateFiles parses the template files matching the patterns like template.ParseFS,
+// after adding the CSRF field to their forms
+func parseTemplateFiles(tpl *template.Template, patterns ...string) (*template.Template, error) {
+	for _, pattern := range patterns {
+		names, err := fs.Glob(web.Content, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, errors.New("pattern matches no files: " + pattern)
+		}
+		for _, name := range names {
+			src, err := fs.ReadFile(web.Content, name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tpl.New(path.Base(name)).Parse(injectCSRFField(string(src))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tpl, nil
+}
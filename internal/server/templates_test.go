@@ -0,0 +1,47 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_injectCSRFField(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "post form",
+			src:  `<form method="post" action="/countries/{{.ID}}">`,
+			want: `<form method="post" action="/countries/{{.ID}}">{{csrfField}}`,
+		}, {
+			name: "multiline tag",
+			src:  "<form id=\"f\"\n      method=\"post\"\n      enctype=\"multipart/form-data\">\n",
+			want: "<form id=\"f\"\n      method=\"post\"\n      enctype=\"multipart/form-data\">{{csrfField}}\n",
+		}, {
+			name: "uppercase and unquoted",
+			src:  `<FORM METHOD=POST>`,
+			want: `<FORM METHOD=POST>{{csrfField}}`,
+		}, {
+			name: "get form",
+			src:  `<form method="get"><form>`,
+			want: `<form method="get"><form>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, injectCSRFField(tt.src))
+		})
+	}
+}
+
+func Test_parseTemplates_injectsCSRFField(t *testing.T) {
+	tpls, err := parseTemplates("", "", 0, false)
+	require.NoError(t, err)
+	tree := tpls["country.gohtml"].Lookup("body").Tree.Root.String()
+	assert.True(t, strings.Contains(tree, "{{csrfField}}"))
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+type azureBlobStore struct {
+	client        *azblob.Client
+	containerName string
+}
+
+// NewAzureBlobStore returns a BlobStore keeping the blobs in the given Azure Blob Storage container
+func NewAzureBlobStore(client *azblob.Client, containerName string) BlobStore {
+	return &azureBlobStore{client: client, containerName: containerName}
+}
+
+func (a *azureBlobStore) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := validateBlobName(name); err != nil {
+		return err
+	}
+	_, err := a.client.UploadStream(ctx, a.containerName, name, r, nil)
+	return err
+}
+
+func (a *azureBlobStore) Download(ctx context.Context, name string) (Blob, error) {
+	if err := validateBlobName(name); err != nil {
+		return nil, err
+	}
+	downloadStream, err := a.client.DownloadStream(ctx, a.containerName, name, &azblob.DownloadStreamOptions{})
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, ErrBlobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	blob := &streamBlob{ReadCloser: downloadStream.Body, size: -1, lastModified: time.Now()}
+	if downloadStream.ContentLength != nil {
+		blob.size = *downloadStream.ContentLength
+	}
+	if downloadStream.LastModified != nil {
+		blob.lastModified = *downloadStream.LastModified
+	}
+	return blob, nil
+}
+
+func (a *azureBlobStore) Delete(ctx context.Context, name string) error {
+	if err := validateBlobName(name); err != nil {
+		return err
+	}
+	_, err := a.client.DeleteBlob(ctx, a.containerName, name, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return ErrBlobNotFound
+	}
+	return err
+}
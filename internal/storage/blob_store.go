@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrBlobNotFound is returned when downloading or deleting a blob that does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects, such as exported files, by name
+type BlobStore interface {
+	// Upload stores the content read from r under the given name, replacing any existing blob
+	Upload(ctx context.Context, name string, r io.Reader) error
+	// Download returns the blob stored under the given name. The caller must close the blob
+	Download(ctx context.Context, name string) (Blob, error)
+	// Delete removes the blob stored under the given name
+	Delete(ctx context.Context, name string) error
+}
+
+// Blob is the content of a stored object. It is streamed from the store as it is read
+type Blob interface {
+	io.ReadCloser
+	// Size is the length of the blob in bytes, or -1 if unknown
+	Size() int64
+	// LastModified is the time the blob was last uploaded
+	LastModified() time.Time
+}
+
+// validateBlobName makes sure that a blob name cannot be used to escape the store,
+// for example by traversing directories in the local store
+func validateBlobName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("invalid blob name %q", name)
+	}
+	if strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid blob name %q", name)
+	}
+	return nil
+}
+
+// streamBlob is a Blob read from an underlying stream, such as a file or a network response
+type streamBlob struct {
+	io.ReadCloser
+	size         int64
+	lastModified time.Time
+}
+
+func (b *streamBlob) Size() int64 {
+	return b.size
+}
+
+func (b *streamBlob) LastModified() time.Time {
+	return b.lastModified
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobStores(t *testing.T) {
+	localStore, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	stores := map[string]BlobStore{
+		"memory": NewMemoryBlobStore(),
+		"local":  localStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testBlobStore(t, store)
+		})
+	}
+}
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+
+	_, err := store.Download(ctx, "missing.csv")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	require.NoError(t, store.Upload(ctx, "file.csv", strings.NewReader("a,b,c")))
+	assertBlobContent(t, store, "file.csv", "a,b,c")
+
+	// uploading again replaces the content
+	require.NoError(t, store.Upload(ctx, "file.csv", strings.NewReader("d,e,f")))
+	assertBlobContent(t, store, "file.csv", "d,e,f")
+
+	require.NoError(t, store.Delete(ctx, "file.csv"))
+	_, err = store.Download(ctx, "file.csv")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "file.csv"), ErrBlobNotFound)
+
+	for _, invalidName := range []string{"", "..", "../file.csv", "dir/file.csv", `dir\file.csv`} {
+		assert.Error(t, store.Upload(ctx, invalidName, strings.NewReader("")), invalidName)
+	}
+}
+
+func assertBlobContent(t *testing.T, store BlobStore, name string, want string) {
+	blob, err := store.Download(context.Background(), name)
+	require.NoError(t, err)
+	defer blob.Close()
+
+	content, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(content))
+	assert.Equal(t, int64(len(want)), blob.Size())
+	assert.False(t, blob.LastModified().IsZero())
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type localBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore returns a BlobStore keeping the blobs as files in the given directory.
+// The directory is created if it does not exist.
+func NewLocalBlobStore(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &localBlobStore{dir: dir}, nil
+}
+
+func (l *localBlobStore) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := validateBlobName(name); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that a partially written blob is never visible
+	tmpFile, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), filepath.Join(l.dir, name))
+}
+
+func (l *localBlobStore) Download(ctx context.Context, name string) (Blob, error) {
+	if err := validateBlobName(name); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(l.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &streamBlob{ReadCloser: f, size: info.Size(), lastModified: info.ModTime()}, nil
+}
+
+func (l *localBlobStore) Delete(ctx context.Context, name string) error {
+	if err := validateBlobName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(l.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrBlobNotFound
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+type memoryBlobStore struct {
+	lock  sync.RWMutex
+	blobs map[string]memoryBlobEntry
+}
+
+type memoryBlobEntry struct {
+	content      []byte
+	lastModified time.Time
+}
+
+// NewMemoryBlobStore returns a BlobStore keeping the blobs in memory.
+// It is meant for tests and local development; blobs are lost when the process exits.
+func NewMemoryBlobStore() BlobStore {
+	return &memoryBlobStore{blobs: map[string]memoryBlobEntry{}}
+}
+
+func (m *memoryBlobStore) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := validateBlobName(name); err != nil {
+		return err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.blobs[name] = memoryBlobEntry{content: content, lastModified: time.Now()}
+	return nil
+}
+
+func (m *memoryBlobStore) Download(ctx context.Context, name string) (Blob, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	entry, ok := m.blobs[name]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return &memoryBlob{Reader: bytes.NewReader(entry.content), lastModified: entry.lastModified}, nil
+}
+
+func (m *memoryBlobStore) Delete(ctx context.Context, name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.blobs[name]; !ok {
+		return ErrBlobNotFound
+	}
+	delete(m.blobs, name)
+	return nil
+}
+
+type memoryBlob struct {
+	*bytes.Reader
+	lastModified time.Time
+}
+
+func (b *memoryBlob) Close() error {
+	return nil
+}
+
+func (b *memoryBlob) LastModified() time.Time {
+	return b.lastModified
+}
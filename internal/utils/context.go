@@ -15,6 +15,9 @@ const (
 	keyAuthContext
 	keyCountries
 	keySelectedCountryID
+	keyCSRFToken
+	keyAPIToken
+	keyUserSession
 )
 
 func WithRequestID(ctx context.Context, id string) context.Context {
@@ -75,6 +78,20 @@ func GetCountries(ctx context.Context) ([]*api.Country, error) {
 	return nil, fmt.Errorf("failed to get countries: value not present")
 }
 
+// GetCountry returns the country with the given id among the countries of the context
+func GetCountry(ctx context.Context, countryID string) (*api.Country, error) {
+	countries, err := GetCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, country := range countries {
+		if country.ID == countryID {
+			return country, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get country: %s not found", countryID)
+}
+
 func WithSelectedCountryID(ctx context.Context, selectedCountryID string) context.Context {
 	ctx = context.WithValue(ctx, keySelectedCountryID, selectedCountryID)
 	return ctx
@@ -89,3 +106,44 @@ func GetSelectedCountryID(ctx context.Context) (string, error) {
 	}
 	return "", nil
 }
+
+// WithCSRFToken stores the CSRF token to embed in the forms of the response
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, keyCSRFToken, token)
+}
+
+// GetCSRFToken returns the CSRF token of the request, or an empty string if there is none
+func GetCSRFToken(ctx context.Context) string {
+	if token, ok := ctx.Value(keyCSRFToken).(string); ok {
+		return token
+	}
+	return ""
+}
+
+// WithAPIToken stores the API token the request was authenticated with
+func WithAPIToken(ctx context.Context, token *api.APIToken) context.Context {
+	return context.WithValue(ctx, keyAPIToken, token)
+}
+
+// GetAPIToken returns the API token the request was authenticated with, if it was
+func GetAPIToken(ctx context.Context) (*api.APIToken, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	token, ok := ctx.Value(keyAPIToken).(*api.APIToken)
+	return token, ok && token != nil
+}
+
+// WithUserSession stores the server-side session of the user
+func WithUserSession(ctx context.Context, session *api.UserSession) context.Context {
+	return context.WithValue(ctx, keyUserSession, session)
+}
+
+// GetUserSession returns the server-side session of the user, if the request has one
+func GetUserSession(ctx context.Context) (*api.UserSession, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	session, ok := ctx.Value(keyUserSession).(*api.UserSession)
+	return session, ok && session != nil
+}
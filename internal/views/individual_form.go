@@ -23,14 +23,20 @@ type IndividualForm struct {
 	serviceSection         *forms.FormSection
 }
 
-func NewIndividualForm(i *api.Individual) (*IndividualForm, error) {
+// NewIndividualForm builds the form of the individual, adapted to the validation rules and the service catalogue of its country,
+// and to the fields the user can view and edit
+func NewIndividualForm(i *api.Individual, rules api.CountryValidationRules, catalogue api.ServiceCatalogue, access api.IndividualFieldAccess) (*IndividualForm, error) {
 	f := &IndividualForm{
 		Form:       &forms.Form{},
 		individual: i,
 	}
-	if err := f.build(locales.GetTranslator()); err != nil {
+	t := locales.GetTranslator()
+	if err := f.build(t); err != nil {
 		return nil, err
 	}
+	f.applyServiceCatalogue(t, catalogue)
+	f.applyValidationRules(rules)
+	f.applyFieldAccess(access)
 	return f, nil
 }
 
@@ -449,6 +455,7 @@ func (f *IndividualForm) buildAge(t locales.Translator) error {
 	return buildField(&forms.NumberInputField{
 		Name:        constants.DBColumnIndividualAge,
 		DisplayName: t("age"),
+		Help:        f.derivedAgeHelp(t),
 	}, f.personalInfoSection, f.individual.Age)
 }
 
@@ -456,6 +463,7 @@ func (f *IndividualForm) buildIsMinor(t locales.Translator) error {
 	return buildField(&forms.OptionalBooleanInputField{
 		Name:        constants.DBColumnIndividualIsMinor,
 		DisplayName: t("is_minor"),
+		Help:        f.derivedIsMinorHelp(t),
 	}, f.personalInfoSection, f.individual.IsMinor)
 }
 
@@ -0,0 +1,37 @@
+package views
+
+import (
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/pkg/views/forms"
+)
+
+// applyFieldAccess adapts the form to the permissions of the user on the field groups. The fields the user
+// cannot view are left out, and the fields the user can view but not edit are shown as read-only.
+func (f *IndividualForm) applyFieldAccess(access api.IndividualFieldAccess) {
+	f.Form.ReadOnly = map[string]bool{}
+	sections := make([]*forms.FormSection, 0, len(f.Form.Sections))
+	for _, section := range f.Form.Sections {
+		fields := make([]forms.Field, 0, len(section.Fields))
+		for _, field := range section.Fields {
+			inputField, ok := field.(forms.InputField)
+			if !ok {
+				fields = append(fields, field)
+				continue
+			}
+			name := inputField.GetName()
+			if !access.CanView(name) {
+				continue
+			}
+			if !access.CanEdit(name) {
+				f.Form.ReadOnly[name] = true
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		section.Fields = fields
+		sections = append(sections, section)
+	}
+	f.Form.Sections = sections
+}
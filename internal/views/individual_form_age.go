@@ -0,0 +1,34 @@
+package views
+
+import (
+	"time"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/locales"
+)
+
+// derivedAgeHelp explains the current age of the individual and what it was computed from,
+// as the recorded age is only true at the collection time
+func (f *IndividualForm) derivedAgeHelp(t locales.Translator) string {
+	age, source := f.individual.DerivedAge(time.Now())
+	switch source {
+	case api.AgeSourceBirthDate:
+		return t("derived_age_birth_date", *age)
+	case api.AgeSourceRecordedAge:
+		return t("derived_age_recorded_age", *age)
+	default:
+		return ""
+	}
+}
+
+// derivedIsMinorHelp explains whether the individual is currently a minor, when it can be derived from the age
+func (f *IndividualForm) derivedIsMinorHelp(t locales.Translator) string {
+	age, _ := f.individual.DerivedAge(time.Now())
+	if age == nil {
+		return ""
+	}
+	if *age < api.AdultAge {
+		return t("derived_is_minor_yes")
+	}
+	return t("derived_is_minor_no")
+}
@@ -0,0 +1,88 @@
+package views
+
+import (
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/pkg/views/forms"
+)
+
+// applyValidationRules adapts the form to the validation rules of the country. Hidden fields are left out,
+// unless they still have a value that must be cleared, required fields are marked as such, and the select
+// fields only offer the allowed values.
+func (f *IndividualForm) applyValidationRules(rules api.CountryValidationRules) {
+	enumOfColumn := map[string]string{}
+	for _, enum := range api.RestrictableEnums() {
+		for _, column := range enum.Columns {
+			enumOfColumn[column] = enum.Name
+		}
+	}
+
+	sections := make([]*forms.FormSection, 0, len(f.Form.Sections))
+	for _, section := range f.Form.Sections {
+		fields := make([]forms.Field, 0, len(section.Fields))
+		for _, field := range section.Fields {
+			inputField, ok := field.(forms.InputField)
+			if !ok {
+				fields = append(fields, field)
+				continue
+			}
+			name := inputField.GetName()
+			if rules.IsHidden(name) && isEmptyFormValue(inputField) {
+				continue
+			}
+			if rules.IsRequired(name) {
+				setFieldRequired(field)
+			}
+			if selectField, ok := field.(*forms.SelectInputField); ok {
+				if enum, ok := enumOfColumn[name]; ok {
+					selectField.Options = allowedOptions(selectField, enum, rules)
+				}
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		section.Fields = fields
+		sections = append(sections, section)
+	}
+	f.Form.Sections = sections
+}
+
+// allowedOptions returns the options of the select field that are allowed in the country.
+// The current value is kept so that it is not lost when the form is saved.
+func allowedOptions(field *forms.SelectInputField, enum string, rules api.CountryValidationRules) []forms.SelectInputFieldOption {
+	ret := make([]forms.SelectInputFieldOption, 0, len(field.Options))
+	for _, option := range field.Options {
+		if rules.IsAllowed(enum, option.Value) || field.IsSelected(option.Value) {
+			ret = append(ret, option)
+		}
+	}
+	return ret
+}
+
+func isEmptyFormValue(field forms.InputField) bool {
+	value := field.GetStringValue()
+	if _, ok := field.(*forms.CheckboxInputField); ok {
+		return value == "" || value == "false"
+	}
+	return value == ""
+}
+
+func setFieldRequired(field forms.Field) {
+	switch f := field.(type) {
+	case *forms.TextInputField:
+		f.Required = true
+	case *forms.TextAreaInputField:
+		f.Required = true
+	case *forms.NumberInputField:
+		f.Required = true
+	case *forms.DateInputField:
+		f.Required = true
+	case *forms.SelectInputField:
+		f.Required = true
+	case *forms.CheckboxInputField:
+		f.Required = true
+	case *forms.OptionalBooleanInputField:
+		f.Required = true
+	}
+}
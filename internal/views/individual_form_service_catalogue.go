@@ -0,0 +1,98 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/nrc-no/notcore/internal/api"
+	"github.com/nrc-no/notcore/internal/locales"
+	"github.com/nrc-no/notcore/pkg/views/forms"
+)
+
+// serviceCatalogueField is a service field whose values come from the service catalogue
+type serviceCatalogueField struct {
+	kind api.ServiceCatalogueEntryKind
+	// parentField is the name of the field holding the parent entry, or of the core competency for service types
+	parentField string
+}
+
+func serviceCatalogueFields() map[string]serviceCatalogueField {
+	ret := map[string]serviceCatalogueField{}
+	for n := 1; n <= 7; n++ {
+		ret[fmt.Sprintf("service_type_%d", n)] = serviceCatalogueField{api.ServiceCatalogueEntryKindServiceType, fmt.Sprintf("service_cc_%d", n)}
+		ret[fmt.Sprintf("service_%d", n)] = serviceCatalogueField{api.ServiceCatalogueEntryKindService, fmt.Sprintf("service_type_%d", n)}
+		ret[fmt.Sprintf("service_sub_service_%d", n)] = serviceCatalogueField{api.ServiceCatalogueEntryKindSubService, fmt.Sprintf("service_%d", n)}
+		ret[fmt.Sprintf("service_donor_%d", n)] = serviceCatalogueField{api.ServiceCatalogueEntryKindDonor, ""}
+		ret[fmt.Sprintf("service_project_name_%d", n)] = serviceCatalogueField{api.ServiceCatalogueEntryKindProject, fmt.Sprintf("service_donor_%d", n)}
+	}
+	return ret
+}
+
+// applyServiceCatalogue replaces the free text service fields managed by the service catalogue of the country
+// with selects, which only offer the entries under the value of their parent field
+func (f *IndividualForm) applyServiceCatalogue(t locales.Translator, catalogue api.ServiceCatalogue) {
+	if catalogue.IsEmpty() {
+		return
+	}
+	catalogueFields := serviceCatalogueFields()
+	values := map[string]string{}
+	for _, field := range f.serviceSection.Fields {
+		if inputField, ok := field.(forms.InputField); ok {
+			values[inputField.GetName()] = inputField.GetStringValue()
+		}
+	}
+	for i, field := range f.serviceSection.Fields {
+		textField, ok := field.(*forms.TextInputField)
+		if !ok {
+			continue
+		}
+		catalogueField, ok := catalogueFields[textField.Name]
+		if !ok || !catalogue.Manages(catalogueField.kind) {
+			continue
+		}
+		selectField := &forms.SelectInputField{
+			Name:        textField.Name,
+			DisplayName: textField.DisplayName,
+			Value:       textField.Value,
+			Options:     serviceCatalogueOptions(t, catalogue, catalogueField.kind),
+		}
+		parentKind := catalogueField.kind.ParentKind()
+		if catalogueField.kind == api.ServiceCatalogueEntryKindServiceType || catalogue.Manages(parentKind) {
+			selectField.ParentField = catalogueField.parentField
+		}
+		if textField.Value != "" && !hasOption(selectField.Options, textField.Value, values[selectField.ParentField]) {
+			// keep the value recorded before the catalogue, so that it is not lost when the form is saved
+			selectField.Options = append(selectField.Options, forms.SelectInputFieldOption{
+				Value:  textField.Value,
+				Label:  textField.Value,
+				Parent: values[selectField.ParentField],
+			})
+		}
+		f.serviceSection.Fields[i] = selectField
+	}
+}
+
+// serviceCatalogueOptions returns the entries of the given kind as options, whose parent is the value
+// of the parent field they are offered for
+func serviceCatalogueOptions(t locales.Translator, catalogue api.ServiceCatalogue, kind api.ServiceCatalogueEntryKind) []forms.SelectInputFieldOption {
+	ret := []forms.SelectInputFieldOption{{Value: "", Label: t("select_a_value")}}
+	for _, entry := range catalogue.Children(kind, "") {
+		parent := string(entry.ServiceCC)
+		if parentEntry, ok := catalogue.EntryByID(entry.ParentID); ok {
+			parent = parentEntry.Name
+		}
+		if hasOption(ret, entry.Name, parent) {
+			continue
+		}
+		ret = append(ret, forms.SelectInputFieldOption{Value: entry.Name, Label: entry.Name, Parent: parent})
+	}
+	return ret
+}
+
+func hasOption(options []forms.SelectInputFieldOption, value string, parent string) bool {
+	for _, option := range options {
+		if option.Value == value && (option.Parent == parent || option.Parent == "") {
+			return true
+		}
+	}
+	return false
+}
@@ -30,7 +30,9 @@ const (
 )
 
 type DeduplicationTypeValue struct {
-	Columns          []string
+	Columns []string
+	// QueryColumns are the columns compared by the queries, when they are not the Columns themselves
+	QueryColumns     []string
 	Condition        LogicOperator
 	QueryAnd         string
 	QueryOr          string
@@ -54,8 +56,9 @@ var DeduplicationTypes = map[DeduplicationTypeName]DeduplicationType{
 		ID:    DeduplicationTypeNamePhoneNumbers,
 		Label: "deduplication_type_phone_numbers",
 		Config: DeduplicationTypeValue{
-			Columns:   []string{constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3},
-			Condition: LOGICAL_OPERATOR_OR,
+			Columns:      []string{constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3},
+			QueryColumns: []string{constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber3},
+			Condition:    LOGICAL_OPERATOR_OR,
 			QueryAnd: fmt.Sprintf(`
 				(ti.%s != '' AND (ti.%s = ir.%s OR ti.%s = ir.%s OR ti.%s = ir.%s))
 				OR
@@ -65,19 +68,19 @@ var DeduplicationTypes = map[DeduplicationTypeName]DeduplicationType{
 				OR 
 				(ti.%s = '' AND ti.%s = '' AND ti.%s ='')
 			`,
-				constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3),
+				constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber3),
 			QueryOr: fmt.Sprintf(`
 				(ti.%s != '' AND (ti.%s = ir.%s OR ti.%s = ir.%s OR ti.%s = ir.%s))
 				OR
@@ -85,20 +88,20 @@ var DeduplicationTypes = map[DeduplicationTypeName]DeduplicationType{
 				OR
 				(ti.%s != '' AND (ti.%s = ir.%s OR ti.%s = ir.%s OR ti.%s = ir.%s))
 			`,
-				constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber3,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber1,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber2,
-				constants.DBColumnIndividualPhoneNumber3, constants.DBColumnIndividualPhoneNumber3),
+				constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber3,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber1,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber2,
+				constants.DBColumnIndividualNormalizedPhoneNumber3, constants.DBColumnIndividualNormalizedPhoneNumber3),
 			QueryNotAllEmpty: fmt.Sprintf("ti.%s != '' OR ti.%s != '' OR ti.%s != ''",
-				constants.DBColumnIndividualPhoneNumber1, constants.DBColumnIndividualPhoneNumber2, constants.DBColumnIndividualPhoneNumber3),
+				constants.DBColumnIndividualNormalizedPhoneNumber1, constants.DBColumnIndividualNormalizedPhoneNumber2, constants.DBColumnIndividualNormalizedPhoneNumber3),
 		},
 		Order: 4,
 	},
@@ -22,6 +22,9 @@ type SelectInputField struct {
 	Options []SelectInputFieldOption
 	// Codec is the codec of the field.
 	Codec Codec
+	// ParentField is the name of the field that the options depend on. Only the options whose Parent
+	// is the value of that field are offered.
+	ParentField string
 }
 
 // Ensure SelectInputField implements InputField
@@ -97,6 +100,8 @@ type SelectInputFieldOption struct {
 	Value string
 	// Label is the label of the option.
 	Label string
+	// Parent is the value of the parent field for which the option is offered. See SelectInputField.ParentField.
+	Parent string
 }
 
 func (f *SelectInputField) getCodecOrDefault() Codec {
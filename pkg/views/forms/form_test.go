@@ -222,3 +222,15 @@ func TestFormParseURLValues(t *testing.T) {
 		})
 	}
 }
+
+func TestFormParseURLValuesReadOnly(t *testing.T) {
+	f := formWithFields(textField("textField", "foo"), textField("otherField", "foo"))
+	f.ReadOnly = map[string]bool{"textField": true}
+
+	f.ParseURLValues(url.Values{"textField": []string{"bar"}, "otherField": []string{"bar"}})
+
+	assert.Equal(t, "foo", f.Sections[0].Fields[0].(*TextInputField).Value)
+	assert.Equal(t, "bar", f.Sections[0].Fields[1].(*TextInputField).Value)
+	assert.True(t, f.IsFieldReadOnly(f.Sections[0].Fields[0]))
+	assert.False(t, f.IsFieldReadOnly(f.Sections[0].Fields[1]))
+}
@@ -21,6 +21,18 @@ type FormSection struct {
 type Form struct {
 	Sections []*FormSection
 	Title    string
+	// ReadOnly are the names of the fields that are shown but cannot be changed.
+	// Their posted values are ignored.
+	ReadOnly map[string]bool
+}
+
+// IsFieldReadOnly returns true if the field is shown but cannot be changed
+func (f *Form) IsFieldReadOnly(field Field) bool {
+	inputField, ok := field.(InputField)
+	if !ok {
+		return false
+	}
+	return f.ReadOnly[inputField.GetName()]
 }
 
 func (f *Form) HTML() (template.HTML, error) {
@@ -51,7 +63,7 @@ func (f *Form) ParseURLValues(v url.Values) {
 			if fieldName == "" {
 				return
 			}
-			if !v.Has(fieldName) {
+			if !v.Has(fieldName) || f.ReadOnly[fieldName] {
 				continue
 			}
 			urlValueForField := v.Get(fieldName)